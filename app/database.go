@@ -90,6 +90,9 @@ func runMigrations() error {
 		&model.Category{},
 		&model.Brand{},
 		&model.Product{},
+		&model.ProductOption{},
+		&model.ProductVariant{},
+		&model.ProductVariantValue{},
 		&model.ProductImage{},
 		&model.Review{},
 		&model.Cart{},
@@ -99,6 +102,21 @@ func runMigrations() error {
 		&model.Coupon{},
 		&model.Address{},
 		&model.News{},
+		&model.UserPermission{},
+		&model.AuditLog{},
+		&model.OwnerTransfer{},
+		&model.APIKey{},
+		&model.ImportJob{},
+		&model.StockMovement{},
+		&model.StockReservation{},
+		&model.Warehouse{},
+		&model.WarehouseStock{},
+		&model.StockTransfer{},
+		&model.Supplier{},
+		&model.PurchaseOrder{},
+		&model.PurchaseOrderLine{},
+		&model.Stocktake{},
+		&model.StocktakeLine{},
 	)
 }
 
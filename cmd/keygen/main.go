@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "keys/jwt", "Directory to write the key pair into")
+	kid := flag.String("kid", time.Now().Format("20060102"), "Key ID (file name without extension)")
+	alg := flag.String("alg", "EdDSA", "Signing algorithm: EdDSA or RS256")
+	flag.Parse()
+
+	var privateDER, publicDER []byte
+	var err error
+
+	switch *alg {
+	case "EdDSA":
+		publicKey, privateKey, genErr := ed25519.GenerateKey(rand.Reader)
+		if genErr != nil {
+			log.Fatal("Failed to generate Ed25519 key:", genErr)
+		}
+		privateDER, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		if err == nil {
+			publicDER, err = x509.MarshalPKIXPublicKey(publicKey)
+		}
+	case "RS256":
+		privateKey, genErr := rsa.GenerateKey(rand.Reader, 3072)
+		if genErr != nil {
+			log.Fatal("Failed to generate RSA key:", genErr)
+		}
+		privateDER, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		if err == nil {
+			publicDER, err = x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		}
+	default:
+		log.Fatalf("Unsupported algorithm %q (use EdDSA or RS256)", *alg)
+	}
+	if err != nil {
+		log.Fatal("Failed to encode key:", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatal("Failed to create key directory:", err)
+	}
+
+	privatePath := filepath.Join(*dir, *kid+".pem")
+	publicPath := filepath.Join(*dir, *kid+".pub.pem")
+
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		log.Fatal("Failed to write private key:", err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		log.Fatal("Failed to write public key:", err)
+	}
+
+	log.Printf("✅ Generated %s key %q", *alg, *kid)
+	log.Printf("Private key: %s", privatePath)
+	log.Printf("Public key:  %s", publicPath)
+	log.Println("Set JWT_ACTIVE_KID to this kid to start signing with it; keep old *.pub.pem files until their tokens expire.")
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"backend/app"
+	"backend/internal/helpers"
+	"backend/internal/search"
+	"backend/internal/storage"
+	"backend/internal/worker"
 	"backend/router"
 	"backend/utils"
 	"log"
@@ -17,9 +21,54 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// Load JWT signing keys
+	if err := helpers.LoadJWTKeys(); err != nil {
+		log.Fatal("❌ Failed to load JWT keys:", err)
+	}
+
+	// Initialize file storage for uploads
+	if _, err := storage.Default(); err != nil {
+		log.Fatal("❌ Failed to initialize storage:", err)
+	}
+
+	// Open full-text search index
+	if _, err := search.Default(); err != nil {
+		log.Fatal("❌ Failed to open search index:", err)
+	}
+
 	// Connect to database and initialize
 	app.Connect()
 
+	// Start background workers that generate image variants
+	worker.StartImageWorkers()
+
+	// Build the search index in the background when it is empty
+	worker.StartSearchIndexer()
+
+	// Build the in-memory autocomplete trie
+	worker.StartSuggestionBuilder()
+
+	// Product import jobs only live in memory; mark the ones cut off by a restart as failed
+	worker.FailInterruptedImports()
+
+	// Create the default warehouses and move stock from before multi-warehouse into the default one
+	worker.InitWarehouses()
+
+	// Index variant option values of variants created before color/material/size filtering covered variants
+	worker.InitVariantValues()
+
+	// Permanently delete trashed records past their retention period
+	worker.StartTrashPurger()
+
+	// Cancel unpaid online-payment orders whose stock reservation expired and return the stock
+	worker.StartReservationSweeper()
+
+	// Alert admins when products fall to or below their low-stock threshold
+	worker.StartLowStockChecker()
+
+	// Release backordered order items once incoming stock covers them
+	worker.StartBackorderReleaser()
+
 	// Set Gin mode
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -31,14 +80,23 @@ func main() {
 	// Add CORS middleware
 	r.Use(utils.CORSMiddleware())
 
+	// Gán mã request để truy vết nhật ký
+	r.Use(utils.RequestIDMiddleware())
+
 	// Setup routes
 	router.SetupAuthRoutes(r)
 	router.SetupAdminRoutes(r)
 	router.SetupCategoryRoutes(r)
+	router.SetupBrandRoutes(r)
 	router.SetupProductRoutes(r)
 	router.SetupOrderRoutes(r)
 	router.SetupCartRoutes(r)
 	router.SetupNewsRoutes(r)
+	router.SetupSearchRoutes(r)
+	router.SetupTrashRoutes(r)
+	router.SetupWarehouseRoutes(r)
+	router.SetupPurchasingRoutes(r)
+	router.SetupStocktakeRoutes(r)
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Các loại đối tượng được ghi nhật ký
+const (
+	EntityProduct       = "product"
+	EntityCategory      = "category"
+	EntityBrand         = "brand"
+	EntityOrder         = "order"
+	EntityNews          = "news"
+	EntityUser          = "user"
+	EntityPermission    = "permission"
+	EntityAPIKey        = "api_key"
+	EntityWarehouse     = "warehouse"
+	EntitySupplier      = "supplier"
+	EntityPurchaseOrder = "purchase_order"
+	EntityStocktake     = "stocktake"
+)
+
+// FieldChange mô tả giá trị trước và sau của một trường bị thay đổi
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Record ghi nhật ký một thao tác thay đổi dữ liệu của quản trị viên.
+// before/after có thể là nil (tạo mới hoặc xóa). Lỗi ghi nhật ký không làm hỏng request.
+func Record(c *gin.Context, action, entityType string, entityID uint, before, after interface{}) {
+	entry := model.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 500),
+		RequestID:  c.GetString("request_id"),
+	}
+
+	if userID, exists := c.Get("user_id"); exists {
+		actorID := userID.(uint)
+		entry.ActorID = &actorID
+	}
+	entry.ActorRole = c.GetString("user_role")
+	if impersonatorID, exists := c.Get("impersonator_id"); exists {
+		id := impersonatorID.(uint)
+		entry.ImpersonatorID = &id
+	}
+
+	beforeMap := toMap(before)
+	afterMap := toMap(after)
+	entry.Before = marshal(beforeMap)
+	entry.After = marshal(afterMap)
+	entry.Changes = marshal(Diff(beforeMap, afterMap))
+
+	if err := repo.NewAuditRepo().Create(&entry); err != nil {
+		log.Printf("⚠️  Failed to write audit log %s %s#%d: %v", action, entityType, entityID, err)
+	}
+}
+
+// Diff so sánh hai ảnh chụp dữ liệu và trả về các trường khác nhau
+func Diff(before, after map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+
+	for key, oldValue := range before {
+		newValue, exists := after[key]
+		if !exists || !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = FieldChange{Before: oldValue, After: newValue}
+		}
+	}
+	for key, newValue := range after {
+		if _, exists := before[key]; !exists {
+			changes[key] = FieldChange{Before: nil, After: newValue}
+		}
+	}
+
+	// Bỏ qua các trường thời gian tự cập nhật để diff gọn hơn
+	delete(changes, "updated_at")
+
+	return changes
+}
+
+// toMap chuyển một giá trị bất kỳ thành map thông qua JSON để so sánh theo trường
+func toMap(value interface{}) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(data, &result); err != nil {
+		return map[string]interface{}{"value": json.RawMessage(data)}
+	}
+	return result
+}
+
+func marshal(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Map && v.Len() == 0 {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   map[string]FieldChange
+	}{
+		{
+			name:   "unchanged fields are omitted",
+			before: map[string]interface{}{"name": "Áo", "price": 100.0},
+			after:  map[string]interface{}{"name": "Áo", "price": 100.0},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "changed field",
+			before: map[string]interface{}{"name": "Áo", "price": 100.0},
+			after:  map[string]interface{}{"name": "Áo", "price": 120.0},
+			want:   map[string]FieldChange{"price": {Before: 100.0, After: 120.0}},
+		},
+		{
+			name:   "added and removed fields",
+			before: map[string]interface{}{"old": "x"},
+			after:  map[string]interface{}{"new": "y"},
+			want: map[string]FieldChange{
+				"old": {Before: "x", After: nil},
+				"new": {Before: nil, After: "y"},
+			},
+		},
+		{
+			name:   "nested values compared deeply",
+			before: map[string]interface{}{"tags": []interface{}{"a", "b"}},
+			after:  map[string]interface{}{"tags": []interface{}{"a", "b"}},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "updated_at is ignored",
+			before: map[string]interface{}{"updated_at": "2024-01-01"},
+			after:  map[string]interface{}{"updated_at": "2024-01-02"},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "create has no before snapshot",
+			before: nil,
+			after:  map[string]interface{}{"name": "Áo"},
+			want:   map[string]FieldChange{"name": {Before: nil, After: "Áo"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMap(t *testing.T) {
+	type snapshot struct {
+		Name  string `json:"name"`
+		Stock int    `json:"stock"`
+	}
+	var nilSnapshot *snapshot
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  map[string]interface{}
+	}{
+		{"nil", nil, nil},
+		{"nil pointer", nilSnapshot, nil},
+		{"struct", snapshot{Name: "Áo", Stock: 3}, map[string]interface{}{"name": "Áo", "stock": 3.0}},
+		{"non-object value", []int{1, 2}, map[string]interface{}{"value": json.RawMessage("[1,2]")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toMap(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package catalog
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ActionDeleted là kết quả của sản phẩm bị xóa hàng loạt
+const ActionDeleted = "deleted"
+
+// errBulkRejected buộc giao dịch rollback khi có sản phẩm bị từ chối
+var errBulkRejected = errors.New("bulk action rejected")
+
+// BulkChange là thao tác áp dụng cho nhiều sản phẩm
+type BulkChange struct {
+	Action     string
+	CategoryID *uint   // set_category; nil để bỏ danh mục
+	BrandID    *uint   // set_brand; nil để bỏ thương hiệu
+	PriceMode  string  // adjust_price: percent hoặc amount
+	PriceValue float64 // adjust_price: phần trăm hoặc số tiền, âm để giảm
+}
+
+// BulkItemResult là kết quả của một sản phẩm; Changes theo tên cột như bảng tính sản phẩm,
+// giá ghi đè của biến thể có khóa "variant:<SKU>"
+type BulkItemResult struct {
+	ProductID uint                         `json:"product_id"`
+	SKU       string                       `json:"sku,omitempty"`
+	Action    string                       `json:"action"`
+	Changes   map[string]audit.FieldChange `json:"changes,omitempty"`
+	Errors    []string                     `json:"errors,omitempty"`
+}
+
+// BulkReport là kết quả thao tác hàng loạt; Applied = false nghĩa là không có thay đổi nào được lưu
+type BulkReport struct {
+	Action    string           `json:"action"`
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Total     int              `json:"total"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Deleted   int              `json:"deleted"`
+	Rejected  int              `json:"rejected"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+func (r *BulkReport) add(item BulkItemResult) {
+	switch item.Action {
+	case ActionUpdated:
+		r.Updated++
+	case ActionUnchanged:
+		r.Unchanged++
+	case ActionDeleted:
+		r.Deleted++
+	default:
+		r.Rejected++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// ChangedIDs trả về ID các sản phẩm đã được cập nhật hoặc xóa
+func (r *BulkReport) ChangedIDs(action string) []uint {
+	var ids []uint
+	for _, item := range r.Items {
+		if item.Action == action {
+			ids = append(ids, item.ProductID)
+		}
+	}
+	return ids
+}
+
+// Validate kiểm tra thao tác và tham số trước khi chạy
+func (b *BulkChange) Validate() error {
+	valid := b.Action == consts.BulkActionDelete
+	for _, action := range consts.BulkUpdateActions {
+		if b.Action == action {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return errors.New("action phải là một trong: " + strings.Join(consts.BulkUpdateActions, ", "))
+	}
+
+	if b.Action == consts.BulkActionAdjustPrice {
+		switch b.PriceMode {
+		case consts.PriceAdjustPercent:
+			if b.PriceValue <= -100 {
+				return errors.New("price_value theo phần trăm phải lớn hơn -100")
+			}
+		case consts.PriceAdjustAmount:
+		default:
+			return fmt.Errorf("price_mode phải là %s hoặc %s", consts.PriceAdjustPercent, consts.PriceAdjustAmount)
+		}
+		if b.PriceValue == 0 {
+			return errors.New("price_value phải khác 0")
+		}
+	}
+	return nil
+}
+
+// adjustPrice tính giá mới, làm tròn đến 2 chữ số thập phân như cột decimal(10,2)
+func (b *BulkChange) adjustPrice(price float64) float64 {
+	if b.PriceMode == consts.PriceAdjustPercent {
+		price = price * (100 + b.PriceValue) / 100
+	} else {
+		price += b.PriceValue
+	}
+	return math.Round(price*100) / 100
+}
+
+// RunBulk áp dụng change cho các sản phẩm ids trong một giao dịch. Nếu có sản phẩm bị từ chối
+// (không tìm thấy, giá mới không hợp lệ...) thì toàn bộ giao dịch rollback và báo cáo có Applied = false.
+// Với dryRun, giao dịch luôn rollback để xem trước kết quả. Lỗi "category not found"/"brand not found"
+// được trả về khi danh mục/thương hiệu đích không hợp lệ.
+func RunBulk(change BulkChange, ids []uint, dryRun bool) (*BulkReport, error) {
+	if err := change.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{Action: change.Action, DryRun: dryRun, Total: len(ids)}
+	run := func(tx *gorm.DB) error {
+		report.Items = make([]BulkItemResult, 0, len(ids))
+		report.Updated, report.Unchanged, report.Deleted, report.Rejected = 0, 0, 0, 0
+		if err := applyBulk(tx, change, ids, report); err != nil {
+			return err
+		}
+		if report.Rejected > 0 {
+			return errBulkRejected
+		}
+		return nil
+	}
+
+	var err error
+	if dryRun {
+		err = repo.DryRun(run)
+	} else {
+		err = repo.Transaction(run)
+	}
+	if err != nil && !errors.Is(err, errBulkRejected) {
+		return nil, err
+	}
+	report.Applied = !dryRun && report.Rejected == 0
+	return report, nil
+}
+
+func applyBulk(tx *gorm.DB, change BulkChange, ids []uint, report *BulkReport) error {
+	productRepo := repo.NewProductRepo().WithTx(tx)
+	variantRepo := repo.NewProductVariantRepo().WithTx(tx)
+
+	var category *model.Category
+	var brand *model.Brand
+	var err error
+	if change.Action == consts.BulkActionSetCategory && change.CategoryID != nil {
+		if category, err = repo.NewCategoryRepo().WithTx(tx).GetByID(*change.CategoryID); err != nil {
+			return err
+		}
+	}
+	if change.Action == consts.BulkActionSetBrand && change.BrandID != nil {
+		if brand, err = repo.NewBrandRepo().WithTx(tx).GetActiveByID(*change.BrandID); err != nil {
+			return err
+		}
+	}
+
+	products, err := productRepo.LockByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]*model.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	for _, id := range ids {
+		product, ok := byID[id]
+		if !ok {
+			report.add(BulkItemResult{ProductID: id, Action: ActionRejected, Errors: []string{"không tìm thấy sản phẩm"}})
+			continue
+		}
+
+		item := BulkItemResult{ProductID: product.ID, SKU: product.SKU}
+		if change.Action == consts.BulkActionDelete {
+			if err := productRepo.Delete(product.ID); err != nil {
+				return err
+			}
+			item.Action = ActionDeleted
+			report.add(item)
+			continue
+		}
+
+		before := ProductRow(product)
+		fields := make(map[string]interface{})
+		variantPrices := make(map[uint]float64)
+
+		switch change.Action {
+		case consts.BulkActionActivate, consts.BulkActionDeactivate:
+			product.IsActive = change.Action == consts.BulkActionActivate
+			fields["is_active"] = product.IsActive
+		case consts.BulkActionFeature, consts.BulkActionUnfeature:
+			product.IsFeatured = change.Action == consts.BulkActionFeature
+			fields["is_featured"] = product.IsFeatured
+		case consts.BulkActionSetCategory:
+			product.CategoryID = change.CategoryID
+			product.Category = category
+			fields["category_id"] = change.CategoryID
+		case consts.BulkActionSetBrand:
+			product.BrandID = change.BrandID
+			product.Brand = brand
+			fields["brand_id"] = change.BrandID
+		case consts.BulkActionAdjustPrice:
+			product.Price = change.adjustPrice(product.Price)
+			fields["price"] = product.Price
+			if product.Price <= 0 {
+				item.Errors = append(item.Errors, fmt.Sprintf("price: giá mới %.2f phải lớn hơn 0", product.Price))
+			}
+			// Giá ghi đè của biến thể được điều chỉnh cùng tỷ lệ/số tiền để giữ chênh lệch giữa các biến thể
+			for _, variant := range product.Variants {
+				if variant.Price == nil {
+					continue
+				}
+				price := change.adjustPrice(*variant.Price)
+				if price <= 0 {
+					item.Errors = append(item.Errors, fmt.Sprintf("variant %s: giá mới %.2f phải lớn hơn 0", variant.SKU, price))
+				}
+				variantPrices[variant.ID] = price
+			}
+		}
+
+		if len(item.Errors) > 0 {
+			item.Action = ActionRejected
+			report.add(item)
+			continue
+		}
+
+		item.Changes = diffRows(before, ProductRow(product))
+		for _, variant := range product.Variants {
+			if price, ok := variantPrices[variant.ID]; ok && price != *variant.Price {
+				item.Changes["variant:"+variant.SKU] = audit.FieldChange{Before: *variant.Price, After: price}
+			}
+		}
+		if len(item.Changes) == 0 {
+			item.Action = ActionUnchanged
+			report.add(item)
+			continue
+		}
+
+		if err := productRepo.UpdateFields(product.ID, fields); err != nil {
+			return err
+		}
+		for variantID, price := range variantPrices {
+			if err := variantRepo.UpdatePrice(variantID, price); err != nil {
+				return err
+			}
+		}
+		item.Action = ActionUpdated
+		report.add(item)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"backend/internal/consts"
+	"reflect"
+	"testing"
+)
+
+func TestBulkChangeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  BulkChange
+		wantErr bool
+	}{
+		{"delete", BulkChange{Action: consts.BulkActionDelete}, false},
+		{"activate", BulkChange{Action: consts.BulkActionActivate}, false},
+		{"unknown action", BulkChange{Action: "archive"}, true},
+		{"percent decrease", BulkChange{Action: consts.BulkActionAdjustPrice, PriceMode: consts.PriceAdjustPercent, PriceValue: -10}, false},
+		{"percent at -100", BulkChange{Action: consts.BulkActionAdjustPrice, PriceMode: consts.PriceAdjustPercent, PriceValue: -100}, true},
+		{"amount", BulkChange{Action: consts.BulkActionAdjustPrice, PriceMode: consts.PriceAdjustAmount, PriceValue: -50000}, false},
+		{"zero adjustment", BulkChange{Action: consts.BulkActionAdjustPrice, PriceMode: consts.PriceAdjustAmount}, true},
+		{"unknown price mode", BulkChange{Action: consts.BulkActionAdjustPrice, PriceMode: "ratio", PriceValue: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBulkChangeAdjustPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		change BulkChange
+		price  float64
+		want   float64
+	}{
+		{"percent increase", BulkChange{PriceMode: consts.PriceAdjustPercent, PriceValue: 10}, 200000, 220000},
+		{"percent rounded to cents", BulkChange{PriceMode: consts.PriceAdjustPercent, PriceValue: -33}, 10.01, 6.71},
+		{"amount decrease", BulkChange{PriceMode: consts.PriceAdjustAmount, PriceValue: -50000}, 200000, 150000},
+		{"amount may go negative", BulkChange{PriceMode: consts.PriceAdjustAmount, PriceValue: -300}, 200, -100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.change.adjustPrice(tt.price); got != tt.want {
+				t.Errorf("adjustPrice(%v) = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkReport(t *testing.T) {
+	report := &BulkReport{}
+	for _, item := range []BulkItemResult{
+		{ProductID: 1, Action: ActionUpdated},
+		{ProductID: 2, Action: ActionUnchanged},
+		{ProductID: 3, Action: ActionUpdated},
+		{ProductID: 4, Action: ActionDeleted},
+		{ProductID: 5, Action: ActionRejected},
+	} {
+		report.add(item)
+	}
+
+	if report.Updated != 2 || report.Unchanged != 1 || report.Deleted != 1 || report.Rejected != 1 {
+		t.Errorf("counts = %+v", report)
+	}
+	if got := report.ChangedIDs(ActionUpdated); !reflect.DeepEqual(got, []uint{1, 3}) {
+		t.Errorf("ChangedIDs(updated) = %v, want [1 3]", got)
+	}
+	if got := report.ChangedIDs(ActionDeleted); !reflect.DeepEqual(got, []uint{4}) {
+		t.Errorf("ChangedIDs(deleted) = %v, want [4]", got)
+	}
+}
@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Các cột của bảng tính sản phẩm dùng chung cho nhập và xuất; danh mục và thương hiệu ghi bằng slug
+const (
+	ColumnSKU         = "sku"
+	ColumnName        = "name"
+	ColumnDescription = "description"
+	ColumnPrice       = "price"
+	ColumnStock       = "stock"
+	ColumnCategory    = "category"
+	ColumnBrand       = "brand"
+	ColumnMaterial    = "material"
+	ColumnColor       = "color"
+	ColumnSize        = "size"
+	ColumnWeight      = "weight"
+	ColumnDimensions  = "dimensions"
+	ColumnFeatured    = "is_featured"
+	ColumnActive      = "is_active"
+)
+
+var ProductColumns = []string{
+	ColumnSKU,
+	ColumnName,
+	ColumnDescription,
+	ColumnPrice,
+	ColumnStock,
+	ColumnCategory,
+	ColumnBrand,
+	ColumnMaterial,
+	ColumnColor,
+	ColumnSize,
+	ColumnWeight,
+	ColumnDimensions,
+	ColumnFeatured,
+	ColumnActive,
+}
+
+// ProductRow trả về giá trị các cột của sản phẩm theo thứ tự ProductColumns (cần nạp sẵn Category và Brand)
+func ProductRow(product *model.Product) []interface{} {
+	category := ""
+	if product.Category != nil {
+		category = product.Category.Slug
+	}
+	brand := ""
+	if product.Brand != nil {
+		brand = product.Brand.Slug
+	}
+
+	return []interface{}{
+		product.SKU,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.Stock,
+		category,
+		brand,
+		product.Material,
+		product.Color,
+		product.Size,
+		product.Weight,
+		product.Dimensions,
+		product.IsFeatured,
+		product.IsActive,
+	}
+}
+
+// NormalizeColumn chuẩn hóa tên cột do người dùng nhập ("Is Featured" -> "is_featured")
+func NormalizeColumn(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// parseHeader ánh xạ tên cột sang vị trí; cột sku là bắt buộc, cột lạ hoặc trùng bị từ chối
+func parseHeader(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(ProductColumns))
+	for _, column := range ProductColumns {
+		known[column] = true
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		column := NormalizeColumn(name)
+		if column == "" {
+			continue
+		}
+		if !known[column] {
+			return nil, fmt.Errorf("cột %q không được hỗ trợ (các cột hợp lệ: %s)", name, strings.Join(ProductColumns, ", "))
+		}
+		if _, exists := columns[column]; exists {
+			return nil, fmt.Errorf("cột %q bị lặp lại", name)
+		}
+		columns[column] = i
+	}
+
+	if _, ok := columns[ColumnSKU]; !ok {
+		return nil, errors.New("thiếu cột sku")
+	}
+	return columns, nil
+}
+
+// ErrorReportHeader là dòng tiêu đề của báo cáo lỗi: số dòng, các cột gốc của tệp và nội dung lỗi
+func ErrorReportHeader(columns []string) []interface{} {
+	header := []interface{}{"line"}
+	for _, column := range columns {
+		header = append(header, column)
+	}
+	return append(header, "errors")
+}
+
+// ErrorReportRow là một dòng của báo cáo lỗi, giữ nguyên giá trị gốc để sửa và nhập lại
+func ErrorReportRow(columns []string, rowError model.ImportRowError) []interface{} {
+	row := []interface{}{rowError.Line}
+	for i := range columns {
+		value := ""
+		if i < len(rowError.Values) {
+			value = rowError.Values[i]
+		}
+		row = append(row, value)
+	}
+	return append(row, strings.Join(rowError.Errors, "; "))
+}
@@ -0,0 +1,551 @@
+package catalog
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/spreadsheet"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Kết quả xử lý một dòng
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionRejected  = "rejected"
+)
+
+// RowResult là kết quả nhập một dòng dữ liệu; Changes chỉ có khi cập nhật (theo tên cột),
+// Errors chỉ có khi dòng bị từ chối
+type RowResult struct {
+	Line      int                          `json:"line"`
+	SKU       string                       `json:"sku"`
+	Action    string                       `json:"action"`
+	ProductID uint                         `json:"product_id,omitempty"`
+	Changes   map[string]audit.FieldChange `json:"changes,omitempty"`
+	Errors    []string                     `json:"errors,omitempty"`
+}
+
+// Report là kết quả chạy thử toàn bộ tệp, không có thay đổi nào được lưu
+type Report struct {
+	Mode      string      `json:"mode"`
+	TotalRows int         `json:"total_rows"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Unchanged int         `json:"unchanged"`
+	Rejected  int         `json:"rejected"`
+	Rows      []RowResult `json:"rows"`
+}
+
+// rowValues là giá trị của một dòng sau khi ghép lên dữ liệu hiện có của sản phẩm
+type rowValues struct {
+	input    model.ProductInput
+	active   bool
+	category *model.Category
+	brand    *model.Brand
+	errors   []string
+}
+
+// fieldColumns ánh xạ trường của ProductInput sang cột bảng tính để báo lỗi kiểm tra dữ liệu
+var fieldColumns = map[string]string{
+	"Name":        ColumnName,
+	"Description": ColumnDescription,
+	"Price":       ColumnPrice,
+	"SKU":         ColumnSKU,
+	"Stock":       ColumnStock,
+	"Material":    ColumnMaterial,
+	"Color":       ColumnColor,
+	"Size":        ColumnSize,
+	"Weight":      ColumnWeight,
+	"Dimensions":  ColumnDimensions,
+}
+
+// Importer áp dụng từng dòng của bảng tính sản phẩm vào cơ sở dữ liệu, tạo mới hoặc cập nhật theo SKU.
+// Chỉ các cột có trong tệp mới được ghi; ô số/bool để trống giữ nguyên giá trị cũ khi cập nhật,
+// ô văn bản, danh mục và thương hiệu để trống sẽ xóa giá trị. Importer không an toàn khi dùng đồng thời.
+type Importer struct {
+	mode         string
+	columns      map[string]int
+	productRepo  *repo.ProductRepo
+	variantRepo  *repo.ProductVariantRepo
+	categoryRepo *repo.CategoryRepo
+	brandRepo    *repo.BrandRepo
+	stockRepo    *repo.StockRepo
+	userID       *uint // Người nhập, ghi vào sổ kho
+
+	seen       map[string]int             // SKU -> dòng đầu tiên chứa SKU trong tệp
+	categories map[string]*model.Category // Giá trị ô danh mục -> danh mục (nil nếu không tìm thấy)
+	brands     map[string]*model.Brand    // Giá trị ô thương hiệu -> thương hiệu (nil nếu không tìm thấy hoặc ngừng hoạt động)
+}
+
+// NewImporter kiểm tra dòng tiêu đề và chế độ nhập; userID là người nhập (nil nếu không xác định)
+func NewImporter(header []string, mode string, userID *uint) (*Importer, error) {
+	validMode := false
+	for _, m := range consts.ImportModes {
+		if mode == m {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		return nil, errors.New("mode phải là một trong: " + strings.Join(consts.ImportModes, ", "))
+	}
+
+	columns, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Importer{
+		mode:         mode,
+		columns:      columns,
+		productRepo:  repo.NewProductRepo(),
+		variantRepo:  repo.NewProductVariantRepo(),
+		categoryRepo: repo.NewCategoryRepo(),
+		brandRepo:    repo.NewBrandRepo(),
+		stockRepo:    repo.NewStockRepo(),
+		userID:       userID,
+		seen:         make(map[string]int),
+		categories:   make(map[string]*model.Category),
+		brands:       make(map[string]*model.Brand),
+	}, nil
+}
+
+// withTx trả về importer mới (bộ nhớ đệm rỗng) ghi vào giao dịch tx
+func (im *Importer) withTx(tx *gorm.DB) *Importer {
+	return &Importer{
+		mode:         im.mode,
+		columns:      im.columns,
+		productRepo:  im.productRepo.WithTx(tx),
+		variantRepo:  im.variantRepo.WithTx(tx),
+		categoryRepo: im.categoryRepo.WithTx(tx),
+		brandRepo:    im.brandRepo.WithTx(tx),
+		stockRepo:    im.stockRepo.WithTx(tx),
+		userID:       im.userID,
+		seen:         make(map[string]int),
+		categories:   make(map[string]*model.Category),
+		brands:       make(map[string]*model.Brand),
+	}
+}
+
+// DryRun chạy Apply cho mọi dòng (không gồm dòng tiêu đề) trong một giao dịch được rollback
+// và trả về báo cáo. Sản phẩm tạo mới không có ProductID vì ID chỉ tồn tại trong giao dịch.
+func (im *Importer) DryRun(rows [][]string) (*Report, error) {
+	report := &Report{Mode: im.mode, TotalRows: len(rows), Rows: []RowResult{}}
+
+	err := repo.DryRun(func(tx *gorm.DB) error {
+		importer := im.withTx(tx)
+		for i, row := range rows {
+			if spreadsheet.IsBlank(row) {
+				continue
+			}
+			result, err := importer.Apply(i+2, row)
+			if err != nil {
+				return err
+			}
+
+			switch result.Action {
+			case ActionCreated:
+				result.ProductID = 0
+				report.Created++
+			case ActionUpdated:
+				report.Updated++
+			case ActionUnchanged:
+				report.Unchanged++
+			default:
+				report.Rejected++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// cell trả về giá trị ô của cột và cột đó có trong tệp hay không
+func (im *Importer) cell(values []string, column string) (string, bool) {
+	index, ok := im.columns[column]
+	if !ok {
+		return "", false
+	}
+	if index < len(values) {
+		return strings.TrimSpace(values[index]), true
+	}
+	return "", true
+}
+
+// Apply kiểm tra và ghi một dòng (line là số dòng trong tệp, tính cả dòng tiêu đề).
+// Dòng không hợp lệ được trả về với Action = rejected; error chỉ dành cho lỗi cơ sở dữ liệu.
+func (im *Importer) Apply(line int, values []string) (RowResult, error) {
+	sku, _ := im.cell(values, ColumnSKU)
+	sku = strings.ToUpper(sku)
+	result := RowResult{Line: line, SKU: sku, Action: ActionRejected}
+
+	if sku == "" {
+		result.Errors = []string{"sku: bắt buộc"}
+		return result, nil
+	}
+	if first, exists := im.seen[sku]; exists {
+		result.Errors = []string{fmt.Sprintf("sku: trùng với dòng %d", first)}
+		return result, nil
+	}
+	im.seen[sku] = line
+
+	product, err := im.productRepo.GetBySKU(sku)
+	if err != nil && err.Error() != "product not found" {
+		return result, err
+	}
+	exists := err == nil
+
+	switch {
+	case exists && im.mode == consts.ImportModeCreate:
+		result.Errors = []string{"sku: sản phẩm với SKU này đã tồn tại"}
+		return result, nil
+	case !exists && im.mode == consts.ImportModeUpdate:
+		result.Errors = []string{"sku: không tìm thấy sản phẩm với SKU này"}
+		return result, nil
+	}
+
+	// SKU sản phẩm không được trùng với SKU của biến thể
+	variantExists, err := im.variantRepo.CheckSKUExists(sku, 0)
+	if err != nil {
+		return result, err
+	}
+	if variantExists {
+		result.Errors = []string{"sku: SKU đã được dùng cho một biến thể"}
+		return result, nil
+	}
+
+	if !exists {
+		product = &model.Product{SKU: sku, IsActive: true}
+	}
+	row := rowValues{
+		input: model.ProductInput{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+			SKU:         sku,
+			Stock:       product.Stock,
+			CategoryID:  product.CategoryID,
+			BrandID:     product.BrandID,
+			Material:    product.Material,
+			Color:       product.Color,
+			Size:        product.Size,
+			Weight:      product.Weight,
+			Dimensions:  product.Dimensions,
+			IsFeatured:  product.IsFeatured,
+		},
+		active:   product.IsActive,
+		category: product.Category,
+		brand:    product.Brand,
+	}
+
+	if err := im.readRow(values, &row); err != nil {
+		return result, err
+	}
+	if err := binding.Validator.ValidateStruct(&row.input); err != nil {
+		row.errors = append(row.errors, validationMessages(err)...)
+	}
+	if len(row.errors) > 0 {
+		result.Errors = row.errors
+		return result, nil
+	}
+
+	before := ProductRow(product)
+	oldStock := product.Stock
+	input := row.input
+	product.Name = input.Name
+	product.Description = input.Description
+	product.Price = input.Price
+	// Tồn kho của sản phẩm có biến thể là tổng tồn kho các biến thể, không sửa trực tiếp
+	if len(product.Variants) == 0 {
+		product.Stock = input.Stock
+	}
+	product.CategoryID = input.CategoryID
+	product.BrandID = input.BrandID
+	product.Material = input.Material
+	product.Color = input.Color
+	product.Size = input.Size
+	product.Weight = input.Weight
+	product.Dimensions = input.Dimensions
+	product.IsFeatured = input.IsFeatured
+	product.IsActive = row.active
+	product.Category = row.category
+	product.Brand = row.brand
+
+	if exists {
+		result.ProductID = product.ID
+		result.Changes = diffRows(before, ProductRow(product))
+		if len(result.Changes) == 0 {
+			result.Action = ActionUnchanged
+			return result, nil
+		}
+		// Ghi sổ kho trước để dòng bị từ chối (tồn kho vừa thay đổi) không để lại cập nhật dở dang
+		if err := im.moveStock(product, oldStock, input.Stock); err != nil {
+			if err.Error() == "insufficient stock" {
+				result.ProductID = 0
+				result.Changes = nil
+				result.Errors = []string{"stock: tồn kho hiện tại không đủ để giảm theo giá trị mới"}
+				return result, nil
+			}
+			return result, err
+		}
+		if err := im.productRepo.Update(product); err != nil {
+			return result, err
+		}
+		result.Action = ActionUpdated
+	} else {
+		// Không lưu kèm danh mục/thương hiệu đã nạp, chỉ dùng khóa ngoại; tồn kho được ghi qua sổ kho
+		product.Category = nil
+		product.Brand = nil
+		product.Stock = 0
+		if err := im.productRepo.Create(product); err != nil {
+			return result, err
+		}
+		// is_active có default:true nên giá trị false bị bỏ qua khi tạo, phải cập nhật riêng
+		if !row.active {
+			if err := im.productRepo.SetActive(product.ID, false); err != nil {
+				return result, err
+			}
+		}
+		if err := im.moveStock(product, 0, input.Stock); err != nil {
+			return result, err
+		}
+		result.Action = ActionCreated
+	}
+	result.ProductID = product.ID
+	return result, nil
+}
+
+// moveStock ghi tồn kho của dòng nhập vào sổ kho khi giá trị trong tệp khác tồn kho đã đọc (oldStock); số dư mới
+// được áp dụng trên tồn kho đã khóa nên đơn hàng vừa diễn ra trong lúc nhập không bị ghi đè bằng số chênh lệch cũ.
+// Sản phẩm có biến thể không sửa tồn kho trực tiếp.
+func (im *Importer) moveStock(product *model.Product, oldStock, stock int) error {
+	if stock == oldStock || len(product.Variants) > 0 {
+		return nil
+	}
+	return im.stockRepo.MoveToBalance(&model.StockMovement{
+		ProductID: product.ID,
+		Reason:    consts.StockReasonImport,
+		UserID:    im.userID,
+	}, stock)
+}
+
+// diffRows so sánh hai dòng theo ProductColumns, trả về các cột có giá trị khác nhau
+func diffRows(before, after []interface{}) map[string]audit.FieldChange {
+	changes := make(map[string]audit.FieldChange)
+	for i, column := range ProductColumns {
+		if before[i] != after[i] {
+			changes[column] = audit.FieldChange{Before: before[i], After: after[i]}
+		}
+	}
+	return changes
+}
+
+// readRow ghi đè các giá trị có trong dòng lên row, lỗi định dạng được thêm vào row.errors
+func (im *Importer) readRow(values []string, row *rowValues) error {
+	input := &row.input
+	rowErrors := &row.errors
+	text := map[string]*string{
+		ColumnName:        &input.Name,
+		ColumnDescription: &input.Description,
+		ColumnMaterial:    &input.Material,
+		ColumnColor:       &input.Color,
+		ColumnSize:        &input.Size,
+		ColumnDimensions:  &input.Dimensions,
+	}
+	for column, target := range text {
+		if value, ok := im.cell(values, column); ok {
+			*target = value
+		}
+	}
+
+	if value, ok := im.cell(values, ColumnPrice); ok && value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			*rowErrors = append(*rowErrors, "price: phải là số")
+		} else {
+			input.Price = price
+		}
+	}
+	if value, ok := im.cell(values, ColumnWeight); ok && value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			*rowErrors = append(*rowErrors, "weight: phải là số")
+		} else {
+			input.Weight = weight
+		}
+	}
+	if value, ok := im.cell(values, ColumnStock); ok && value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			*rowErrors = append(*rowErrors, "stock: phải là số nguyên")
+		} else if stock < 0 && stock != input.Stock {
+			// Chỉ giữ nguyên tồn kho âm hiện có của sản phẩm đang bán vượt tồn kho
+			*rowErrors = append(*rowErrors, "stock: không được âm")
+		} else {
+			input.Stock = stock
+		}
+	}
+	if value, ok := im.cell(values, ColumnFeatured); ok && value != "" {
+		featured, err := parseBool(value)
+		if err != nil {
+			*rowErrors = append(*rowErrors, "is_featured: "+err.Error())
+		} else {
+			input.IsFeatured = featured
+		}
+	}
+	if value, ok := im.cell(values, ColumnActive); ok && value != "" {
+		isActive, err := parseBool(value)
+		if err != nil {
+			*rowErrors = append(*rowErrors, "is_active: "+err.Error())
+		} else {
+			row.active = isActive
+		}
+	}
+
+	if value, ok := im.cell(values, ColumnCategory); ok {
+		input.CategoryID = nil
+		row.category = nil
+		if value != "" {
+			category, err := im.resolveCategory(value)
+			if err != nil {
+				return err
+			}
+			if category == nil {
+				*rowErrors = append(*rowErrors, "category: không tìm thấy danh mục "+value)
+			} else {
+				input.CategoryID = &category.ID
+				row.category = category
+			}
+		}
+	}
+	if value, ok := im.cell(values, ColumnBrand); ok {
+		input.BrandID = nil
+		row.brand = nil
+		if value != "" {
+			brand, err := im.resolveBrand(value)
+			if err != nil {
+				return err
+			}
+			if brand == nil {
+				*rowErrors = append(*rowErrors, "brand: không tìm thấy thương hiệu đang hoạt động "+value)
+			} else {
+				input.BrandID = &brand.ID
+				row.brand = brand
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCategory tìm danh mục theo slug, sau đó theo ID nếu giá trị là số
+func (im *Importer) resolveCategory(value string) (*model.Category, error) {
+	if category, cached := im.categories[value]; cached {
+		return category, nil
+	}
+
+	category, err := im.categoryRepo.GetBySlug(strings.ToLower(value))
+	if err != nil {
+		if err.Error() != "category not found" {
+			return nil, err
+		}
+		category = nil
+		if numeric, parseErr := strconv.ParseUint(value, 10, 32); parseErr == nil {
+			category, err = im.categoryRepo.GetByID(uint(numeric))
+			if err != nil {
+				if err.Error() != "category not found" {
+					return nil, err
+				}
+				category = nil
+			}
+		}
+	}
+
+	im.categories[value] = category
+	return category, nil
+}
+
+// resolveBrand tìm thương hiệu đang hoạt động theo slug, sau đó theo ID nếu giá trị là số
+func (im *Importer) resolveBrand(value string) (*model.Brand, error) {
+	if brand, cached := im.brands[value]; cached {
+		return brand, nil
+	}
+
+	brand, err := im.brandRepo.GetBySlug(strings.ToLower(value))
+	if err != nil {
+		if err.Error() != "brand not found" {
+			return nil, err
+		}
+		brand = nil
+		if numeric, parseErr := strconv.ParseUint(value, 10, 32); parseErr == nil {
+			brand, err = im.brandRepo.GetActiveByID(uint(numeric))
+			if err != nil {
+				if err.Error() != "brand not found" {
+					return nil, err
+				}
+				brand = nil
+			}
+		}
+	}
+
+	im.brands[value] = brand
+	return brand, nil
+}
+
+// parseBool nhận true/false, 1/0, yes/no, x và có/không
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "y", "x", "có":
+		return true, nil
+	case "false", "0", "no", "n", "không":
+		return false, nil
+	}
+	return false, errors.New("phải là true hoặc false")
+}
+
+// validationMessages chuyển lỗi kiểm tra ProductInput thành thông báo theo tên cột
+func validationMessages(err error) []string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		column, ok := fieldColumns[fieldErr.Field()]
+		if !ok {
+			column = NormalizeColumn(fieldErr.Field())
+		}
+
+		var message string
+		switch fieldErr.Tag() {
+		case "required":
+			message = "bắt buộc"
+		case "min":
+			message = "tối thiểu " + fieldErr.Param() + " ký tự"
+		case "max":
+			message = "tối đa " + fieldErr.Param() + " ký tự"
+		case "gt":
+			message = "phải lớn hơn " + fieldErr.Param()
+		case "gte":
+			message = "không được nhỏ hơn " + fieldErr.Param()
+		default:
+			message = "không hợp lệ (" + fieldErr.Tag() + ")"
+		}
+		messages = append(messages, column+": "+message)
+	}
+	return messages
+}
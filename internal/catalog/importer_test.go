@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"backend/internal/model"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"normalized names and blank columns", []string{" SKU ", "", "Is Featured"}, map[string]int{"sku": 0, "is_featured": 2}, false},
+		{"missing sku", []string{"name", "price"}, nil, true},
+		{"unknown column", []string{"sku", "colour"}, nil, true},
+		{"duplicate column", []string{"sku", "Name", "name"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeader(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHeader error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHeader = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{"TRUE", true, false},
+		{"1", true, false},
+		{"x", true, false},
+		{"Có", true, false},
+		{"no", false, false},
+		{"không", false, false},
+		{"maybe", false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBool(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseBool(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadRow(t *testing.T) {
+	header := []string{"sku", "name", "price", "stock", "weight", "is_featured", "is_active"}
+	columns, err := parseHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Không có cột danh mục/thương hiệu nên readRow không cần truy vấn cơ sở dữ liệu
+	im := &Importer{columns: columns}
+
+	existing := model.ProductInput{Name: "Ví cũ", Price: 100, Stock: 3, Weight: 0.2}
+	tests := []struct {
+		name       string
+		values     []string
+		stock      int
+		want       model.ProductInput
+		wantActive bool
+		wantErrors []string
+	}{
+		{
+			name:       "all values parsed",
+			values:     []string{"A1", "Ví mới", "250000", "7", "0.5", "yes", "0"},
+			want:       model.ProductInput{Name: "Ví mới", Price: 250000, Stock: 7, Weight: 0.5, IsFeatured: true},
+			wantActive: false,
+		},
+		{
+			name:       "blank number and bool cells keep existing values, short rows blank the name",
+			values:     []string{"A1"},
+			want:       model.ProductInput{Price: 100, Stock: 3, Weight: 0.2},
+			wantActive: true,
+		},
+		{
+			name:       "invalid values are reported per column",
+			values:     []string{"A1", "Ví", "abc", "1.5", "nặng", "maybe", "?"},
+			want:       model.ProductInput{Name: "Ví", Price: 100, Stock: 3, Weight: 0.2},
+			wantActive: true,
+			wantErrors: []string{
+				"price: phải là số",
+				"weight: phải là số",
+				"stock: phải là số nguyên",
+				"is_featured: phải là true hoặc false",
+				"is_active: phải là true hoặc false",
+			},
+		},
+		{
+			name:       "negative stock rejected",
+			values:     []string{"A1", "Ví", "", "-2"},
+			want:       model.ProductInput{Name: "Ví", Price: 100, Stock: 3, Weight: 0.2},
+			wantActive: true,
+			wantErrors: []string{"stock: không được âm"},
+		},
+		{
+			name:       "existing negative stock of an oversold product kept",
+			values:     []string{"A1", "Ví", "", "-2"},
+			stock:      -2,
+			want:       model.ProductInput{Name: "Ví", Price: 100, Stock: -2, Weight: 0.2},
+			wantActive: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := rowValues{input: existing, active: true}
+			if tt.stock != 0 {
+				row.input.Stock = tt.stock
+			}
+			if err := im.readRow(tt.values, &row); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(row.input, tt.want) {
+				t.Errorf("input = %+v, want %+v", row.input, tt.want)
+			}
+			if row.active != tt.wantActive {
+				t.Errorf("active = %v, want %v", row.active, tt.wantActive)
+			}
+			if !reflect.DeepEqual(row.errors, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", row.errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValidationMessages(t *testing.T) {
+	err := binding.Validator.ValidateStruct(&model.ProductInput{SKU: "A1", Weight: -1})
+	got := validationMessages(err)
+	sort.Strings(got)
+	want := []string{"name: bắt buộc", "price: bắt buộc", "weight: không được nhỏ hơn 0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validationMessages = %q, want %q", got, want)
+	}
+
+	if got := validationMessages(errors.New("boom")); !reflect.DeepEqual(got, []string{"boom"}) {
+		t.Errorf("validationMessages(plain error) = %q", got)
+	}
+}
+
+func TestErrorReport(t *testing.T) {
+	columns := []string{"sku", "price"}
+	if got, want := ErrorReportHeader(columns), []interface{}{"line", "sku", "price", "errors"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorReportHeader = %v, want %v", got, want)
+	}
+
+	rowError := model.ImportRowError{Line: 4, Values: []string{"A1"}, Errors: []string{"price: bắt buộc", "name: bắt buộc"}}
+	want := []interface{}{4, "A1", "", "price: bắt buộc; name: bắt buộc"}
+	if got := ErrorReportRow(columns, rowError); !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorReportRow = %v, want %v", got, want)
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	before := &model.Product{SKU: "A1", Name: "Ví", Price: 100, Stock: 3, IsActive: true,
+		Brand: &model.Brand{Slug: "gucci"}}
+	after := *before
+	after.Price = 120
+	after.Stock = 5
+	after.Brand = nil
+	after.Category = &model.Category{Slug: "vi-da"}
+
+	want := map[string]struct{ before, after interface{} }{
+		ColumnPrice:    {100.0, 120.0},
+		ColumnStock:    {3, 5},
+		ColumnBrand:    {"gucci", ""},
+		ColumnCategory: {"", "vi-da"},
+	}
+	changes := diffRows(ProductRow(before), ProductRow(&after))
+	if len(changes) != len(want) {
+		t.Errorf("changed columns = %v, want %d columns", changes, len(want))
+	}
+	for column, change := range want {
+		got, ok := changes[column]
+		if !ok || got.Before != change.before || got.After != change.after {
+			t.Errorf("change of %s = %+v, want %v -> %v", column, got, change.before, change.after)
+		}
+	}
+
+	if changes := diffRows(ProductRow(before), ProductRow(before)); len(changes) != 0 {
+		t.Errorf("diff of identical rows = %v, want none", changes)
+	}
+}
@@ -1,10 +1,35 @@
 package consts
 
 const (
-	// JWT
-	JWT_SECRET_KEY   = "your-secret-key-here"
+	// JWT (khóa ký được nạp từ JWT_KEYS_DIR, xem helpers.LoadJWTKeys)
 	JWT_EXPIRE_HOURS = 24
 
+	// Token giả danh người dùng (hỗ trợ khách hàng) có thời hạn ngắn
+	IMPERSONATION_EXPIRE_MINUTES = 15
+
+	// Thời hạn chờ người nhận xác nhận chuyển quyền owner
+	OWNER_TRANSFER_EXPIRE_HOURS = 48
+
+	// API key cho tích hợp (ERP, sàn TMĐT): tiền tố nhận diện, giới hạn số khóa và chu kỳ cập nhật last_used_at
+	API_KEY_PREFIX                   = "wsk_"
+	API_KEY_MAX_PER_USER             = 20
+	API_KEY_LAST_USED_UPDATE_SECONDS = 60
+
+	// Thời hạn bộ nhớ đệm người dùng và quyền riêng dùng khi xác thực mỗi request
+	AUTH_CACHE_SECONDS = 30
+
+	// Ảnh sản phẩm: dung lượng tối đa mỗi tệp (ghi đè bằng MAX_UPLOAD_SIZE, đơn vị byte) và số ảnh tối đa
+	DEFAULT_MAX_UPLOAD_SIZE = 5 << 20
+	MAX_IMAGES_PER_PRODUCT  = 20
+	MAX_IMAGES_PER_UPLOAD   = 10
+
+	// Nhập sản phẩm từ bảng tính: dung lượng tệp và số dòng dữ liệu tối đa mỗi lần nhập
+	MAX_IMPORT_FILE_SIZE = 10 << 20
+	MAX_IMPORT_ROWS      = 10000
+
+	// Thao tác hàng loạt trên sản phẩm: số sản phẩm tối đa mỗi lần
+	MAX_BULK_PRODUCTS = 1000
+
 	// Vai trò người dùng
 	ROLE_ADMIN = "admin"
 	ROLE_USER  = "user"
@@ -37,6 +62,178 @@ const (
 	PermissionNone   = "none"   // Không có quyền truy cập
 )
 
+// Tài nguyên có thể phân quyền
+const (
+	ResourceProducts   = "products"
+	ResourceCategories = "categories"
+	ResourceBrands     = "brands"
+	ResourceOrders     = "orders"
+	ResourceNews       = "news"
+	ResourceUsers      = "users"
+	ResourceCoupons    = "coupons"
+)
+
+// Danh sách tài nguyên hợp lệ để phân quyền
+var Resources = []string{
+	ResourceProducts,
+	ResourceCategories,
+	ResourceBrands,
+	ResourceOrders,
+	ResourceNews,
+	ResourceUsers,
+	ResourceCoupons,
+}
+
+// Thứ bậc cấp độ quyền để so sánh
+var PermissionLevels = map[string]int{
+	PermissionNone:  0,
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionFull:  3,
+}
+
+// Cấp độ quyền mặc định theo vai trò khi chưa được phân quyền riêng
+var DefaultRolePermissions = map[string]string{
+	RoleOwner:  PermissionFull,
+	RoleAdmin:  PermissionFull,
+	RoleMember: PermissionNone,
+	RoleUser:   PermissionNone,
+}
+
+// Trạng thái yêu cầu chuyển quyền owner
+const (
+	OwnerTransferPending   = "pending"
+	OwnerTransferCompleted = "completed"
+	OwnerTransferCancelled = "cancelled"
+	OwnerTransferDeclined  = "declined"
+	OwnerTransferExpired   = "expired"
+)
+
+// Trạng thái job nhập sản phẩm
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// Cách nhập sản phẩm theo SKU
+const (
+	ImportModeUpsert = "upsert" // Tạo mới hoặc cập nhật theo SKU
+	ImportModeCreate = "create" // Chỉ tạo mới, SKU đã tồn tại bị từ chối
+	ImportModeUpdate = "update" // Chỉ cập nhật, SKU chưa tồn tại bị từ chối
+)
+
+var ImportModes = []string{ImportModeUpsert, ImportModeCreate, ImportModeUpdate}
+
+// Lý do biến động tồn kho (sổ kho)
+const (
+	StockReasonInitial      = "initial"      // Tồn kho ban đầu khi tạo sản phẩm/biến thể
+	StockReasonSale         = "sale"         // Bán hàng (tạo đơn)
+	StockReasonCancellation = "cancellation" // Hủy đơn, hoàn lại tồn kho
+	StockReasonReturn       = "return"       // Khách trả hàng
+	StockReasonAdjustment   = "adjustment"   // Quản trị viên điều chỉnh
+	StockReasonImport       = "import"       // Nhập sản phẩm từ bảng tính
+	StockReasonStocktake    = "stocktake"    // Kiểm kê
+	StockReasonReservation  = "reservation"  // Giữ hàng cho đơn chờ thanh toán online
+	StockReasonTransfer     = "transfer"     // Chuyển hàng giữa các kho
+	StockReasonPurchase     = "purchase"     // Nhận hàng từ đơn đặt hàng nhà cung cấp
+)
+
+var StockReasons = []string{
+	StockReasonInitial,
+	StockReasonSale,
+	StockReasonCancellation,
+	StockReasonReturn,
+	StockReasonAdjustment,
+	StockReasonImport,
+	StockReasonStocktake,
+	StockReasonReservation,
+	StockReasonTransfer,
+	StockReasonPurchase,
+}
+
+// Trạng thái đơn đặt hàng nhà cung cấp
+const (
+	PurchaseOrderDraft             = "draft"              // Nháp, còn sửa được dòng hàng
+	PurchaseOrderOrdered           = "ordered"            // Đã gửi nhà cung cấp, chờ nhận hàng
+	PurchaseOrderPartiallyReceived = "partially_received" // Đã nhận một phần
+	PurchaseOrderReceived          = "received"           // Đã nhận đủ
+)
+
+var PurchaseOrderStatuses = []string{
+	PurchaseOrderDraft,
+	PurchaseOrderOrdered,
+	PurchaseOrderPartiallyReceived,
+	PurchaseOrderReceived,
+}
+
+// Trạng thái phiên kiểm kê
+const (
+	StocktakeOpen      = "open"      // Đang đếm
+	StocktakeApproved  = "approved"  // Đã duyệt, chênh lệch đã được ghi sổ kho
+	StocktakeCancelled = "cancelled" // Đã hủy, không điều chỉnh tồn kho
+)
+
+var StocktakeStatuses = []string{
+	StocktakeOpen,
+	StocktakeApproved,
+	StocktakeCancelled,
+}
+
+// Trạng thái giữ hàng cho đơn chờ thanh toán online
+const (
+	ReservationHeld      = "held"      // Đang giữ, chờ thanh toán
+	ReservationConverted = "converted" // Đã thanh toán, chuyển thành bán
+	ReservationReleased  = "released"  // Hết hạn hoặc đơn bị hủy, đã trả lại kho
+)
+
+// Chính sách bán khi hết hàng của sản phẩm
+const (
+	StockPolicyDeny      = "deny"      // Chỉ bán khi còn hàng
+	StockPolicyBackorder = "backorder" // Cho đặt khi hết hàng, giao khi hàng về
+	StockPolicyPreorder  = "preorder"  // Đặt trước, giao từ ngày dự kiến
+)
+
+// Tình trạng hàng của sản phẩm/biến thể và của dòng đơn hàng
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+	AvailabilityBackorder  = "backorder"
+	AvailabilityPreorder   = "preorder"
+)
+
+// Phương thức thanh toán online cần giữ hàng trong lúc chờ thanh toán
+var ReservationPaymentMethods = []string{"bank_transfer", "momo", "zalopay"}
+
+// Thao tác cập nhật hàng loạt sản phẩm (xóa hàng loạt dùng endpoint riêng)
+const (
+	BulkActionActivate    = "activate"
+	BulkActionDeactivate  = "deactivate"
+	BulkActionFeature     = "feature"
+	BulkActionUnfeature   = "unfeature"
+	BulkActionSetCategory = "set_category" // category_id = null để bỏ danh mục
+	BulkActionSetBrand    = "set_brand"    // brand_id = null để bỏ thương hiệu
+	BulkActionAdjustPrice = "adjust_price"
+	BulkActionDelete      = "delete"
+)
+
+var BulkUpdateActions = []string{
+	BulkActionActivate,
+	BulkActionDeactivate,
+	BulkActionFeature,
+	BulkActionUnfeature,
+	BulkActionSetCategory,
+	BulkActionSetBrand,
+	BulkActionAdjustPrice,
+}
+
+// Cách điều chỉnh giá hàng loạt
+const (
+	PriceAdjustPercent = "percent" // Tăng/giảm theo phần trăm (-10 là giảm 10%)
+	PriceAdjustAmount  = "amount"  // Cộng/trừ một số tiền cố định
+)
+
 // Ràng buộc hệ thống
 const (
 	MaxOwnerAccounts = 1 // Chỉ cho phép một tài khoản owner
@@ -77,3 +274,33 @@ func CanManageRole(userRole, targetRole string) bool {
 	
 	return false
 }
+
+// Kiểm tra tài nguyên có nằm trong danh sách được phân quyền không
+func IsValidResource(resource string) bool {
+	for _, r := range Resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// Trả về cấp độ quyền thấp hơn trong hai cấp độ (dùng để giới hạn quyền theo scope của API key)
+func MinPermission(a, b string) string {
+	if PermissionLevels[a] <= PermissionLevels[b] {
+		return a
+	}
+	return b
+}
+
+// Kiểm tra cấp độ quyền được cấp có đáp ứng cấp độ yêu cầu không
+func PermissionSatisfies(granted, required string) bool {
+	grantedLevel, exists1 := PermissionLevels[granted]
+	requiredLevel, exists2 := PermissionLevels[required]
+
+	if !exists1 || !exists2 {
+		return false
+	}
+
+	return grantedLevel >= requiredLevel
+}
@@ -0,0 +1,59 @@
+package consts
+
+import "testing"
+
+func TestPermissionSatisfies(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  string
+		required string
+		want     bool
+	}{
+		{"full satisfies write", PermissionFull, PermissionWrite, true},
+		{"write satisfies write", PermissionWrite, PermissionWrite, true},
+		{"write satisfies read", PermissionWrite, PermissionRead, true},
+		{"read does not satisfy write", PermissionRead, PermissionWrite, false},
+		{"none does not satisfy read", PermissionNone, PermissionRead, false},
+		{"none satisfies none", PermissionNone, PermissionNone, true},
+		{"unknown granted level", "admin", PermissionRead, false},
+		{"unknown required level", PermissionFull, "owner", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PermissionSatisfies(tt.granted, tt.required); got != tt.want {
+				t.Errorf("PermissionSatisfies(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinPermission(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{PermissionFull, PermissionRead, PermissionRead},
+		{PermissionRead, PermissionFull, PermissionRead},
+		{PermissionWrite, PermissionWrite, PermissionWrite},
+		{PermissionNone, PermissionFull, PermissionNone},
+		{PermissionWrite, PermissionNone, PermissionNone},
+	}
+	for _, tt := range tests {
+		if got := MinPermission(tt.a, tt.b); got != tt.want {
+			t.Errorf("MinPermission(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidResource(t *testing.T) {
+	for _, resource := range Resources {
+		if !IsValidResource(resource) {
+			t.Errorf("IsValidResource(%q) = false, want true", resource)
+		}
+	}
+	for _, resource := range []string{"", "settings", "Products"} {
+		if IsValidResource(resource) {
+			t.Errorf("IsValidResource(%q) = true, want false", resource)
+		}
+	}
+}
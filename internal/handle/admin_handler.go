@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"backend/internal/audit"
 	"backend/internal/consts"
 	"backend/internal/helpers"
 	"backend/internal/model"
@@ -142,12 +143,16 @@ func (h *AdminHandler) ToggleUserStatus(c *gin.Context) {
 		return
 	}
 
+	before := user.ToResponse()
+
 	user.IsActive = !user.IsActive
 	if err := h.userRepo.UpdateUser(user); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
 		return
 	}
 
+	audit.Record(c, "user.status_toggle", audit.EntityUser, user.ID, before, user.ToResponse())
+
 	response := model.UserResponse{
 		ID:       user.ID,
 		Username: user.Username,
@@ -169,7 +174,7 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 	}
 
 	// Kiểm tra xem người dùng có tồn tại không
-	_, err = h.userRepo.GetUserByID(uint(id))
+	user, err := h.userRepo.GetUserByID(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			helpers.ErrorResponse(c, http.StatusNotFound, consts.MSG_USER_NOT_FOUND, nil)
@@ -184,10 +189,12 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "user.delete", audit.EntityUser, user.ID, user.ToResponse(), nil)
+
 	helpers.SuccessResponse(c, "Xóa người dùng thành công", nil)
 }
 
-// CreateUser tạo tài khoản người dùng mới (chỉ Owner và Admin)
+// CreateUser tạo tài khoản người dùng mới (yêu cầu quyền ghi users)
 func (h *AdminHandler) CreateUser(c *gin.Context) {
 	currentUserRole, exists := c.Get("user_role")
 	if !exists {
@@ -195,18 +202,18 @@ func (h *AdminHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// Chỉ owner và admin mới có thể tạo người dùng
-	if currentUserRole != "owner" && currentUserRole != "admin" {
-		helpers.ErrorResponse(c, http.StatusForbidden, "Không đủ quyền", nil)
-		return
-	}
-
 	var input model.CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
 		return
 	}
 
+	// Không được tạo tài khoản có vai trò cao hơn vai trò hiện tại
+	if !consts.HasPermission(currentUserRole.(string), input.Role) {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Không đủ quyền tạo tài khoản với vai trò này", nil)
+		return
+	}
+
 	// Admin không thể tạo tài khoản owner
 	if currentUserRole == "admin" && input.Role == "owner" {
 		helpers.ErrorResponse(c, http.StatusForbidden, "Admin không thể tạo tài khoản owner", nil)
@@ -260,6 +267,8 @@ func (h *AdminHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "user.create", audit.EntityUser, user.ID, nil, user.ToResponse())
+
 	c.JSON(http.StatusCreated, helpers.Response{
 		Success: true,
 		Message: "Tạo người dùng thành công",
@@ -269,18 +278,6 @@ func (h *AdminHandler) CreateUser(c *gin.Context) {
 
 // GetUsersByRole lấy danh sách người dùng được lọc theo vai trò
 func (h *AdminHandler) GetUsersByRole(c *gin.Context) {
-	currentUserRole, exists := c.Get("user_role")
-	if !exists {
-		helpers.UnauthorizedResponse(c, "Chưa xác thực")
-		return
-	}
-
-	// Chỉ owner và admin mới có thể xem người dùng theo vai trò
-	if currentUserRole != "owner" && currentUserRole != "admin" {
-		helpers.ErrorResponse(c, http.StatusForbidden, "Không đủ quyền", nil)
-		return
-	}
-
 	role := c.Param("role")
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "10")
@@ -323,7 +320,7 @@ func (h *AdminHandler) GetUsersByRole(c *gin.Context) {
 	})
 }
 
-// AssignUserRole gán hoặc cập nhật vai trò người dùng (chỉ Owner và Admin)
+// AssignUserRole gán hoặc cập nhật vai trò người dùng (yêu cầu quyền ghi users)
 func (h *AdminHandler) AssignUserRole(c *gin.Context) {
 	currentUserID, exists := c.Get("user_id")
 	if !exists {
@@ -337,12 +334,6 @@ func (h *AdminHandler) AssignUserRole(c *gin.Context) {
 		return
 	}
 
-	// Chỉ owner và admin mới có thể gán vai trò
-	if currentUserRole != "owner" && currentUserRole != "admin" {
-		helpers.ErrorResponse(c, http.StatusForbidden, "Không đủ quyền", nil)
-		return
-	}
-
 	targetUserIDStr := c.Param("id")
 	targetUserID, err := strconv.ParseUint(targetUserIDStr, 10, 32)
 	if err != nil {
@@ -387,6 +378,13 @@ func (h *AdminHandler) AssignUserRole(c *gin.Context) {
 		}
 	}
 
+	targetUser, err := h.userRepo.GetUserByID(uint(targetUserID))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	before := targetUser.ToResponse()
+
 	// Cập nhật vai trò người dùng
 	if err := h.userRepo.UpdateUserRole(uint(targetUserID), input.Role); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật vai trò người dùng", err)
@@ -400,6 +398,8 @@ func (h *AdminHandler) AssignUserRole(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "user.role_update", audit.EntityUser, updatedUser.ID, before, updatedUser.ToResponse())
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật vai trò người dùng thành công",
@@ -407,20 +407,8 @@ func (h *AdminHandler) AssignUserRole(c *gin.Context) {
 	})
 }
 
-// GetUserStats lấy thống kê người dùng (chỉ Owner và Admin)
+// GetUserStats lấy thống kê người dùng (yêu cầu quyền đọc users)
 func (h *AdminHandler) GetUserStats(c *gin.Context) {
-	currentUserRole, exists := c.Get("user_role")
-	if !exists {
-		helpers.UnauthorizedResponse(c, "Chưa xác thực")
-		return
-	}
-
-	// Chỉ owner và admin mới có thể xem thống kê
-	if currentUserRole != "owner" && currentUserRole != "admin" {
-		helpers.ErrorResponse(c, http.StatusForbidden, "Không đủ quyền", nil)
-		return
-	}
-
 	stats, err := h.userRepo.GetUserStats()
 	if err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy thống kê người dùng", err)
@@ -0,0 +1,195 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyRepo     *repo.APIKeyRepo
+	permissionRepo *repo.PermissionRepo
+}
+
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo:     repo.NewAPIKeyRepo(),
+		permissionRepo: repo.NewPermissionRepo(),
+	}
+}
+
+// GetAPIKeys lấy danh sách API key của người dùng hiện tại
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+		return
+	}
+
+	keys, err := h.apiKeyRepo.GetByUserID(userID.(uint))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách API key", err)
+		return
+	}
+
+	responses := make([]model.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responses = append(responses, key.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách API key thành công",
+		Data:    responses,
+	})
+}
+
+// CreateAPIKey tạo API key mới cho người dùng hiện tại; khóa bí mật chỉ được trả về một lần
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+		return
+	}
+	userRole := c.GetString("user_role")
+
+	var input model.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	// Scope không được vượt quá quyền hiện có của người dùng trên tài nguyên
+	resources := make([]string, 0, len(input.Scopes))
+	for resource, level := range input.Scopes {
+		if !consts.IsValidResource(resource) {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tài nguyên không hợp lệ: "+resource, nil)
+			return
+		}
+		if level != consts.PermissionRead && level != consts.PermissionWrite && level != consts.PermissionFull {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Cấp độ quyền không hợp lệ cho tài nguyên "+resource, nil)
+			return
+		}
+
+		granted, _, err := h.permissionRepo.GetEffectiveLevel(userID.(uint), userRole, resource)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+			return
+		}
+		if !consts.PermissionSatisfies(granted, level) {
+			helpers.ErrorResponse(c, http.StatusForbidden, "Không thể cấp scope vượt quá quyền hiện có trên tài nguyên "+resource, nil)
+			return
+		}
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	count, err := h.apiKeyRepo.CountActiveByUserID(userID.(uint))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+	if count >= consts.API_KEY_MAX_PER_USER {
+		helpers.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Mỗi tài khoản chỉ được có tối đa %d API key còn hiệu lực", consts.API_KEY_MAX_PER_USER), nil)
+		return
+	}
+
+	plainKey, prefix, hash, err := helpers.GenerateAPIKey()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo API key", err)
+		return
+	}
+
+	scopes := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		scopes = append(scopes, resource+":"+input.Scopes[resource])
+	}
+
+	key := model.APIKey{
+		UserID:  userID.(uint),
+		Name:    strings.TrimSpace(input.Name),
+		Prefix:  prefix,
+		KeyHash: hash,
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := h.apiKeyRepo.Create(&key); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo API key", err)
+		return
+	}
+
+	audit.Record(c, "api_key.create", audit.EntityAPIKey, key.ID, nil, key.ToResponse())
+
+	response := key.ToResponse()
+	response.Key = plainKey
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo API key thành công. Hãy lưu lại khóa, khóa sẽ không được hiển thị lại",
+		Data:    response,
+	})
+}
+
+// RevokeAPIKey thu hồi API key của người dùng hiện tại
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ValidationErrorResponse(c, "ID API key không hợp lệ")
+		return
+	}
+
+	key, err := h.apiKeyRepo.GetByIDForUser(uint(id), userID.(uint))
+	if err != nil {
+		if err.Error() == "api key not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy API key", nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	if key.RevokedAt != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "API key đã bị thu hồi trước đó", nil)
+		return
+	}
+
+	before := key.ToResponse()
+	if err := h.apiKeyRepo.Revoke(key.ID); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể thu hồi API key", err)
+		return
+	}
+
+	updated, err := h.apiKeyRepo.GetByIDForUser(key.ID, userID.(uint))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	audit.Record(c, "api_key.revoke", audit.EntityAPIKey, key.ID, before, updated.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Thu hồi API key thành công",
+		Data:    updated.ToResponse(),
+	})
+}
@@ -0,0 +1,152 @@
+package handle
+
+import (
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditRepo *repo.AuditRepo
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditRepo: repo.NewAuditRepo(),
+	}
+}
+
+// GetAuditLogs tìm kiếm nhật ký thao tác của quản trị viên (chỉ Owner)
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "20")
+
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repo.AuditLogFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		RequestID:  c.Query("request_id"),
+		Keyword:    c.Query("q"),
+	}
+
+	if actorIDStr := c.Query("actor_id"); actorIDStr != "" {
+		actorID, err := strconv.ParseUint(actorIDStr, 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "ID người thực hiện không hợp lệ", errors.New("actor_id phải là số hợp lệ"))
+			return
+		}
+		value := uint(actorID)
+		filter.ActorID = &value
+	}
+
+	if entityIDStr := c.Query("entity_id"); entityIDStr != "" {
+		entityID, err := strconv.ParseUint(entityIDStr, 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "ID đối tượng không hợp lệ", errors.New("entity_id phải là số hợp lệ"))
+			return
+		}
+		value := uint(entityID)
+		filter.EntityID = &value
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimeParam(fromStr, false)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Thời gian bắt đầu không hợp lệ", err)
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimeParam(toStr, true)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Thời gian kết thúc không hợp lệ", err)
+			return
+		}
+		filter.To = &to
+	}
+
+	logs, total, err := h.auditRepo.Search(filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy nhật ký thao tác", err)
+		return
+	}
+
+	var response []model.AuditLogResponse
+	for _, log := range logs {
+		response = append(response, log.ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy nhật ký thao tác thành công",
+		Data: map[string]interface{}{
+			"logs":        response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// GetAuditLogByID lấy chi tiết một bản ghi nhật ký (chỉ Owner)
+func (h *AuditHandler) GetAuditLogByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID nhật ký không hợp lệ", errors.New("ID nhật ký phải là số hợp lệ"))
+		return
+	}
+
+	log, err := h.auditRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "audit log not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy nhật ký", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy nhật ký thao tác thành công",
+		Data:    log.ToResponse(),
+	})
+}
+
+// parseTimeParam chấp nhận RFC3339 hoặc YYYY-MM-DD; endOfDay dùng cho mốc kết thúc theo ngày
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("thời gian phải theo định dạng RFC3339 hoặc YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -214,3 +214,15 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 	helpers.SuccessResponse(c, consts.MSG_SUCCESS, response)
 }
+
+// GetJWKS trả về khóa công khai dùng để xác minh token (chuẩn JWKS)
+func (h *AuthHandler) GetJWKS(c *gin.Context) {
+	jwks, err := helpers.JWKS()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
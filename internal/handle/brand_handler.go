@@ -0,0 +1,296 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/worker"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BrandHandler struct {
+	brandRepo *repo.BrandRepo
+}
+
+func NewBrandHandler() *BrandHandler {
+	return &BrandHandler{
+		brandRepo: repo.NewBrandRepo(),
+	}
+}
+
+// CreateBrand tạo thương hiệu mới
+func (h *BrandHandler) CreateBrand(c *gin.Context) {
+	var input model.BrandInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	// Chuẩn hóa slug
+	input.Slug = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(input.Slug), " ", "-"))
+
+	// Kiểm tra xem slug đã tồn tại chưa
+	exists, err := h.brandRepo.CheckSlugExists(input.Slug, 0)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "Slug đã tồn tại", errors.New("thương hiệu với slug này đã tồn tại"))
+		return
+	}
+
+	brand := model.Brand{
+		Name:        input.Name,
+		Slug:        input.Slug,
+		Description: input.Description,
+		LogoURL:     input.LogoURL,
+		Website:     input.Website,
+		IsActive:    true,
+	}
+	if input.IsActive != nil {
+		brand.IsActive = *input.IsActive
+	}
+
+	if err := h.brandRepo.Create(&brand); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo thương hiệu", err)
+		return
+	}
+
+	// Cột is_active có default:true nên cần cập nhật lại khi tạo thương hiệu ở trạng thái ẩn
+	if !brand.IsActive {
+		if err := h.brandRepo.Update(&brand); err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo thương hiệu", err)
+			return
+		}
+	}
+
+	audit.Record(c, "brand.create", audit.EntityBrand, brand.ID, nil, brand.ToResponse())
+	worker.RefreshSuggestions()
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo thương hiệu thành công",
+		Data:    brand.ToResponse(),
+	})
+}
+
+// GetBrands lấy danh sách thương hiệu đang hoạt động kèm số sản phẩm
+func (h *BrandHandler) GetBrands(c *gin.Context) {
+	h.listBrands(c, true)
+}
+
+// GetAllBrands lấy tất cả thương hiệu, kể cả thương hiệu đã ẩn (trang quản trị)
+func (h *BrandHandler) GetAllBrands(c *gin.Context) {
+	h.listBrands(c, false)
+}
+
+func (h *BrandHandler) listBrands(c *gin.Context, activeOnly bool) {
+	brands, err := h.brandRepo.GetAll(activeOnly)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách thương hiệu", err)
+		return
+	}
+
+	brandIDs := make([]uint, 0, len(brands))
+	for _, brand := range brands {
+		brandIDs = append(brandIDs, brand.ID)
+	}
+
+	counts, err := h.brandRepo.CountProducts(brandIDs)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể đếm sản phẩm theo thương hiệu", err)
+		return
+	}
+
+	response := make([]model.BrandResponse, 0, len(brands))
+	for _, brand := range brands {
+		item := brand.ToResponse()
+		count := counts[brand.ID]
+		item.ProductCount = &count
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách thương hiệu thành công",
+		Data:    response,
+	})
+}
+
+// GetBrandBySlug lấy thương hiệu đang hoạt động theo slug kèm số sản phẩm
+func (h *BrandHandler) GetBrandBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Slug không hợp lệ", errors.New("slug không được để trống"))
+		return
+	}
+
+	brand, err := h.brandRepo.GetBySlug(slug)
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	h.respondWithCount(c, brand)
+}
+
+// GetBrandByID lấy thương hiệu theo ID (trang quản trị)
+func (h *BrandHandler) GetBrandByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID thương hiệu không hợp lệ", errors.New("ID thương hiệu phải là số hợp lệ"))
+		return
+	}
+
+	brand, err := h.brandRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	h.respondWithCount(c, brand)
+}
+
+func (h *BrandHandler) respondWithCount(c *gin.Context, brand *model.Brand) {
+	counts, err := h.brandRepo.CountProducts([]uint{brand.ID})
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể đếm sản phẩm theo thương hiệu", err)
+		return
+	}
+
+	response := brand.ToResponse()
+	count := counts[brand.ID]
+	response.ProductCount = &count
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy thông tin thương hiệu thành công",
+		Data:    response,
+	})
+}
+
+// UpdateBrand cập nhật thương hiệu
+func (h *BrandHandler) UpdateBrand(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID thương hiệu không hợp lệ", errors.New("ID thương hiệu phải là số hợp lệ"))
+		return
+	}
+
+	var input model.BrandInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	// Lấy thương hiệu hiện tại
+	brand, err := h.brandRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	// Chuẩn hóa slug
+	input.Slug = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(input.Slug), " ", "-"))
+
+	// Kiểm tra xem slug đã tồn tại chưa (loại trừ thương hiệu hiện tại)
+	exists, err := h.brandRepo.CheckSlugExists(input.Slug, uint(id))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "Slug đã tồn tại", errors.New("thương hiệu khác với slug này đã tồn tại"))
+		return
+	}
+
+	before := brand.ToResponse()
+	renamed := brand.Name != input.Name
+
+	// Cập nhật thương hiệu
+	brand.Name = input.Name
+	brand.Slug = input.Slug
+	brand.Description = input.Description
+	brand.LogoURL = input.LogoURL
+	brand.Website = input.Website
+	if input.IsActive != nil {
+		brand.IsActive = *input.IsActive
+	}
+
+	if err := h.brandRepo.Update(brand); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật thương hiệu", err)
+		return
+	}
+
+	audit.Record(c, "brand.update", audit.EntityBrand, brand.ID, before, brand.ToResponse())
+	// Tên thương hiệu là thẻ tìm kiếm của sản phẩm nên cần lập chỉ mục lại các sản phẩm của thương hiệu
+	if renamed {
+		worker.SyncMatchingProducts(repo.ProductFilter{BrandIDs: []uint{brand.ID}})
+	}
+	worker.RefreshSuggestions()
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật thương hiệu thành công",
+		Data:    brand.ToResponse(),
+	})
+}
+
+// DeleteBrand xóa thương hiệu; các sản phẩm thuộc thương hiệu được gỡ liên kết
+func (h *BrandHandler) DeleteBrand(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID thương hiệu không hợp lệ", errors.New("ID thương hiệu phải là số hợp lệ"))
+		return
+	}
+
+	// Kiểm tra xem thương hiệu có tồn tại không
+	brand, err := h.brandRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	productIDs, err := h.brandRepo.Delete(uint(id))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể xóa thương hiệu", err)
+		return
+	}
+
+	result := map[string]interface{}{"products_detached": len(productIDs)}
+	audit.Record(c, "brand.delete", audit.EntityBrand, brand.ID, brand.ToResponse(), result)
+	worker.SyncProducts(productIDs)
+	worker.RefreshSuggestions()
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Xóa thương hiệu thành công",
+		Data:    result,
+	})
+}
@@ -1,9 +1,17 @@
 package handle
 
 import (
+	"backend/internal/audit"
 	"backend/internal/helpers"
 	"backend/internal/model"
 	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/storage"
+	"backend/internal/worker"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,12 +20,14 @@ import (
 )
 
 type CartHandler struct {
-	cartRepo *repo.CartRepo
+	cartRepo    *repo.CartRepo
+	productRepo *repo.ProductRepo
 }
 
 func NewCartHandler() *CartHandler {
 	return &CartHandler{
-		cartRepo: repo.NewCartRepo(),
+		cartRepo:    repo.NewCartRepo(),
+		productRepo: repo.NewProductRepo(),
 	}
 }
 
@@ -56,7 +66,11 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartRepo.AddItem(userID.(uint), input.ProductID, input.Quantity); err != nil {
+	if !h.validateCartItem(c, input.ProductID, input.VariantID) {
+		return
+	}
+
+	if err := h.cartRepo.AddItem(userID.(uint), input.ProductID, input.VariantID, input.Quantity); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to add item to cart", err)
 		return
 	}
@@ -90,6 +104,11 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
+	variantID, ok := parseVariantQuery(c)
+	if !ok {
+		return
+	}
+
 	var input struct {
 		Quantity int `json:"quantity" binding:"required,gte=0"`
 	}
@@ -98,7 +117,7 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartRepo.UpdateItemQuantity(userID.(uint), uint(productID), input.Quantity); err != nil {
+	if err := h.cartRepo.UpdateItemQuantity(userID.(uint), uint(productID), variantID, input.Quantity); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to update cart item", err)
 		return
 	}
@@ -132,7 +151,12 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartRepo.RemoveItem(userID.(uint), uint(productID)); err != nil {
+	variantID, ok := parseVariantQuery(c)
+	if !ok {
+		return
+	}
+
+	if err := h.cartRepo.RemoveItem(userID.(uint), uint(productID), variantID); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to remove item from cart", err)
 		return
 	}
@@ -171,13 +195,74 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	})
 }
 
+// validateCartItem checks that the product is purchasable and the variant matches it:
+// products with variants require one of their active variants, others must not send one
+func (h *CartHandler) validateCartItem(c *gin.Context, productID uint, variantID *uint) bool {
+	product, err := h.productRepo.GetByID(productID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Product not found", err)
+			return false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return false
+	}
+	if !product.IsActive {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Product is not available", nil)
+		return false
+	}
+
+	if len(product.Variants) == 0 {
+		if variantID != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Product has no variants", errors.New("variant_id must be omitted for this product"))
+			return false
+		}
+		return true
+	}
+
+	if variantID == nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Please choose a variant", errors.New("variant_id is required for products with variants"))
+		return false
+	}
+	for _, variant := range product.Variants {
+		if variant.ID == *variantID {
+			if !variant.IsActive {
+				helpers.ErrorResponse(c, http.StatusBadRequest, "Variant is not available", nil)
+				return false
+			}
+			return true
+		}
+	}
+
+	helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid variant", errors.New("variant does not belong to this product"))
+	return false
+}
+
+// parseVariantQuery reads the optional variant_id query parameter that identifies a variant line in the cart
+func parseVariantQuery(c *gin.Context) (*uint, bool) {
+	raw := c.Query("variant_id")
+	if raw == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid variant ID", err)
+		return nil, false
+	}
+	variantID := uint(id)
+	return &variantID, true
+}
+
 type NewsHandler struct {
 	newsRepo *repo.NewsRepo
+	store    storage.Storage
 }
 
 func NewNewsHandler() *NewsHandler {
 	return &NewsHandler{
 		newsRepo: repo.NewNewsRepo(),
+		store:    storage.MustDefault(),
 	}
 }
 
@@ -236,6 +321,9 @@ func (h *NewsHandler) CreateNews(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "news.create", audit.EntityNews, createdNews.ID, nil, createdNews.ToResponse())
+	search.SyncNews(createdNews)
+
 	c.JSON(http.StatusCreated, helpers.Response{
 		Success: true,
 		Message: "News created successfully",
@@ -381,6 +469,15 @@ func (h *NewsHandler) UpdateNews(c *gin.Context) {
 		return
 	}
 
+	before := news.ToResponse()
+
+	// Switching to an external image URL detaches the uploaded image and its variants
+	oldImageKey, oldVariants := "", ""
+	if news.ImageKey != "" && input.ImageURL != news.ImageURL {
+		oldImageKey, oldVariants = news.ImageKey, news.Variants
+		news.ImageKey = ""
+	}
+
 	// Update news
 	news.Title = input.Title
 	news.Slug = input.Slug
@@ -402,6 +499,13 @@ func (h *NewsHandler) UpdateNews(c *gin.Context) {
 		return
 	}
 
+	if oldImageKey != "" {
+		if err := h.newsRepo.ClearImageVariants(news.ID); err != nil {
+			log.Printf("⚠️  Failed to clear image variants for news #%d: %v", news.ID, err)
+		}
+		h.deleteStoredImage(c, oldImageKey, oldVariants)
+	}
+
 	// Load updated news with author
 	updatedNews, err := h.newsRepo.GetByID(news.ID)
 	if err != nil {
@@ -409,6 +513,9 @@ func (h *NewsHandler) UpdateNews(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "news.update", audit.EntityNews, updatedNews.ID, before, updatedNews.ToResponse())
+	search.SyncNews(updatedNews)
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "News updated successfully",
@@ -416,6 +523,107 @@ func (h *NewsHandler) UpdateNews(c *gin.Context) {
 	})
 }
 
+// UploadNewsImage uploads the featured image of a news article (multipart field "image")
+func (h *NewsHandler) UploadNewsImage(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid news ID", err)
+		return
+	}
+
+	news, err := h.newsRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "news not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "News not found", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	maxSize := maxUploadSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+(1<<20))
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Upload exceeds the size limit", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Image file is required", err)
+		return
+	}
+	if file.Size > maxSize {
+		helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image exceeds the size limit (%d MB)", maxSize>>20), nil)
+		return
+	}
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Failed to read image", err)
+		return
+	}
+	extension, allowed := allowedImageTypes[contentType]
+	if !allowed {
+		helpers.ErrorResponse(c, http.StatusUnsupportedMediaType, "Unsupported image format", errors.New("only JPEG, PNG, GIF or WebP images are accepted"))
+		return
+	}
+
+	data, err := readSanitizedImage(file, contentType)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid image", err)
+		return
+	}
+
+	key, err := newUploadKey(fmt.Sprintf("news/%d", news.ID), extension)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to store image", err)
+		return
+	}
+	if err := h.store.Put(c.Request.Context(), key, bytes.NewReader(data), contentType); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to store image", err)
+		return
+	}
+
+	before := news.ToResponse()
+	oldImageKey, oldVariants := news.ImageKey, news.Variants
+
+	if err := h.newsRepo.UpdateImage(news.ID, h.store.URL(key), key); err != nil {
+		h.deleteStoredImage(c, key, "")
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to update news", err)
+		return
+	}
+	if oldImageKey != "" {
+		h.deleteStoredImage(c, oldImageKey, oldVariants)
+	}
+
+	worker.EnqueueImage(worker.ImageJob{Kind: worker.ImageJobNews, ID: news.ID})
+
+	updatedNews, err := h.newsRepo.GetByID(news.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to load updated news", err)
+		return
+	}
+
+	audit.Record(c, "news.image_upload", audit.EntityNews, updatedNews.ID, before, updatedNews.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "News image uploaded successfully",
+		Data:    updatedNews.ToResponse(),
+	})
+}
+
+// deleteStoredImage removes an uploaded image and its variants; failures only leave orphaned files
+func (h *NewsHandler) deleteStoredImage(c *gin.Context, key, variants string) {
+	if err := h.store.Delete(c.Request.Context(), key); err != nil {
+		log.Printf("⚠️  Failed to delete stored image %s: %v", key, err)
+	}
+	worker.DeleteImageVariants(h.store, variants)
+}
+
 // DeleteNews deletes a news article
 func (h *NewsHandler) DeleteNews(c *gin.Context) {
 	idStr := c.Param("id")
@@ -426,7 +634,7 @@ func (h *NewsHandler) DeleteNews(c *gin.Context) {
 	}
 
 	// Check if news exists
-	_, err = h.newsRepo.GetByID(uint(id))
+	news, err := h.newsRepo.GetByID(uint(id))
 	if err != nil {
 		if err.Error() == "news not found" {
 			helpers.ErrorResponse(c, http.StatusNotFound, "News not found", err)
@@ -441,6 +649,9 @@ func (h *NewsHandler) DeleteNews(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "news.delete", audit.EntityNews, news.ID, news.ToResponse(), nil)
+	search.RemoveNews(news.ID)
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "News deleted successfully",
@@ -1,10 +1,14 @@
 package handle
 
 import (
+	"backend/internal/audit"
 	"backend/internal/helpers"
 	"backend/internal/model"
 	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/worker"
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,11 +18,13 @@ import (
 
 type CategoryHandler struct {
 	categoryRepo *repo.CategoryRepo
+	productRepo  *repo.ProductRepo
 }
 
 func NewCategoryHandler() *CategoryHandler {
 	return &CategoryHandler{
 		categoryRepo: repo.NewCategoryRepo(),
+		productRepo:  repo.NewProductRepo(),
 	}
 }
 
@@ -44,10 +50,17 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
+	// Kiểm tra danh mục cha (nếu được cung cấp)
+	if !h.validateParent(c, 0, input.ParentID) {
+		return
+	}
+
 	category := model.Category{
 		Name:        input.Name,
 		Description: input.Description,
 		Slug:        input.Slug,
+		ParentID:    input.ParentID,
+		SortOrder:   input.SortOrder,
 		IsActive:    true,
 	}
 
@@ -56,6 +69,14 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
+	// Tải lại để có breadcrumb
+	if created, err := h.categoryRepo.GetByID(category.ID); err == nil {
+		category = *created
+	}
+
+	audit.Record(c, "category.create", audit.EntityCategory, category.ID, nil, category.ToResponse())
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusCreated, helpers.Response{
 		Success: true,
 		Message: "Tạo danh mục thành công",
@@ -93,6 +114,30 @@ func (h *CategoryHandler) GetCategories(c *gin.Context) {
 	})
 }
 
+// GetCategoryTree lấy cây danh mục đang hoạt động; danh mục đã ẩn bị loại cùng toàn bộ nhánh con
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	h.respondTree(c, true)
+}
+
+// GetAdminCategoryTree lấy cây danh mục gồm cả danh mục đã ẩn (trang quản trị)
+func (h *CategoryHandler) GetAdminCategoryTree(c *gin.Context) {
+	h.respondTree(c, false)
+}
+
+func (h *CategoryHandler) respondTree(c *gin.Context, activeOnly bool) {
+	tree, err := h.categoryRepo.GetTree(activeOnly)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy cây danh mục", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy cây danh mục thành công",
+		Data:    tree,
+	})
+}
+
 // GetCategoryByID lấy danh mục theo ID
 func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -184,16 +229,38 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	// Kiểm tra danh mục cha, không cho phép chuyển danh mục vào chính nó hoặc nhánh con của nó
+	if !h.validateParent(c, category.ID, input.ParentID) {
+		return
+	}
+
+	before := category.ToResponse()
+	// Đổi tên hoặc chuyển danh mục làm thay đổi đường dẫn (thẻ tìm kiếm) của sản phẩm trong cả nhánh
+	pathChanged := category.Name != input.Name || !sameParent(category.ParentID, input.ParentID)
+
 	// Cập nhật danh mục
 	category.Name = input.Name
 	category.Description = input.Description
 	category.Slug = input.Slug
+	category.ParentID = input.ParentID
+	category.SortOrder = input.SortOrder
 
 	if err := h.categoryRepo.Update(category); err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật danh mục", err)
 		return
 	}
 
+	// Tải lại để breadcrumb phản ánh danh mục cha mới
+	if updated, err := h.categoryRepo.GetByID(category.ID); err == nil {
+		category = updated
+	}
+
+	audit.Record(c, "category.update", audit.EntityCategory, category.ID, before, category.ToResponse())
+	if pathChanged {
+		h.syncSubtree(category.ID)
+	}
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật danh mục thành công",
@@ -201,7 +268,8 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	})
 }
 
-// DeleteCategory xóa danh mục
+// DeleteCategory xóa danh mục; mode quyết định cách xử lý sản phẩm của danh mục:
+// restrict (mặc định) từ chối nếu còn sản phẩm, reassign chuyển sang target_category_id, detach bỏ danh mục khỏi sản phẩm
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -210,8 +278,21 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
+	mode := c.DefaultQuery("mode", repo.CategoryDeleteRestrict)
+	validMode := false
+	for _, m := range repo.CategoryDeleteModes {
+		if mode == m {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Cách xóa không hợp lệ", errors.New("mode phải là một trong: "+strings.Join(repo.CategoryDeleteModes, ", ")))
+		return
+	}
+
 	// Kiểm tra xem danh mục có tồn tại không
-	_, err = h.categoryRepo.GetByID(uint(id))
+	category, err := h.categoryRepo.GetByID(uint(id))
 	if err != nil {
 		if err.Error() == "category not found" {
 			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy danh mục", err)
@@ -221,14 +302,172 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryRepo.Delete(uint(id)); err != nil {
+	// Danh mục đích khi chuyển sản phẩm
+	var targetID *uint
+	if mode == repo.CategoryDeleteReassign {
+		targetStr := c.Query("target_category_id")
+		target, err := strconv.ParseUint(targetStr, 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục đích không hợp lệ", errors.New("target_category_id là bắt buộc khi mode=reassign"))
+			return
+		}
+		if !h.validateTarget(c, category.ID, uint(target)) {
+			return
+		}
+		targetCategoryID := uint(target)
+		targetID = &targetCategoryID
+	}
+
+	result, err := h.categoryRepo.Delete(uint(id), mode, targetID)
+	if err != nil {
+		if err.Error() == "category has products" {
+			c.JSON(http.StatusConflict, helpers.Response{
+				Success: false,
+				Message: "Danh mục còn sản phẩm, hãy chọn mode=reassign hoặc mode=detach",
+				Data:    result,
+			})
+			return
+		}
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể xóa danh mục", err)
 		return
 	}
 
+	audit.Record(c, "category.delete", audit.EntityCategory, category.ID, category.ToResponse(), result)
+	h.syncProducts(result.ProductIDs)
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Xóa danh mục thành công",
-		Data:    nil,
+		Data:    result,
+	})
+}
+
+// MoveCategoryProducts chuyển sản phẩm giữa hai danh mục (to_category_id = null để bỏ danh mục);
+// product_ids rỗng nghĩa là chuyển toàn bộ sản phẩm của danh mục nguồn
+func (h *CategoryHandler) MoveCategoryProducts(c *gin.Context) {
+	var input struct {
+		FromCategoryID uint   `json:"from_category_id" binding:"required"`
+		ToCategoryID   *uint  `json:"to_category_id"`
+		ProductIDs     []uint `json:"product_ids" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	if _, err := h.categoryRepo.GetByID(input.FromCategoryID); err != nil {
+		if err.Error() == "category not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy danh mục nguồn", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if input.ToCategoryID != nil && !h.validateTarget(c, input.FromCategoryID, *input.ToCategoryID) {
+		return
+	}
+
+	moved, err := h.categoryRepo.MoveProducts(input.FromCategoryID, input.ToCategoryID, input.ProductIDs)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể chuyển sản phẩm", err)
+		return
+	}
+
+	result := map[string]interface{}{
+		"from_category_id": input.FromCategoryID,
+		"to_category_id":   input.ToCategoryID,
+		"products_moved":   len(moved),
+		"product_ids":      moved,
+	}
+
+	audit.Record(c, "category.move_products", audit.EntityCategory, input.FromCategoryID, nil, result)
+	h.syncProducts(moved)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Chuyển sản phẩm thành công",
+		Data:    result,
 	})
 }
+
+// validateTarget kiểm tra danh mục đích tồn tại và khác danh mục nguồn; tự trả lỗi khi không hợp lệ
+func (h *CategoryHandler) validateTarget(c *gin.Context, sourceID, targetID uint) bool {
+	if targetID == sourceID {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục đích không hợp lệ", errors.New("danh mục đích phải khác danh mục nguồn"))
+		return false
+	}
+	if _, err := h.categoryRepo.GetByID(targetID); err != nil {
+		if err.Error() == "category not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục đích không hợp lệ", errors.New("không tìm thấy danh mục đích"))
+			return false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	return true
+}
+
+// syncProducts cập nhật danh mục của các sản phẩm đã chuyển trong chỉ mục tìm kiếm
+func (h *CategoryHandler) syncProducts(ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	products, err := h.productRepo.GetActiveByIDs(ids)
+	if err != nil {
+		log.Printf("⚠️  Failed to reload moved products for search index: %v", err)
+		return
+	}
+	for i := range products {
+		search.SyncProduct(&products[i])
+	}
+}
+
+// syncSubtree lập chỉ mục lại sản phẩm của danh mục và mọi danh mục con cháu sau khi đường dẫn danh mục thay đổi
+func (h *CategoryHandler) syncSubtree(categoryID uint) {
+	ids, err := h.categoryRepo.GetSubtreeIDs(categoryID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load category #%d subtree for search index: %v", categoryID, err)
+		return
+	}
+	worker.SyncMatchingProducts(repo.ProductFilter{CategoryIDs: ids})
+}
+
+// sameParent so sánh hai danh mục cha (nil là danh mục gốc)
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// validateParent kiểm tra danh mục cha tồn tại và không tạo vòng lặp (categoryID = 0 khi tạo mới); tự trả lỗi khi không hợp lệ
+func (h *CategoryHandler) validateParent(c *gin.Context, categoryID uint, parentID *uint) bool {
+	if parentID == nil {
+		return true
+	}
+
+	if _, err := h.categoryRepo.GetByID(*parentID); err != nil {
+		if err.Error() == "category not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục cha không hợp lệ", errors.New("không tìm thấy danh mục cha"))
+			return false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+
+	if categoryID == 0 {
+		return true
+	}
+
+	cycle, err := h.categoryRepo.IsDescendant(categoryID, *parentID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	if cycle {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục cha không hợp lệ", errors.New("không thể chọn chính danh mục này hoặc danh mục con của nó làm danh mục cha"))
+		return false
+	}
+	return true
+}
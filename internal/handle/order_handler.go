@@ -1,29 +1,42 @@
 package handle
 
 import (
+	"backend/internal/audit"
+	"backend/internal/consts"
 	"backend/internal/helpers"
+	"backend/internal/inventory"
 	"backend/internal/model"
 	"backend/internal/repo"
+	"backend/internal/worker"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type OrderHandler struct {
-	orderRepo   *repo.OrderRepo
-	productRepo *repo.ProductRepo
-	cartRepo    *repo.CartRepo
+	orderRepo       *repo.OrderRepo
+	productRepo     *repo.ProductRepo
+	variantRepo     *repo.ProductVariantRepo
+	cartRepo        *repo.CartRepo
+	stockRepo       *repo.StockRepo
+	reservationRepo *repo.ReservationRepo
 }
 
 func NewOrderHandler() *OrderHandler {
 	return &OrderHandler{
-		orderRepo:   repo.NewOrderRepo(),
-		productRepo: repo.NewProductRepo(),
-		cartRepo:    repo.NewCartRepo(),
+		orderRepo:       repo.NewOrderRepo(),
+		productRepo:     repo.NewProductRepo(),
+		variantRepo:     repo.NewProductVariantRepo(),
+		cartRepo:        repo.NewCartRepo(),
+		stockRepo:       repo.NewStockRepo(),
+		reservationRepo: repo.NewReservationRepo(),
 	}
 }
 
@@ -50,6 +63,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Tính tổng tiền
 	var totalAmount float64 = 0
 	var orderItems []model.OrderItem
+	var products []*model.Product
 
 	for _, item := range input.Items {
 		// Lấy thông tin sản phẩm
@@ -59,26 +73,42 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			return
 		}
 
-		// Kiểm tra tồn kho
-		if product.Stock < item.Quantity {
-			helpers.ErrorResponse(c, http.StatusBadRequest, 
-				fmt.Sprintf("Không đủ hàng tồn kho cho sản phẩm %s", product.Name), nil)
+		orderItem := model.OrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitCost:  product.AverageCost,
+		}
+		price := product.Price
+
+		// Sản phẩm có biến thể phải chọn biến thể; giá và tồn kho lấy theo biến thể
+		if len(product.Variants) > 0 {
+			variant := findProductVariant(product, item.VariantID)
+			if variant == nil || !variant.IsActive {
+				helpers.ErrorResponse(c, http.StatusBadRequest,
+					fmt.Sprintf("Vui lòng chọn biến thể hợp lệ cho sản phẩm %s", product.Name), nil)
+				return
+			}
+			price = variant.EffectivePrice(product.Price)
+			orderItem.VariantID = &variant.ID
+			orderItem.VariantSKU = variant.SKU
+			orderItem.VariantName = variant.DisplayName()
+		} else if item.VariantID != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest,
+				fmt.Sprintf("Sản phẩm %s không có biến thể", product.Name), nil)
 			return
 		}
 
-		itemTotal := float64(item.Quantity) * product.Price
+		itemTotal := float64(item.Quantity) * price
 		totalAmount += itemTotal
 
-		orderItems = append(orderItems, model.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
-			Total:     itemTotal,
-		})
+		orderItem.Price = price
+		orderItem.Total = itemTotal
+		orderItems = append(orderItems, orderItem)
+		products = append(products, product)
 	}
 
 	// Áp dụng phí vận chuyển
-	shippingAmount := 30000.0 // Phí vận chuyển cố định
+	shippingAmount := 30000.0  // Phí vận chuyển cố định
 	if totalAmount >= 500000 { // Miễn phí vận chuyển cho đơn hàng >= 500k
 		shippingAmount = 0
 	}
@@ -90,37 +120,96 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	// Tạo đơn hàng
 	order := model.Order{
-		UserID:          input.UserID,
-		OrderNumber:     orderNumber,
-		Status:          "pending",
-		PaymentStatus:   "pending",
-		PaymentMethod:   input.PaymentMethod,
-		TotalAmount:     totalAmount,
-		ShippingAmount:  shippingAmount,
-		FinalAmount:     finalAmount,
-		CouponCode:      input.CouponCode,
-		ShippingAddress: input.ShippingAddress,
-		BillingAddress:  input.BillingAddress,
-		CustomerName:    input.CustomerName,
-		CustomerPhone:   input.CustomerPhone,
-		CustomerEmail:   input.CustomerEmail,
-		Notes:           input.Notes,
-		IsGuestOrder:    isGuestOrder,
-		OrderItems:      orderItems,
-	}
-
-	if err := h.orderRepo.Create(&order); err != nil {
+		UserID:           input.UserID,
+		OrderNumber:      orderNumber,
+		Status:           "pending",
+		PaymentStatus:    "pending",
+		PaymentMethod:    input.PaymentMethod,
+		TotalAmount:      totalAmount,
+		ShippingAmount:   shippingAmount,
+		FinalAmount:      finalAmount,
+		CouponCode:       input.CouponCode,
+		ShippingAddress:  input.ShippingAddress,
+		ShippingProvince: strings.TrimSpace(input.ShippingProvince),
+		BillingAddress:   input.BillingAddress,
+		CustomerName:     input.CustomerName,
+		CustomerPhone:    input.CustomerPhone,
+		CustomerEmail:    input.CustomerEmail,
+		Notes:            input.Notes,
+		IsGuestOrder:     isGuestOrder,
+		OrderItems:       orderItems,
+	}
+
+	// Tạo đơn và trừ tồn kho (ghi sổ kho) trong cùng giao dịch để không bán quá số hàng còn lại.
+	// Đơn thanh toán online chỉ giữ hàng đến khi hết hạn, quá hạn chưa thanh toán thì tự hủy và hoàn kho.
+	// Kho xuất hàng được chọn theo tỉnh/thành giao hàng và tồn kho từng kho.
+	reserve := requiresReservation(input.PaymentMethod)
+	var outOfStock *model.Product
+	err := repo.Transaction(func(tx *gorm.DB) error {
+		// Kiểm tra tồn kho trên số dư đã khóa; sản phẩm cho phép backorder/preorder được đặt vượt tồn kho,
+		// phần vượt chờ hàng về
+		requested := make(map[repo.StockKey]int)
+		for i := range order.OrderItems {
+			orderItem, product := &order.OrderItems[i], products[i]
+			key := repo.NewStockKey(orderItem.ProductID, orderItem.VariantID)
+			stock, err := h.stockRepo.WithTx(tx).LockStock(key)
+			if err != nil {
+				return err
+			}
+			backordered, ok := product.Oversell(stock-requested[key], orderItem.Quantity)
+			if !ok {
+				outOfStock = product
+				return errors.New("insufficient stock")
+			}
+			requested[key] += orderItem.Quantity
+
+			orderItem.Availability = consts.AvailabilityInStock
+			if backordered > 0 {
+				orderItem.Availability = consts.AvailabilityBackorder
+				if product.StockPolicy == consts.StockPolicyPreorder {
+					orderItem.Availability = consts.AvailabilityPreorder
+					orderItem.ExpectedShipAt = product.PreorderShipDate
+				}
+				orderItem.BackorderedQuantity = backordered
+				order.AwaitingStock = true
+			}
+		}
+
+		warehouseID, err := inventory.Allocate(tx, order.ShippingProvince, order.OrderItems)
+		if err != nil {
+			return err
+		}
+		order.WarehouseID = warehouseID
+
+		if err := h.orderRepo.WithTx(tx).Create(&order); err != nil {
+			return err
+		}
+		if !reserve {
+			return h.stockRepo.WithTx(tx).MoveOrder(&order, consts.StockReasonSale, -1, input.UserID)
+		}
+		if err := h.stockRepo.WithTx(tx).MoveOrder(&order, consts.StockReasonReservation, -1, input.UserID); err != nil {
+			return err
+		}
+		return h.reservationRepo.WithTx(tx).Hold(&order, time.Now().Add(worker.ReservationTimeout()))
+	})
+	if err != nil {
+		if outOfStock != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest,
+				fmt.Sprintf("Không đủ hàng tồn kho cho sản phẩm %s", outOfStock.Name), nil)
+			return
+		}
+		if err.Error() == "insufficient stock" {
+			helpers.ErrorResponse(c, http.StatusConflict, "Không đủ hàng tồn kho", errors.New("tồn kho vừa thay đổi, vui lòng thử lại"))
+			return
+		}
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusServiceUnavailable, "Chưa có kho hàng nào đang hoạt động", err)
+			return
+		}
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo đơn hàng", err)
 		return
 	}
 
-	// Cập nhật tồn kho sản phẩm
-	for _, item := range input.Items {
-		product, _ := h.productRepo.GetByID(item.ProductID)
-		newStock := product.Stock - item.Quantity
-		h.productRepo.UpdateStock(item.ProductID, newStock)
-	}
-
 	// Xóa giỏ hàng người dùng chỉ khi đó là người dùng đã đăng nhập
 	if input.UserID != nil {
 		h.cartRepo.ClearCart(*input.UserID)
@@ -275,11 +364,65 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderRepo.UpdateStatus(uint(id), input.Status); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật trạng thái đơn hàng", err)
+	order, err := h.orderRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "order not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn hàng", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	// Hủy đơn thì hoàn kho (kể cả hàng đang giữ chờ thanh toán); mở lại đơn đã hủy thì trừ kho lại.
+	// Trạng thái được đọc lại trên dòng đơn đã khóa để hủy song song (hoặc tự hủy khi hết hạn giữ hàng)
+	// không hoàn kho hai lần.
+	previousStatus := order.Status
+	err = repo.Transaction(func(tx *gorm.DB) error {
+		locked, err := h.orderRepo.WithTx(tx).LockByID(uint(id))
+		if err != nil {
+			return err
+		}
+		previousStatus = locked.Status
+
+		// Đơn còn sản phẩm bán vượt tồn kho chưa có hàng thì chưa được giao
+		if locked.AwaitingStock && (input.Status == "shipped" || input.Status == "delivered") {
+			return errors.New("order awaiting stock")
+		}
+
+		switch {
+		case input.Status == "cancelled" && locked.Status != "cancelled":
+			if err := h.stockRepo.WithTx(tx).MoveOrder(locked, consts.StockReasonCancellation, 1, currentUserID(c)); err != nil {
+				return err
+			}
+			if _, err := h.reservationRepo.WithTx(tx).Release(locked.ID); err != nil {
+				return err
+			}
+		case locked.Status == "cancelled" && input.Status != "cancelled":
+			if err := h.stockRepo.WithTx(tx).MoveOrder(locked, consts.StockReasonSale, -1, currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		return h.orderRepo.WithTx(tx).UpdateStatus(uint(id), input.Status)
+	})
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn hàng", err)
+		case "order awaiting stock":
+			helpers.ErrorResponse(c, http.StatusConflict, "Đơn hàng còn sản phẩm chờ hàng về", nil)
+		case "insufficient stock":
+			helpers.ErrorResponse(c, http.StatusConflict, "Không đủ hàng tồn kho để mở lại đơn hàng", err)
+		default:
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật trạng thái đơn hàng", err)
+		}
 		return
 	}
 
+	audit.Record(c, "order.status_update", audit.EntityOrder, order.ID,
+		map[string]interface{}{"order_number": order.OrderNumber, "status": previousStatus},
+		map[string]interface{}{"order_number": order.OrderNumber, "status": input.Status})
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật trạng thái đơn hàng thành công",
@@ -304,11 +447,49 @@ func (h *OrderHandler) UpdatePaymentStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderRepo.UpdatePaymentStatus(uint(id), input.PaymentStatus); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật trạng thái thanh toán", err)
+	order, err := h.orderRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "order not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn hàng", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	// Thanh toán thành công thì hàng đang giữ chuyển thành bán, không còn bị hủy khi hết hạn.
+	// Đơn được khóa trước để không ghi nhận thanh toán cho đơn vừa bị tự hủy và hoàn kho.
+	err = repo.Transaction(func(tx *gorm.DB) error {
+		locked, err := h.orderRepo.WithTx(tx).LockByID(uint(id))
+		if err != nil {
+			return err
+		}
+		if input.PaymentStatus == "paid" {
+			if locked.Status == "cancelled" {
+				return errors.New("order cancelled")
+			}
+			if _, err := h.reservationRepo.WithTx(tx).Convert(locked.ID); err != nil {
+				return err
+			}
+		}
+		return h.orderRepo.WithTx(tx).UpdatePaymentStatus(uint(id), input.PaymentStatus)
+	})
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn hàng", err)
+		case "order cancelled":
+			helpers.ErrorResponse(c, http.StatusConflict, "Đơn hàng đã bị hủy", errors.New("không thể ghi nhận thanh toán cho đơn đã hủy"))
+		default:
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật trạng thái thanh toán", err)
+		}
 		return
 	}
 
+	audit.Record(c, "order.payment_update", audit.EntityOrder, order.ID,
+		map[string]interface{}{"order_number": order.OrderNumber, "payment_status": order.PaymentStatus},
+		map[string]interface{}{"order_number": order.OrderNumber, "payment_status": input.PaymentStatus})
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật trạng thái thanh toán thành công",
@@ -316,6 +497,16 @@ func (h *OrderHandler) UpdatePaymentStatus(c *gin.Context) {
 	})
 }
 
+// requiresReservation cho biết phương thức thanh toán có cần giữ hàng trong lúc chờ thanh toán hay không
+func requiresReservation(paymentMethod string) bool {
+	for _, method := range consts.ReservationPaymentMethods {
+		if paymentMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
 // GetOrderStats lấy thống kê đơn hàng
 func (h *OrderHandler) GetOrderStats(c *gin.Context) {
 	stats, err := h.orderRepo.GetOrderStats()
@@ -331,6 +522,19 @@ func (h *OrderHandler) GetOrderStats(c *gin.Context) {
 	})
 }
 
+// findProductVariant tìm biến thể đã nạp của sản phẩm theo ID
+func findProductVariant(product *model.Product, variantID *uint) *model.ProductVariant {
+	if variantID == nil {
+		return nil
+	}
+	for i := range product.Variants {
+		if product.Variants[i].ID == *variantID {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
 func (h *OrderHandler) generateUniqueOrderNumber() string {
 	for {
 		timestamp := time.Now().Format("20060102150405")
@@ -0,0 +1,332 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OwnerHandler struct {
+	userRepo     *repo.UserRepository
+	transferRepo *repo.OwnerTransferRepo
+}
+
+func NewOwnerHandler(userRepo *repo.UserRepository) *OwnerHandler {
+	return &OwnerHandler{
+		userRepo:     userRepo,
+		transferRepo: repo.NewOwnerTransferRepo(),
+	}
+}
+
+// InitiateOwnerTransfer owner gửi yêu cầu chuyển quyền owner cho người dùng khác (xác nhận bằng mật khẩu)
+func (h *OwnerHandler) InitiateOwnerTransfer(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		helpers.UnauthorizedResponse(c, "Chưa xác thực")
+		return
+	}
+
+	var input model.OwnerTransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	owner, err := h.userRepo.GetUserByID(currentUserID.(uint))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	// Owner xác nhận bằng mật khẩu hiện tại
+	if !helpers.CheckPasswordHash(input.Password, owner.Password) {
+		helpers.ErrorResponse(c, http.StatusUnauthorized, "Mật khẩu không chính xác", nil)
+		return
+	}
+
+	if input.ToUserID == owner.ID {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể chuyển quyền cho chính mình", nil)
+		return
+	}
+
+	target, err := h.userRepo.GetUserByID(input.ToUserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.ErrorResponse(c, http.StatusNotFound, consts.MSG_USER_NOT_FOUND, nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+	if !target.IsActive {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Người nhận đang bị vô hiệu hóa", nil)
+		return
+	}
+
+	// Mỗi thời điểm chỉ có một yêu cầu đang chờ
+	if err := h.transferRepo.CancelPendingFrom(owner.ID); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể hủy yêu cầu cũ", err)
+		return
+	}
+
+	transfer := model.OwnerTransfer{
+		FromUserID: owner.ID,
+		ToUserID:   target.ID,
+		Status:     consts.OwnerTransferPending,
+		ExpiresAt:  time.Now().Add(time.Hour * consts.OWNER_TRANSFER_EXPIRE_HOURS),
+	}
+	if err := h.transferRepo.Create(&transfer); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo yêu cầu chuyển quyền", err)
+		return
+	}
+
+	created, err := h.transferRepo.GetByID(transfer.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tải yêu cầu chuyển quyền", err)
+		return
+	}
+
+	audit.Record(c, "owner_transfer.initiate", audit.EntityUser, target.ID, nil, created.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Đã gửi yêu cầu chuyển quyền owner, chờ người nhận xác nhận",
+		Data:    created.ToResponse(),
+	})
+}
+
+// GetOutgoingOwnerTransfer lấy yêu cầu chuyển quyền đang chờ do owner gửi
+func (h *OwnerHandler) GetOutgoingOwnerTransfer(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	transfer, err := h.transferRepo.GetPending(currentUserID.(uint), 0)
+	if err != nil {
+		if err.Error() == "owner transfer not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không có yêu cầu chuyển quyền đang chờ", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	helpers.SuccessResponse(c, "Lấy yêu cầu chuyển quyền thành công", transfer.ToResponse())
+}
+
+// CancelOwnerTransfer owner hủy yêu cầu chuyển quyền đang chờ
+func (h *OwnerHandler) CancelOwnerTransfer(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	transfer, ok := h.loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	if transfer.FromUserID != currentUserID.(uint) {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Không thể hủy yêu cầu của người khác", nil)
+		return
+	}
+
+	if err := h.transferRepo.UpdateStatus(transfer.ID, consts.OwnerTransferCancelled); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể hủy yêu cầu chuyển quyền", err)
+		return
+	}
+
+	audit.Record(c, "owner_transfer.cancel", audit.EntityUser, transfer.ToUserID,
+		map[string]interface{}{"transfer_id": transfer.ID, "status": transfer.Status},
+		map[string]interface{}{"transfer_id": transfer.ID, "status": consts.OwnerTransferCancelled})
+
+	helpers.SuccessResponse(c, "Đã hủy yêu cầu chuyển quyền", nil)
+}
+
+// GetIncomingOwnerTransfer người dùng xem yêu cầu chuyển quyền owner gửi đến mình
+func (h *OwnerHandler) GetIncomingOwnerTransfer(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	transfer, err := h.transferRepo.GetPending(0, currentUserID.(uint))
+	if err != nil {
+		if err.Error() == "owner transfer not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không có yêu cầu chuyển quyền đang chờ", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	helpers.SuccessResponse(c, "Lấy yêu cầu chuyển quyền thành công", transfer.ToResponse())
+}
+
+// AcceptOwnerTransfer người nhận xác nhận (bằng mật khẩu) và hoàn tất chuyển quyền owner
+func (h *OwnerHandler) AcceptOwnerTransfer(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	var input model.ConfirmPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	transfer, ok := h.loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	if transfer.ToUserID != currentUserID.(uint) {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Yêu cầu chuyển quyền không dành cho bạn", nil)
+		return
+	}
+
+	recipient, err := h.userRepo.GetUserByID(transfer.ToUserID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+	if !helpers.CheckPasswordHash(input.Password, recipient.Password) {
+		helpers.ErrorResponse(c, http.StatusUnauthorized, "Mật khẩu không chính xác", nil)
+		return
+	}
+
+	if err := h.transferRepo.Complete(transfer); err != nil {
+		helpers.ErrorResponse(c, http.StatusConflict, "Không thể hoàn tất chuyển quyền owner", err)
+		return
+	}
+
+	newOwner, err := h.userRepo.GetUserByID(transfer.ToUserID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	audit.Record(c, "owner_transfer.complete", audit.EntityUser, newOwner.ID,
+		map[string]interface{}{"owner_id": transfer.FromUserID, "recipient_role": recipient.Role},
+		map[string]interface{}{"owner_id": newOwner.ID, "previous_owner_role": consts.RoleAdmin})
+
+	// Cấp token mới phản ánh vai trò owner
+	token, err := helpers.GenerateJWT(newOwner.ID, newOwner.Username, newOwner.Role)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	helpers.SuccessResponse(c, "Chuyển quyền owner thành công", gin.H{
+		"user":  newOwner.ToResponse(),
+		"token": token,
+	})
+}
+
+// DeclineOwnerTransfer người nhận từ chối yêu cầu chuyển quyền owner
+func (h *OwnerHandler) DeclineOwnerTransfer(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	transfer, ok := h.loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	if transfer.ToUserID != currentUserID.(uint) {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Yêu cầu chuyển quyền không dành cho bạn", nil)
+		return
+	}
+
+	if err := h.transferRepo.UpdateStatus(transfer.ID, consts.OwnerTransferDeclined); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể từ chối yêu cầu chuyển quyền", err)
+		return
+	}
+
+	audit.Record(c, "owner_transfer.decline", audit.EntityUser, transfer.ToUserID,
+		map[string]interface{}{"transfer_id": transfer.ID, "status": transfer.Status},
+		map[string]interface{}{"transfer_id": transfer.ID, "status": consts.OwnerTransferDeclined})
+
+	helpers.SuccessResponse(c, "Đã từ chối yêu cầu chuyển quyền", nil)
+}
+
+// ImpersonateUser owner nhận token ngắn hạn để thao tác dưới danh nghĩa người dùng (hỗ trợ khách hàng)
+func (h *OwnerHandler) ImpersonateUser(c *gin.Context) {
+	currentUserID, _ := c.Get("user_id")
+
+	targetID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		helpers.ValidationErrorResponse(c, "ID người dùng không hợp lệ")
+		return
+	}
+
+	var input model.ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Cần nêu lý do giả danh", err)
+		return
+	}
+
+	target, err := h.userRepo.GetUserByID(uint(targetID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.ErrorResponse(c, http.StatusNotFound, consts.MSG_USER_NOT_FOUND, nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	if target.Role == consts.RoleOwner {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Không thể giả danh tài khoản owner", errors.New("owner không thể bị giả danh"))
+		return
+	}
+	if !target.IsActive {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Người dùng đang bị vô hiệu hóa", nil)
+		return
+	}
+
+	token, expiresAt, err := helpers.GenerateImpersonationJWT(target.ID, target.Username, target.Role, currentUserID.(uint))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	audit.Record(c, "user.impersonate", audit.EntityUser, target.ID, nil, map[string]interface{}{
+		"reason":     input.Reason,
+		"expires_at": expiresAt,
+	})
+
+	helpers.SuccessResponse(c, "Tạo token giả danh thành công", gin.H{
+		"user":            target.ToResponse(),
+		"token":           token,
+		"impersonation":   true,
+		"impersonator_id": currentUserID,
+		"expires_at":      expiresAt,
+	})
+}
+
+// loadPendingTransfer đọc :id và đảm bảo yêu cầu vẫn đang chờ xác nhận
+func (h *OwnerHandler) loadPendingTransfer(c *gin.Context) (*model.OwnerTransfer, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID yêu cầu không hợp lệ", errors.New("ID yêu cầu phải là số hợp lệ"))
+		return nil, false
+	}
+
+	if err := h.transferRepo.ExpireStale(); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+
+	transfer, err := h.transferRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "owner transfer not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy yêu cầu chuyển quyền", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+
+	if transfer.Status != consts.OwnerTransferPending {
+		helpers.ErrorResponse(c, http.StatusConflict, "Yêu cầu chuyển quyền không còn hiệu lực", errors.New("trạng thái hiện tại: "+transfer.Status))
+		return nil, false
+	}
+
+	return transfer, true
+}
@@ -0,0 +1,170 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PermissionHandler struct {
+	permissionRepo *repo.PermissionRepo
+	userRepo       *repo.UserRepository
+}
+
+func NewPermissionHandler(userRepo *repo.UserRepository) *PermissionHandler {
+	return &PermissionHandler{
+		permissionRepo: repo.NewPermissionRepo(),
+		userRepo:       userRepo,
+	}
+}
+
+// GetUserPermissions lấy quyền thực tế của người dùng trên tất cả tài nguyên (chỉ Owner)
+func (h *PermissionHandler) GetUserPermissions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		helpers.ValidationErrorResponse(c, "ID người dùng không hợp lệ")
+		return
+	}
+
+	user, err := h.userRepo.GetUserByID(uint(userID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.ErrorResponse(c, http.StatusNotFound, consts.MSG_USER_NOT_FOUND, nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	var permissions []model.EffectivePermissionResponse
+	for _, resource := range consts.Resources {
+		level, isDefault, err := h.permissionRepo.GetEffectiveLevel(user.ID, user.Role, resource)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy quyền người dùng", err)
+			return
+		}
+		permissions = append(permissions, model.EffectivePermissionResponse{
+			Resource:   resource,
+			Permission: level,
+			IsDefault:  isDefault,
+		})
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy quyền người dùng thành công",
+		Data: map[string]interface{}{
+			"user":        user.ToResponse(),
+			"permissions": permissions,
+		},
+	})
+}
+
+// AssignPermission cấp hoặc cập nhật quyền của người dùng trên một tài nguyên (chỉ Owner)
+func (h *PermissionHandler) AssignPermission(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		helpers.UnauthorizedResponse(c, "Chưa xác thực")
+		return
+	}
+
+	var input model.AssignPermissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	if !consts.IsValidResource(input.Resource) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tài nguyên không hợp lệ", errors.New("tài nguyên không được hỗ trợ phân quyền"))
+		return
+	}
+
+	user, err := h.userRepo.GetUserByID(input.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.ErrorResponse(c, http.StatusNotFound, consts.MSG_USER_NOT_FOUND, nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	// Owner luôn có toàn quyền, không thể phân quyền lại
+	if user.Role == consts.RoleOwner {
+		helpers.ErrorResponse(c, http.StatusForbidden, "Không thể thay đổi quyền của tài khoản owner", nil)
+		return
+	}
+
+	var before interface{}
+	if existing, err := h.permissionRepo.Get(user.ID, input.Resource); err == nil {
+		before = existing.ToResponse()
+	}
+
+	permission := model.UserPermission{
+		UserID:     user.ID,
+		Resource:   input.Resource,
+		Permission: input.Permission,
+		GrantedBy:  currentUserID.(uint),
+	}
+
+	if err := h.permissionRepo.Upsert(&permission); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật quyền", err)
+		return
+	}
+
+	saved, err := h.permissionRepo.Get(user.ID, input.Resource)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tải quyền đã cập nhật", err)
+		return
+	}
+
+	audit.Record(c, "permission.grant", audit.EntityPermission, saved.UserID, before, saved.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật quyền thành công",
+		Data:    saved.ToResponse(),
+	})
+}
+
+// RevokePermission thu hồi quyền riêng, người dùng quay về quyền mặc định theo vai trò (chỉ Owner)
+func (h *PermissionHandler) RevokePermission(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		helpers.ValidationErrorResponse(c, "ID người dùng không hợp lệ")
+		return
+	}
+
+	resource := c.Param("resource")
+	if !consts.IsValidResource(resource) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tài nguyên không hợp lệ", errors.New("tài nguyên không được hỗ trợ phân quyền"))
+		return
+	}
+
+	existing, err := h.permissionRepo.Get(uint(userID), resource)
+	if err != nil {
+		if err.Error() == "permission not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy quyền", errors.New("người dùng không có quyền riêng trên tài nguyên này"))
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+		return
+	}
+
+	if _, err := h.permissionRepo.Delete(uint(userID), resource); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể thu hồi quyền", err)
+		return
+	}
+
+	audit.Record(c, "permission.revoke", audit.EntityPermission, existing.UserID, existing.ToResponse(), nil)
+
+	helpers.SuccessResponse(c, "Thu hồi quyền thành công", nil)
+}
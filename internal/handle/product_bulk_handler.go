@@ -0,0 +1,193 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/catalog"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/search"
+	"backend/internal/worker"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkTargetInput chọn sản phẩm cho thao tác hàng loạt: danh sách ID hoặc bộ lọc dạng query giống
+// GET /api/admin/products (ví dụ "category_id=3&include_descendants=true&brand=nike"), thêm is_active=false
+// để chọn sản phẩm đang ngừng bán. dry_run=true trả về kết quả dự kiến mà không lưu.
+type bulkTargetInput struct {
+	IDs    []uint `json:"ids"`
+	Filter string `json:"filter"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type bulkUpdateInput struct {
+	bulkTargetInput
+	Action     string  `json:"action" binding:"required"`
+	CategoryID *uint   `json:"category_id"`
+	BrandID    *uint   `json:"brand_id"`
+	PriceMode  string  `json:"price_mode"`
+	PriceValue float64 `json:"price_value"`
+}
+
+// BulkUpdateProducts kích hoạt/ngừng bán, đánh dấu nổi bật, chuyển danh mục, đặt thương hiệu hoặc
+// điều chỉnh giá cho nhiều sản phẩm trong một giao dịch; có sản phẩm bị từ chối thì không lưu gì
+func (h *ProductHandler) BulkUpdateProducts(c *gin.Context) {
+	var input bulkUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+	if input.Action == consts.BulkActionDelete {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thao tác không hợp lệ", errors.New("dùng POST /api/admin/products/bulk/delete để xóa hàng loạt"))
+		return
+	}
+
+	h.runBulk(c, input.bulkTargetInput, catalog.BulkChange{
+		Action:     input.Action,
+		CategoryID: input.CategoryID,
+		BrandID:    input.BrandID,
+		PriceMode:  input.PriceMode,
+		PriceValue: input.PriceValue,
+	})
+}
+
+// BulkDeleteProducts xóa mềm nhiều sản phẩm trong một giao dịch (có thể khôi phục từ thùng rác)
+func (h *ProductHandler) BulkDeleteProducts(c *gin.Context) {
+	var input bulkTargetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	h.runBulk(c, input, catalog.BulkChange{Action: consts.BulkActionDelete})
+}
+
+func (h *ProductHandler) runBulk(c *gin.Context, target bulkTargetInput, change catalog.BulkChange) {
+	if err := change.Validate(); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thao tác không hợp lệ", err)
+		return
+	}
+
+	ids, ok := h.resolveBulkTargets(c, target)
+	if !ok {
+		return
+	}
+
+	report, err := catalog.RunBulk(change, ids, target.DryRun)
+	if err != nil {
+		switch err.Error() {
+		case "category not found":
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục không hợp lệ", errors.New("không tìm thấy danh mục"))
+		case "brand not found":
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Thương hiệu không hợp lệ", errors.New("không tìm thấy thương hiệu đang hoạt động"))
+		default:
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể thực hiện thao tác hàng loạt", err)
+		}
+		return
+	}
+
+	if report.Rejected > 0 {
+		c.JSON(http.StatusUnprocessableEntity, helpers.Response{
+			Success: false,
+			Message: fmt.Sprintf("%d sản phẩm không thể xử lý, không có thay đổi nào được lưu", report.Rejected),
+			Data:    report,
+		})
+		return
+	}
+	if report.DryRun {
+		c.JSON(http.StatusOK, helpers.Response{
+			Success: true,
+			Message: "Chạy thử thao tác hàng loạt thành công, chưa có thay đổi nào được lưu",
+			Data:    report,
+		})
+		return
+	}
+
+	audit.Record(c, "product.bulk_"+change.Action, audit.EntityProduct, 0, nil, report)
+
+	deletedIDs := report.ChangedIDs(catalog.ActionDeleted)
+	for _, id := range deletedIDs {
+		search.RemoveProduct(id)
+	}
+	updatedIDs := report.ChangedIDs(catalog.ActionUpdated)
+	worker.SyncProducts(updatedIDs)
+	if len(deletedIDs)+len(updatedIDs) > 0 {
+		worker.RefreshSuggestions()
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Thực hiện thao tác hàng loạt thành công",
+		Data:    report,
+	})
+}
+
+// resolveBulkTargets trả về ID sản phẩm cần xử lý (đã bỏ trùng); tự trả lỗi khi không hợp lệ
+func (h *ProductHandler) resolveBulkTargets(c *gin.Context, target bulkTargetInput) ([]uint, bool) {
+	filter := strings.TrimSpace(target.Filter)
+	if (len(target.IDs) == 0) == (filter == "") {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("cần cung cấp ids hoặc filter (không dùng cả hai)"))
+		return nil, false
+	}
+
+	if len(target.IDs) > 0 {
+		seen := make(map[uint]bool, len(target.IDs))
+		ids := make([]uint, 0, len(target.IDs))
+		for _, id := range target.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > consts.MAX_BULK_PRODUCTS {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Quá nhiều sản phẩm", fmt.Errorf("tối đa %d sản phẩm mỗi lần", consts.MAX_BULK_PRODUCTS))
+			return nil, false
+		}
+		return ids, true
+	}
+
+	values, err := url.ParseQuery(strings.TrimPrefix(filter, "?"))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return nil, false
+	}
+	productFilter, err := h.parseFilterValues(values)
+	if err == nil {
+		if value := values.Get("is_active"); value != "" {
+			active, parseErr := strconv.ParseBool(value)
+			if parseErr != nil {
+				err = errors.New("is_active phải là true hoặc false")
+			}
+			productFilter.Active = &active
+		}
+	}
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return nil, false
+	}
+
+	ids, err := h.productRepo.FilteredIDs(productFilter, consts.MAX_BULK_PRODUCTS+1)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách sản phẩm", err)
+		return nil, false
+	}
+	if len(ids) == 0 {
+		helpers.ErrorResponse(c, http.StatusNotFound, "Không có sản phẩm nào khớp bộ lọc", nil)
+		return nil, false
+	}
+	if len(ids) > consts.MAX_BULK_PRODUCTS {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Quá nhiều sản phẩm", fmt.Errorf("bộ lọc khớp hơn %d sản phẩm, hãy thu hẹp bộ lọc", consts.MAX_BULK_PRODUCTS))
+		return nil, false
+	}
+	return ids, true
+}
@@ -1,26 +1,42 @@
 package handle
 
 import (
+	"backend/internal/audit"
+	"backend/internal/consts"
 	"backend/internal/helpers"
 	"backend/internal/model"
 	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/worker"
 	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ProductHandler struct {
-	productRepo  *repo.ProductRepo
-	categoryRepo *repo.CategoryRepo
+	productRepo   *repo.ProductRepo
+	variantRepo   *repo.ProductVariantRepo
+	categoryRepo  *repo.CategoryRepo
+	brandRepo     *repo.BrandRepo
+	importJobRepo *repo.ImportJobRepo
+	stockRepo     *repo.StockRepo
+	warehouseRepo *repo.WarehouseRepo
 }
 
 func NewProductHandler() *ProductHandler {
 	return &ProductHandler{
-		productRepo:  repo.NewProductRepo(),
-		categoryRepo: repo.NewCategoryRepo(),
+		productRepo:   repo.NewProductRepo(),
+		variantRepo:   repo.NewProductVariantRepo(),
+		categoryRepo:  repo.NewCategoryRepo(),
+		brandRepo:     repo.NewBrandRepo(),
+		importJobRepo: repo.NewImportJobRepo(),
+		stockRepo:     repo.NewStockRepo(),
+		warehouseRepo: repo.NewWarehouseRepo(),
 	}
 }
 
@@ -35,8 +51,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	// Chuẩn hóa SKU
 	input.SKU = strings.ToUpper(strings.TrimSpace(input.SKU))
 
-	// Kiểm tra xem SKU đã tồn tại chưa
+	// Kiểm tra xem SKU đã tồn tại chưa (kể cả SKU của biến thể)
 	exists, err := h.productRepo.CheckSKUExists(input.SKU, 0)
+	if err == nil && !exists {
+		exists, err = h.variantRepo.CheckSKUExists(input.SKU, 0)
+	}
 	if err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
 		return
@@ -55,17 +74,62 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		}
 	}
 
-	product := model.Product{
-		Name:        input.Name,
-		Description: input.Description,
-		Price:       input.Price,
-		SKU:         input.SKU,
-		Stock:       input.Stock,
-		CategoryID:  input.CategoryID,
-		IsActive:    true,
+	// Kiểm tra thương hiệu tồn tại và đang hoạt động (nếu được cung cấp)
+	if input.BrandID != nil {
+		_, err := h.brandRepo.GetActiveByID(*input.BrandID)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Thương hiệu không hợp lệ", errors.New("không tìm thấy thương hiệu đang hoạt động"))
+			return
+		}
+	}
+
+	if !validateStockPolicy(c, input, 0) {
+		return
 	}
 
-	if err := h.productRepo.Create(&product); err != nil {
+	product := model.Product{
+		Name:              input.Name,
+		Description:       input.Description,
+		Price:             input.Price,
+		SKU:               input.SKU,
+		CategoryID:        input.CategoryID,
+		BrandID:           input.BrandID,
+		Material:          input.Material,
+		Color:             input.Color,
+		Size:              input.Size,
+		Weight:            input.Weight,
+		Dimensions:        input.Dimensions,
+		IsActive:          true,
+		LowStockThreshold: input.LowStockThreshold,
+		StockPolicy:       input.StockPolicy,
+		MaxBackorder:      input.MaxBackorder,
+		PreorderShipDate:  input.PreorderShipDate,
+		IsFeatured:        input.IsFeatured,
+	}
+	if product.StockPolicy == "" {
+		product.StockPolicy = consts.StockPolicyDeny
+	}
+
+	// Tạo sản phẩm và ghi tồn kho ban đầu vào sổ kho trong cùng giao dịch
+	err = repo.Transaction(func(tx *gorm.DB) error {
+		if err := h.productRepo.WithTx(tx).Create(&product); err != nil {
+			return err
+		}
+		if input.Stock <= 0 {
+			return nil
+		}
+		return h.stockRepo.WithTx(tx).Move(&model.StockMovement{
+			ProductID: product.ID,
+			Delta:     input.Stock,
+			Reason:    consts.StockReasonInitial,
+			UserID:    currentUserID(c),
+		})
+	})
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			stockErrorResponse(c, err)
+			return
+		}
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo sản phẩm", err)
 		return
 	}
@@ -77,6 +141,10 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "product.create", audit.EntityProduct, createdProduct.ID, nil, createdProduct.ToResponse())
+	search.SyncProduct(createdProduct)
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusCreated, helpers.Response{
 		Success: true,
 		Message: "Tạo sản phẩm thành công",
@@ -84,12 +152,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	})
 }
 
-// GetProducts lấy tất cả sản phẩm với phân trang
+// GetProducts lấy danh sách sản phẩm với tìm kiếm, bộ lọc, sắp xếp, phân trang và số lượng theo từng nhóm lọc
 func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Phân tích các tham số phân trang
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "10")
-	categoryIDStr := c.Query("category_id")
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
@@ -101,29 +168,26 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		limit = 10
 	}
 
-	var products []model.Product
-	var total int64
-
-	if categoryIDStr != "" {
-		 // Lấy sản phẩm theo danh mục
-		categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
-		if err != nil {
-			helpers.ErrorResponse(c, http.StatusBadRequest, "ID danh mục không hợp lệ", errors.New("ID danh mục phải là số hợp lệ"))
+	filter, err := h.parseProductFilter(c)
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
 			return
 		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
 
-		products, total, err = h.productRepo.GetByCategoryID(uint(categoryID), page, limit)
-		if err != nil {
-			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách sản phẩm", err)
-			return
-		}
-	} else {
-		// Lấy tất cả sản phẩm
-		products, total, err = h.productRepo.GetAll(page, limit)
-		if err != nil {
-			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách sản phẩm", err)
-			return
-		}
+	products, total, err := h.productRepo.Search(filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách sản phẩm", err)
+		return
+	}
+
+	facets, err := h.productRepo.Facets(filter)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể thống kê bộ lọc sản phẩm", err)
+		return
 	}
 
 	var response []model.ProductResponse
@@ -138,17 +202,136 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		Success: true,
 		Message: "Lấy danh sách sản phẩm thành công",
 		Data: map[string]interface{}{
-			"products":     response,
-			"total":        total,
-			"page":         page,
-			"limit":        limit,
-			"total_pages":  totalPages,
-			"has_next":     page < int(totalPages),
-			"has_prev":     page > 1,
+			"products":    response,
+			"facets":      facets,
+			"sort":        filter.Sort,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
 		},
 	})
 }
 
+// parseProductFilter đọc bộ lọc từ query: q, category_id (include_descendants), brand_id (hoặc brand=slug), min_price, max_price,
+// color, material, size (nhiều giá trị cách nhau bởi dấu phẩy), featured, in_stock và sort
+func (h *ProductHandler) parseProductFilter(c *gin.Context) (repo.ProductFilter, error) {
+	return h.parseFilterValues(c.Request.URL.Query())
+}
+
+// parseFilterValues đọc bộ lọc sản phẩm từ các tham số dạng query (xem parseProductFilter)
+func (h *ProductHandler) parseFilterValues(values url.Values) (repo.ProductFilter, error) {
+	filter := repo.ProductFilter{
+		Search:    strings.TrimSpace(values.Get("q")),
+		Colors:    splitQueryList(values.Get("color")),
+		Materials: splitQueryList(values.Get("material")),
+		Sizes:     splitQueryList(values.Get("size")),
+		Sort:      repo.ProductSortNewest,
+	}
+	if _, ok := values["sort"]; ok {
+		filter.Sort = values.Get("sort")
+	}
+
+	// Lọc theo danh mục; include_descendants=true để lấy cả sản phẩm thuộc các danh mục con cháu
+	if categoryIDStr := values.Get("category_id"); categoryIDStr != "" {
+		categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
+		if err != nil {
+			return filter, errors.New("ID danh mục phải là số hợp lệ")
+		}
+		filter.CategoryIDs = []uint{uint(categoryID)}
+		if values.Get("include_descendants") == "true" {
+			if filter.CategoryIDs, err = h.categoryRepo.GetDescendantIDs(uint(categoryID)); err != nil {
+				return filter, err
+			}
+		}
+	}
+
+	// Lọc theo thương hiệu (brand_id hoặc brand=slug, nhiều giá trị cách nhau bởi dấu phẩy)
+	if brandIDStr := values.Get("brand_id"); brandIDStr != "" {
+		for _, value := range splitQueryList(brandIDStr) {
+			brandID, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return filter, errors.New("ID thương hiệu phải là số hợp lệ")
+			}
+			filter.BrandIDs = append(filter.BrandIDs, uint(brandID))
+		}
+	} else if brandSlugs := values.Get("brand"); brandSlugs != "" {
+		for _, slug := range splitQueryList(brandSlugs) {
+			brand, err := h.brandRepo.GetBySlug(slug)
+			if err != nil {
+				return filter, err
+			}
+			filter.BrandIDs = append(filter.BrandIDs, brand.ID)
+		}
+	}
+
+	var err error
+	if filter.MinPrice, err = parseQueryPrice(values, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parseQueryPrice(values, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price không được lớn hơn max_price")
+	}
+
+	if featuredStr := values.Get("featured"); featuredStr != "" {
+		featured, err := strconv.ParseBool(featuredStr)
+		if err != nil {
+			return filter, errors.New("featured phải là true hoặc false")
+		}
+		filter.Featured = &featured
+	}
+
+	if inStockStr := values.Get("in_stock"); inStockStr != "" {
+		inStock, err := strconv.ParseBool(inStockStr)
+		if err != nil {
+			return filter, errors.New("in_stock phải là true hoặc false")
+		}
+		filter.InStock = inStock
+	}
+
+	validSort := false
+	for _, sort := range repo.ProductSorts {
+		if filter.Sort == sort {
+			validSort = true
+			break
+		}
+	}
+	if !validSort {
+		return filter, errors.New("sort phải là một trong: " + strings.Join(repo.ProductSorts, ", "))
+	}
+
+	return filter, nil
+}
+
+// parseQueryPrice đọc giá không âm từ query, trả về nil nếu không có
+func parseQueryPrice(values url.Values, key string) (*float64, error) {
+	value := values.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, errors.New(key + " phải là số không âm")
+	}
+	return &price, nil
+}
+
+// splitQueryList tách giá trị query dạng "a,b,c", bỏ khoảng trắng và phần tử rỗng
+func splitQueryList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
 // GetProductByID lấy sản phẩm theo ID
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -229,8 +412,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	// Chuẩn hóa SKU
 	input.SKU = strings.ToUpper(strings.TrimSpace(input.SKU))
 
-	// Kiểm tra xem SKU đã tồn tại chưa (loại trừ sản phẩm hiện tại)
+	// Kiểm tra xem SKU đã tồn tại chưa (loại trừ sản phẩm hiện tại, kể cả SKU của biến thể)
 	exists, err := h.productRepo.CheckSKUExists(input.SKU, uint(id))
+	if err == nil && !exists {
+		exists, err = h.variantRepo.CheckSKUExists(input.SKU, 0)
+	}
 	if err != nil {
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
 		return
@@ -249,15 +435,70 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		}
 	}
 
+	// Kiểm tra thương hiệu tồn tại và đang hoạt động (nếu được cung cấp)
+	if input.BrandID != nil {
+		_, err := h.brandRepo.GetActiveByID(*input.BrandID)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Thương hiệu không hợp lệ", errors.New("không tìm thấy thương hiệu đang hoạt động"))
+			return
+		}
+	}
+
+	if !validateStockPolicy(c, input, product.Stock) {
+		return
+	}
+
+	before := product.ToResponse()
+
 	// Cập nhật sản phẩm
 	product.Name = input.Name
 	product.Description = input.Description
 	product.Price = input.Price
 	product.SKU = input.SKU
-	product.Stock = input.Stock
 	product.CategoryID = input.CategoryID
-
-	if err := h.productRepo.Update(product); err != nil {
+	product.BrandID = input.BrandID
+	product.Material = input.Material
+	product.Color = input.Color
+	product.Size = input.Size
+	product.Weight = input.Weight
+	product.Dimensions = input.Dimensions
+	product.IsFeatured = input.IsFeatured
+	product.LowStockThreshold = input.LowStockThreshold
+	product.StockPolicy = input.StockPolicy
+	if product.StockPolicy == "" {
+		product.StockPolicy = consts.StockPolicyDeny
+	}
+	product.MaxBackorder = input.MaxBackorder
+	product.PreorderShipDate = input.PreorderShipDate
+	product.Brand = nil // Bỏ quan hệ đã nạp để Save không ghi đè brand_id bằng thương hiệu cũ
+
+	// Cập nhật sản phẩm và điều chỉnh tồn kho trong cùng giao dịch. Tồn kho của sản phẩm có biến thể là tổng
+	// tồn kho các biến thể, không sửa trực tiếp; thay đổi tồn kho được ghi vào sổ kho như một lần điều chỉnh,
+	// tính từ tồn kho đã khóa để không ghi đè đơn hàng hay điều chỉnh vừa diễn ra
+	err = repo.Transaction(func(tx *gorm.DB) error {
+		stockRepo := h.stockRepo.WithTx(tx)
+		currentStock, err := stockRepo.LockStock(repo.NewStockKey(product.ID, nil))
+		if err != nil {
+			return err
+		}
+		if err := h.productRepo.WithTx(tx).Update(product); err != nil {
+			return err
+		}
+		if len(product.Variants) > 0 || input.Stock == currentStock {
+			return nil
+		}
+		return stockRepo.Move(&model.StockMovement{
+			ProductID: product.ID,
+			Delta:     input.Stock - currentStock,
+			Reason:    consts.StockReasonAdjustment,
+			UserID:    currentUserID(c),
+		})
+	})
+	if err != nil {
+		if err.Error() == "insufficient stock" || err.Error() == "warehouse not found" {
+			stockErrorResponse(c, err)
+			return
+		}
 		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật sản phẩm", err)
 		return
 	}
@@ -269,6 +510,10 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "product.update", audit.EntityProduct, updatedProduct.ID, before, updatedProduct.ToResponse())
+	search.SyncProduct(updatedProduct)
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật sản phẩm thành công",
@@ -286,7 +531,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	// Kiểm tra xem sản phẩm có tồn tại không
-	_, err = h.productRepo.GetByID(uint(id))
+	product, err := h.productRepo.GetByID(uint(id))
 	if err != nil {
 		if err.Error() == "product not found" {
 			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
@@ -301,6 +546,10 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, "product.delete", audit.EntityProduct, product.ID, product.ToResponse(), nil)
+	search.RemoveProduct(product.ID)
+	worker.RefreshSuggestions()
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Xóa sản phẩm thành công",
@@ -308,7 +557,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	})
 }
 
-// UpdateProductStock cập nhật số lượng tồn kho sản phẩm
+// UpdateProductStock điều chỉnh tồn kho sản phẩm theo delta (hoặc số dư mới) và ghi vào sổ kho
 func (h *ProductHandler) UpdateProductStock(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -317,14 +566,6 @@ func (h *ProductHandler) UpdateProductStock(c *gin.Context) {
 		return
 	}
 
-	var input struct {
-		Stock int `json:"stock" binding:"required,gte=0"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
-		return
-	}
-
 	// Kiểm tra xem sản phẩm có tồn tại không
 	product, err := h.productRepo.GetByID(uint(id))
 	if err != nil {
@@ -336,18 +577,50 @@ func (h *ProductHandler) UpdateProductStock(c *gin.Context) {
 		return
 	}
 
-	if err := h.productRepo.UpdateStock(uint(id), input.Stock); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật số lượng tồn kho", err)
+	if len(product.Variants) > 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Sản phẩm có biến thể", errors.New("hãy cập nhật tồn kho theo từng biến thể"))
+		return
+	}
+
+	movement, ok := bindStockAdjustment(c, product.ID, nil)
+	if !ok {
+		return
+	}
+	if err := h.stockRepo.Move(movement); err != nil {
+		stockErrorResponse(c, err)
 		return
 	}
 
+	audit.Record(c, "product.stock_update", audit.EntityProduct, product.ID,
+		map[string]interface{}{"stock": product.Stock},
+		map[string]interface{}{"stock": movement.Balance, "delta": movement.Delta, "reason": movement.Reason})
+
 	c.JSON(http.StatusOK, helpers.Response{
 		Success: true,
 		Message: "Cập nhật số lượng tồn kho thành công",
 		Data: map[string]interface{}{
 			"product_id": product.ID,
 			"old_stock":  product.Stock,
-			"new_stock":  input.Stock,
+			"new_stock":  movement.Balance,
+			"movement":   movement.ToResponse(),
 		},
 	})
 }
+
+// validateStockPolicy kiểm tra tồn kho và chính sách bán khi hết hàng của sản phẩm; tự trả lỗi khi không hợp lệ.
+// Tồn kho không được âm, trừ khi giữ nguyên tồn kho âm hiện tại (currentStock) của sản phẩm đang bán vượt.
+func validateStockPolicy(c *gin.Context, input model.ProductInput, currentStock int) bool {
+	if input.Stock < 0 && input.Stock != currentStock {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("tồn kho không được âm"))
+		return false
+	}
+	if (input.StockPolicy == consts.StockPolicyBackorder || input.StockPolicy == consts.StockPolicyPreorder) && input.MaxBackorder <= 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("sản phẩm cho bán vượt tồn kho cần có số lượng bán vượt tối đa (max_backorder) lớn hơn 0"))
+		return false
+	}
+	if input.StockPolicy == consts.StockPolicyPreorder && input.PreorderShipDate == nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("sản phẩm đặt trước cần có ngày dự kiến giao hàng"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package handle
+
+import (
+	"backend/internal/consts"
+	"backend/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateStockPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	shipDate := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		input        model.ProductInput
+		currentStock int
+		want         bool
+	}{
+		{"deny", model.ProductInput{Stock: 5}, 0, true},
+		{"negative stock", model.ProductInput{Stock: -1}, 0, false},
+		{"keeps the current oversold stock", model.ProductInput{Stock: -2, StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 5}, -2, true},
+		{"backorder with a limit", model.ProductInput{StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 5}, 0, true},
+		{"backorder without a limit", model.ProductInput{StockPolicy: consts.StockPolicyBackorder}, 0, false},
+		{"preorder without a ship date", model.ProductInput{StockPolicy: consts.StockPolicyPreorder, MaxBackorder: 5}, 0, false},
+		{"preorder without a limit", model.ProductInput{StockPolicy: consts.StockPolicyPreorder, PreorderShipDate: &shipDate}, 0, false},
+		{"preorder", model.ProductInput{StockPolicy: consts.StockPolicyPreorder, MaxBackorder: 5, PreorderShipDate: &shipDate}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			if got := validateStockPolicy(c, tt.input, tt.currentStock); got != tt.want {
+				t.Fatalf("validateStockPolicy = %v, want %v", got, tt.want)
+			}
+			if !tt.want && recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
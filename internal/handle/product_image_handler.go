@@ -0,0 +1,421 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/storage"
+	"backend/internal/worker"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductImageHandler struct {
+	productRepo *repo.ProductRepo
+	imageRepo   *repo.ProductImageRepo
+	store       storage.Storage
+}
+
+func NewProductImageHandler() *ProductImageHandler {
+	return &ProductImageHandler{
+		productRepo: repo.NewProductRepo(),
+		imageRepo:   repo.NewProductImageRepo(),
+		store:       storage.MustDefault(),
+	}
+}
+
+// sniffedUpload là tệp tải lên đã được kiểm tra định dạng, dung lượng và làm sạch siêu dữ liệu
+type sniffedUpload struct {
+	header      *multipart.FileHeader
+	contentType string
+	extension   string
+	data        []byte
+}
+
+// GetProductImages lấy thư viện ảnh của sản phẩm
+func (h *ProductImageHandler) GetProductImages(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	images, err := h.imageRepo.GetByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách ảnh", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách ảnh thành công",
+		Data:    toProductImageResponses(images),
+	})
+}
+
+// UploadProductImages tải lên một hoặc nhiều ảnh (multipart, trường "images") cho sản phẩm
+func (h *ProductImageHandler) UploadProductImages(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	maxSize := maxUploadSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize*consts.MAX_IMAGES_PER_UPLOAD+(1<<20))
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Dung lượng tải lên vượt quá giới hạn", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu tải lên không hợp lệ", err)
+		return
+	}
+
+	files := append(form.File["images"], form.File["image"]...)
+	if len(files) == 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Vui lòng chọn ít nhất một ảnh", nil)
+		return
+	}
+	if len(files) > consts.MAX_IMAGES_PER_UPLOAD {
+		helpers.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Chỉ được tải lên tối đa %d ảnh mỗi lần", consts.MAX_IMAGES_PER_UPLOAD), nil)
+		return
+	}
+
+	count, err := h.imageRepo.CountByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if int(count)+len(files) > consts.MAX_IMAGES_PER_PRODUCT {
+		helpers.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Mỗi sản phẩm chỉ được có tối đa %d ảnh", consts.MAX_IMAGES_PER_PRODUCT), nil)
+		return
+	}
+
+	// Kiểm tra toàn bộ tệp trước khi lưu để không lưu dở dang khi có tệp lỗi
+	uploads := make([]sniffedUpload, 0, len(files))
+	for _, file := range files {
+		if file.Size > maxSize {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Ảnh %s vượt quá dung lượng cho phép (%d MB)", file.Filename, maxSize>>20), nil)
+			return
+		}
+
+		contentType, err := sniffContentType(file)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể đọc tệp "+file.Filename, err)
+			return
+		}
+		extension, allowed := allowedImageTypes[contentType]
+		if !allowed {
+			helpers.ErrorResponse(c, http.StatusUnsupportedMediaType, "Định dạng ảnh không được hỗ trợ: "+file.Filename, errors.New("chỉ chấp nhận JPEG, PNG, GIF hoặc WebP"))
+			return
+		}
+
+		data, err := readSanitizedImage(file, contentType)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Ảnh không hợp lệ: "+file.Filename, err)
+			return
+		}
+
+		uploads = append(uploads, sniffedUpload{header: file, contentType: contentType, extension: extension, data: data})
+	}
+
+	// Ảnh có thể gắn với một biến thể (vd: ảnh màu đen) qua trường variant_id
+	var variantID *uint
+	if raw := c.PostForm("variant_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "ID biến thể không hợp lệ", err)
+			return
+		}
+		value := uint(id)
+		variantID = &value
+	}
+	if !checkImageVariant(c, product, variantID) {
+		return
+	}
+
+	altText := c.PostForm("alt_text")
+	if runes := []rune(altText); len(runes) > 200 {
+		altText = string(runes[:200])
+	}
+
+	created := make([]model.ProductImage, 0, len(uploads))
+	for _, upload := range uploads {
+		image, err := h.storeImage(c, product.ID, variantID, upload, altText)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lưu ảnh "+upload.header.Filename, err)
+			return
+		}
+		created = append(created, *image)
+	}
+
+	for _, image := range created {
+		worker.EnqueueImage(worker.ImageJob{Kind: worker.ImageJobProductImage, ID: image.ID})
+	}
+
+	audit.Record(c, "product.image_upload", audit.EntityProduct, product.ID, nil, map[string]interface{}{
+		"images": toProductImageResponses(created),
+	})
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tải ảnh lên thành công",
+		Data:    toProductImageResponses(created),
+	})
+}
+
+// storeImage ghi tệp vào kho lưu trữ rồi tạo bản ghi; xóa tệp nếu không ghi được bản ghi.
+// Ảnh dẫn xuất được worker tạo sau khi bản ghi đã lưu.
+func (h *ProductImageHandler) storeImage(c *gin.Context, productID uint, variantID *uint, upload sniffedUpload, altText string) (*model.ProductImage, error) {
+	key, err := newUploadKey(fmt.Sprintf("products/%d", productID), upload.extension)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.store.Put(c.Request.Context(), key, bytes.NewReader(upload.data), upload.contentType); err != nil {
+		return nil, err
+	}
+
+	image := model.ProductImage{
+		ProductID:     productID,
+		VariantID:     variantID,
+		ImageURL:      h.store.URL(key),
+		StorageKey:    key,
+		ContentType:   upload.contentType,
+		FileSize:      int64(len(upload.data)),
+		AltText:       altText,
+		VariantStatus: model.ImageVariantPending,
+	}
+	if err := h.imageRepo.Create(&image); err != nil {
+		if delErr := h.store.Delete(c.Request.Context(), key); delErr != nil {
+			log.Printf("⚠️  Failed to remove orphaned upload %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// UpdateProductImage cập nhật mô tả (alt text) của ảnh
+func (h *ProductImageHandler) UpdateProductImage(c *gin.Context) {
+	product, image, ok := h.loadImage(c)
+	if !ok {
+		return
+	}
+
+	var input model.ProductImageUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	if !checkImageVariant(c, product, input.VariantID) {
+		return
+	}
+
+	before := image.ToResponse()
+	image.AltText = input.AltText
+	image.VariantID = input.VariantID
+	if err := h.imageRepo.Update(image); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật ảnh", err)
+		return
+	}
+
+	audit.Record(c, "product.image_update", audit.EntityProduct, product.ID, before, image.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật ảnh thành công",
+		Data:    image.ToResponse(),
+	})
+}
+
+// SetPrimaryProductImage đặt ảnh chính cho sản phẩm
+func (h *ProductImageHandler) SetPrimaryProductImage(c *gin.Context) {
+	product, image, ok := h.loadImage(c)
+	if !ok {
+		return
+	}
+
+	if err := h.imageRepo.SetPrimary(product.ID, image.ID); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể đặt ảnh chính", err)
+		return
+	}
+
+	images, err := h.imageRepo.GetByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách ảnh", err)
+		return
+	}
+
+	audit.Record(c, "product.image_primary", audit.EntityProduct, product.ID, nil, map[string]interface{}{"primary_image_id": image.ID})
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Đặt ảnh chính thành công",
+		Data:    toProductImageResponses(images),
+	})
+}
+
+// ReorderProductImages sắp xếp lại thứ tự ảnh; danh sách phải gồm đúng toàn bộ ảnh của sản phẩm
+func (h *ProductImageHandler) ReorderProductImages(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	var input model.ProductImageReorderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	images, err := h.imageRepo.GetByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách ảnh", err)
+		return
+	}
+
+	existing := make(map[uint]bool, len(images))
+	before := make([]uint, 0, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+		before = append(before, image.ID)
+	}
+
+	seen := make(map[uint]bool, len(input.ImageIDs))
+	for _, imageID := range input.ImageIDs {
+		if !existing[imageID] || seen[imageID] {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Danh sách ảnh không hợp lệ", fmt.Errorf("ảnh #%d không thuộc sản phẩm hoặc bị trùng", imageID))
+			return
+		}
+		seen[imageID] = true
+	}
+	if len(seen) != len(existing) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Danh sách ảnh không hợp lệ", errors.New("phải gửi đầy đủ tất cả ảnh của sản phẩm"))
+		return
+	}
+
+	if err := h.imageRepo.Reorder(product.ID, input.ImageIDs); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể sắp xếp ảnh", err)
+		return
+	}
+
+	images, err = h.imageRepo.GetByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách ảnh", err)
+		return
+	}
+
+	audit.Record(c, "product.image_reorder", audit.EntityProduct, product.ID,
+		map[string]interface{}{"image_ids": before},
+		map[string]interface{}{"image_ids": input.ImageIDs})
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Sắp xếp ảnh thành công",
+		Data:    toProductImageResponses(images),
+	})
+}
+
+// DeleteProductImage xóa ảnh khỏi sản phẩm và kho lưu trữ
+func (h *ProductImageHandler) DeleteProductImage(c *gin.Context) {
+	product, image, ok := h.loadImage(c)
+	if !ok {
+		return
+	}
+
+	if err := h.imageRepo.Delete(image); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể xóa ảnh", err)
+		return
+	}
+
+	// Bản ghi đã xóa; lỗi xóa tệp chỉ để lại tệp mồ côi nên không làm hỏng request
+	if image.StorageKey != "" {
+		if err := h.store.Delete(c.Request.Context(), image.StorageKey); err != nil {
+			log.Printf("⚠️  Failed to delete stored image %s: %v", image.StorageKey, err)
+		}
+	}
+	worker.DeleteImageVariants(h.store, image.Variants)
+
+	audit.Record(c, "product.image_delete", audit.EntityProduct, product.ID, image.ToResponse(), nil)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Xóa ảnh thành công",
+		Data:    nil,
+	})
+}
+
+func (h *ProductImageHandler) loadProduct(c *gin.Context) (*model.Product, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID sản phẩm không hợp lệ", errors.New("ID sản phẩm phải là số hợp lệ"))
+		return nil, false
+	}
+
+	product, err := h.productRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+
+	return product, true
+}
+
+func (h *ProductImageHandler) loadImage(c *gin.Context) (*model.Product, *model.ProductImage, bool) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	imageID, err := strconv.ParseUint(c.Param("image_id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID ảnh không hợp lệ", errors.New("ID ảnh phải là số hợp lệ"))
+		return nil, nil, false
+	}
+
+	image, err := h.imageRepo.GetByID(product.ID, uint(imageID))
+	if err != nil {
+		if err.Error() == "product image not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy ảnh", err)
+			return nil, nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, nil, false
+	}
+
+	return product, image, true
+}
+
+// checkImageVariant kiểm tra biến thể được gắn cho ảnh thuộc đúng sản phẩm
+func checkImageVariant(c *gin.Context, product *model.Product, variantID *uint) bool {
+	if variantID == nil || findProductVariant(product, variantID) != nil {
+		return true
+	}
+	helpers.ErrorResponse(c, http.StatusBadRequest, "Biến thể không hợp lệ", errors.New("biến thể không thuộc sản phẩm này"))
+	return false
+}
+
+func toProductImageResponses(images []model.ProductImage) []model.ProductImageResponse {
+	responses := make([]model.ProductImageResponse, 0, len(images))
+	for _, image := range images {
+		responses = append(responses, image.ToResponse())
+	}
+	return responses
+}
@@ -0,0 +1,303 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/catalog"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/spreadsheet"
+	"backend/internal/worker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Số sản phẩm đọc mỗi lô khi xuất bảng tính
+const productExportBatchSize = 500
+
+// ImportProducts nhận tệp CSV/XLSX (trường "file", mode=upsert|create|update) và tạo job nhập chạy nền.
+// Dòng tiêu đề và định dạng tệp được kiểm tra ngay; từng dòng dữ liệu được kiểm tra khi job chạy.
+// Với dry_run=true, tệp được xử lý ngay trong giao dịch bị rollback và trả về báo cáo
+// created/updated/unchanged/rejected (kèm giá trị trước/sau của các cột thay đổi) mà không lưu gì.
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, consts.MAX_IMPORT_FILE_SIZE+(1<<20))
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Dung lượng tải lên vượt quá giới hạn", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thiếu tệp nhập sản phẩm", err)
+		return
+	}
+	if file.Size > consts.MAX_IMPORT_FILE_SIZE {
+		helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Tệp vượt quá dung lượng cho phép (%d MB)", consts.MAX_IMPORT_FILE_SIZE>>20), nil)
+		return
+	}
+
+	format, err := spreadsheet.FormatFromFilename(file.Filename)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Định dạng tệp không được hỗ trợ", errors.New("chỉ hỗ trợ tệp .csv và .xlsx"))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể đọc tệp", err)
+		return
+	}
+	defer src.Close()
+
+	rows, err := spreadsheet.Read(src, format, consts.MAX_IMPORT_ROWS)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrTooManyRows) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Tệp có quá %d dòng dữ liệu", consts.MAX_IMPORT_ROWS), err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể đọc tệp", err)
+		return
+	}
+	if len(rows) < 2 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tệp không có dữ liệu", errors.New("tệp cần dòng tiêu đề và ít nhất một dòng sản phẩm"))
+		return
+	}
+
+	mode := c.DefaultPostForm("mode", consts.ImportModeUpsert)
+	importer, err := catalog.NewImporter(rows[0], mode, currentUserID(c))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tệp nhập không hợp lệ", err)
+		return
+	}
+
+	if dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", "false")); dryRun {
+		report, err := importer.DryRun(rows[1:])
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể chạy thử nhập sản phẩm", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, helpers.Response{
+			Success: true,
+			Message: "Chạy thử nhập sản phẩm thành công, chưa có thay đổi nào được lưu",
+			Data:    report,
+		})
+		return
+	}
+
+	columns := make([]string, len(rows[0]))
+	for i, column := range rows[0] {
+		columns[i] = strings.TrimSpace(column)
+	}
+	job := model.ImportJob{
+		FileName: file.Filename,
+		Format:   format,
+		Mode:     mode,
+		Columns:  marshalJSON(columns),
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uint)
+		job.CreatedByID = &id
+	}
+
+	if err := worker.StartProductImport(&job, importer, rows[1:]); err != nil {
+		if errors.Is(err, worker.ErrImportRunning) {
+			helpers.ErrorResponse(c, http.StatusConflict, "Đang có một lần nhập sản phẩm khác", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo job nhập sản phẩm", err)
+		return
+	}
+
+	audit.Record(c, "product.import", audit.EntityProduct, 0, nil, job.ToResponse())
+
+	c.JSON(http.StatusAccepted, helpers.Response{
+		Success: true,
+		Message: "Đã nhận tệp, đang nhập sản phẩm",
+		Data:    job.ToResponse(),
+	})
+}
+
+// GetImportJobs lấy danh sách các lần nhập sản phẩm
+func (h *ProductHandler) GetImportJobs(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	jobs, total, err := h.importJobRepo.GetAll(page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách lần nhập", err)
+		return
+	}
+
+	response := make([]model.ImportJobResponse, 0, len(jobs))
+	for i := range jobs {
+		response = append(response, jobs[i].ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách lần nhập thành công",
+		Data: map[string]interface{}{
+			"jobs":        response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// GetImportJob lấy trạng thái và tiến độ của một lần nhập
+func (h *ProductHandler) GetImportJob(c *gin.Context) {
+	job, ok := h.findImportJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy trạng thái lần nhập thành công",
+		Data:    job.ToResponse(),
+	})
+}
+
+// DownloadImportErrors tải báo cáo các dòng bị từ chối (định dạng theo tệp gốc, hoặc ?format=csv|xlsx)
+func (h *ProductHandler) DownloadImportErrors(c *gin.Context) {
+	job, ok := h.findImportJob(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", job.Format)
+	if !spreadsheet.ValidFormat(format) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Định dạng không hợp lệ", errors.New("format phải là một trong: "+strings.Join(spreadsheet.Formats, ", ")))
+		return
+	}
+
+	rowErrors := job.RowErrors()
+	if len(rowErrors) == 0 {
+		helpers.ErrorResponse(c, http.StatusNotFound, "Lần nhập không có dòng lỗi", nil)
+		return
+	}
+
+	columns := job.ColumnList()
+	writeSpreadsheet(c, format, fmt.Sprintf("import-%d-errors", job.ID), "Errors", func(writer spreadsheet.Writer) error {
+		if err := writer.Write(catalog.ErrorReportHeader(columns)); err != nil {
+			return err
+		}
+		for _, rowError := range rowErrors {
+			if err := writer.Write(catalog.ErrorReportRow(columns, rowError)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExportProducts xuất danh sách sản phẩm theo cùng bộ lọc với GetProducts (?format=csv|xlsx);
+// tệp xuất có thể sửa và nhập lại qua ImportProducts
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if !spreadsheet.ValidFormat(format) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Định dạng không hợp lệ", errors.New("format phải là một trong: "+strings.Join(spreadsheet.Formats, ", ")))
+		return
+	}
+
+	filter, err := h.parseProductFilter(c)
+	if err != nil {
+		if err.Error() == "brand not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy thương hiệu", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+
+	filename := "products-" + time.Now().Format("20060102-150405")
+	writeSpreadsheet(c, format, filename, "Products", func(writer spreadsheet.Writer) error {
+		header := make([]interface{}, len(catalog.ProductColumns))
+		for i, column := range catalog.ProductColumns {
+			header[i] = column
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		return h.productRepo.EachFiltered(filter, productExportBatchSize, func(products []model.Product) error {
+			for i := range products {
+				if err := writer.Write(catalog.ProductRow(&products[i])); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// findImportJob đọc job theo tham số :job_id; tự trả lỗi khi không hợp lệ
+func (h *ProductHandler) findImportJob(c *gin.Context) (*model.ImportJob, bool) {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID lần nhập không hợp lệ", errors.New("ID lần nhập phải là số hợp lệ"))
+		return nil, false
+	}
+
+	job, err := h.importJobRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "import job not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy lần nhập", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+	return job, true
+}
+
+// writeSpreadsheet ghi tệp tải xuống; lỗi xảy ra khi đã bắt đầu gửi dữ liệu chỉ có thể ghi log
+func writeSpreadsheet(c *gin.Context, format, filename, sheet string, write func(spreadsheet.Writer) error) {
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format, sheet)
+	if err == nil {
+		err = write(writer)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to write spreadsheet %s.%s: %v", filename, format, err)
+		c.Abort()
+	}
+}
+
+// marshalJSON mã hóa giá trị thành chuỗi JSON để lưu vào cột văn bản
+func marshalJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,504 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductVariantHandler struct {
+	productRepo *repo.ProductRepo
+	variantRepo *repo.ProductVariantRepo
+	stockRepo   *repo.StockRepo
+}
+
+func NewProductVariantHandler() *ProductVariantHandler {
+	return &ProductVariantHandler{
+		productRepo: repo.NewProductRepo(),
+		variantRepo: repo.NewProductVariantRepo(),
+		stockRepo:   repo.NewStockRepo(),
+	}
+}
+
+// GetProductVariants lấy ma trận tùy chọn và danh sách biến thể của sản phẩm
+func (h *ProductVariantHandler) GetProductVariants(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	response := product.ToResponse()
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách biến thể thành công",
+		Data: map[string]interface{}{
+			"product_id": product.ID,
+			"options":    response.Options,
+			"variants":   response.Variants,
+		},
+	})
+}
+
+// UpdateProductOptions thay toàn bộ trục tùy chọn (vd: color, size) của sản phẩm
+func (h *ProductVariantHandler) UpdateProductOptions(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	var input model.ProductOptionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	options := make([]model.ProductOption, 0, len(input.Options))
+	seenNames := make(map[string]bool, len(input.Options))
+	for i, optionInput := range input.Options {
+		name := strings.TrimSpace(optionInput.Name)
+		if name == "" || seenNames[strings.ToLower(name)] {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tên tùy chọn không hợp lệ", fmt.Errorf("tên tùy chọn %q bị trống hoặc bị trùng", optionInput.Name))
+			return
+		}
+		seenNames[strings.ToLower(name)] = true
+
+		values := make([]string, 0, len(optionInput.Values))
+		seenValues := make(map[string]bool, len(optionInput.Values))
+		for _, value := range optionInput.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || seenValues[strings.ToLower(value)] {
+				helpers.ErrorResponse(c, http.StatusBadRequest, "Giá trị tùy chọn không hợp lệ", fmt.Errorf("giá trị %q của tùy chọn %s bị trống hoặc bị trùng", value, name))
+				return
+			}
+			seenValues[strings.ToLower(value)] = true
+			values = append(values, value)
+		}
+
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lưu tùy chọn", err)
+			return
+		}
+		options = append(options, model.ProductOption{
+			ProductID: product.ID,
+			Name:      name,
+			Values:    string(encoded),
+			Position:  i,
+		})
+	}
+
+	// Biến thể hiện có phải vẫn khớp với tùy chọn mới; tên/giá trị được chuẩn hóa theo tùy chọn mới
+	variants := product.Variants
+	for i := range variants {
+		resolved, err := resolveVariantOptions(options, variants[i].OptionMap())
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("Biến thể %s không còn khớp với tùy chọn mới", variants[i].SKU), err)
+			return
+		}
+		encoded, err := json.Marshal(resolved)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lưu tùy chọn", err)
+			return
+		}
+		variants[i].Options = string(encoded)
+		variants[i].OptionKey = model.VariantOptionKey(resolved)
+	}
+
+	before := product.ToResponse().Options
+	if err := h.variantRepo.ReplaceOptions(product.ID, options, variants); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lưu tùy chọn", err)
+		return
+	}
+
+	updated, ok := h.reloadProduct(c, product.ID)
+	if !ok {
+		return
+	}
+	response := updated.ToResponse()
+
+	audit.Record(c, "product.options_update", audit.EntityProduct, product.ID,
+		map[string]interface{}{"options": before},
+		map[string]interface{}{"options": response.Options})
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật tùy chọn sản phẩm thành công",
+		Data: map[string]interface{}{
+			"product_id": updated.ID,
+			"options":    response.Options,
+			"variants":   response.Variants,
+		},
+	})
+}
+
+// CreateProductVariant tạo biến thể mới cho sản phẩm
+func (h *ProductVariantHandler) CreateProductVariant(c *gin.Context) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+
+	var input model.ProductVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	variant := model.ProductVariant{
+		ProductID: product.ID,
+		IsActive:  true,
+	}
+	if !h.applyVariantInput(c, product, &variant, input) {
+		return
+	}
+
+	// Biến thể đầu tiên: tồn kho sản phẩm chuyển sang tính theo tổng biến thể nên tồn kho cũ tại mọi kho
+	// được ghi xuất khỏi sổ kho
+	if len(product.Variants) == 0 && product.Stock > 0 {
+		if err := h.stockRepo.Clear(product.ID, nil, model.StockMovement{
+			Reason: consts.StockReasonAdjustment,
+			UserID: currentUserID(c),
+			Note:   "chuyển sang quản lý tồn kho theo biến thể",
+		}); err != nil {
+			stockErrorResponse(c, err)
+			return
+		}
+	}
+
+	initialStock := variant.Stock
+	variant.Stock = 0
+	if err := h.variantRepo.Create(&variant); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo biến thể", err)
+		return
+	}
+	if initialStock > 0 {
+		if err := h.stockRepo.Move(&model.StockMovement{
+			ProductID: product.ID,
+			VariantID: &variant.ID,
+			Delta:     initialStock,
+			Reason:    consts.StockReasonInitial,
+			UserID:    currentUserID(c),
+		}); err != nil {
+			stockErrorResponse(c, err)
+			return
+		}
+	}
+
+	created, ok := h.reloadVariant(c, product, variant.ID)
+	if !ok {
+		return
+	}
+
+	audit.Record(c, "product.variant_create", audit.EntityProduct, product.ID, nil, created)
+	h.indexProduct(product.ID)
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo biến thể thành công",
+		Data:    created,
+	})
+}
+
+// UpdateProductVariant cập nhật SKU, giá, tồn kho và tổ hợp tùy chọn của biến thể
+func (h *ProductVariantHandler) UpdateProductVariant(c *gin.Context) {
+	product, variant, ok := h.loadVariant(c)
+	if !ok {
+		return
+	}
+
+	var input model.ProductVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	before := variant.ToResponse()
+	if !h.applyVariantInput(c, product, variant, input) {
+		return
+	}
+
+	if err := h.variantRepo.Update(variant); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật biến thể", err)
+		return
+	}
+	if delta := variant.Stock - before.Stock; delta != 0 {
+		if err := h.stockRepo.Move(&model.StockMovement{
+			ProductID: product.ID,
+			VariantID: &variant.ID,
+			Delta:     delta,
+			Reason:    consts.StockReasonAdjustment,
+			UserID:    currentUserID(c),
+		}); err != nil {
+			stockErrorResponse(c, err)
+			return
+		}
+	}
+
+	updated, ok := h.reloadVariant(c, product, variant.ID)
+	if !ok {
+		return
+	}
+
+	audit.Record(c, "product.variant_update", audit.EntityProduct, product.ID, before, updated)
+	h.indexProduct(product.ID)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật biến thể thành công",
+		Data:    updated,
+	})
+}
+
+// UpdateProductVariantStock điều chỉnh tồn kho của một biến thể theo delta (hoặc số dư mới) và ghi vào sổ kho
+func (h *ProductVariantHandler) UpdateProductVariantStock(c *gin.Context) {
+	product, variant, ok := h.loadVariant(c)
+	if !ok {
+		return
+	}
+
+	movement, ok := bindStockAdjustment(c, product.ID, &variant.ID)
+	if !ok {
+		return
+	}
+	if err := h.stockRepo.Move(movement); err != nil {
+		stockErrorResponse(c, err)
+		return
+	}
+
+	audit.Record(c, "product.variant_stock_update", audit.EntityProduct, product.ID,
+		map[string]interface{}{"variant_id": variant.ID, "sku": variant.SKU, "stock": variant.Stock},
+		map[string]interface{}{"variant_id": variant.ID, "sku": variant.SKU, "stock": *movement.VariantBalance, "delta": movement.Delta, "reason": movement.Reason})
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật số lượng tồn kho thành công",
+		Data: map[string]interface{}{
+			"product_id": product.ID,
+			"variant_id": variant.ID,
+			"old_stock":  variant.Stock,
+			"new_stock":  *movement.VariantBalance,
+			"movement":   movement.ToResponse(),
+		},
+	})
+}
+
+// DeleteProductVariant xóa biến thể; đơn hàng cũ vẫn giữ SKU và tùy chọn đã lưu
+func (h *ProductVariantHandler) DeleteProductVariant(c *gin.Context) {
+	product, variant, ok := h.loadVariant(c)
+	if !ok {
+		return
+	}
+
+	// Tồn kho còn lại của biến thể bị xóa tại mọi kho được ghi xuất khỏi sổ kho
+	if variant.Stock > 0 {
+		if err := h.stockRepo.Clear(product.ID, &variant.ID, model.StockMovement{
+			Reason: consts.StockReasonAdjustment,
+			UserID: currentUserID(c),
+			Note:   "xóa biến thể",
+		}); err != nil {
+			stockErrorResponse(c, err)
+			return
+		}
+	}
+
+	if err := h.variantRepo.Delete(variant); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể xóa biến thể", err)
+		return
+	}
+
+	audit.Record(c, "product.variant_delete", audit.EntityProduct, product.ID, variant.ToResponse(), nil)
+	h.indexProduct(product.ID)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Xóa biến thể thành công",
+		Data:    nil,
+	})
+}
+
+// applyVariantInput kiểm tra và gán dữ liệu đầu vào cho biến thể; tự trả lỗi khi không hợp lệ
+func (h *ProductVariantHandler) applyVariantInput(c *gin.Context, product *model.Product, variant *model.ProductVariant, input model.ProductVariantInput) bool {
+	if len(product.Options) == 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Sản phẩm chưa có tùy chọn", errors.New("cần khai báo tùy chọn (vd: color, size) trước khi tạo biến thể"))
+		return false
+	}
+	// Tồn kho âm chỉ được giữ nguyên khi biến thể đang bán vượt tồn kho
+	if input.Stock < 0 && input.Stock != variant.Stock {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("tồn kho không được âm"))
+		return false
+	}
+
+	resolved, err := resolveVariantOptions(product.Options, input.Options)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tùy chọn biến thể không hợp lệ", err)
+		return false
+	}
+	optionKey := model.VariantOptionKey(resolved)
+
+	exists, err := h.variantRepo.CheckOptionKeyExists(product.ID, optionKey, variant.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "Biến thể với tổ hợp tùy chọn này đã tồn tại", nil)
+		return false
+	}
+
+	sku := strings.ToUpper(strings.TrimSpace(input.SKU))
+	exists, err = h.variantRepo.CheckSKUExists(sku, variant.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	if !exists {
+		exists, err = h.productRepo.CheckSKUExists(sku, 0)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return false
+		}
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "SKU đã tồn tại", errors.New("SKU đã được dùng cho sản phẩm hoặc biến thể khác"))
+		return false
+	}
+
+	encoded, err := json.Marshal(resolved)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lưu biến thể", err)
+		return false
+	}
+
+	variant.SKU = sku
+	variant.Price = input.Price
+	variant.Stock = input.Stock
+	variant.Options = string(encoded)
+	variant.OptionKey = optionKey
+	if input.IsActive != nil {
+		variant.IsActive = *input.IsActive
+	}
+	return true
+}
+
+// resolveVariantOptions đối chiếu tổ hợp tùy chọn với các trục của sản phẩm (không phân biệt hoa thường)
+// và trả về tổ hợp dùng đúng tên/giá trị đã khai báo
+func resolveVariantOptions(options []model.ProductOption, input map[string]string) (map[string]string, error) {
+	if len(input) != len(options) {
+		return nil, fmt.Errorf("cần chọn đúng %d tùy chọn", len(options))
+	}
+
+	normalized := make(map[string]string, len(input))
+	for name, value := range input {
+		normalized[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	resolved := make(map[string]string, len(options))
+	for _, option := range options {
+		value, ok := normalized[strings.ToLower(option.Name)]
+		if !ok {
+			return nil, fmt.Errorf("thiếu tùy chọn %s", option.Name)
+		}
+
+		matched := ""
+		for _, allowed := range option.ValueList() {
+			if strings.EqualFold(allowed, value) {
+				matched = allowed
+				break
+			}
+		}
+		if matched == "" {
+			return nil, fmt.Errorf("giá trị %q không hợp lệ cho tùy chọn %s", value, option.Name)
+		}
+		resolved[option.Name] = matched
+	}
+
+	return resolved, nil
+}
+
+func (h *ProductVariantHandler) loadProduct(c *gin.Context) (*model.Product, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID sản phẩm không hợp lệ", errors.New("ID sản phẩm phải là số hợp lệ"))
+		return nil, false
+	}
+
+	product, err := h.productRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+
+	return product, true
+}
+
+func (h *ProductVariantHandler) loadVariant(c *gin.Context) (*model.Product, *model.ProductVariant, bool) {
+	product, ok := h.loadProduct(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID biến thể không hợp lệ", errors.New("ID biến thể phải là số hợp lệ"))
+		return nil, nil, false
+	}
+
+	variant, err := h.variantRepo.GetByID(product.ID, uint(variantID))
+	if err != nil {
+		if err.Error() == "product variant not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy biến thể", err)
+			return nil, nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, nil, false
+	}
+	variant.Product = product
+
+	return product, variant, true
+}
+
+func (h *ProductVariantHandler) reloadProduct(c *gin.Context, id uint) (*model.Product, bool) {
+	product, err := h.productRepo.GetByID(id)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tải sản phẩm", err)
+		return nil, false
+	}
+	return product, true
+}
+
+// indexProduct cập nhật SKU biến thể của sản phẩm trong chỉ mục tìm kiếm
+func (h *ProductVariantHandler) indexProduct(id uint) {
+	product, err := h.productRepo.GetByID(id)
+	if err != nil {
+		log.Printf("⚠️  Failed to reload product #%d for search index: %v", id, err)
+		return
+	}
+	search.SyncProduct(product)
+}
+
+func (h *ProductVariantHandler) reloadVariant(c *gin.Context, product *model.Product, variantID uint) (*model.ProductVariantResponse, bool) {
+	variant, err := h.variantRepo.GetByID(product.ID, variantID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tải biến thể", err)
+		return nil, false
+	}
+	variant.Product = product
+	response := variant.ToResponse()
+	return &response, true
+}
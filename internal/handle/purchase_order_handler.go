@@ -0,0 +1,366 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/inventory"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderRepo *repo.PurchaseOrderRepo
+	supplierRepo      *repo.SupplierRepo
+	warehouseRepo     *repo.WarehouseRepo
+	productRepo       *repo.ProductRepo
+}
+
+func NewPurchaseOrderHandler() *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderRepo: repo.NewPurchaseOrderRepo(),
+		supplierRepo:      repo.NewSupplierRepo(),
+		warehouseRepo:     repo.NewWarehouseRepo(),
+		productRepo:       repo.NewProductRepo(),
+	}
+}
+
+// GetPurchaseOrders lấy danh sách đơn đặt hàng nhà cung cấp (lọc theo status, supplier_id, warehouse_id)
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var filter repo.PurchaseOrderFilter
+	if status := c.Query("status"); status != "" {
+		valid := false
+		for _, s := range consts.PurchaseOrderStatuses {
+			if status == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", errors.New("status phải là một trong: "+strings.Join(consts.PurchaseOrderStatuses, ", ")))
+			return
+		}
+		filter.Status = status
+	}
+	if filter.SupplierID, err = queryID(c, "supplier_id"); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+	if filter.WarehouseID, err = queryID(c, "warehouse_id"); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+
+	orders, total, err := h.purchaseOrderRepo.GetAll(filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách đơn đặt hàng", err)
+		return
+	}
+
+	// Danh sách chỉ trả về tổng số lượng và giá trị, chi tiết dòng hàng xem ở GetPurchaseOrder
+	response := make([]model.PurchaseOrderResponse, 0, len(orders))
+	for i := range orders {
+		item := orders[i].ToResponse()
+		item.Lines = nil
+		response = append(response, item)
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách đơn đặt hàng thành công",
+		Data: map[string]interface{}{
+			"purchase_orders": response,
+			"total":           total,
+			"page":            page,
+			"limit":           limit,
+			"total_pages":     totalPages,
+			"has_next":        page < int(totalPages),
+			"has_prev":        page > 1,
+		},
+	})
+}
+
+// GetPurchaseOrder lấy chi tiết đơn đặt hàng kèm dòng hàng
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	order, ok := h.loadPurchaseOrder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy đơn đặt hàng thành công",
+		Data:    order.ToResponse(),
+	})
+}
+
+// CreatePurchaseOrder tạo đơn đặt hàng nhà cung cấp ở trạng thái nháp
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var input model.PurchaseOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	order := model.PurchaseOrder{
+		Status: consts.PurchaseOrderDraft,
+		UserID: currentUserID(c),
+	}
+	if !h.applyPurchaseOrderInput(c, &order, input) {
+		return
+	}
+
+	code, err := h.purchaseOrderRepo.GenerateCode()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo mã đơn đặt hàng", err)
+		return
+	}
+	order.Code = code
+
+	if err := h.purchaseOrderRepo.Create(&order); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo đơn đặt hàng", err)
+		return
+	}
+
+	created, err := h.purchaseOrderRepo.GetByID(order.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	audit.Record(c, "purchase_order.create", audit.EntityPurchaseOrder, created.ID, nil, created.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo đơn đặt hàng thành công",
+		Data:    created.ToResponse(),
+	})
+}
+
+// UpdatePurchaseOrder cập nhật nhà cung cấp, kho nhận và toàn bộ dòng hàng của đơn nháp
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(c *gin.Context) {
+	order, ok := h.loadPurchaseOrder(c)
+	if !ok {
+		return
+	}
+	if order.Status != consts.PurchaseOrderDraft {
+		helpers.ErrorResponse(c, http.StatusConflict, "Không thể cập nhật đơn đặt hàng", errors.New("chỉ có thể sửa đơn đặt hàng ở trạng thái nháp"))
+		return
+	}
+
+	var input model.PurchaseOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	before := order.ToResponse()
+	if !h.applyPurchaseOrderInput(c, order, input) {
+		return
+	}
+	order.Supplier, order.Warehouse = nil, nil
+	if err := h.purchaseOrderRepo.ReplaceDraft(order); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật đơn đặt hàng", err)
+		return
+	}
+
+	updated, err := h.purchaseOrderRepo.GetByID(order.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	audit.Record(c, "purchase_order.update", audit.EntityPurchaseOrder, updated.ID, before, updated.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật đơn đặt hàng thành công",
+		Data:    updated.ToResponse(),
+	})
+}
+
+// PlacePurchaseOrder chốt đơn nháp đã gửi nhà cung cấp (draft → ordered); từ đây đơn không sửa được và có thể nhận hàng
+func (h *PurchaseOrderHandler) PlacePurchaseOrder(c *gin.Context) {
+	order, ok := h.loadPurchaseOrder(c)
+	if !ok {
+		return
+	}
+	if order.Status != consts.PurchaseOrderDraft {
+		helpers.ErrorResponse(c, http.StatusConflict, "Không thể đặt hàng", errors.New("đơn đặt hàng không ở trạng thái nháp"))
+		return
+	}
+
+	before := order.ToResponse()
+	now := time.Now()
+	if err := h.purchaseOrderRepo.UpdateStatus(order.ID, map[string]interface{}{
+		"status":     consts.PurchaseOrderOrdered,
+		"ordered_at": &now,
+	}); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật trạng thái đơn đặt hàng", err)
+		return
+	}
+	order.Status = consts.PurchaseOrderOrdered
+	order.OrderedAt = &now
+
+	audit.Record(c, "purchase_order.order", audit.EntityPurchaseOrder, order.ID, before, order.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Đặt hàng nhà cung cấp thành công",
+		Data:    order.ToResponse(),
+	})
+}
+
+// ReceivePurchaseOrder nhận hàng (toàn bộ hoặc một phần) vào kho của đơn: ghi sổ kho purchase cho từng dòng
+// và cập nhật giá vốn bình quân của sản phẩm
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c *gin.Context) {
+	order, ok := h.loadPurchaseOrder(c)
+	if !ok {
+		return
+	}
+
+	var input model.PurchaseReceiptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	before := order.ToResponse()
+	note := strings.TrimSpace(input.Note)
+	if note == "" {
+		note = "Nhận hàng " + order.Code
+	}
+	if err := inventory.Receive(order.ID, input.Lines, note, currentUserID(c)); err != nil {
+		var receiptErr *inventory.ReceiptError
+		switch {
+		case errors.As(err, &receiptErr):
+			helpers.ErrorResponse(c, http.StatusConflict, "Không thể nhận hàng", err)
+		case err.Error() == "purchase order not found":
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn đặt hàng", err)
+		default:
+			stockErrorResponse(c, err)
+		}
+		return
+	}
+
+	received, err := h.purchaseOrderRepo.GetByID(order.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	audit.Record(c, "purchase_order.receive", audit.EntityPurchaseOrder, received.ID, before, received.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Nhận hàng thành công",
+		Data:    received.ToResponse(),
+	})
+}
+
+// loadPurchaseOrder đọc đơn đặt hàng theo tham số :id; tự trả lỗi khi không hợp lệ
+func (h *PurchaseOrderHandler) loadPurchaseOrder(c *gin.Context) (*model.PurchaseOrder, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID đơn đặt hàng không hợp lệ", errors.New("ID đơn đặt hàng phải là số hợp lệ"))
+		return nil, false
+	}
+
+	order, err := h.purchaseOrderRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "purchase order not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy đơn đặt hàng", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+	return order, true
+}
+
+// applyPurchaseOrderInput kiểm tra nhà cung cấp, kho nhận và sản phẩm/biến thể của từng dòng rồi gán cho đơn;
+// tự trả lỗi khi không hợp lệ
+func (h *PurchaseOrderHandler) applyPurchaseOrderInput(c *gin.Context, order *model.PurchaseOrder, input model.PurchaseOrderInput) bool {
+	supplier, err := h.supplierRepo.GetByID(input.SupplierID)
+	if err != nil {
+		if err.Error() == "supplier not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Nhà cung cấp không hợp lệ", errors.New("không tìm thấy nhà cung cấp"))
+			return false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	if !supplier.IsActive {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Nhà cung cấp không hợp lệ", errors.New("nhà cung cấp đang ngừng hợp tác"))
+		return false
+	}
+
+	warehouse, err := h.warehouseRepo.GetByID(input.WarehouseID)
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("không tìm thấy kho hàng"))
+			return false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return false
+	}
+	if !warehouse.IsActive {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("kho nhận đang ngừng hoạt động"))
+		return false
+	}
+
+	lines := make([]model.PurchaseOrderLine, 0, len(input.Lines))
+	for i, line := range input.Lines {
+		product, err := h.productRepo.GetByID(line.ProductID)
+		if err != nil {
+			if err.Error() == "product not found" {
+				helpers.ErrorResponse(c, http.StatusBadRequest, "Sản phẩm không hợp lệ", fmt.Errorf("dòng %d: không tìm thấy sản phẩm", i+1))
+				return false
+			}
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return false
+		}
+		if len(product.Variants) > 0 && findProductVariant(product, line.VariantID) == nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Biến thể không hợp lệ", fmt.Errorf("dòng %d: sản phẩm có biến thể, hãy chọn biến thể cần nhập", i+1))
+			return false
+		}
+		if len(product.Variants) == 0 && line.VariantID != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Biến thể không hợp lệ", fmt.Errorf("dòng %d: sản phẩm không có biến thể", i+1))
+			return false
+		}
+
+		lines = append(lines, model.PurchaseOrderLine{
+			ProductID: product.ID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			UnitCost:  line.UnitCost,
+		})
+	}
+
+	order.SupplierID = supplier.ID
+	order.WarehouseID = warehouse.ID
+	order.Notes = strings.TrimSpace(input.Notes)
+	order.ExpectedAt = input.ExpectedAt
+	order.Lines = lines
+	return true
+}
@@ -0,0 +1,240 @@
+package handle
+
+import (
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/worker"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSearchQueryLength = 100
+
+// Thời gian xử lý tối đa mặc định cho mỗi yêu cầu gợi ý
+const defaultSuggestBudget = 50 * time.Millisecond
+
+type SearchHandler struct {
+	index         search.Index
+	productRepo   *repo.ProductRepo
+	newsRepo      *repo.NewsRepo
+	suggestBudget time.Duration
+}
+
+func NewSearchHandler() *SearchHandler {
+	suggestBudget := defaultSuggestBudget
+	if ms, err := strconv.Atoi(os.Getenv("SEARCH_SUGGEST_BUDGET_MS")); err == nil && ms > 0 {
+		suggestBudget = time.Duration(ms) * time.Millisecond
+	}
+
+	return &SearchHandler{
+		index:         search.MustDefault(),
+		productRepo:   repo.NewProductRepo(),
+		newsRepo:      repo.NewNewsRepo(),
+		suggestBudget: suggestBudget,
+	}
+}
+
+// SearchHitResponse là một kết quả tìm kiếm kèm đoạn trích và dữ liệu sản phẩm hoặc bài viết
+type SearchHitResponse struct {
+	Type       string                 `json:"type"`
+	ID         uint                   `json:"id"`
+	Score      float64                `json:"score"`
+	Highlights map[string][]string    `json:"highlights,omitempty"`
+	Product    *model.ProductResponse `json:"product,omitempty"`
+	News       *model.NewsResponse    `json:"news,omitempty"`
+}
+
+// Search tìm sản phẩm và bài viết theo từ khóa (không phân biệt dấu, chấp nhận lỗi gõ nhẹ), xếp theo độ liên quan
+func (h *SearchHandler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thiếu từ khóa tìm kiếm", errors.New("tham số q là bắt buộc"))
+		return
+	}
+	if utf8.RuneCountInString(text) > maxSearchQueryLength {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Từ khóa tìm kiếm quá dài", errors.New("q tối đa 100 ký tự"))
+		return
+	}
+
+	// Lọc theo loại kết quả (type=product,news), mặc định tìm tất cả
+	types := splitQueryList(c.Query("type"))
+	for _, docType := range types {
+		if !search.ValidType(docType) {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Loại kết quả không hợp lệ", errors.New("type phải là một trong: "+strings.Join(search.Types, ", ")))
+			return
+		}
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	result, err := h.index.Search(search.Query{
+		Text:  text,
+		Types: types,
+		From:  (page - 1) * limit,
+		Size:  limit,
+	})
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tìm kiếm", err)
+		return
+	}
+
+	hits, err := h.loadHits(result.Hits)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	// Chỉ ghi nhận lượt tìm ở trang đầu và có kết quả để gợi ý truy vấn phổ biến
+	if page == 1 && len(hits) > 0 {
+		search.RecordQuery(text)
+	}
+
+	total := int64(result.Total)
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Tìm kiếm thành công",
+		Data: map[string]interface{}{
+			"query":       text,
+			"hits":        hits,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// loadHits nạp sản phẩm và bài viết theo thứ tự xếp hạng; bỏ qua kết quả mà bản ghi đã bị xóa hoặc ẩn
+func (h *SearchHandler) loadHits(hits []search.Hit) ([]SearchHitResponse, error) {
+	var productIDs, newsIDs []uint
+	for _, hit := range hits {
+		switch hit.Type {
+		case search.TypeProduct:
+			productIDs = append(productIDs, hit.ID)
+		case search.TypeNews:
+			newsIDs = append(newsIDs, hit.ID)
+		}
+	}
+
+	products, err := h.productRepo.GetActiveByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	productsByID := make(map[uint]model.ProductResponse, len(products))
+	for _, product := range products {
+		productsByID[product.ID] = product.ToResponse()
+	}
+
+	news, err := h.newsRepo.GetPublishedByIDs(newsIDs)
+	if err != nil {
+		return nil, err
+	}
+	newsByID := make(map[uint]model.NewsResponse, len(news))
+	for _, item := range news {
+		newsByID[item.ID] = item.ToResponse()
+	}
+
+	responses := make([]SearchHitResponse, 0, len(hits))
+	for _, hit := range hits {
+		response := SearchHitResponse{
+			Type:       hit.Type,
+			ID:         hit.ID,
+			Score:      hit.Score,
+			Highlights: hit.Highlights,
+		}
+		switch hit.Type {
+		case search.TypeProduct:
+			product, ok := productsByID[hit.ID]
+			if !ok {
+				continue
+			}
+			response.Product = &product
+		case search.TypeNews:
+			item, ok := newsByID[hit.ID]
+			if !ok {
+				continue
+			}
+			response.News = &item
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// Suggest gợi ý tên sản phẩm, thương hiệu, danh mục và truy vấn phổ biến gần đây theo tiền tố (không phân biệt dấu).
+// Yêu cầu được giới hạn trong SEARCH_SUGGEST_BUDGET_MS; quá thời gian thì trả về phần kết quả đã có.
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if prefix == "" {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thiếu từ khóa gợi ý", errors.New("tham số q là bắt buộc"))
+		return
+	}
+	if utf8.RuneCountInString(prefix) > maxSearchQueryLength {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Từ khóa gợi ý quá dài", errors.New("q tối đa 100 ký tự"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if err != nil || limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.suggestBudget)
+	defer cancel()
+
+	result := search.Suggest(ctx, prefix, limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy gợi ý thành công",
+		Data: map[string]interface{}{
+			"query":           prefix,
+			"completions":     result.Completions,
+			"popular_queries": result.PopularQueries,
+			"partial":         result.Partial,
+			"took_ms":         float64(time.Since(start).Microseconds()) / 1000,
+		},
+	})
+}
+
+// Reindex dựng lại toàn bộ chỉ mục tìm kiếm từ cơ sở dữ liệu (vd: sau khi đổi tên thương hiệu, danh mục)
+func (h *SearchHandler) Reindex(c *gin.Context) {
+	stats, err := worker.ReindexSearch()
+	if err != nil {
+		if errors.Is(err, worker.ErrReindexRunning) {
+			helpers.ErrorResponse(c, http.StatusConflict, "Chỉ mục đang được dựng lại", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể dựng lại chỉ mục tìm kiếm", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Dựng lại chỉ mục tìm kiếm thành công",
+		Data:    stats,
+	})
+}
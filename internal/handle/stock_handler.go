@@ -0,0 +1,431 @@
+package handle
+
+import (
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stockAdjustmentInput là điều chỉnh tồn kho thủ công tại một kho (mặc định là kho mặc định): delta (cộng/trừ)
+// hoặc stock (số dư mới tại kho, quy đổi thành delta). reason mặc định là adjustment; return dùng khi nhập lại
+// hàng khách trả, kèm order_id của đơn gốc.
+type stockAdjustmentInput struct {
+	WarehouseID *uint  `json:"warehouse_id"`
+	Delta       *int   `json:"delta"`
+	Stock       *int   `json:"stock" binding:"omitempty,gte=0"`
+	Reason      string `json:"reason" binding:"omitempty,oneof=adjustment return"`
+	OrderID     *uint  `json:"order_id"`
+	Note        string `json:"note" binding:"max=255"`
+}
+
+// bindStockAdjustment đọc điều chỉnh tồn kho của sản phẩm/biến thể; tự trả lỗi khi không hợp lệ
+func bindStockAdjustment(c *gin.Context, productID uint, variantID *uint) (*model.StockMovement, bool) {
+	var input stockAdjustmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return nil, false
+	}
+	if (input.Delta == nil) == (input.Stock == nil) {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("cần cung cấp delta hoặc stock (không dùng cả hai)"))
+		return nil, false
+	}
+
+	warehouseRepo := repo.NewWarehouseRepo()
+	var warehouse *model.Warehouse
+	var err error
+	if input.WarehouseID != nil {
+		warehouse, err = warehouseRepo.GetByID(*input.WarehouseID)
+	} else {
+		warehouse, err = warehouseRepo.Default()
+	}
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("không tìm thấy kho hàng"))
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+
+	movement := &model.StockMovement{
+		ProductID:   productID,
+		VariantID:   variantID,
+		WarehouseID: &warehouse.ID,
+		Reason:      consts.StockReasonAdjustment,
+		OrderID:     input.OrderID,
+		UserID:      currentUserID(c),
+		Note:        strings.TrimSpace(input.Note),
+	}
+	if input.Reason != "" {
+		movement.Reason = input.Reason
+	}
+	if input.Delta != nil {
+		movement.Delta = *input.Delta
+	} else {
+		current, err := warehouseRepo.Level(warehouse.ID, productID, variantID)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return nil, false
+		}
+		movement.Delta = *input.Stock - current
+	}
+	if movement.Delta == 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tồn kho không thay đổi", errors.New("delta phải khác 0"))
+		return nil, false
+	}
+
+	if movement.Reason == consts.StockReasonReturn && movement.Delta < 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("hàng trả lại phải làm tăng tồn kho"))
+		return nil, false
+	}
+	if input.OrderID != nil {
+		if _, err := repo.NewOrderRepo().GetByID(*input.OrderID); err != nil {
+			if err.Error() == "order not found" {
+				helpers.ErrorResponse(c, http.StatusBadRequest, "Đơn hàng không hợp lệ", errors.New("không tìm thấy đơn hàng"))
+				return nil, false
+			}
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return nil, false
+		}
+	}
+	return movement, true
+}
+
+// stockErrorResponse trả lỗi phù hợp cho lỗi ghi sổ kho
+func stockErrorResponse(c *gin.Context, err error) {
+	switch err.Error() {
+	case "insufficient stock":
+		helpers.ErrorResponse(c, http.StatusConflict, "Tồn kho không đủ", errors.New("tồn kho không được âm"))
+	case "product not found", "product variant not found":
+		helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
+	case "warehouse not found":
+		helpers.ErrorResponse(c, http.StatusServiceUnavailable, "Chưa có kho hàng nào đang hoạt động", err)
+	default:
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật số lượng tồn kho", err)
+	}
+}
+
+// currentUserID trả về ID người dùng đang đăng nhập, nil với khách
+func currentUserID(c *gin.Context) *uint {
+	userID, exists := c.Get("user_id")
+	if !exists || userID == nil {
+		return nil
+	}
+	id := userID.(uint)
+	return &id
+}
+
+// GetStockMovements lấy lịch sử biến động tồn kho của sản phẩm (lọc theo variant_id, reason)
+func (h *ProductHandler) GetStockMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID sản phẩm không hợp lệ", errors.New("ID sản phẩm phải là số hợp lệ"))
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var filter repo.StockMovementFilter
+	if variantIDStr := c.Query("variant_id"); variantIDStr != "" {
+		variantID, err := strconv.ParseUint(variantIDStr, 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", errors.New("ID biến thể phải là số hợp lệ"))
+			return
+		}
+		variant := uint(variantID)
+		filter.VariantID = &variant
+	}
+	if reason := c.Query("reason"); reason != "" {
+		valid := false
+		for _, r := range consts.StockReasons {
+			if reason == r {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", errors.New("reason phải là một trong: "+strings.Join(consts.StockReasons, ", ")))
+			return
+		}
+		filter.Reason = reason
+	}
+
+	product, err := h.productRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	movements, total, err := h.stockRepo.GetByProductID(product.ID, filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy lịch sử tồn kho", err)
+		return
+	}
+
+	response := make([]model.StockMovementResponse, 0, len(movements))
+	for i := range movements {
+		response = append(response, movements[i].ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy lịch sử tồn kho thành công",
+		Data: map[string]interface{}{
+			"product_id":  product.ID,
+			"stock":       product.Stock,
+			"movements":   response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// GetWarehouseStock lấy tồn kho tại từng kho của sản phẩm và các biến thể
+func (h *ProductHandler) GetWarehouseStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID sản phẩm không hợp lệ", errors.New("ID sản phẩm phải là số hợp lệ"))
+		return
+	}
+
+	product, err := h.productRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy sản phẩm", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	levels, err := h.warehouseRepo.GetByProductID(product.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy tồn kho theo kho", err)
+		return
+	}
+
+	response := make([]model.WarehouseStockResponse, 0, len(levels))
+	for i := range levels {
+		response = append(response, levels[i].ToResponse())
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy tồn kho theo kho thành công",
+		Data: map[string]interface{}{
+			"product_id": product.ID,
+			"stock":      product.Stock,
+			"warehouses": response,
+		},
+	})
+}
+
+// lowStockReportItem là một dòng báo cáo tồn kho thấp; DaysOfCover = nil khi không bán được trong kỳ
+type lowStockReportItem struct {
+	ProductID     uint     `json:"product_id"`
+	SKU           string   `json:"sku"`
+	Name          string   `json:"name"`
+	CategoryName  string   `json:"category_name,omitempty"`
+	BrandName     string   `json:"brand_name,omitempty"`
+	Stock         int      `json:"stock"`
+	Threshold     int      `json:"low_stock_threshold"`
+	Sold          int      `json:"sold"`
+	DailyVelocity float64  `json:"daily_velocity"`
+	DaysOfCover   *float64 `json:"days_of_cover"`
+}
+
+// GetLowStockReport lấy các sản phẩm bằng hoặc dưới ngưỡng tồn kho, kèm số đã bán trong days ngày gần nhất
+// (mặc định 30), tốc độ bán mỗi ngày và số ngày ước tính còn đủ hàng; sản phẩm sắp hết hàng nhất trước
+func (h *ProductHandler) GetLowStockReport(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số không hợp lệ", errors.New("days phải từ 1 đến 365"))
+		return
+	}
+
+	products, err := h.productRepo.GetLowStock()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách sản phẩm tồn kho thấp", err)
+		return
+	}
+
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	sales, err := h.stockRepo.SalesByProduct(ids, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tính tốc độ bán hàng", err)
+		return
+	}
+
+	items := make([]lowStockReportItem, 0, len(products))
+	for _, product := range products {
+		item := lowStockReportItem{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Stock:     product.Stock,
+			Threshold: product.LowStockThreshold,
+			Sold:      sales[product.ID],
+		}
+		if product.Category != nil {
+			item.CategoryName = product.Category.Name
+		}
+		if product.Brand != nil {
+			item.BrandName = product.Brand.Name
+		}
+		if item.Sold > 0 {
+			item.DailyVelocity = math.Round(float64(item.Sold)/float64(days)*100) / 100
+			cover := math.Round(float64(item.Stock)*float64(days)/float64(item.Sold)*10) / 10
+			item.DaysOfCover = &cover
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].DaysOfCover, items[j].DaysOfCover
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a < *b
+		}
+		return items[i].Stock < items[j].Stock
+	})
+
+	total := len(items)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy báo cáo tồn kho thấp thành công",
+		Data: map[string]interface{}{
+			"days":        days,
+			"products":    items[start:end],
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < totalPages,
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+type marginReportItem struct {
+	repo.ProductMargin
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+func newMarginReportItem(margin repo.ProductMargin) marginReportItem {
+	item := marginReportItem{ProductMargin: margin}
+	item.Margin = math.Round((margin.Revenue-margin.Cost)*100) / 100
+	if margin.Revenue > 0 {
+		item.MarginPercent = math.Round(item.Margin/margin.Revenue*10000) / 100
+	}
+	return item
+}
+
+// GetMarginReport lấy doanh thu, giá vốn và lãi gộp theo sản phẩm của các đơn không bị hủy trong days ngày gần
+// nhất (mặc định 30); giá vốn lấy theo giá vốn bình quân tại thời điểm bán
+func (h *ProductHandler) GetMarginReport(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số không hợp lệ", errors.New("days phải từ 1 đến 365"))
+		return
+	}
+
+	margins, total, summary, err := h.productRepo.GetMargins(time.Now().AddDate(0, 0, -days), page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy báo cáo lãi gộp", err)
+		return
+	}
+
+	items := make([]marginReportItem, 0, len(margins))
+	for _, margin := range margins {
+		items = append(items, newMarginReportItem(margin))
+	}
+	totals := newMarginReportItem(*summary)
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy báo cáo lãi gộp thành công",
+		Data: map[string]interface{}{
+			"days":     days,
+			"products": items,
+			"summary": map[string]interface{}{
+				"quantity":       totals.Quantity,
+				"revenue":        totals.Revenue,
+				"cost":           totals.Cost,
+				"margin":         totals.Margin,
+				"margin_percent": totals.MarginPercent,
+			},
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
@@ -0,0 +1,550 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/inventory"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/spreadsheet"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StocktakeHandler struct {
+	stocktakeRepo *repo.StocktakeRepo
+	warehouseRepo *repo.WarehouseRepo
+	categoryRepo  *repo.CategoryRepo
+}
+
+func NewStocktakeHandler() *StocktakeHandler {
+	return &StocktakeHandler{
+		stocktakeRepo: repo.NewStocktakeRepo(),
+		warehouseRepo: repo.NewWarehouseRepo(),
+		categoryRepo:  repo.NewCategoryRepo(),
+	}
+}
+
+// GetStocktakes lấy danh sách phiên kiểm kê kèm tổng hợp chênh lệch (lọc theo status, warehouse_id)
+func (h *StocktakeHandler) GetStocktakes(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var filter repo.StocktakeFilter
+	if status := c.Query("status"); status != "" {
+		valid := false
+		for _, s := range consts.StocktakeStatuses {
+			if status == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", errors.New("status phải là một trong: "+strings.Join(consts.StocktakeStatuses, ", ")))
+			return
+		}
+		filter.Status = status
+	}
+	if filter.WarehouseID, err = queryID(c, "warehouse_id"); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+
+	stocktakes, total, err := h.stocktakeRepo.GetAll(filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách phiên kiểm kê", err)
+		return
+	}
+
+	response := make([]model.StocktakeResponse, 0, len(stocktakes))
+	for i := range stocktakes {
+		if err := h.refresh(&stocktakes[i]); err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy tồn kho", err)
+			return
+		}
+		response = append(response, stocktakes[i].ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách phiên kiểm kê thành công",
+		Data: map[string]interface{}{
+			"stocktakes":  response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// OpenStocktake mở phiên kiểm kê cho một kho (mặc định là kho mặc định), giới hạn trong một danh mục nếu có
+func (h *StocktakeHandler) OpenStocktake(c *gin.Context) {
+	var input model.StocktakeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	var warehouse *model.Warehouse
+	var err error
+	if input.WarehouseID != nil {
+		warehouse, err = h.warehouseRepo.GetByID(*input.WarehouseID)
+	} else {
+		warehouse, err = h.warehouseRepo.Default()
+	}
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("không tìm thấy kho hàng"))
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	var categoryIDs []uint
+	if input.CategoryID != nil {
+		if _, err := h.categoryRepo.GetByID(*input.CategoryID); err != nil {
+			if err.Error() == "category not found" {
+				helpers.ErrorResponse(c, http.StatusBadRequest, "Danh mục không hợp lệ", errors.New("không tìm thấy danh mục"))
+				return
+			}
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return
+		}
+		if categoryIDs, err = h.categoryRepo.GetDescendantIDs(*input.CategoryID); err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return
+		}
+	}
+
+	code, err := h.stocktakeRepo.GenerateCode()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo mã phiên kiểm kê", err)
+		return
+	}
+
+	stocktake := model.Stocktake{
+		Code:        code,
+		WarehouseID: warehouse.ID,
+		CategoryID:  input.CategoryID,
+		Status:      consts.StocktakeOpen,
+		Notes:       strings.TrimSpace(input.Notes),
+		UserID:      currentUserID(c),
+	}
+	if err := inventory.OpenStocktake(&stocktake, categoryIDs); err != nil {
+		var stocktakeErr *inventory.StocktakeError
+		if errors.As(err, &stocktakeErr) {
+			helpers.ErrorResponse(c, http.StatusConflict, "Không thể mở phiên kiểm kê", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể mở phiên kiểm kê", err)
+		return
+	}
+
+	created, err := h.stocktakeRepo.GetByID(stocktake.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	audit.Record(c, "stocktake.open", audit.EntityStocktake, created.ID, nil, created.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Mở phiên kiểm kê thành công",
+		Data:    created.ToResponse(),
+	})
+}
+
+// GetStocktake lấy tổng hợp phiên kiểm kê; với phiên đang mở, chênh lệch tính theo tồn kho hiện tại
+func (h *StocktakeHandler) GetStocktake(c *gin.Context) {
+	stocktake, ok := h.loadStocktake(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy phiên kiểm kê thành công",
+		Data:    stocktake.ToResponse(),
+	})
+}
+
+// GetStocktakeLines lấy báo cáo chênh lệch theo dòng kiểm kê có phân trang. filter=counted|uncounted|variance|skipped
+// để lọc dòng đã đếm, chưa đếm, có chênh lệch (sắp theo chênh lệch lớn nhất trước) hoặc bị bỏ qua khi duyệt,
+// mặc định lấy tất cả.
+func (h *StocktakeHandler) GetStocktakeLines(c *gin.Context) {
+	stocktake, ok := h.loadStocktake(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := c.DefaultQuery("filter", "all")
+	lines := make([]model.StocktakeLineResponse, 0, len(stocktake.Lines))
+	for i := range stocktake.Lines {
+		line := stocktake.Lines[i].ToResponse()
+		switch filter {
+		case "all":
+		case "counted":
+			if line.Variance == nil {
+				continue
+			}
+		case "uncounted":
+			if line.Variance != nil {
+				continue
+			}
+		case "variance":
+			if line.Variance == nil || *line.Variance == 0 {
+				continue
+			}
+		case "skipped":
+			if line.SkipReason == "" {
+				continue
+			}
+		default:
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", errors.New("filter phải là một trong: all, counted, uncounted, variance, skipped"))
+			return
+		}
+		lines = append(lines, line)
+	}
+	if filter == "variance" {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return abs(*lines[i].Variance) > abs(*lines[j].Variance)
+		})
+	}
+
+	total := len(lines)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy báo cáo chênh lệch kiểm kê thành công",
+		Data: map[string]interface{}{
+			"stocktake":   stocktake.ToResponse(),
+			"lines":       lines[start:end],
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < totalPages,
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// SubmitStocktakeCounts ghi số đếm theo line_id hoặc sku (mode=set ghi đè, mode=add cộng dồn)
+func (h *StocktakeHandler) SubmitStocktakeCounts(c *gin.Context) {
+	id, ok := parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	var input model.StocktakeCountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = "set"
+	}
+
+	h.applyCounts(c, id, input.Counts, input.Mode)
+}
+
+// UploadStocktakeCounts nhận tệp CSV/XLSX (trường "file") từ máy quét mã vạch: cột đầu là SKU, cột thứ hai tùy chọn
+// là số lượng (mặc định 1 mỗi lần quét); dòng tiêu đề sku/barcode được bỏ qua. Số lượng được gộp theo SKU và mặc định
+// cộng dồn vào số đếm hiện có (mode=set để ghi đè).
+func (h *StocktakeHandler) UploadStocktakeCounts(c *gin.Context) {
+	id, ok := parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, consts.MAX_IMPORT_FILE_SIZE+(1<<20))
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Dung lượng tải lên vượt quá giới hạn", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Thiếu tệp số đếm", err)
+		return
+	}
+	if file.Size > consts.MAX_IMPORT_FILE_SIZE {
+		helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Tệp vượt quá dung lượng cho phép (%d MB)", consts.MAX_IMPORT_FILE_SIZE>>20), nil)
+		return
+	}
+
+	mode := c.DefaultPostForm("mode", "add")
+	if mode != "add" && mode != "set" {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errors.New("mode phải là add hoặc set"))
+		return
+	}
+
+	format, err := spreadsheet.FormatFromFilename(file.Filename)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Định dạng tệp không được hỗ trợ", errors.New("chỉ hỗ trợ tệp .csv và .xlsx"))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể đọc tệp", err)
+		return
+	}
+	defer src.Close()
+
+	rows, err := spreadsheet.Read(src, format, consts.MAX_IMPORT_ROWS)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrTooManyRows) {
+			helpers.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Tệp có quá %d dòng dữ liệu", consts.MAX_IMPORT_ROWS), err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể đọc tệp", err)
+		return
+	}
+
+	counts, err := parseScanRows(rows)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tệp số đếm không hợp lệ", err)
+		return
+	}
+	if len(counts) == 0 {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tệp không có dữ liệu", errors.New("tệp cần ít nhất một dòng SKU"))
+		return
+	}
+
+	h.applyCounts(c, id, counts, mode)
+}
+
+// ApproveStocktake duyệt phiên kiểm kê: ghi sổ kho stocktake cho chênh lệch của các dòng đã đếm và đóng phiên
+func (h *StocktakeHandler) ApproveStocktake(c *gin.Context) {
+	id, ok := parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	if err := inventory.ApproveStocktake(id, currentUserID(c)); err != nil {
+		h.stocktakeErrorResponse(c, err, "Không thể duyệt phiên kiểm kê")
+		return
+	}
+
+	approved, err := h.stocktakeRepo.GetByID(id)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	audit.Record(c, "stocktake.approve", audit.EntityStocktake, approved.ID, nil, approved.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Duyệt phiên kiểm kê thành công",
+		Data:    approved.ToResponse(),
+	})
+}
+
+// CancelStocktake hủy phiên kiểm kê đang mở, không điều chỉnh tồn kho
+func (h *StocktakeHandler) CancelStocktake(c *gin.Context) {
+	stocktake, ok := h.loadStocktake(c)
+	if !ok {
+		return
+	}
+	if stocktake.Status != consts.StocktakeOpen {
+		helpers.ErrorResponse(c, http.StatusConflict, "Không thể hủy phiên kiểm kê", errors.New("phiên kiểm kê đã đóng"))
+		return
+	}
+
+	now := time.Now()
+	userID := currentUserID(c)
+	if err := h.stocktakeRepo.UpdateStatus(stocktake.ID, map[string]interface{}{
+		"status":      consts.StocktakeCancelled,
+		"approved_by": userID,
+		"closed_at":   &now,
+	}); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể hủy phiên kiểm kê", err)
+		return
+	}
+	stocktake.Status = consts.StocktakeCancelled
+	stocktake.ApprovedBy = userID
+	stocktake.ClosedAt = &now
+
+	audit.Record(c, "stocktake.cancel", audit.EntityStocktake, stocktake.ID, nil, stocktake.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Hủy phiên kiểm kê thành công",
+		Data:    stocktake.ToResponse(),
+	})
+}
+
+// applyCounts ghi số đếm và trả về kết quả từng mục cùng tổng hợp phiên sau khi ghi
+func (h *StocktakeHandler) applyCounts(c *gin.Context, id uint, counts []model.StocktakeCountLineInput, mode string) {
+	results, err := inventory.ApplyCounts(id, counts, mode, currentUserID(c))
+	if err != nil {
+		h.stocktakeErrorResponse(c, err, "Không thể ghi số đếm")
+		return
+	}
+
+	stocktake, err := h.stocktakeRepo.GetByID(id)
+	if err == nil {
+		err = h.refresh(stocktake)
+	}
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	rejected := 0
+	for _, result := range results {
+		if result.Error != "" {
+			rejected++
+		}
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: fmt.Sprintf("Đã ghi %d/%d số đếm", len(results)-rejected, len(results)),
+		Data: map[string]interface{}{
+			"stocktake": stocktake.ToResponse(),
+			"results":   results,
+			"rejected":  rejected,
+		},
+	})
+}
+
+// stocktakeErrorResponse trả lỗi tương ứng của các thao tác trên phiên kiểm kê
+func (h *StocktakeHandler) stocktakeErrorResponse(c *gin.Context, err error, message string) {
+	var stocktakeErr *inventory.StocktakeError
+	switch {
+	case errors.As(err, &stocktakeErr):
+		helpers.ErrorResponse(c, http.StatusConflict, message, err)
+	case err.Error() == "stocktake not found":
+		helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy phiên kiểm kê", err)
+	default:
+		stockErrorResponse(c, err)
+	}
+}
+
+// refresh tính lại tồn kho hệ thống theo hiện tại cho phiên đang mở; phiên đã đóng giữ số đã chốt
+func (h *StocktakeHandler) refresh(stocktake *model.Stocktake) error {
+	if stocktake.Status != consts.StocktakeOpen {
+		return nil
+	}
+	return inventory.RefreshSystemStock(h.warehouseRepo, stocktake)
+}
+
+// loadStocktake đọc phiên kiểm kê theo tham số :id (tồn kho hệ thống đã tính lại); tự trả lỗi khi không hợp lệ
+func (h *StocktakeHandler) loadStocktake(c *gin.Context) (*model.Stocktake, bool) {
+	id, ok := parseStocktakeID(c)
+	if !ok {
+		return nil, false
+	}
+
+	stocktake, err := h.stocktakeRepo.GetByID(id)
+	if err != nil {
+		if err.Error() == "stocktake not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy phiên kiểm kê", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+	if err := h.refresh(stocktake); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy tồn kho", err)
+		return nil, false
+	}
+	return stocktake, true
+}
+
+func parseStocktakeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID phiên kiểm kê không hợp lệ", errors.New("ID phiên kiểm kê phải là số hợp lệ"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseScanRows gộp các dòng quét mã vạch (SKU[, số lượng]) thành số đếm theo SKU, giữ thứ tự xuất hiện đầu tiên
+func parseScanRows(rows [][]string) ([]model.StocktakeCountLineInput, error) {
+	index := make(map[string]int)
+	var counts []model.StocktakeCountLineInput
+	for i, row := range rows {
+		if spreadsheet.IsBlank(row) {
+			continue
+		}
+		sku := strings.TrimSpace(row[0])
+		if i == 0 && (strings.EqualFold(sku, "sku") || strings.EqualFold(sku, "barcode")) {
+			continue
+		}
+		if sku == "" {
+			return nil, fmt.Errorf("dòng %d: thiếu SKU", i+1)
+		}
+
+		quantity := 1
+		if len(row) > 1 && strings.TrimSpace(row[1]) != "" {
+			parsed, err := strconv.Atoi(strings.TrimSpace(row[1]))
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("dòng %d: số lượng phải là số nguyên không âm", i+1)
+			}
+			quantity = parsed
+		}
+
+		key := strings.ToUpper(sku)
+		if j, ok := index[key]; ok {
+			counts[j].Quantity += quantity
+			continue
+		}
+		index[key] = len(counts)
+		counts = append(counts, model.StocktakeCountLineInput{SKU: sku, Quantity: quantity})
+	}
+	return counts, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
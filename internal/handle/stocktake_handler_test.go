@@ -0,0 +1,53 @@
+package handle
+
+import (
+	"backend/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestParseScanRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]string
+		want    []model.StocktakeCountLineInput
+		wantErr bool
+	}{
+		{
+			name: "header skipped, repeated scans summed case-insensitively",
+			rows: [][]string{{"SKU", "quantity"}, {"vi-01"}, {"AO-02", "3"}, {""}, {"VI-01", " 2 "}},
+			want: []model.StocktakeCountLineInput{{SKU: "vi-01", Quantity: 3}, {SKU: "AO-02", Quantity: 3}},
+		},
+		{
+			name: "barcode header and zero counts",
+			rows: [][]string{{"barcode"}, {"VI-01", "0"}},
+			want: []model.StocktakeCountLineInput{{SKU: "VI-01", Quantity: 0}},
+		},
+		{
+			name:    "missing SKU",
+			rows:    [][]string{{"VI-01"}, {"", "2"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative quantity",
+			rows:    [][]string{{"VI-01", "-1"}},
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric quantity",
+			rows:    [][]string{{"VI-01", "two"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScanRows(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScanRows error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseScanRows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
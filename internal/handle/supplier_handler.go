@@ -0,0 +1,130 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupplierHandler struct {
+	supplierRepo *repo.SupplierRepo
+}
+
+func NewSupplierHandler() *SupplierHandler {
+	return &SupplierHandler{
+		supplierRepo: repo.NewSupplierRepo(),
+	}
+}
+
+// GetSuppliers lấy danh sách nhà cung cấp (tìm theo search, active=true để chỉ lấy nhà cung cấp đang hợp tác)
+func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
+	suppliers, err := h.supplierRepo.GetAll(strings.TrimSpace(c.Query("search")), c.Query("active") == "true")
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách nhà cung cấp", err)
+		return
+	}
+
+	response := make([]model.SupplierResponse, 0, len(suppliers))
+	for i := range suppliers {
+		response = append(response, suppliers[i].ToResponse())
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách nhà cung cấp thành công",
+		Data:    response,
+	})
+}
+
+// CreateSupplier tạo nhà cung cấp mới
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var input model.SupplierInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	supplier := model.Supplier{IsActive: true}
+	applySupplierInput(&supplier, input)
+	if err := h.supplierRepo.Create(&supplier); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo nhà cung cấp", err)
+		return
+	}
+
+	// Cột is_active có default:true nên cần cập nhật lại khi tạo nhà cung cấp ở trạng thái ngừng hợp tác
+	if !supplier.IsActive {
+		if err := h.supplierRepo.Update(&supplier); err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo nhà cung cấp", err)
+			return
+		}
+	}
+
+	audit.Record(c, "supplier.create", audit.EntitySupplier, supplier.ID, nil, supplier.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo nhà cung cấp thành công",
+		Data:    supplier.ToResponse(),
+	})
+}
+
+// UpdateSupplier cập nhật thông tin và trạng thái hợp tác của nhà cung cấp
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID nhà cung cấp không hợp lệ", errors.New("ID nhà cung cấp phải là số hợp lệ"))
+		return
+	}
+
+	supplier, err := h.supplierRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "supplier not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy nhà cung cấp", err)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	var input model.SupplierInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	before := supplier.ToResponse()
+	applySupplierInput(supplier, input)
+	if err := h.supplierRepo.Update(supplier); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật nhà cung cấp", err)
+		return
+	}
+
+	audit.Record(c, "supplier.update", audit.EntitySupplier, supplier.ID, before, supplier.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật nhà cung cấp thành công",
+		Data:    supplier.ToResponse(),
+	})
+}
+
+// applySupplierInput gán dữ liệu đầu vào cho nhà cung cấp
+func applySupplierInput(supplier *model.Supplier, input model.SupplierInput) {
+	supplier.Name = strings.TrimSpace(input.Name)
+	supplier.ContactName = strings.TrimSpace(input.ContactName)
+	supplier.Email = strings.TrimSpace(input.Email)
+	supplier.Phone = strings.TrimSpace(input.Phone)
+	supplier.Address = strings.TrimSpace(input.Address)
+	supplier.TaxCode = strings.TrimSpace(input.TaxCode)
+	supplier.Notes = strings.TrimSpace(input.Notes)
+	if input.IsActive != nil {
+		supplier.IsActive = *input.IsActive
+	}
+}
@@ -0,0 +1,239 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/helpers"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/worker"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrashHandler struct {
+	trashRepo    *repo.TrashRepo
+	productRepo  *repo.ProductRepo
+	categoryRepo *repo.CategoryRepo
+	newsRepo     *repo.NewsRepo
+	orderRepo    *repo.OrderRepo
+	userRepo     *repo.UserRepository
+}
+
+func NewTrashHandler(userRepo *repo.UserRepository) *TrashHandler {
+	return &TrashHandler{
+		trashRepo:    repo.NewTrashRepo(),
+		productRepo:  repo.NewProductRepo(),
+		categoryRepo: repo.NewCategoryRepo(),
+		newsRepo:     repo.NewNewsRepo(),
+		orderRepo:    repo.NewOrderRepo(),
+		userRepo:     userRepo,
+	}
+}
+
+// trashEntry là một bản ghi trong thùng rác kèm thời điểm xóa và thời điểm sẽ bị xóa vĩnh viễn
+type trashEntry struct {
+	Item      interface{} `json:"item"`
+	DeletedAt time.Time   `json:"deleted_at"`
+	PurgeAt   time.Time   `json:"purge_at"`
+}
+
+func newTrashEntry(item interface{}, deletedAt gorm.DeletedAt, retention time.Duration) trashEntry {
+	return trashEntry{Item: item, DeletedAt: deletedAt.Time, PurgeAt: deletedAt.Time.Add(retention)}
+}
+
+// trashAuditEntities ánh xạ loại bản ghi trong thùng rác sang loại đối tượng của nhật ký thao tác
+var trashAuditEntities = map[string]string{
+	repo.TrashProducts:   audit.EntityProduct,
+	repo.TrashCategories: audit.EntityCategory,
+	repo.TrashNews:       audit.EntityNews,
+	repo.TrashUsers:      audit.EntityUser,
+	repo.TrashOrders:     audit.EntityOrder,
+}
+
+// List trả về handler liệt kê bản ghi đã xóa mềm của một loại, mới xóa nhất trước
+func (h *TrashHandler) List(trashType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 100 {
+			limit = 10
+		}
+
+		entries, total, err := h.listEntries(trashType, page, limit)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách thùng rác", err)
+			return
+		}
+
+		totalPages := (total + int64(limit) - 1) / int64(limit)
+
+		c.JSON(http.StatusOK, helpers.Response{
+			Success: true,
+			Message: "Lấy danh sách thùng rác thành công",
+			Data: map[string]interface{}{
+				"type":           trashType,
+				"items":          entries,
+				"retention_days": int(worker.TrashRetention().Hours() / 24),
+				"total":          total,
+				"page":           page,
+				"limit":          limit,
+				"total_pages":    totalPages,
+				"has_next":       page < int(totalPages),
+				"has_prev":       page > 1,
+			},
+		})
+	}
+}
+
+func (h *TrashHandler) listEntries(trashType string, page, limit int) ([]trashEntry, int64, error) {
+	retention := worker.TrashRetention()
+	entries := []trashEntry{}
+
+	switch trashType {
+	case repo.TrashProducts:
+		products, total, err := h.trashRepo.ListProducts(page, limit)
+		for i := range products {
+			entries = append(entries, newTrashEntry(products[i].ToResponse(), products[i].DeletedAt, retention))
+		}
+		return entries, total, err
+	case repo.TrashCategories:
+		categories, total, err := h.trashRepo.ListCategories(page, limit)
+		for i := range categories {
+			entries = append(entries, newTrashEntry(categories[i].ToResponse(), categories[i].DeletedAt, retention))
+		}
+		return entries, total, err
+	case repo.TrashNews:
+		news, total, err := h.trashRepo.ListNews(page, limit)
+		for i := range news {
+			entries = append(entries, newTrashEntry(news[i].ToResponse(), news[i].DeletedAt, retention))
+		}
+		return entries, total, err
+	case repo.TrashUsers:
+		users, total, err := h.trashRepo.ListUsers(page, limit)
+		for i := range users {
+			entries = append(entries, newTrashEntry(users[i].ToResponse(), users[i].DeletedAt, retention))
+		}
+		return entries, total, err
+	case repo.TrashOrders:
+		orders, total, err := h.trashRepo.ListOrders(page, limit)
+		for i := range orders {
+			entries = append(entries, newTrashEntry(orders[i].ToResponse(), orders[i].DeletedAt, retention))
+		}
+		return entries, total, err
+	}
+	return nil, 0, errors.New("unknown trash type")
+}
+
+// Restore trả về handler khôi phục một bản ghi đã xóa mềm; trùng slug/SKU/email/username với bản ghi đang tồn tại trả về 409
+func (h *TrashHandler) Restore(trashType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "ID không hợp lệ", errors.New("ID phải là số hợp lệ"))
+			return
+		}
+
+		restored, err := h.restore(trashType, uint(id))
+		if err != nil {
+			switch {
+			case strings.HasSuffix(err.Error(), "not found"):
+				helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy bản ghi trong thùng rác", err)
+			case strings.HasSuffix(err.Error(), "already exists"):
+				helpers.ErrorResponse(c, http.StatusConflict, "Không thể khôi phục vì trùng với bản ghi đang tồn tại", err)
+			default:
+				helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể khôi phục bản ghi", err)
+			}
+			return
+		}
+
+		entity := trashAuditEntities[trashType]
+		audit.Record(c, entity+".restore", entity, uint(id), nil, restored)
+
+		c.JSON(http.StatusOK, helpers.Response{
+			Success: true,
+			Message: "Khôi phục bản ghi thành công",
+			Data:    restored,
+		})
+	}
+}
+
+// restore khôi phục bản ghi, nạp lại và đồng bộ chỉ mục tìm kiếm; trả về dữ liệu phản hồi của bản ghi
+func (h *TrashHandler) restore(trashType string, id uint) (interface{}, error) {
+	switch trashType {
+	case repo.TrashProducts:
+		if err := h.trashRepo.RestoreProduct(id); err != nil {
+			return nil, err
+		}
+		product, err := h.productRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		search.SyncProduct(product)
+		worker.RefreshSuggestions()
+		return product.ToResponse(), nil
+	case repo.TrashCategories:
+		if err := h.trashRepo.RestoreCategory(id); err != nil {
+			return nil, err
+		}
+		category, err := h.categoryRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		worker.RefreshSuggestions()
+		return category.ToResponse(), nil
+	case repo.TrashNews:
+		if err := h.trashRepo.RestoreNews(id); err != nil {
+			return nil, err
+		}
+		news, err := h.newsRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		search.SyncNews(news)
+		return news.ToResponse(), nil
+	case repo.TrashUsers:
+		if err := h.trashRepo.RestoreUser(id); err != nil {
+			return nil, err
+		}
+		user, err := h.userRepo.GetUserByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return user.ToResponse(), nil
+	case repo.TrashOrders:
+		if err := h.trashRepo.RestoreOrder(id); err != nil {
+			return nil, err
+		}
+		order, err := h.orderRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return order.ToResponse(), nil
+	}
+	return nil, errors.New("unknown trash type")
+}
+
+// PurgeTrash xóa vĩnh viễn ngay các bản ghi đã quá thời hạn lưu trong thùng rác (TRASH_RETENTION_DAYS)
+func (h *TrashHandler) PurgeTrash(c *gin.Context) {
+	result, err := worker.PurgeTrash(time.Now().Add(-worker.TrashRetention()))
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể dọn thùng rác", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Dọn thùng rác thành công",
+		Data:    result,
+	})
+}
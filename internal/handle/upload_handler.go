@@ -0,0 +1,118 @@
+package handle
+
+import (
+	"backend/internal/consts"
+	"backend/internal/helpers"
+	"backend/internal/imaging"
+	"backend/internal/storage"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Định dạng ảnh được chấp nhận, xác định bằng nội dung tệp thay vì phần mở rộng do client gửi
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type UploadHandler struct {
+	store storage.Storage
+}
+
+func NewUploadHandler() *UploadHandler {
+	return &UploadHandler{
+		store: storage.MustDefault(),
+	}
+}
+
+// ServeUpload phục vụ tệp đã tải lên từ kho lưu trữ
+func (h *UploadHandler) ServeUpload(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+	if !storage.ValidKey(key) {
+		helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy tệp", nil)
+		return
+	}
+
+	reader, info, err := h.store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy tệp", nil)
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể đọc tệp", err)
+		return
+	}
+	defer reader.Close()
+
+	// Tên tệp là chuỗi ngẫu nhiên và không bao giờ bị ghi đè nên có thể cache lâu dài
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"Last-Modified":          info.ModTime.UTC().Format(http.TimeFormat),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// sniffContentType đọc 512 byte đầu của tệp để xác định định dạng thực sự
+func sniffContentType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if n == 0 {
+		return "", errors.New("tệp rỗng")
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
+
+// maxUploadSize đọc giới hạn dung lượng mỗi tệp từ MAX_UPLOAD_SIZE (byte)
+func maxUploadSize() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("MAX_UPLOAD_SIZE"), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return consts.DEFAULT_MAX_UPLOAD_SIZE
+}
+
+// readSanitizedImage đọc toàn bộ ảnh tải lên và loại bỏ siêu dữ liệu (EXIF, GPS...) trước khi lưu
+func readSanitizedImage(file *multipart.FileHeader, contentType string) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return imaging.Sanitize(data, contentType)
+}
+
+// newUploadKey tạo khóa lưu trữ ngẫu nhiên trong thư mục prefix, vd: products/12/<32 ký tự hex>.jpg
+func newUploadKey(prefix, extension string) (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s%s", prefix, hex.EncodeToString(name), extension), nil
+}
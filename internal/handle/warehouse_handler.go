@@ -0,0 +1,371 @@
+package handle
+
+import (
+	"backend/internal/audit"
+	"backend/internal/helpers"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WarehouseHandler struct {
+	warehouseRepo *repo.WarehouseRepo
+	productRepo   *repo.ProductRepo
+	stockRepo     *repo.StockRepo
+}
+
+func NewWarehouseHandler() *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseRepo: repo.NewWarehouseRepo(),
+		productRepo:   repo.NewProductRepo(),
+		stockRepo:     repo.NewStockRepo(),
+	}
+}
+
+// GetWarehouses lấy tất cả kho theo thứ tự ưu tiên kèm tổng tồn kho từng kho
+func (h *WarehouseHandler) GetWarehouses(c *gin.Context) {
+	warehouses, err := h.warehouseRepo.GetAll()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy danh sách kho hàng", err)
+		return
+	}
+	totals, err := h.warehouseRepo.TotalStock()
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy tồn kho", err)
+		return
+	}
+
+	response := make([]model.WarehouseResponse, 0, len(warehouses))
+	for i := range warehouses {
+		item := warehouses[i].ToResponse()
+		item.TotalStock = totals[warehouses[i].ID]
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy danh sách kho hàng thành công",
+		Data:    response,
+	})
+}
+
+// CreateWarehouse tạo kho mới
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var input model.WarehouseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	exists, err := h.warehouseRepo.CheckCodeExists(input.Code, 0)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "Mã kho đã tồn tại", errors.New("kho với mã này đã tồn tại"))
+		return
+	}
+
+	warehouse := model.Warehouse{IsActive: true}
+	applyWarehouseInput(&warehouse, input)
+	if err := h.warehouseRepo.Create(&warehouse); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo kho hàng", err)
+		return
+	}
+
+	// Cột is_active có default:true nên cần cập nhật lại khi tạo kho ở trạng thái ngừng hoạt động
+	if !warehouse.IsActive {
+		if err := h.warehouseRepo.Update(&warehouse); err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể tạo kho hàng", err)
+			return
+		}
+	}
+
+	audit.Record(c, "warehouse.create", audit.EntityWarehouse, warehouse.ID, nil, warehouse.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Tạo kho hàng thành công",
+		Data:    warehouse.ToResponse(),
+	})
+}
+
+// UpdateWarehouse cập nhật thông tin, tỉnh/thành phục vụ, độ ưu tiên và trạng thái của kho
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	warehouse, ok := h.loadWarehouse(c)
+	if !ok {
+		return
+	}
+
+	var input model.WarehouseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	exists, err := h.warehouseRepo.CheckCodeExists(input.Code, warehouse.ID)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if exists {
+		helpers.ErrorResponse(c, http.StatusConflict, "Mã kho đã tồn tại", errors.New("kho khác với mã này đã tồn tại"))
+		return
+	}
+
+	// Phải còn ít nhất một kho hoạt động để nhận hàng và phân bổ đơn
+	if warehouse.IsActive && input.IsActive != nil && !*input.IsActive {
+		active, err := h.warehouseRepo.GetActive()
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+			return
+		}
+		if len(active) <= 1 {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Không thể ngừng hoạt động kho", errors.New("phải còn ít nhất một kho đang hoạt động"))
+			return
+		}
+	}
+
+	before := warehouse.ToResponse()
+	applyWarehouseInput(warehouse, input)
+	if err := h.warehouseRepo.Update(warehouse); err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể cập nhật kho hàng", err)
+		return
+	}
+
+	audit.Record(c, "warehouse.update", audit.EntityWarehouse, warehouse.ID, before, warehouse.ToResponse())
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Cập nhật kho hàng thành công",
+		Data:    warehouse.ToResponse(),
+	})
+}
+
+// GetWarehouseStock lấy tồn kho từng sản phẩm/biến thể tại kho có phân trang
+func (h *WarehouseHandler) GetWarehouseStock(c *gin.Context) {
+	warehouse, ok := h.loadWarehouse(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	levels, total, err := h.warehouseRepo.GetStock(warehouse.ID, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy tồn kho", err)
+		return
+	}
+
+	response := make([]model.WarehouseStockResponse, 0, len(levels))
+	for i := range levels {
+		response = append(response, levels[i].ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy tồn kho thành công",
+		Data: map[string]interface{}{
+			"warehouse":   warehouse.ToResponse(),
+			"stock":       response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// CreateTransfer chuyển hàng của một sản phẩm/biến thể từ kho này sang kho khác
+func (h *WarehouseHandler) CreateTransfer(c *gin.Context) {
+	var input model.StockTransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", err)
+		return
+	}
+
+	from, err := h.warehouseRepo.GetByID(input.FromWarehouseID)
+	if err == nil {
+		var to *model.Warehouse
+		if to, err = h.warehouseRepo.GetByID(input.ToWarehouseID); err == nil && !to.IsActive {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("kho nhận đang ngừng hoạt động"))
+			return
+		}
+	}
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Kho hàng không hợp lệ", errors.New("không tìm thấy kho hàng"))
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+
+	product, err := h.productRepo.GetByID(input.ProductID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			helpers.ErrorResponse(c, http.StatusBadRequest, "Sản phẩm không hợp lệ", errors.New("không tìm thấy sản phẩm"))
+			return
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return
+	}
+	if len(product.Variants) > 0 && findProductVariant(product, input.VariantID) == nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Biến thể không hợp lệ", errors.New("sản phẩm có biến thể, hãy chọn biến thể cần chuyển"))
+		return
+	}
+	if len(product.Variants) == 0 && input.VariantID != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Biến thể không hợp lệ", errors.New("sản phẩm không có biến thể"))
+		return
+	}
+
+	transfer := model.StockTransfer{
+		FromWarehouseID: from.ID,
+		ToWarehouseID:   input.ToWarehouseID,
+		ProductID:       product.ID,
+		VariantID:       input.VariantID,
+		Quantity:        input.Quantity,
+		UserID:          currentUserID(c),
+		Note:            strings.TrimSpace(input.Note),
+	}
+	if err := h.stockRepo.Transfer(&transfer); err != nil {
+		if err.Error() == "insufficient stock" {
+			helpers.ErrorResponse(c, http.StatusConflict, "Tồn kho không đủ", errors.New("kho xuất không đủ hàng để chuyển"))
+			return
+		}
+		stockErrorResponse(c, err)
+		return
+	}
+
+	audit.Record(c, "warehouse.transfer", audit.EntityWarehouse, from.ID, nil, transfer.ToResponse())
+
+	c.JSON(http.StatusCreated, helpers.Response{
+		Success: true,
+		Message: "Chuyển kho thành công",
+		Data:    transfer.ToResponse(),
+	})
+}
+
+// GetTransfers lấy lịch sử chuyển kho (lọc theo warehouse_id, product_id)
+func (h *WarehouseHandler) GetTransfers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var filter repo.StockTransferFilter
+	if filter.WarehouseID, err = queryID(c, "warehouse_id"); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+	if filter.ProductID, err = queryID(c, "product_id"); err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "Tham số lọc không hợp lệ", err)
+		return
+	}
+
+	transfers, total, err := h.warehouseRepo.GetTransfers(filter, page, limit)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Không thể lấy lịch sử chuyển kho", err)
+		return
+	}
+
+	response := make([]model.StockTransferResponse, 0, len(transfers))
+	for i := range transfers {
+		response = append(response, transfers[i].ToResponse())
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	c.JSON(http.StatusOK, helpers.Response{
+		Success: true,
+		Message: "Lấy lịch sử chuyển kho thành công",
+		Data: map[string]interface{}{
+			"transfers":   response,
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"has_next":    page < int(totalPages),
+			"has_prev":    page > 1,
+		},
+	})
+}
+
+// queryID đọc ID tùy chọn từ query string, nil khi không truyền
+func queryID(c *gin.Context, key string) (*uint, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, errors.New(key + " phải là số hợp lệ")
+	}
+	parsed := uint(id)
+	return &parsed, nil
+}
+
+// loadWarehouse đọc kho theo tham số :id; tự trả lỗi khi không hợp lệ
+func (h *WarehouseHandler) loadWarehouse(c *gin.Context) (*model.Warehouse, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		helpers.ErrorResponse(c, http.StatusBadRequest, "ID kho hàng không hợp lệ", errors.New("ID kho hàng phải là số hợp lệ"))
+		return nil, false
+	}
+
+	warehouse, err := h.warehouseRepo.GetByID(uint(id))
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			helpers.ErrorResponse(c, http.StatusNotFound, "Không tìm thấy kho hàng", err)
+			return nil, false
+		}
+		helpers.ErrorResponse(c, http.StatusInternalServerError, "Lỗi cơ sở dữ liệu", err)
+		return nil, false
+	}
+	return warehouse, true
+}
+
+// applyWarehouseInput gán dữ liệu đầu vào (mã kho đã chuẩn hóa) cho kho
+func applyWarehouseInput(warehouse *model.Warehouse, input model.WarehouseInput) {
+	provinces := make([]string, 0, len(input.Provinces))
+	for _, province := range input.Provinces {
+		if province = strings.TrimSpace(province); province != "" {
+			provinces = append(provinces, province)
+		}
+	}
+
+	warehouse.Code = input.Code
+	warehouse.Name = strings.TrimSpace(input.Name)
+	warehouse.Province = strings.TrimSpace(input.Province)
+	warehouse.Address = strings.TrimSpace(input.Address)
+	warehouse.Provinces = strings.Join(provinces, ", ")
+	warehouse.Priority = input.Priority
+	if input.IsActive != nil {
+		warehouse.IsActive = *input.IsActive
+	}
+}
@@ -2,6 +2,11 @@ package helpers
 
 import (
 	"backend/internal/consts"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,6 +25,25 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+// GenerateAPIKey generates a random API key and returns the plain key (shown once),
+// a short display prefix and the SHA-256 hash that is stored in the database
+func GenerateAPIKey() (string, string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+
+	key := consts.API_KEY_PREFIX + hex.EncodeToString(buf)
+	prefix := key[:len(consts.API_KEY_PREFIX)+8]
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes an API key with SHA-256 (keys are high-entropy, so a slow hash is not needed)
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 // GenerateJWT generates a JWT token
 func GenerateJWT(userID uint, username string, role string) (string, error) {
 	claims := jwt.MapClaims{
@@ -29,13 +53,70 @@ func GenerateJWT(userID uint, username string, role string) (string, error) {
 		"exp":      time.Now().Add(time.Hour * consts.JWT_EXPIRE_HOURS).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(consts.JWT_SECRET_KEY))
+	return signClaims(claims)
+}
+
+// GenerateImpersonationJWT generates a short-lived token for an owner acting as another user.
+// The impersonator_id claim marks the token so every request can be attributed to the owner.
+func GenerateImpersonationJWT(userID uint, username string, role string, impersonatorID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(time.Minute * consts.IMPERSONATION_EXPIRE_MINUTES)
+	claims := jwt.MapClaims{
+		"user_id":         userID,
+		"username":        username,
+		"role":            role,
+		"impersonator_id": impersonatorID,
+		"exp":             expiresAt.Unix(),
+	}
+
+	token, err := signClaims(claims)
+	return token, expiresAt, err
 }
 
-// ValidateJWT validates a JWT token
+// signClaims signs claims with the active asymmetric key, adding issuer/audience and the kid header
+func signClaims(claims jwt.MapClaims) (string, error) {
+	store, err := getJWTKeys()
+	if err != nil {
+		return "", err
+	}
+
+	claims["iss"] = store.issuer
+	claims["aud"] = store.audience
+	claims["iat"] = time.Now().Unix()
+
+	token := jwt.NewWithClaims(store.active.method, claims)
+	token.Header["kid"] = store.active.kid
+	return token.SignedString(store.active.private)
+}
+
+// ValidateJWT validates a JWT token: only RS256/EdDSA signed by a known kid, with matching issuer and audience
 func ValidateJWT(tokenString string) (*jwt.Token, error) {
+	store, err := getJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
 	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(consts.JWT_SECRET_KEY), nil
-	})
+		kid, ok := token.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+
+		key, exists := store.keys[kid]
+		if !exists {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		// Thuật toán trong header phải khớp với loại khóa, chống tấn công đổi alg
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+
+		return key.public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(store.issuer),
+		jwt.WithAudience(store.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 }
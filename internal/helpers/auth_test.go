@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"backend/internal/consts"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGenerateImpersonationJWT(t *testing.T) {
+	t.Setenv("JWT_KEYS_DIR", t.TempDir())
+
+	tokenString, expiresAt, err := GenerateImpersonationJWT(42, "customer", consts.RoleUser, 7)
+	if err != nil {
+		t.Fatalf("GenerateImpersonationJWT returned error: %v", err)
+	}
+	if ttl := time.Until(expiresAt); ttl <= 0 || ttl > consts.IMPERSONATION_EXPIRE_MINUTES*time.Minute {
+		t.Errorf("expiresAt is %v from now, want within %d minutes", ttl, consts.IMPERSONATION_EXPIRE_MINUTES)
+	}
+
+	token, err := ValidateJWT(tokenString)
+	if err != nil {
+		t.Fatalf("ValidateJWT returned error: %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	tests := map[string]interface{}{
+		"user_id":         42.0,
+		"username":        "customer",
+		"role":            consts.RoleUser,
+		"impersonator_id": 7.0,
+	}
+	for claim, want := range tests {
+		if got := claims[claim]; got != want {
+			t.Errorf("claim %s = %v, want %v", claim, got, want)
+		}
+	}
+}
+
+func TestGenerateJWTHasNoImpersonator(t *testing.T) {
+	t.Setenv("JWT_KEYS_DIR", t.TempDir())
+
+	tokenString, err := GenerateJWT(42, "customer", consts.RoleUser)
+	if err != nil {
+		t.Fatalf("GenerateJWT returned error: %v", err)
+	}
+	token, err := ValidateJWT(tokenString)
+	if err != nil {
+		t.Fatalf("ValidateJWT returned error: %v", err)
+	}
+	if _, exists := token.Claims.(jwt.MapClaims)["impersonator_id"]; exists {
+		t.Error("session token carries an impersonator_id claim")
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey returned error: %v", err)
+	}
+	if !strings.HasPrefix(key, consts.API_KEY_PREFIX) || len(key) != len(consts.API_KEY_PREFIX)+64 {
+		t.Errorf("key %q does not look like %s followed by 64 hex characters", key, consts.API_KEY_PREFIX)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != len(consts.API_KEY_PREFIX)+8 {
+		t.Errorf("prefix %q is not the first %d characters of the key", prefix, len(consts.API_KEY_PREFIX)+8)
+	}
+	if hash != HashAPIKey(key) {
+		t.Error("returned hash does not match HashAPIKey(key)")
+	}
+
+	other, _, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key || otherHash == hash {
+		t.Error("two generated keys are identical")
+	}
+}
@@ -0,0 +1,11 @@
+package helpers
+
+import "os"
+
+// EnvOrDefault đọc biến môi trường name, trả về fallback khi biến không được đặt hoặc rỗng
+func EnvOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,240 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWTKeysDir  = "keys/jwt"
+	defaultJWTIssuer   = "walletshop-backend"
+	defaultJWTAudience = "walletshop-api"
+	minRSAKeyBits      = 2048
+)
+
+// jwtKey là một khóa ký/xác minh JWT được nhận diện bằng kid
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil nếu khóa chỉ dùng để xác minh (khóa đã nghỉ hưu)
+	public  crypto.PublicKey
+}
+
+type jwtKeyStore struct {
+	keys     map[string]*jwtKey
+	active   *jwtKey
+	issuer   string
+	audience string
+}
+
+var (
+	jwtKeys     *jwtKeyStore
+	jwtKeysErr  error
+	jwtKeysOnce sync.Once
+)
+
+// LoadJWTKeys nạp các khóa JWT từ JWT_KEYS_DIR. Khóa riêng (*.pem) dùng để ký và xác minh,
+// khóa công khai (*.pub.pem) chỉ dùng để xác minh token cũ trong thời gian xoay vòng khóa.
+// JWT_ACTIVE_KID chọn khóa ký; mặc định là khóa riêng có kid lớn nhất theo thứ tự chữ cái.
+func LoadJWTKeys() error {
+	jwtKeysOnce.Do(func() {
+		jwtKeys, jwtKeysErr = loadJWTKeyStore()
+	})
+	return jwtKeysErr
+}
+
+func getJWTKeys() (*jwtKeyStore, error) {
+	if err := LoadJWTKeys(); err != nil {
+		return nil, err
+	}
+	return jwtKeys, nil
+}
+
+func loadJWTKeyStore() (*jwtKeyStore, error) {
+	store := &jwtKeyStore{
+		keys:     make(map[string]*jwtKey),
+		issuer:   EnvOrDefault("JWT_ISSUER", defaultJWTIssuer),
+		audience: EnvOrDefault("JWT_AUDIENCE", defaultJWTAudience),
+	}
+
+	dir := EnvOrDefault("JWT_KEYS_DIR", defaultJWTKeysDir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		key, err := readJWTKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", file, err)
+		}
+		if existing, exists := store.keys[key.kid]; exists {
+			// Ưu tiên khóa riêng nếu cùng kid có cả khóa công khai
+			if existing.private != nil {
+				continue
+			}
+		}
+		store.keys[key.kid] = key
+	}
+
+	if len(store.keys) == 0 {
+		if os.Getenv("GIN_MODE") == "release" {
+			return nil, fmt.Errorf("no JWT signing keys found in %s", dir)
+		}
+		key, err := generateEphemeralJWTKey()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("⚠️  No JWT keys found in %s, using an ephemeral Ed25519 key (tokens will not survive restarts)", dir)
+		store.keys[key.kid] = key
+	}
+
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	if activeKID == "" {
+		var kids []string
+		for kid, key := range store.keys {
+			if key.private != nil {
+				kids = append(kids, kid)
+			}
+		}
+		sort.Strings(kids)
+		if len(kids) > 0 {
+			activeKID = kids[len(kids)-1]
+		}
+	}
+
+	active, exists := store.keys[activeKID]
+	if !exists || active.private == nil {
+		return nil, fmt.Errorf("active JWT key %q not found or has no private key", activeKID)
+	}
+	store.active = active
+
+	return store, nil
+}
+
+func readJWTKeyFile(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newJWTKey(kid, privateKey)
+	case "PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newJWTKey(kid, privateKey)
+	case "PUBLIC KEY":
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newJWTKey(kid, publicKey)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// newJWTKey xác định thuật toán từ loại khóa; chỉ hỗ trợ RSA (RS256) và Ed25519 (EdDSA)
+func newJWTKey(kid string, key interface{}) (*jwtKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+func generateEphemeralJWTKey() (*jwtKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return nil, err
+	}
+	return newJWTKey("ephemeral-"+base64.RawURLEncoding.EncodeToString(kidBytes), privateKey)
+}
+
+// JWKS trả về tập khóa công khai (RFC 7517) để các dịch vụ khác xác minh token
+func JWKS() (map[string]interface{}, error) {
+	store, err := getJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(store.keys))
+	for kid := range store.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]map[string]interface{}, 0, len(kids))
+	for _, kid := range kids {
+		key := store.keys[kid]
+		jwk := map[string]interface{}{
+			"kid": key.kid,
+			"use": "sig",
+			"alg": key.method.Alg(),
+		}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return map[string]interface{}{"keys": keys}, nil
+}
@@ -0,0 +1,205 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeEd25519Key ghi khóa riêng (PKCS#8) hoặc chỉ khóa công khai (kid.pub.pem) vào dir
+func writeEd25519Key(t *testing.T, dir, kid string, publicOnly bool) ed25519.PrivateKey {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicOnly {
+		der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePEM(t, filepath.Join(dir, kid+".pub.pem"), "PUBLIC KEY", der)
+		return privateKey
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, kid+".pem"), "PRIVATE KEY", der)
+	return privateKey
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadJWTKeyStoreActiveKID(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2024-01", false)
+	writeEd25519Key(t, dir, "2024-06", false)
+	writeEd25519Key(t, dir, "2025-01", true)
+	t.Setenv("JWT_KEYS_DIR", dir)
+
+	tests := []struct {
+		name      string
+		activeKID string
+		want      string
+		wantErr   bool
+	}{
+		{"newest private key by default", "", "2024-06", false},
+		{"explicit active kid", "2024-01", "2024-01", false},
+		{"public-only key cannot sign", "2025-01", "", true},
+		{"unknown kid", "2030-01", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_ACTIVE_KID", tt.activeKID)
+			store, err := loadJWTKeyStore()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadJWTKeyStore succeeded with active kid %q", tt.activeKID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadJWTKeyStore returned error: %v", err)
+			}
+			if store.active.kid != tt.want {
+				t.Errorf("active kid = %q, want %q", store.active.kid, tt.want)
+			}
+			if len(store.keys) != 3 {
+				t.Errorf("loaded %d keys, want 3 (retired public key kept for verification)", len(store.keys))
+			}
+		})
+	}
+}
+
+func TestReadJWTKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "ed", false)
+	writeEd25519Key(t, dir, "retired", true)
+
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, "weak.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weakRSA))
+	writePEM(t, filepath.Join(dir, "cert.pem"), "CERTIFICATE", []byte("not a key"))
+	if err := os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file       string
+		wantKID    string
+		wantAlg    string
+		wantSigner bool
+		wantErr    string
+	}{
+		{"ed.pem", "ed", "EdDSA", true, ""},
+		{"retired.pub.pem", "retired", "EdDSA", false, ""},
+		{"weak.pem", "", "", false, "at least 2048 bits"},
+		{"cert.pem", "", "", false, "unsupported PEM block"},
+		{"garbage.pem", "", "", false, "invalid PEM data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			key, err := readJWTKeyFile(filepath.Join(dir, tt.file))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readJWTKeyFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readJWTKeyFile returned error: %v", err)
+			}
+			if key.kid != tt.wantKID || key.method.Alg() != tt.wantAlg || (key.private != nil) != tt.wantSigner {
+				t.Errorf("key = {kid %q, alg %q, signer %v}, want {kid %q, alg %q, signer %v}",
+					key.kid, key.method.Alg(), key.private != nil, tt.wantKID, tt.wantAlg, tt.wantSigner)
+			}
+		})
+	}
+}
+
+func TestValidateJWTKeySelection(t *testing.T) {
+	dir := t.TempDir()
+	retired := writeEd25519Key(t, dir, "2024-01", false)
+	writeEd25519Key(t, dir, "2024-06", false)
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "")
+
+	store, err := loadJWTKeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	previous := jwtKeys
+	jwtKeys = store
+	defer func() { jwtKeys = previous }()
+
+	sign := func(kid string, method jwt.SigningMethod, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{
+			"user_id": 1,
+			"iss":     store.issuer,
+			"aud":     store.audience,
+			"iat":     time.Now().Unix(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+	hmacToken.Header["kid"] = "2024-06"
+	hmacSigned, err := hmacToken.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := GenerateJWT(1, "owner", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		wantOK bool
+	}{
+		{"token signed with the active key", active, true},
+		{"token signed with an older key still loaded", sign("2024-01", jwt.SigningMethodEdDSA, retired), true},
+		{"missing kid header", sign("", jwt.SigningMethodEdDSA, retired), false},
+		{"unknown kid", sign("2023-01", jwt.SigningMethodEdDSA, retired), false},
+		{"kid of another key", sign("2024-06", jwt.SigningMethodEdDSA, retired), false},
+		{"symmetric algorithm rejected", hmacSigned, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateJWT(tt.token)
+			if ok := err == nil; ok != tt.wantOK {
+				t.Errorf("ValidateJWT error = %v, want ok %v", err, tt.wantOK)
+			}
+		})
+	}
+}
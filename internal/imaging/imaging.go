@@ -0,0 +1,304 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // đăng ký bộ giải mã GIF cho image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // đăng ký bộ giải mã WebP cho image.Decode
+)
+
+const (
+	// Giới hạn số điểm ảnh để tránh ảnh "bom giải nén" làm cạn bộ nhớ
+	maxPixels = 50_000_000
+
+	jpegQuality         = 85
+	originalJPEGQuality = 92
+)
+
+// Size là một kích thước dẫn xuất: ảnh được thu nhỏ để cạnh dài nhất không vượt quá MaxDimension
+type Size struct {
+	Name         string
+	MaxDimension int
+}
+
+// Config là danh sách kích thước và định dạng cần tạo cho mỗi ảnh
+type Config struct {
+	Sizes   []Size
+	Formats []string
+}
+
+// Variant là một ảnh dẫn xuất đã được mã hóa
+type Variant struct {
+	Size        string
+	Format      string
+	Extension   string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+var formatInfo = map[string]struct {
+	extension   string
+	contentType string
+}{
+	"jpeg": {".jpg", "image/jpeg"},
+	"png":  {".png", "image/png"},
+	"webp": {".webp", "image/webp"},
+}
+
+// ConfigFromEnv đọc IMAGE_VARIANT_SIZES (vd: "thumbnail:150,medium:600,large:1200")
+// và IMAGE_VARIANT_FORMATS (vd: "webp,jpeg")
+func ConfigFromEnv() Config {
+	config := Config{}
+
+	sizes := os.Getenv("IMAGE_VARIANT_SIZES")
+	if sizes == "" {
+		sizes = "thumbnail:150,medium:600,large:1200"
+	}
+	for _, item := range strings.Split(sizes, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		dimension, err := strconv.Atoi(parts[1])
+		if err != nil || dimension <= 0 {
+			continue
+		}
+		config.Sizes = append(config.Sizes, Size{Name: parts[0], MaxDimension: dimension})
+	}
+	sort.Slice(config.Sizes, func(i, j int) bool {
+		return config.Sizes[i].MaxDimension < config.Sizes[j].MaxDimension
+	})
+
+	formats := os.Getenv("IMAGE_VARIANT_FORMATS")
+	if formats == "" {
+		formats = "webp,jpeg"
+	}
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		if _, supported := formatInfo[format]; supported {
+			config.Formats = append(config.Formats, format)
+		}
+	}
+
+	return config
+}
+
+// Sanitize làm sạch ảnh gốc trước khi lưu: bỏ siêu dữ liệu và, nếu ảnh JPEG có hướng xoay EXIF,
+// xoay sẵn điểm ảnh để ảnh vẫn hiển thị đúng sau khi mất thẻ Orientation.
+func Sanitize(data []byte, contentType string) ([]byte, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+
+	cleaned, orientation, err := StripMetadata(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if orientation == 1 {
+		return cleaned, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(cleaned))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	oriented := applyOrientation(img, orientation)
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: originalJPEGQuality})
+	case "image/webp":
+		err = nativewebp.Encode(&buf, oriented, nil)
+	default:
+		err = png.Encode(&buf, oriented)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateVariants tạo các ảnh dẫn xuất theo cấu hình. Ảnh không bao giờ bị phóng to;
+// định dạng JPEG được thay bằng PNG nếu ảnh có vùng trong suốt.
+func GenerateVariants(data []byte, config Config) ([]Variant, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Ảnh gốc có thể chưa qua Sanitize (dữ liệu cũ), xoay theo EXIF nếu còn
+	if _, orientation, err := StripMetadata(data, contentTypeOf(data)); err == nil && orientation != 1 {
+		img = applyOrientation(img, orientation)
+	}
+
+	opaque := isOpaque(img)
+	var variants []Variant
+	for _, size := range config.Sizes {
+		resized := resize(img, size.MaxDimension)
+		bounds := resized.Bounds()
+
+		for _, format := range config.Formats {
+			if format == "jpeg" && !opaque {
+				format = "png"
+			}
+			if containsVariant(variants, size.Name, format) {
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := encode(&buf, resized, format); err != nil {
+				return nil, fmt.Errorf("encode %s %s: %w", size.Name, format, err)
+			}
+
+			info := formatInfo[format]
+			variants = append(variants, Variant{
+				Size:        size.Name,
+				Format:      format,
+				Extension:   info.extension,
+				ContentType: info.contentType,
+				Width:       bounds.Dx(),
+				Height:      bounds.Dy(),
+				Data:        buf.Bytes(),
+			})
+		}
+	}
+
+	return variants, nil
+}
+
+func checkDimensions(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return errors.New("invalid image dimensions")
+	}
+	if config.Width*config.Height > maxPixels {
+		return fmt.Errorf("image too large: %dx%d", config.Width, config.Height)
+	}
+	return nil
+}
+
+func contentTypeOf(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, pngSignature):
+		return "image/png"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+func containsVariant(variants []Variant, size, format string) bool {
+	for _, variant := range variants {
+		if variant.Size == size && variant.Format == format {
+			return true
+		}
+	}
+	return false
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case "png":
+		return png.Encode(w, img)
+	case "webp":
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// resize thu nhỏ ảnh giữ nguyên tỉ lệ để cạnh dài nhất bằng maxDimension
+func resize(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxDimension/width)
+		width = maxDimension
+	} else {
+		width = max(1, width*maxDimension/height)
+		height = maxDimension
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// applyOrientation xoay/lật ảnh theo giá trị Orientation của EXIF (1-8)
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // lật ngang
+				dx, dy = bounds.Dx()-1-x, y
+			case 3: // xoay 180
+				dx, dy = bounds.Dx()-1-x, bounds.Dy()-1-y
+			case 4: // lật dọc
+				dx, dy = x, bounds.Dy()-1-y
+			case 5: // chuyển vị
+				dx, dy = y, x
+			case 6: // xoay 90 theo chiều kim đồng hồ
+				dx, dy = bounds.Dy()-1-y, x
+			case 7: // chuyển vị ngược
+				dx, dy = bounds.Dy()-1-y, bounds.Dx()-1-x
+			case 8: // xoay 90 ngược chiều kim đồng hồ
+				dx, dy = y, bounds.Dx()-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,165 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		sizes       string
+		formats     string
+		wantSizes   []Size
+		wantFormats []string
+	}{
+		{
+			name:        "defaults",
+			wantSizes:   []Size{{"thumbnail", 150}, {"medium", 600}, {"large", 1200}},
+			wantFormats: []string{"webp", "jpeg"},
+		},
+		{
+			name:        "custom sizes sorted, invalid entries skipped",
+			sizes:       "large:1000, small:100,bad,zero:0,nan:abc",
+			formats:     "JPG, png, gif",
+			wantSizes:   []Size{{"small", 100}, {"large", 1000}},
+			wantFormats: []string{"jpeg", "png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMAGE_VARIANT_SIZES", tt.sizes)
+			t.Setenv("IMAGE_VARIANT_FORMATS", tt.formats)
+			config := ConfigFromEnv()
+			if !reflect.DeepEqual(config.Sizes, tt.wantSizes) {
+				t.Errorf("Sizes = %v, want %v", config.Sizes, tt.wantSizes)
+			}
+			if !reflect.DeepEqual(config.Formats, tt.wantFormats) {
+				t.Errorf("Formats = %v, want %v", config.Formats, tt.wantFormats)
+			}
+		})
+	}
+}
+
+// encodeImage tạo ảnh width x height đã mã hóa; opaque = false tạo PNG có vùng trong suốt
+func encodeImage(t *testing.T, width, height int, opaque bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			alpha := uint8(255)
+			if !opaque && x == 0 {
+				alpha = 0
+			}
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: alpha})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if opaque {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateVariants(t *testing.T) {
+	config := Config{
+		Sizes:   []Size{{"thumbnail", 100}, {"large", 1000}},
+		Formats: []string{"webp", "jpeg", "png"},
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		wanted []string // size/format WxH
+	}{
+		{
+			name: "opaque landscape image, never upscaled",
+			data: encodeImage(t, 400, 200, true),
+			wanted: []string{
+				"thumbnail/webp 100x50", "thumbnail/jpeg 100x50", "thumbnail/png 100x50",
+				"large/webp 400x200", "large/jpeg 400x200", "large/png 400x200",
+			},
+		},
+		{
+			name: "transparent portrait image gets png instead of jpeg",
+			data: encodeImage(t, 100, 300, false),
+			wanted: []string{
+				"thumbnail/webp 33x100", "thumbnail/png 33x100",
+				"large/webp 100x300", "large/png 100x300",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants, err := GenerateVariants(tt.data, config)
+			if err != nil {
+				t.Fatalf("GenerateVariants returned error: %v", err)
+			}
+			var got []string
+			for _, variant := range variants {
+				got = append(got, fmt.Sprintf("%s/%s %dx%d", variant.Size, variant.Format, variant.Width, variant.Height))
+				decoded, _, err := image.DecodeConfig(bytes.NewReader(variant.Data))
+				if err != nil {
+					t.Errorf("%s/%s is not a decodable image: %v", variant.Size, variant.Format, err)
+				} else if decoded.Width != variant.Width || decoded.Height != variant.Height {
+					t.Errorf("%s/%s decodes as %dx%d, reported %dx%d", variant.Size, variant.Format,
+						decoded.Width, decoded.Height, variant.Width, variant.Height)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wanted) {
+				t.Errorf("variants = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestGenerateVariantsRejectsInvalidData(t *testing.T) {
+	if _, err := GenerateVariants([]byte("not an image"), Config{Sizes: []Size{{"thumbnail", 100}}, Formats: []string{"jpeg"}}); err == nil {
+		t.Error("GenerateVariants accepted invalid image data")
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// Ảnh 2x1: điểm trái đỏ, điểm phải xanh
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation   int
+		width, height int
+		redAt         image.Point
+	}{
+		{1, 2, 1, image.Pt(0, 0)},
+		{2, 2, 1, image.Pt(1, 0)},
+		{3, 2, 1, image.Pt(1, 0)},
+		{6, 1, 2, image.Pt(0, 0)},
+		{8, 1, 2, image.Pt(0, 1)},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		bounds := got.Bounds()
+		if bounds.Dx() != tt.width || bounds.Dy() != tt.height {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, bounds.Dx(), bounds.Dy(), tt.width, tt.height)
+			continue
+		}
+		if c := color.NRGBAModel.Convert(got.At(tt.redAt.X, tt.redAt.Y)); c != red {
+			t.Errorf("orientation %d: pixel at %v = %v, want red", tt.orientation, tt.redAt, c)
+		}
+	}
+}
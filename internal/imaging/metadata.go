@@ -0,0 +1,208 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+	// Các chunk PNG chứa siêu dữ liệu (EXIF, văn bản, thời gian chỉnh sửa) bị loại bỏ
+	pngMetadataChunks = map[string]bool{
+		"eXIf": true,
+		"tEXt": true,
+		"zTXt": true,
+		"iTXt": true,
+		"tIME": true,
+	}
+)
+
+// StripMetadata loại bỏ EXIF/XMP/IPTC và chú thích khỏi ảnh mà không giải mã lại điểm ảnh.
+// Trả về dữ liệu đã làm sạch và hướng xoay EXIF (1 nếu không có) để người gọi xoay ảnh nếu cần.
+func StripMetadata(data []byte, contentType string) ([]byte, int, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		cleaned, err := stripPNG(data)
+		return cleaned, 1, err
+	case "image/webp":
+		return stripWebP(data)
+	default:
+		return data, 1, nil
+	}
+}
+
+// stripJPEG bỏ các segment APP1 (EXIF/XMP), APP13 (IPTC) và COM, giữ nguyên dữ liệu ảnh
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 1, errors.New("invalid JPEG data")
+	}
+
+	orientation := 1
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 1, errors.New("invalid JPEG marker")
+		}
+		marker := data[pos+1]
+
+		// Byte đệm 0xFF trước marker
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+
+		// Marker không có độ dài
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		}
+
+		// Bắt đầu dữ liệu ảnh nén: sao chép phần còn lại
+		if marker == 0xDA {
+			out.Write(data[pos:])
+			return out.Bytes(), orientation, nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 1, errors.New("truncated JPEG segment")
+		}
+
+		switch marker {
+		case 0xE1:
+			if o := exifOrientation(data[pos+4 : end]); o > 0 {
+				orientation = o
+			}
+		case 0xED, 0xFE:
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	return nil, 1, errors.New("JPEG image data not found")
+}
+
+// stripPNG bỏ các chunk siêu dữ liệu, giữ nguyên các chunk ảnh và màu sắc
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("invalid PNG data")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+
+	return nil, errors.New("PNG end chunk not found")
+}
+
+// stripWebP bỏ chunk EXIF/XMP trong container RIFF và xóa cờ tương ứng trong VP8X
+func stripWebP(data []byte) ([]byte, int, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, 1, errors.New("invalid WebP data")
+	}
+
+	orientation := 1
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if size < 0 || pos+8+size > len(data) {
+			return nil, 1, errors.New("truncated WebP chunk")
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+
+		switch fourCC {
+		case "EXIF":
+			if o := exifOrientation(data[pos+8 : pos+8+size]); o > 0 {
+				orientation = o
+			}
+		case "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04 // cờ EXIF và XMP
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	cleaned := out.Bytes()
+	binary.LittleEndian.PutUint32(cleaned[4:8], uint32(len(cleaned)-8))
+	return cleaned, orientation, nil
+}
+
+// exifOrientation đọc thẻ Orientation (0x0112) trong IFD0 của khối EXIF; trả về 0 nếu không có
+func exifOrientation(payload []byte) int {
+	payload = bytes.TrimPrefix(payload, []byte("Exif\x00\x00"))
+	if len(payload) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(payload[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(payload[4:8]))
+	if offset < 8 || offset+2 > len(payload) {
+		return 0
+	}
+
+	entries := int(order.Uint16(payload[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(payload) {
+			return 0
+		}
+		if order.Uint16(payload[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(payload[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 0
+		}
+	}
+	return 0
+}
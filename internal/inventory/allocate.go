@@ -0,0 +1,141 @@
+package inventory
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"errors"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Tiền tố hành chính bị bỏ qua khi so khớp tỉnh/thành ("TP. Hồ Chí Minh", "Thành phố Hà Nội", "Tỉnh Lào Cai")
+var provincePrefixes = []string{"thanh pho ", "tp. ", "tp.", "tp ", "tinh "}
+
+// NormalizeProvince chuẩn hóa tên tỉnh/thành để so khớp: chữ thường, không dấu, bỏ tiền tố hành chính
+func NormalizeProvince(province string) string {
+	normalized := strings.Join(strings.Fields(search.Fold(province)), " ")
+	for _, prefix := range provincePrefixes {
+		if strings.HasPrefix(normalized, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(normalized, prefix))
+		}
+	}
+	return normalized
+}
+
+// serves cho biết kho có phục vụ tỉnh/thành (đã chuẩn hóa) hay không
+func serves(warehouse *model.Warehouse, province string) bool {
+	if province == "" {
+		return false
+	}
+	if NormalizeProvince(warehouse.Province) == province {
+		return true
+	}
+	for _, served := range warehouse.ServedProvinces() {
+		if NormalizeProvince(served) == province {
+			return true
+		}
+	}
+	return false
+}
+
+// Allocate chọn kho xuất hàng cho các dòng của đơn và gán WarehouseID cho từng dòng. Các kho đang hoạt động
+// được xếp hạng: kho phục vụ tỉnh/thành giao hàng trước, sau đó theo độ ưu tiên. Kho đầu tiên đủ hàng cho cả đơn
+// được chọn và trả về; nếu không kho nào đủ, từng dòng được lấy từ kho xếp hạng cao nhất còn đủ hàng cho dòng đó
+// và kết quả là nil (đơn chia nhiều kho). Phần chờ hàng về (BackorderedQuantity) không cần tồn kho; dòng bán vượt
+// tồn kho mà không kho nào đủ phần còn lại được xuất từ kho xếp hạng cao nhất.
+// Trả về "insufficient stock" khi có dòng không kho nào đủ hàng.
+// Chạy trong giao dịch tạo đơn; sổ kho vẫn kiểm tra lại tồn kho khi trừ nên phân bổ cũ chỉ gây lỗi, không gây âm kho.
+func Allocate(tx *gorm.DB, province string, items []model.OrderItem) (*uint, error) {
+	warehouseRepo := repo.NewWarehouseRepo().WithTx(tx)
+	warehouses, err := warehouseRepo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+	if len(warehouses) == 0 {
+		return nil, errors.New("warehouse not found")
+	}
+
+	seen := make(map[repo.StockKey]bool)
+	keys := make([]repo.StockKey, 0, len(items))
+	for _, item := range items {
+		key := repo.NewStockKey(item.ProductID, item.VariantID)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	levels, err := warehouseRepo.LevelsFor(keys)
+	if err != nil {
+		return nil, err
+	}
+	return allocate(warehouses, province, items, levels)
+}
+
+// allocate phân bổ các dòng của đơn theo tồn kho levels (kho -> dòng tồn kho -> số lượng) như Allocate mô tả;
+// warehouses là các kho đang hoạt động theo thứ tự ưu tiên và không được rỗng
+func allocate(warehouses []model.Warehouse, province string, items []model.OrderItem, levels map[uint]map[repo.StockKey]int) (*uint, error) {
+	province = NormalizeProvince(province)
+	sort.SliceStable(warehouses, func(i, j int) bool {
+		return serves(&warehouses[i], province) && !serves(&warehouses[j], province)
+	})
+
+	needed := make(map[repo.StockKey]int)
+	for _, item := range items {
+		needed[repo.NewStockKey(item.ProductID, item.VariantID)] += item.Quantity - item.BackorderedQuantity
+	}
+
+	for i := range warehouses {
+		warehouseID := warehouses[i].ID
+		if covers(levels[warehouseID], needed) {
+			for j := range items {
+				items[j].WarehouseID = &warehouseID
+			}
+			return &warehouseID, nil
+		}
+	}
+
+	// Không kho nào đủ cả đơn: phân bổ từng dòng
+	remaining := make(map[uint]map[repo.StockKey]int, len(levels))
+	for warehouseID, stock := range levels {
+		remaining[warehouseID] = make(map[repo.StockKey]int, len(stock))
+		for key, quantity := range stock {
+			remaining[warehouseID][key] = quantity
+		}
+	}
+	for j := range items {
+		key := repo.NewStockKey(items[j].ProductID, items[j].VariantID)
+		quantity := items[j].Quantity - items[j].BackorderedQuantity
+		allocated := false
+		for i := range warehouses {
+			warehouseID := warehouses[i].ID
+			if remaining[warehouseID][key] >= quantity {
+				remaining[warehouseID][key] -= quantity
+				items[j].WarehouseID = &warehouseID
+				allocated = true
+				break
+			}
+		}
+		if !allocated && items[j].BackorderedQuantity > 0 {
+			warehouseID := warehouses[0].ID
+			items[j].WarehouseID = &warehouseID
+			allocated = true
+		}
+		if !allocated {
+			return nil, errors.New("insufficient stock")
+		}
+	}
+	return nil, nil
+}
+
+// covers cho biết tồn kho stock có đủ cho mọi dòng cần needed
+func covers(stock map[repo.StockKey]int, needed map[repo.StockKey]int) bool {
+	for key, quantity := range needed {
+		if stock[key] < quantity {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package inventory
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeProvince(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"TP. Hồ Chí Minh", "ho chi minh"},
+		{"tp.Hồ Chí Minh", "ho chi minh"},
+		{"Thành phố  Hà Nội", "ha noi"},
+		{"Tỉnh Lào Cai", "lao cai"},
+		{"Đà Nẵng", "da nang"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeProvince(tt.input); got != tt.want {
+			t.Errorf("NormalizeProvince(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	variantID := uint(5)
+	shirt := repo.NewStockKey(1, nil)
+	shoe := repo.NewStockKey(2, &variantID)
+
+	// Kho theo thứ tự ưu tiên như GetActive trả về
+	warehouses := []model.Warehouse{
+		{ID: 1, Province: "Hà Nội"},
+		{ID: 2, Province: "TP. Hồ Chí Minh", Provinces: "Bình Dương, Đồng Nai"},
+		{ID: 3, Province: "Đà Nẵng"},
+	}
+	item := func(productID uint, variantID *uint, quantity, backordered int) model.OrderItem {
+		return model.OrderItem{ProductID: productID, VariantID: variantID, Quantity: quantity, BackorderedQuantity: backordered}
+	}
+
+	tests := []struct {
+		name     string
+		province string
+		items    []model.OrderItem
+		levels   map[uint]map[repo.StockKey]int
+		want     uint // 0: đơn chia nhiều kho
+		wantErr  bool
+		wantEach []uint
+	}{
+		{
+			name:     "warehouse in the shipping province ships the whole order",
+			province: "Hồ Chí Minh",
+			items:    []model.OrderItem{item(1, nil, 2, 0), item(2, &variantID, 1, 0)},
+			levels: map[uint]map[repo.StockKey]int{
+				1: {shirt: 10, shoe: 10},
+				2: {shirt: 2, shoe: 1},
+			},
+			want:     2,
+			wantEach: []uint{2, 2},
+		},
+		{
+			name:     "served province ranks the warehouse first",
+			province: "tỉnh Bình Dương",
+			items:    []model.OrderItem{item(1, nil, 1, 0)},
+			levels:   map[uint]map[repo.StockKey]int{1: {shirt: 5}, 2: {shirt: 5}},
+			want:     2,
+			wantEach: []uint{2},
+		},
+		{
+			name:     "falls back to the highest priority warehouse with everything",
+			province: "Hồ Chí Minh",
+			items:    []model.OrderItem{item(1, nil, 2, 0), item(2, &variantID, 1, 0)},
+			levels: map[uint]map[repo.StockKey]int{
+				1: {shirt: 2, shoe: 1},
+				2: {shirt: 1, shoe: 1},
+				3: {shirt: 2, shoe: 1},
+			},
+			want:     1,
+			wantEach: []uint{1, 1},
+		},
+		{
+			name:     "split per line when no warehouse has everything",
+			province: "Đà Nẵng",
+			items:    []model.OrderItem{item(1, nil, 2, 0), item(2, &variantID, 1, 0)},
+			levels: map[uint]map[repo.StockKey]int{
+				1: {shirt: 2},
+				3: {shoe: 1},
+			},
+			wantEach: []uint{1, 3},
+		},
+		{
+			name:     "lines of the same product draw down the same warehouse",
+			province: "Hồ Chí Minh",
+			items:    []model.OrderItem{item(1, nil, 2, 0), item(1, nil, 2, 0)},
+			levels: map[uint]map[repo.StockKey]int{
+				1: {shirt: 2},
+				2: {shirt: 3},
+			},
+			wantEach: []uint{2, 1},
+		},
+		{
+			name:     "insufficient stock",
+			province: "Hà Nội",
+			items:    []model.OrderItem{item(1, nil, 3, 0)},
+			levels:   map[uint]map[repo.StockKey]int{1: {shirt: 2}, 2: {shirt: 2}},
+			wantErr:  true,
+		},
+		{
+			name:     "fully backordered line needs no stock",
+			province: "Đà Nẵng",
+			items:    []model.OrderItem{item(1, nil, 3, 3)},
+			levels:   map[uint]map[repo.StockKey]int{},
+			want:     3,
+			wantEach: []uint{3},
+		},
+		{
+			name:     "partly backordered line without a covering warehouse ships from the top ranked one",
+			province: "Đà Nẵng",
+			items:    []model.OrderItem{item(1, nil, 5, 2), item(2, &variantID, 1, 0)},
+			levels: map[uint]map[repo.StockKey]int{
+				1: {shirt: 2, shoe: 1},
+				2: {shirt: 1},
+			},
+			wantEach: []uint{3, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := append([]model.Warehouse(nil), warehouses...)
+			got, err := allocate(candidates, tt.province, tt.items, tt.levels)
+			if tt.wantErr {
+				if err == nil || err.Error() != "insufficient stock" {
+					t.Fatalf("allocate error = %v, want insufficient stock", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want == 0 && got != nil {
+				t.Errorf("allocate = %d, want a split order", *got)
+			}
+			if tt.want != 0 && (got == nil || *got != tt.want) {
+				t.Errorf("allocate = %v, want warehouse %d", got, tt.want)
+			}
+			var each []uint
+			for _, item := range tt.items {
+				if item.WarehouseID == nil {
+					t.Fatalf("item %+v has no warehouse", item)
+				}
+				each = append(each, *item.WarehouseID)
+			}
+			if !reflect.DeepEqual(each, tt.wantEach) {
+				t.Errorf("item warehouses = %v, want %v", each, tt.wantEach)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package inventory
+
+import (
+	"backend/internal/consts"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReceiptError là lỗi dữ liệu nhận hàng không hợp lệ (dòng không thuộc đơn, nhận vượt số lượng đặt...)
+type ReceiptError struct {
+	Message string
+}
+
+func (e *ReceiptError) Error() string {
+	return e.Message
+}
+
+// Receive nhận hàng cho đơn đặt hàng nhà cung cấp trong một giao dịch: mỗi dòng được ghi sổ kho purchase vào kho
+// của đơn, cập nhật số lượng đã nhận và giá vốn bình quân của sản phẩm; đơn chuyển sang partially_received hoặc
+// received khi đã nhận đủ. Trả về "purchase order not found" hoặc *ReceiptError khi không hợp lệ.
+func Receive(orderID uint, lines []model.PurchaseReceiptLineInput, note string, userID *uint) error {
+	return repo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := repo.NewPurchaseOrderRepo().WithTx(tx)
+		productRepo := repo.NewProductRepo().WithTx(tx)
+		stockRepo := repo.NewStockRepo().WithTx(tx)
+
+		order, err := orderRepo.LockByID(orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != consts.PurchaseOrderOrdered && order.Status != consts.PurchaseOrderPartiallyReceived {
+			return &ReceiptError{Message: fmt.Sprintf("không thể nhận hàng cho đơn ở trạng thái %s", order.Status)}
+		}
+
+		byID := make(map[uint]*model.PurchaseOrderLine, len(order.Lines))
+		for i := range order.Lines {
+			byID[order.Lines[i].ID] = &order.Lines[i]
+		}
+
+		for _, input := range lines {
+			line, ok := byID[input.LineID]
+			if !ok {
+				return &ReceiptError{Message: fmt.Sprintf("dòng hàng %d không thuộc đơn đặt hàng", input.LineID)}
+			}
+			if input.Quantity > line.Remaining() {
+				return &ReceiptError{Message: fmt.Sprintf("dòng hàng %d chỉ còn %d chờ nhận", line.ID, line.Remaining())}
+			}
+
+			if _, err := productRepo.ApplyPurchaseCost(line.ProductID, input.Quantity, line.UnitCost); err != nil {
+				return err
+			}
+			purchaseOrderID := order.ID
+			warehouseID := order.WarehouseID
+			if err := stockRepo.Move(&model.StockMovement{
+				ProductID:       line.ProductID,
+				VariantID:       line.VariantID,
+				WarehouseID:     &warehouseID,
+				Delta:           input.Quantity,
+				Reason:          consts.StockReasonPurchase,
+				PurchaseOrderID: &purchaseOrderID,
+				UserID:          userID,
+				Note:            note,
+			}); err != nil {
+				return err
+			}
+			if err := orderRepo.AddReceived(line.ID, input.Quantity); err != nil {
+				return err
+			}
+			line.ReceivedQuantity += input.Quantity
+		}
+
+		status := consts.PurchaseOrderReceived
+		for _, line := range order.Lines {
+			if line.Remaining() > 0 {
+				status = consts.PurchaseOrderPartiallyReceived
+				break
+			}
+		}
+		updates := map[string]interface{}{"status": status}
+		if status == consts.PurchaseOrderReceived {
+			updates["received_at"] = time.Now()
+		}
+		return orderRepo.UpdateStatus(order.ID, updates)
+	})
+}
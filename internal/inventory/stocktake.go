@@ -0,0 +1,214 @@
+package inventory
+
+import (
+	"backend/internal/consts"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StocktakeError là lỗi thao tác không hợp lệ với phiên kiểm kê (kho đang có phiên mở, phiên đã đóng...)
+type StocktakeError struct {
+	Message string
+}
+
+func (e *StocktakeError) Error() string {
+	return e.Message
+}
+
+// CountResult là kết quả ghi số đếm của một mục gửi lên; Error khác rỗng nghĩa là mục đó bị bỏ qua
+type CountResult struct {
+	LineID          uint   `json:"line_id,omitempty"`
+	SKU             string `json:"sku"`
+	CountedQuantity *int   `json:"counted_quantity,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// OpenStocktake mở phiên kiểm kê tại kho stocktake.WarehouseID cho sản phẩm thuộc categoryIDs (nil là toàn bộ kho),
+// chụp lại tồn kho hệ thống tại kho cho từng dòng. Mỗi kho chỉ có một phiên đang mở.
+func OpenStocktake(stocktake *model.Stocktake, categoryIDs []uint) error {
+	return repo.Transaction(func(tx *gorm.DB) error {
+		stocktakeRepo := repo.NewStocktakeRepo().WithTx(tx)
+
+		open, err := stocktakeRepo.HasOpen(stocktake.WarehouseID)
+		if err != nil {
+			return err
+		}
+		if open {
+			return &StocktakeError{Message: "kho đang có phiên kiểm kê chưa đóng"}
+		}
+
+		lines, err := stocktakeRepo.ScopeLines(categoryIDs)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return &StocktakeError{Message: "không có sản phẩm nào cần kiểm kê"}
+		}
+		stocktake.Lines = lines
+		if err := RefreshSystemStock(repo.NewWarehouseRepo().WithTx(tx), stocktake); err != nil {
+			return err
+		}
+		return stocktakeRepo.Create(stocktake)
+	})
+}
+
+// RefreshSystemStock cập nhật SystemStock của các dòng theo tồn kho hiện tại tại kho (không lưu), để chênh lệch
+// của phiên đang mở phản ánh cả bán hàng và nhập hàng phát sinh trong lúc đếm
+func RefreshSystemStock(warehouseRepo *repo.WarehouseRepo, stocktake *model.Stocktake) error {
+	keys := make([]repo.StockKey, 0, len(stocktake.Lines))
+	for _, line := range stocktake.Lines {
+		keys = append(keys, repo.NewStockKey(line.ProductID, line.VariantID))
+	}
+	levels, err := warehouseRepo.LevelsFor(keys)
+	if err != nil {
+		return err
+	}
+	for i := range stocktake.Lines {
+		line := &stocktake.Lines[i]
+		line.SystemStock = levels[stocktake.WarehouseID][repo.NewStockKey(line.ProductID, line.VariantID)]
+	}
+	return nil
+}
+
+// ApplyCounts ghi số đếm của phiên kiểm kê đang mở theo line_id hoặc SKU. mode=add cộng dồn số đếm (quét mã vạch
+// nhiều lượt), ngược lại ghi đè; các mục cùng dòng được xử lý lần lượt. Mục không hợp lệ được báo lỗi trong kết quả,
+// các mục còn lại vẫn được ghi.
+func ApplyCounts(stocktakeID uint, counts []model.StocktakeCountLineInput, mode string, userID *uint) ([]CountResult, error) {
+	results := make([]CountResult, 0, len(counts))
+	err := repo.Transaction(func(tx *gorm.DB) error {
+		stocktakeRepo := repo.NewStocktakeRepo().WithTx(tx)
+
+		stocktake, err := stocktakeRepo.LockByID(stocktakeID)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != consts.StocktakeOpen {
+			return &StocktakeError{Message: "phiên kiểm kê đã đóng"}
+		}
+
+		byID := make(map[uint]*model.StocktakeLine, len(stocktake.Lines))
+		bySKU := make(map[string]*model.StocktakeLine, len(stocktake.Lines))
+		for i := range stocktake.Lines {
+			line := &stocktake.Lines[i]
+			byID[line.ID] = line
+			bySKU[strings.ToUpper(line.SKU)] = line
+		}
+
+		changed := make(map[uint]*model.StocktakeLine)
+		for _, count := range counts {
+			result := CountResult{LineID: count.LineID, SKU: strings.TrimSpace(count.SKU)}
+			var line *model.StocktakeLine
+			switch {
+			case count.LineID != 0:
+				line = byID[count.LineID]
+			case result.SKU != "":
+				line = bySKU[strings.ToUpper(result.SKU)]
+			default:
+				result.Error = "cần cung cấp line_id hoặc sku"
+				results = append(results, result)
+				continue
+			}
+			if line == nil {
+				result.Error = "không có trong phiên kiểm kê"
+				results = append(results, result)
+				continue
+			}
+
+			quantity := count.Quantity
+			if mode == "add" && line.CountedQuantity != nil {
+				quantity += *line.CountedQuantity
+			}
+			line.CountedQuantity = &quantity
+			changed[line.ID] = line
+
+			result.LineID, result.SKU = line.ID, line.SKU
+			result.CountedQuantity = &quantity
+			results = append(results, result)
+		}
+
+		now := time.Now()
+		for id, line := range changed {
+			if err := stocktakeRepo.UpdateLine(id, map[string]interface{}{
+				"counted_quantity": *line.CountedQuantity,
+				"counted_by":       userID,
+				"counted_at":       &now,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ApproveStocktake duyệt phiên kiểm kê đang mở: mỗi dòng đã đếm được điều chỉnh về đúng số đếm bằng một dòng sổ kho
+// stocktake tại kho của phiên; tồn kho hệ thống và số điều chỉnh được chốt lại trên dòng. Dòng chưa đếm giữ nguyên
+// tồn kho; dòng của sản phẩm/biến thể đã bị xóa hẳn không được điều chỉnh (Adjustment = 0) và ghi lại lý do bỏ qua.
+func ApproveStocktake(stocktakeID uint, userID *uint) error {
+	return repo.Transaction(func(tx *gorm.DB) error {
+		stocktakeRepo := repo.NewStocktakeRepo().WithTx(tx)
+		warehouseRepo := repo.NewWarehouseRepo().WithTx(tx)
+		stockRepo := repo.NewStockRepo().WithTx(tx)
+
+		stocktake, err := stocktakeRepo.LockByID(stocktakeID)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != consts.StocktakeOpen {
+			return &StocktakeError{Message: "phiên kiểm kê đã đóng"}
+		}
+
+		for _, line := range stocktake.Lines {
+			if line.CountedQuantity == nil {
+				continue
+			}
+			system, err := warehouseRepo.LockLevel(stocktake.WarehouseID, line.ProductID, line.VariantID)
+			if err != nil {
+				return err
+			}
+
+			adjustment := *line.CountedQuantity - system
+			fields := map[string]interface{}{"system_stock": system}
+			if adjustment != 0 {
+				warehouseID, id := stocktake.WarehouseID, stocktake.ID
+				err := stockRepo.Move(&model.StockMovement{
+					ProductID:   line.ProductID,
+					VariantID:   line.VariantID,
+					WarehouseID: &warehouseID,
+					Delta:       adjustment,
+					Reason:      consts.StockReasonStocktake,
+					StocktakeID: &id,
+					UserID:      userID,
+					Note:        fmt.Sprintf("Kiểm kê %s", stocktake.Code),
+				})
+				if err != nil {
+					if err.Error() != "product not found" && err.Error() != "product variant not found" {
+						return err
+					}
+					adjustment = 0
+					fields["skip_reason"] = "sản phẩm hoặc biến thể đã bị xóa, không điều chỉnh tồn kho"
+				}
+			}
+
+			fields["adjustment"] = adjustment
+			if err := stocktakeRepo.UpdateLine(line.ID, fields); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		return stocktakeRepo.UpdateStatus(stocktake.ID, map[string]interface{}{
+			"status":      consts.StocktakeApproved,
+			"approved_by": userID,
+			"closed_at":   &now,
+		})
+	})
+}
@@ -79,15 +79,21 @@ type Brand struct {
 }
 
 type ProductImage struct {
-	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	ProductID uint           `json:"product_id" gorm:"not null;index"`
-	ImageURL  string         `json:"image_url" gorm:"not null;size:500"`
-	AltText   string         `json:"alt_text" gorm:"size:200"`
-	IsPrimary bool           `json:"is_primary" gorm:"default:false;index"`
-	SortOrder int            `json:"sort_order" gorm:"default:0"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID     uint           `json:"product_id" gorm:"not null;index"`
+	VariantID     *uint          `json:"variant_id" gorm:"index"` // Ảnh riêng của một biến thể sản phẩm
+	ImageURL      string         `json:"image_url" gorm:"not null;size:500"`
+	StorageKey    string         `json:"-" gorm:"size:500"` // Key trong kho lưu trữ, rỗng nếu ảnh là URL bên ngoài
+	ContentType   string         `json:"content_type" gorm:"size:50"`
+	FileSize      int64          `json:"file_size" gorm:"default:0"`
+	Variants      string         `json:"-" gorm:"type:text"`                  // JSON danh sách ImageVariant
+	VariantStatus string         `json:"variant_status" gorm:"size:20;index"` // pending, ready, failed
+	AltText       string         `json:"alt_text" gorm:"size:200"`
+	IsPrimary     bool           `json:"is_primary" gorm:"default:false;index"`
+	SortOrder     int            `json:"sort_order" gorm:"default:0"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
 	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -139,6 +145,7 @@ type BrandInput struct {
 	Description string `json:"description" binding:"max=500"`
 	LogoURL     string `json:"logo_url" binding:"max=500"`
 	Website     string `json:"website" binding:"max=200"`
+	IsActive    *bool  `json:"is_active"`
 }
 
 type ProductImageInput struct {
@@ -198,26 +205,43 @@ type AddressResponse struct {
 }
 
 type BrandResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description string    `json:"description"`
-	LogoURL     string    `json:"logo_url"`
-	Website     string    `json:"website"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Slug         string    `json:"slug"`
+	Description  string    `json:"description"`
+	LogoURL      string    `json:"logo_url"`
+	Website      string    `json:"website"`
+	IsActive     bool      `json:"is_active"`
+	ProductCount *int64    `json:"product_count,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 type ProductImageResponse struct {
-	ID        uint      `json:"id"`
-	ProductID uint      `json:"product_id"`
-	ImageURL  string    `json:"image_url"`
-	AltText   string    `json:"alt_text"`
-	IsPrimary bool      `json:"is_primary"`
-	SortOrder int       `json:"sort_order"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint                         `json:"id"`
+	ProductID     uint                         `json:"product_id"`
+	VariantID     *uint                        `json:"variant_id"`
+	ImageURL      string                       `json:"image_url"`
+	ContentType   string                       `json:"content_type"`
+	FileSize      int64                        `json:"file_size"`
+	Variants      map[string]map[string]string `json:"variants,omitempty"`
+	SrcSet        map[string]string            `json:"srcset,omitempty"`
+	VariantStatus string                       `json:"variant_status,omitempty"`
+	AltText       string                       `json:"alt_text"`
+	IsPrimary     bool                         `json:"is_primary"`
+	SortOrder     int                          `json:"sort_order"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+}
+
+// ProductImageReorderInput là thứ tự mới của toàn bộ ảnh sản phẩm
+type ProductImageReorderInput struct {
+	ImageIDs []uint `json:"image_ids" binding:"required,min=1"`
+}
+
+type ProductImageUpdateInput struct {
+	AltText   string `json:"alt_text" binding:"max=200"`
+	VariantID *uint  `json:"variant_id"`
 }
 
 // ToResponse methods
@@ -293,14 +317,21 @@ func (b *Brand) ToResponse() BrandResponse {
 }
 
 func (pi *ProductImage) ToResponse() ProductImageResponse {
+	variantSet := BuildImageVariantSet(pi.Variants)
 	return ProductImageResponse{
-		ID:        pi.ID,
-		ProductID: pi.ProductID,
-		ImageURL:  pi.ImageURL,
-		AltText:   pi.AltText,
-		IsPrimary: pi.IsPrimary,
-		SortOrder: pi.SortOrder,
-		CreatedAt: pi.CreatedAt,
-		UpdatedAt: pi.UpdatedAt,
+		ID:            pi.ID,
+		ProductID:     pi.ProductID,
+		VariantID:     pi.VariantID,
+		ImageURL:      pi.ImageURL,
+		ContentType:   pi.ContentType,
+		FileSize:      pi.FileSize,
+		Variants:      variantSet.Variants,
+		SrcSet:        variantSet.SrcSet,
+		VariantStatus: pi.VariantStatus,
+		AltText:       pi.AltText,
+		IsPrimary:     pi.IsPrimary,
+		SortOrder:     pi.SortOrder,
+		CreatedAt:     pi.CreatedAt,
+		UpdatedAt:     pi.UpdatedAt,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	Prefix     string     `json:"prefix" gorm:"not null;size:20"`
+	KeyHash    string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Scopes     string     `json:"scopes" gorm:"size:500"` // Danh sách "resource:level" phân tách bằng dấu phẩy, rỗng = theo quyền của người dùng
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:64"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+type APIKeyInput struct {
+	Name          string            `json:"name" binding:"required,max=100"`
+	Scopes        map[string]string `json:"scopes"`                                             // resource -> read|write|full
+	ExpiresInDays int               `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // bỏ trống = không hết hạn
+}
+
+type APIKeyResponse struct {
+	ID         uint              `json:"id"`
+	Name       string            `json:"name"`
+	Prefix     string            `json:"prefix"`
+	Scopes     map[string]string `json:"scopes"`
+	LastUsedAt *time.Time        `json:"last_used_at"`
+	LastUsedIP string            `json:"last_used_ip"`
+	ExpiresAt  *time.Time        `json:"expires_at"`
+	RevokedAt  *time.Time        `json:"revoked_at"`
+	IsActive   bool              `json:"is_active"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Key        string            `json:"key,omitempty"` // Chỉ trả về một lần khi tạo
+}
+
+// ScopeMap phân tích chuỗi scopes thành map resource -> cấp độ quyền
+func (k *APIKey) ScopeMap() map[string]string {
+	scopes := make(map[string]string)
+	for _, item := range strings.Split(k.Scopes, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) == 2 && parts[0] != "" {
+			scopes[parts[0]] = parts[1]
+		}
+	}
+	return scopes
+}
+
+// IsUsable kiểm tra khóa chưa bị thu hồi và chưa hết hạn
+func (k *APIKey) IsUsable() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || k.ExpiresAt.After(time.Now())
+}
+
+// ToResponse chuyển APIKey thành APIKeyResponse (không bao gồm khóa bí mật)
+func (k *APIKey) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeMap(),
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIP: k.LastUsedIP,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		IsActive:   k.IsUsable(),
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyScopeMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes string
+		want   map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"single scope", "products:read", map[string]string{"products": "read"}},
+		{"several scopes with spaces", "products:write, orders:read", map[string]string{"products": "write", "orders": "read"}},
+		{"malformed entries skipped", "products,:read,orders:read", map[string]string{"orders": "read"}},
+		{"level keeps extra colons", "news:read:extra", map[string]string{"news": "read:extra"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &APIKey{Scopes: tt.scopes}
+			if got := key.ScopeMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScopeMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyIsUsable(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{"no expiry", APIKey{}, true},
+		{"not yet expired", APIKey{ExpiresAt: &future}, true},
+		{"expired", APIKey{ExpiresAt: &past}, false},
+		{"revoked", APIKey{RevokedAt: &past, ExpiresAt: &future}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.IsUsable(); got != tt.want {
+				t.Errorf("IsUsable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLog struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorID        *uint     `json:"actor_id" gorm:"index"`
+	ActorRole      string    `json:"actor_role" gorm:"size:20"`
+	ImpersonatorID *uint     `json:"impersonator_id" gorm:"index"`
+	Action         string    `json:"action" gorm:"not null;size:100;index"`
+	EntityType     string    `json:"entity_type" gorm:"not null;size:50;index:idx_audit_entity"`
+	EntityID       uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before         string    `json:"before" gorm:"type:longtext"`
+	After          string    `json:"after" gorm:"type:longtext"`
+	Changes        string    `json:"changes" gorm:"type:longtext"`
+	IPAddress      string    `json:"ip_address" gorm:"size:64"`
+	UserAgent      string    `json:"user_agent" gorm:"size:500"`
+	RequestID      string    `json:"request_id" gorm:"size:64;index"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+
+	// Quan hệ
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model AuditLog
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type AuditLogResponse struct {
+	ID             uint            `json:"id"`
+	ActorID        *uint           `json:"actor_id"`
+	Actor          *UserResponse   `json:"actor,omitempty"`
+	ActorRole      string          `json:"actor_role"`
+	ImpersonatorID *uint           `json:"impersonator_id"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entity_type"`
+	EntityID       uint            `json:"entity_id"`
+	Before         json.RawMessage `json:"before,omitempty"`
+	After          json.RawMessage `json:"after,omitempty"`
+	Changes        json.RawMessage `json:"changes,omitempty"`
+	IPAddress      string          `json:"ip_address"`
+	UserAgent      string          `json:"user_agent"`
+	RequestID      string          `json:"request_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// ToResponse chuyển AuditLog thành AuditLogResponse
+func (a *AuditLog) ToResponse() AuditLogResponse {
+	response := AuditLogResponse{
+		ID:             a.ID,
+		ActorID:        a.ActorID,
+		ActorRole:      a.ActorRole,
+		ImpersonatorID: a.ImpersonatorID,
+		Action:         a.Action,
+		EntityType:     a.EntityType,
+		EntityID:       a.EntityID,
+		IPAddress:      a.IPAddress,
+		UserAgent:      a.UserAgent,
+		RequestID:      a.RequestID,
+		CreatedAt:      a.CreatedAt,
+	}
+
+	// Dữ liệu JSON đã được lưu sẵn, trả về nguyên dạng
+	if a.Before != "" {
+		response.Before = json.RawMessage(a.Before)
+	}
+	if a.After != "" {
+		response.After = json.RawMessage(a.After)
+	}
+	if a.Changes != "" {
+		response.Changes = json.RawMessage(a.Changes)
+	}
+
+	// Bao gồm thông tin người thực hiện nếu đã được nạp
+	if a.Actor != nil {
+		actorResponse := a.Actor.ToResponse()
+		response.Actor = &actorResponse
+	}
+
+	return response
+}
@@ -22,14 +22,16 @@ type CartItem struct {
 	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
 	CartID    uint           `json:"cart_id" gorm:"not null;index"`
 	ProductID uint           `json:"product_id" gorm:"not null;index"`
+	VariantID *uint          `json:"variant_id" gorm:"index"`
 	Quantity  int            `json:"quantity" gorm:"not null;default:1"`
 	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Cart    *Cart    `json:"cart,omitempty" gorm:"foreignKey:CartID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Cart    *Cart           `json:"cart,omitempty" gorm:"foreignKey:CartID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 // TableName specifies the table name for Cart model
@@ -43,8 +45,9 @@ func (CartItem) TableName() string {
 }
 
 type CartItemInput struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	ProductID uint  `json:"product_id" binding:"required"`
+	VariantID *uint `json:"variant_id"` // Bắt buộc với sản phẩm có biến thể
+	Quantity  int   `json:"quantity" binding:"required,min=1"`
 }
 
 type CartResponse struct {
@@ -58,14 +61,16 @@ type CartResponse struct {
 }
 
 type CartItemResponse struct {
-	ID        uint             `json:"id"`
-	CartID    uint             `json:"cart_id"`
-	ProductID uint             `json:"product_id"`
-	Product   *ProductResponse `json:"product,omitempty"`
-	Quantity  int              `json:"quantity"`
-	SubTotal  float64          `json:"sub_total"`
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
+	ID        uint                    `json:"id"`
+	CartID    uint                    `json:"cart_id"`
+	ProductID uint                    `json:"product_id"`
+	Product   *ProductResponse        `json:"product,omitempty"`
+	VariantID *uint                   `json:"variant_id"`
+	Variant   *ProductVariantResponse `json:"variant,omitempty"`
+	Quantity  int                     `json:"quantity"`
+	SubTotal  float64                 `json:"sub_total"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
 }
 
 // ToResponse converts Cart to CartResponse
@@ -86,6 +91,7 @@ func (c *Cart) ToResponse() CartResponse {
 				ID:        item.ID,
 				CartID:    item.CartID,
 				ProductID: item.ProductID,
+				VariantID: item.VariantID,
 				Quantity:  item.Quantity,
 				CreatedAt: item.CreatedAt,
 				UpdatedAt: item.UpdatedAt,
@@ -94,7 +100,13 @@ func (c *Cart) ToResponse() CartResponse {
 			if item.Product != nil {
 				productResponse := item.Product.ToResponse()
 				itemResponse.Product = &productResponse
-				itemResponse.SubTotal = float64(item.Quantity) * item.Product.Price
+				price := item.Product.Price
+				if item.Variant != nil {
+					variantResponse := item.Variant.toResponse(item.Product.Price)
+					itemResponse.Variant = &variantResponse
+					price = variantResponse.Price
+				}
+				itemResponse.SubTotal = float64(item.Quantity) * price
 				totalPrice += itemResponse.SubTotal
 			}
 
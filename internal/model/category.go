@@ -1,6 +1,7 @@
 package model
 
 import (
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,13 +12,19 @@ type Category struct {
 	Name        string         `json:"name" gorm:"not null;size:100;index"`
 	Description string         `json:"description" gorm:"size:500"`
 	Slug        string         `json:"slug" gorm:"unique;not null;size:100;index"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
+	SortOrder   int            `json:"sort_order" gorm:"default:0"`
 	IsActive    bool           `json:"is_active" gorm:"default:true;index"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Quan hệ
-	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Products []Product  `json:"products,omitempty" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+
+	// Đường dẫn từ danh mục gốc đến danh mục này, do repo tính (không lưu trong bảng)
+	Path []CategoryPathItem `json:"-" gorm:"-"`
 }
 
 // TableName chỉ định tên bảng cho model Category
@@ -29,16 +36,29 @@ type CategoryInput struct {
 	Name        string `json:"name" binding:"required,min=1,max=100"`
 	Description string `json:"description" binding:"max=500"`
 	Slug        string `json:"slug" binding:"required,min=1,max=100"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// CategoryPathItem là một mục trong breadcrumb của danh mục
+type CategoryPathItem struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type CategoryResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Slug        string    `json:"slug"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint               `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Slug        string             `json:"slug"`
+	ParentID    *uint              `json:"parent_id"`
+	SortOrder   int                `json:"sort_order"`
+	IsActive    bool               `json:"is_active"`
+	Path        []CategoryPathItem `json:"path,omitempty"`
+	Children    []CategoryResponse `json:"children,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
 }
 
 // ToResponse chuyển Category thành CategoryResponse
@@ -48,8 +68,75 @@ func (c *Category) ToResponse() CategoryResponse {
 		Name:        c.Name,
 		Description: c.Description,
 		Slug:        c.Slug,
+		ParentID:    c.ParentID,
+		SortOrder:   c.SortOrder,
 		IsActive:    c.IsActive,
+		Path:        c.Path,
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 	}
 }
+
+// CategoryPaths tính breadcrumb cho từng danh mục trong danh sách (gồm cả chính nó).
+// Danh mục có cha không nằm trong danh sách được coi là gốc; vòng lặp cha-con (dữ liệu lỗi) bị cắt tại điểm lặp.
+func CategoryPaths(categories []Category) map[uint][]CategoryPathItem {
+	byID := make(map[uint]*Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+
+	paths := make(map[uint][]CategoryPathItem, len(categories))
+	for _, category := range categories {
+		var path []CategoryPathItem
+		visited := make(map[uint]bool)
+		for current := byID[category.ID]; current != nil && !visited[current.ID]; {
+			visited[current.ID] = true
+			path = append(path, CategoryPathItem{ID: current.ID, Name: current.Name, Slug: current.Slug})
+			if current.ParentID == nil {
+				break
+			}
+			current = byID[*current.ParentID]
+		}
+		// Đảo ngược để đi từ gốc xuống
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		paths[category.ID] = path
+	}
+	return paths
+}
+
+// BuildCategoryTree dựng cây danh mục, anh em sắp theo sort_order rồi theo tên.
+// Danh mục có cha không nằm trong danh sách (vd: cha đã ẩn) bị loại cùng toàn bộ nhánh con, trừ danh mục gốc.
+func BuildCategoryTree(categories []Category) []CategoryResponse {
+	paths := CategoryPaths(categories)
+	children := make(map[uint][]Category)
+	var roots []Category
+	for _, category := range categories {
+		category.Path = paths[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	// Chỉ đi xuống từ danh mục gốc nên nhánh có vòng lặp (dữ liệu lỗi) không bao giờ được duyệt tới
+	var build func(nodes []Category) []CategoryResponse
+	build = func(nodes []Category) []CategoryResponse {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].SortOrder != nodes[j].SortOrder {
+				return nodes[i].SortOrder < nodes[j].SortOrder
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+		responses := make([]CategoryResponse, 0, len(nodes))
+		for _, node := range nodes {
+			response := node.ToResponse()
+			response.Children = build(children[node.ID])
+			responses = append(responses, response)
+		}
+		return responses
+	}
+	return build(roots)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestCategoryPaths(t *testing.T) {
+	categories := []Category{
+		{ID: 1, Name: "Ví", Slug: "vi"},
+		{ID: 2, Name: "Ví nam", Slug: "vi-nam", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Ví da", Slug: "vi-da", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Mồ côi", Slug: "mo-coi", ParentID: uintPtr(99)},
+		{ID: 5, Name: "Vòng A", Slug: "vong-a", ParentID: uintPtr(6)},
+		{ID: 6, Name: "Vòng B", Slug: "vong-b", ParentID: uintPtr(5)},
+	}
+
+	tests := []struct {
+		id   uint
+		want []uint
+	}{
+		{1, []uint{1}},
+		{3, []uint{1, 2, 3}},
+		{4, []uint{4}},
+		{5, []uint{6, 5}},
+	}
+	paths := CategoryPaths(categories)
+	for _, tt := range tests {
+		var got []uint
+		for _, item := range paths[tt.id] {
+			got = append(got, item.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("path of %d = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	if got := paths[3][1]; got != (CategoryPathItem{ID: 2, Name: "Ví nam", Slug: "vi-nam"}) {
+		t.Errorf("path item = %+v", got)
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	categories := []Category{
+		{ID: 1, Name: "Túi", SortOrder: 2},
+		{ID: 2, Name: "Ví", SortOrder: 1},
+		{ID: 3, Name: "Ví nữ", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Ví nam", ParentID: uintPtr(2)},
+		{ID: 5, Name: "Ví da", ParentID: uintPtr(4)},
+		{ID: 6, Name: "Nhánh của cha đã ẩn", ParentID: uintPtr(99)},
+	}
+
+	// Mỗi nút dạng "tên(con...)" để so sánh cả thứ tự lẫn cấu trúc
+	var render func(nodes []CategoryResponse) []string
+	render = func(nodes []CategoryResponse) []string {
+		var out []string
+		for _, node := range nodes {
+			label := node.Name
+			if children := render(node.Children); len(children) > 0 {
+				label += "(" + strings.Join(children, ",") + ")"
+			}
+			out = append(out, label)
+		}
+		return out
+	}
+
+	tree := BuildCategoryTree(categories)
+	want := []string{"Ví(Ví nam(Ví da),Ví nữ)", "Túi"}
+	if got := render(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	if got := len(tree[0].Children[0].Children[0].Path); got != 3 {
+		t.Errorf("leaf path length = %d, want 3", got)
+	}
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Trạng thái tạo ảnh dẫn xuất
+const (
+	ImageVariantPending = "pending"
+	ImageVariantReady   = "ready"
+	ImageVariantFailed  = "failed"
+)
+
+// ImageVariant là một ảnh dẫn xuất (thumbnail, medium, large...) được lưu cạnh ảnh gốc
+type ImageVariant struct {
+	Size   string `json:"size"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+}
+
+// ImageVariantSet là dữ liệu ảnh dẫn xuất trả về cho client
+type ImageVariantSet struct {
+	Variants map[string]map[string]string // size -> format -> URL
+	SrcSet   map[string]string            // format -> chuỗi srcset ("url 150w, url 600w")
+}
+
+// ParseImageVariants đọc danh sách ảnh dẫn xuất đã lưu dạng JSON
+func ParseImageVariants(raw string) []ImageVariant {
+	if raw == "" {
+		return nil
+	}
+	var variants []ImageVariant
+	if err := json.Unmarshal([]byte(raw), &variants); err != nil {
+		return nil
+	}
+	return variants
+}
+
+// BuildImageVariantSet dựng map URL theo kích thước/định dạng và chuỗi srcset cho từng định dạng
+func BuildImageVariantSet(raw string) ImageVariantSet {
+	variants := ParseImageVariants(raw)
+	if len(variants) == 0 {
+		return ImageVariantSet{}
+	}
+
+	set := ImageVariantSet{
+		Variants: make(map[string]map[string]string),
+		SrcSet:   make(map[string]string),
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].Width < variants[j].Width
+	})
+
+	candidates := make(map[string][]string)
+	lastWidth := make(map[string]int)
+	for _, variant := range variants {
+		if set.Variants[variant.Size] == nil {
+			set.Variants[variant.Size] = make(map[string]string)
+		}
+		set.Variants[variant.Size][variant.Format] = variant.URL
+
+		// Ảnh gốc nhỏ có thể cho nhiều kích thước cùng chiều rộng; srcset không được trùng mô tả
+		if lastWidth[variant.Format] == variant.Width {
+			continue
+		}
+		lastWidth[variant.Format] = variant.Width
+		candidates[variant.Format] = append(candidates[variant.Format], fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+	}
+	for format, items := range candidates {
+		set.SrcSet[format] = strings.Join(items, ", ")
+	}
+
+	return set
+}
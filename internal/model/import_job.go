@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ImportJob là một lần nhập sản phẩm từ tệp CSV/XLSX, được xử lý nền
+type ImportJob struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	FileName       string     `json:"file_name" gorm:"size:255"`
+	Format         string     `json:"format" gorm:"size:10"`
+	Mode           string     `json:"mode" gorm:"size:10"`
+	Status         string     `json:"status" gorm:"not null;size:20;default:pending;index"`
+	TotalRows      int        `json:"total_rows" gorm:"default:0"`
+	ProcessedRows  int        `json:"processed_rows" gorm:"default:0"`
+	CreatedCount   int        `json:"created_count" gorm:"default:0"`
+	UpdatedCount   int        `json:"updated_count" gorm:"default:0"`
+	UnchangedCount int        `json:"unchanged_count" gorm:"default:0"` // Dòng trùng khớp dữ liệu hiện có, không cần ghi
+	FailedCount    int        `json:"failed_count" gorm:"default:0"`
+	Columns        string     `json:"-" gorm:"type:text"`      // JSON dòng tiêu đề của tệp, dùng để dựng báo cáo lỗi
+	Errors         string     `json:"-" gorm:"type:longtext"`  // JSON danh sách ImportRowError
+	Message        string     `json:"message" gorm:"size:500"` // Lý do khi cả job thất bại
+	CreatedByID    *uint      `json:"created_by_id" gorm:"index"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	CreatedBy *User `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model ImportJob
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+// ImportRowError là một dòng bị từ chối khi nhập, kèm giá trị gốc để người dùng sửa và nhập lại
+type ImportRowError struct {
+	Line   int      `json:"line"`
+	SKU    string   `json:"sku"`
+	Errors []string `json:"errors"`
+	Values []string `json:"values"`
+}
+
+type ImportJobResponse struct {
+	ID             uint       `json:"id"`
+	FileName       string     `json:"file_name"`
+	Format         string     `json:"format"`
+	Mode           string     `json:"mode"`
+	Status         string     `json:"status"`
+	TotalRows      int        `json:"total_rows"`
+	ProcessedRows  int        `json:"processed_rows"`
+	Progress       float64    `json:"progress"` // Phần trăm số dòng đã xử lý
+	CreatedCount   int        `json:"created_count"`
+	UpdatedCount   int        `json:"updated_count"`
+	UnchangedCount int        `json:"unchanged_count"`
+	FailedCount    int        `json:"failed_count"`
+	HasErrors      bool       `json:"has_errors"` // Có báo cáo lỗi để tải xuống
+	Message        string     `json:"message,omitempty"`
+	CreatedByID    *uint      `json:"created_by_id"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// RowErrors giải mã danh sách dòng lỗi đã lưu
+func (j *ImportJob) RowErrors() []ImportRowError {
+	var rowErrors []ImportRowError
+	if j.Errors != "" {
+		_ = json.Unmarshal([]byte(j.Errors), &rowErrors)
+	}
+	return rowErrors
+}
+
+// ColumnList giải mã dòng tiêu đề đã lưu
+func (j *ImportJob) ColumnList() []string {
+	var columns []string
+	if j.Columns != "" {
+		_ = json.Unmarshal([]byte(j.Columns), &columns)
+	}
+	return columns
+}
+
+// ToResponse chuyển ImportJob thành ImportJobResponse
+func (j *ImportJob) ToResponse() ImportJobResponse {
+	response := ImportJobResponse{
+		ID:             j.ID,
+		FileName:       j.FileName,
+		Format:         j.Format,
+		Mode:           j.Mode,
+		Status:         j.Status,
+		TotalRows:      j.TotalRows,
+		ProcessedRows:  j.ProcessedRows,
+		CreatedCount:   j.CreatedCount,
+		UpdatedCount:   j.UpdatedCount,
+		UnchangedCount: j.UnchangedCount,
+		FailedCount:    j.FailedCount,
+		HasErrors:      j.FailedCount > 0,
+		Message:        j.Message,
+		CreatedByID:    j.CreatedByID,
+		StartedAt:      j.StartedAt,
+		FinishedAt:     j.FinishedAt,
+		CreatedAt:      j.CreatedAt,
+		UpdatedAt:      j.UpdatedAt,
+	}
+
+	if j.TotalRows > 0 {
+		response.Progress = float64(j.ProcessedRows) * 100 / float64(j.TotalRows)
+	}
+
+	return response
+}
@@ -7,22 +7,25 @@ import (
 )
 
 type News struct {
-	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	Title       string         `json:"title" gorm:"not null;size:200;index"`
-	Slug        string         `json:"slug" gorm:"unique;not null;size:200;index"`
-	Summary     string         `json:"summary" gorm:"size:500"`
-	Content     string         `json:"content" gorm:"type:longtext;not null"`
-	ImageURL    string         `json:"image_url" gorm:"size:500"`
-	AuthorID    uint           `json:"author_id" gorm:"not null;index"`
-	IsPublished bool           `json:"is_published" gorm:"default:false;index"`
-	PublishedAt *time.Time     `json:"published_at"`
-	ViewCount   int            `json:"view_count" gorm:"default:0"`
-	Tags        string         `json:"tags" gorm:"size:500"`
-	MetaTitle   string         `json:"meta_title" gorm:"size:200"`
-	MetaDesc    string         `json:"meta_description" gorm:"size:300"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title         string         `json:"title" gorm:"not null;size:200;index"`
+	Slug          string         `json:"slug" gorm:"unique;not null;size:200;index"`
+	Summary       string         `json:"summary" gorm:"size:500"`
+	Content       string         `json:"content" gorm:"type:longtext;not null"`
+	ImageURL      string         `json:"image_url" gorm:"size:500"`
+	ImageKey      string         `json:"-" gorm:"size:500"`  // Key trong kho lưu trữ nếu ảnh được tải lên
+	Variants      string         `json:"-" gorm:"type:text"` // JSON danh sách ImageVariant
+	VariantStatus string         `json:"variant_status" gorm:"size:20;index"`
+	AuthorID      uint           `json:"author_id" gorm:"not null;index"`
+	IsPublished   bool           `json:"is_published" gorm:"default:false;index"`
+	PublishedAt   *time.Time     `json:"published_at"`
+	ViewCount     int            `json:"view_count" gorm:"default:0"`
+	Tags          string         `json:"tags" gorm:"size:500"`
+	MetaTitle     string         `json:"meta_title" gorm:"size:200"`
+	MetaDesc      string         `json:"meta_description" gorm:"size:300"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Quan hệ
 	Author *User `json:"author,omitempty" gorm:"foreignKey:AuthorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
@@ -46,22 +49,25 @@ type NewsInput struct {
 }
 
 type NewsResponse struct {
-	ID          uint          `json:"id"`
-	Title       string        `json:"title"`
-	Slug        string        `json:"slug"`
-	Summary     string        `json:"summary"`
-	Content     string        `json:"content"`
-	ImageURL    string        `json:"image_url"`
-	AuthorID    uint          `json:"author_id"`
-	Author      *UserResponse `json:"author,omitempty"`
-	IsPublished bool          `json:"is_published"`
-	PublishedAt *time.Time    `json:"published_at"`
-	ViewCount   int           `json:"view_count"`
-	Tags        string        `json:"tags"`
-	MetaTitle   string        `json:"meta_title"`
-	MetaDesc    string        `json:"meta_description"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	ID            uint                         `json:"id"`
+	Title         string                       `json:"title"`
+	Slug          string                       `json:"slug"`
+	Summary       string                       `json:"summary"`
+	Content       string                       `json:"content"`
+	ImageURL      string                       `json:"image_url"`
+	ImageVariants map[string]map[string]string `json:"image_variants,omitempty"`
+	ImageSrcSet   map[string]string            `json:"image_srcset,omitempty"`
+	VariantStatus string                       `json:"variant_status,omitempty"`
+	AuthorID      uint                         `json:"author_id"`
+	Author        *UserResponse                `json:"author,omitempty"`
+	IsPublished   bool                         `json:"is_published"`
+	PublishedAt   *time.Time                   `json:"published_at"`
+	ViewCount     int                          `json:"view_count"`
+	Tags          string                       `json:"tags"`
+	MetaTitle     string                       `json:"meta_title"`
+	MetaDesc      string                       `json:"meta_description"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
 }
 
 // ToResponse chuyển News thành NewsResponse
@@ -84,6 +90,12 @@ func (n *News) ToResponse() NewsResponse {
 		UpdatedAt:   n.UpdatedAt,
 	}
 
+	// Ảnh dẫn xuất của ảnh đại diện (nếu đã tạo)
+	variantSet := BuildImageVariantSet(n.Variants)
+	response.ImageVariants = variantSet.Variants
+	response.ImageSrcSet = variantSet.SrcSet
+	response.VariantStatus = n.VariantStatus
+
 	// Bao gồm thông tin tác giả nếu đã được nạp
 	if n.Author != nil {
 		authorResponse := n.Author.ToResponse()
@@ -19,9 +19,12 @@ type Order struct {
 	FinalAmount      float64        `json:"final_amount" gorm:"not null;type:decimal(10,2)"`
 	CouponCode       string         `json:"coupon_code" gorm:"size:50"`
 	ShippingAddress  string         `json:"shipping_address" gorm:"type:text;not null"`
+	ShippingProvince string         `json:"shipping_province" gorm:"size:100;index"`   // Tỉnh/thành giao hàng, dùng để chọn kho
+	WarehouseID      *uint          `json:"warehouse_id" gorm:"index"`                 // Kho xuất hàng; nil khi đơn được chia cho nhiều kho
+	AwaitingStock    bool           `json:"awaiting_stock" gorm:"default:false;index"` // Còn dòng hàng đặt trước/chờ hàng về, chưa được giao
 	BillingAddress   string         `json:"billing_address" gorm:"type:text"`
 	CustomerName     string         `json:"customer_name" gorm:"not null;size:100"`
-	CustomerPhone    string         `json:"customer_phone" gorm:"not null;size:20;index"` // Add index for lookup
+	CustomerPhone    string         `json:"customer_phone" gorm:"not null;size:20;index"`  // Add index for lookup
 	CustomerEmail    string         `json:"customer_email" gorm:"not null;size:100;index"` // Add index for lookup
 	Notes            string         `json:"notes" gorm:"type:text"`
 	IsGuestOrder     bool           `json:"is_guest_order" gorm:"default:false;index"` // New field to identify guest orders
@@ -32,24 +35,35 @@ type Order struct {
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	User       *User        `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	OrderItems []OrderItem  `json:"order_items,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User       *User       `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Warehouse  *Warehouse  `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	OrderItems []OrderItem `json:"order_items,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 type OrderItem struct {
-	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	OrderID   uint           `json:"order_id" gorm:"not null;index"`
-	ProductID uint           `json:"product_id" gorm:"not null;index"`
-	Quantity  int            `json:"quantity" gorm:"not null;default:1"`
-	Price     float64        `json:"price" gorm:"not null;type:decimal(10,2)"`
-	Total     float64        `json:"total" gorm:"not null;type:decimal(10,2)"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                  uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID             uint           `json:"order_id" gorm:"not null;index"`
+	ProductID           uint           `json:"product_id" gorm:"not null;index"`
+	VariantID           *uint          `json:"variant_id" gorm:"index"`
+	VariantSKU          string         `json:"variant_sku" gorm:"size:50"`   // Snapshot at order time, kept if the variant changes
+	VariantName         string         `json:"variant_name" gorm:"size:255"` // e.g. "color: black, size: M"
+	Quantity            int            `json:"quantity" gorm:"not null;default:1"`
+	UnitCost            float64        `json:"-" gorm:"type:decimal(12,2);default:0"`                 // Giá vốn bình quân tại thời điểm bán, dùng cho báo cáo lợi nhuận
+	WarehouseID         *uint          `json:"warehouse_id" gorm:"index"`                             // Kho xuất dòng hàng này
+	Availability        string         `json:"availability" gorm:"not null;size:20;default:in_stock"` // in_stock, backorder hoặc preorder tại thời điểm đặt
+	BackorderedQuantity int            `json:"backordered_quantity" gorm:"not null;default:0;index"`  // Số lượng còn chờ hàng về, giảm dần khi nhập hàng
+	ExpectedShipAt      *time.Time     `json:"expected_ship_at"`                                      // Ngày dự kiến giao với hàng đặt trước
+	Price               float64        `json:"price" gorm:"not null;type:decimal(10,2)"`
+	Total               float64        `json:"total" gorm:"not null;type:decimal(10,2)"`
+	CreatedAt           time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Order   *Order   `json:"order,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Order     *Order          `json:"order,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product   *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Warehouse *Warehouse      `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
 
 // TableName specifies the table name for Order model
@@ -63,21 +77,23 @@ func (OrderItem) TableName() string {
 }
 
 type OrderInput struct {
-	UserID          *uint               `json:"user_id"` // Optional for guest orders
-	PaymentMethod   string              `json:"payment_method" binding:"required,oneof=cod bank_transfer momo zalopay"`
-	CouponCode      string              `json:"coupon_code"`
-	ShippingAddress string              `json:"shipping_address" binding:"required"`
-	BillingAddress  string              `json:"billing_address"`
-	CustomerName    string              `json:"customer_name" binding:"required"`
-	CustomerPhone   string              `json:"customer_phone" binding:"required"`
-	CustomerEmail   string              `json:"customer_email" binding:"required,email"`
-	Notes           string              `json:"notes"`
-	Items           []OrderItemInput    `json:"items" binding:"required,min=1"`
+	UserID           *uint            `json:"user_id"` // Optional for guest orders
+	PaymentMethod    string           `json:"payment_method" binding:"required,oneof=cod bank_transfer momo zalopay"`
+	CouponCode       string           `json:"coupon_code"`
+	ShippingAddress  string           `json:"shipping_address" binding:"required"`
+	ShippingProvince string           `json:"shipping_province" binding:"max=100"` // Tỉnh/thành giao hàng, ví dụ "Hà Nội"
+	BillingAddress   string           `json:"billing_address"`
+	CustomerName     string           `json:"customer_name" binding:"required"`
+	CustomerPhone    string           `json:"customer_phone" binding:"required"`
+	CustomerEmail    string           `json:"customer_email" binding:"required,email"`
+	Notes            string           `json:"notes"`
+	Items            []OrderItemInput `json:"items" binding:"required,min=1"`
 }
 
 type OrderItemInput struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	ProductID uint  `json:"product_id" binding:"required"`
+	VariantID *uint `json:"variant_id"` // Required for products that have variants
+	Quantity  int   `json:"quantity" binding:"required,min=1"`
 }
 
 type GuestOrderLookupInput struct {
@@ -97,6 +113,10 @@ type OrderResponse struct {
 	FinalAmount      float64             `json:"final_amount"`
 	CouponCode       string              `json:"coupon_code"`
 	ShippingAddress  string              `json:"shipping_address"`
+	ShippingProvince string              `json:"shipping_province"`
+	WarehouseID      *uint               `json:"warehouse_id"`
+	WarehouseCode    string              `json:"warehouse_code,omitempty"`
+	AwaitingStock    bool                `json:"awaiting_stock"`
 	BillingAddress   string              `json:"billing_address"`
 	CustomerName     string              `json:"customer_name"`
 	CustomerPhone    string              `json:"customer_phone"`
@@ -111,13 +131,20 @@ type OrderResponse struct {
 }
 
 type OrderItemResponse struct {
-	ID        uint             `json:"id"`
-	OrderID   uint             `json:"order_id"`
-	ProductID uint             `json:"product_id"`
-	Product   *ProductResponse `json:"product,omitempty"`
-	Quantity  int              `json:"quantity"`
-	Price     float64          `json:"price"`
-	Total     float64          `json:"total"`
+	ID                  uint             `json:"id"`
+	OrderID             uint             `json:"order_id"`
+	ProductID           uint             `json:"product_id"`
+	Product             *ProductResponse `json:"product,omitempty"`
+	VariantID           *uint            `json:"variant_id"`
+	VariantSKU          string           `json:"variant_sku,omitempty"`
+	VariantName         string           `json:"variant_name,omitempty"`
+	Quantity            int              `json:"quantity"`
+	WarehouseID         *uint            `json:"warehouse_id"`
+	Availability        string           `json:"availability"`
+	BackorderedQuantity int              `json:"backordered_quantity"`
+	ExpectedShipAt      *time.Time       `json:"expected_ship_at"`
+	Price               float64          `json:"price"`
+	Total               float64          `json:"total"`
 }
 
 // ToResponse converts Order to OrderResponse
@@ -135,6 +162,9 @@ func (o *Order) ToResponse() OrderResponse {
 		FinalAmount:      o.FinalAmount,
 		CouponCode:       o.CouponCode,
 		ShippingAddress:  o.ShippingAddress,
+		ShippingProvince: o.ShippingProvince,
+		WarehouseID:      o.WarehouseID,
+		AwaitingStock:    o.AwaitingStock,
 		BillingAddress:   o.BillingAddress,
 		CustomerName:     o.CustomerName,
 		CustomerPhone:    o.CustomerPhone,
@@ -147,16 +177,27 @@ func (o *Order) ToResponse() OrderResponse {
 		UpdatedAt:        o.UpdatedAt,
 	}
 
+	if o.Warehouse != nil {
+		response.WarehouseCode = o.Warehouse.Code
+	}
+
 	// Include order items if loaded
 	if len(o.OrderItems) > 0 {
 		for _, item := range o.OrderItems {
 			itemResponse := OrderItemResponse{
-				ID:        item.ID,
-				OrderID:   item.OrderID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     item.Price,
-				Total:     item.Total,
+				ID:                  item.ID,
+				OrderID:             item.OrderID,
+				ProductID:           item.ProductID,
+				VariantID:           item.VariantID,
+				VariantSKU:          item.VariantSKU,
+				VariantName:         item.VariantName,
+				Quantity:            item.Quantity,
+				WarehouseID:         item.WarehouseID,
+				Availability:        item.Availability,
+				BackorderedQuantity: item.BackorderedQuantity,
+				ExpectedShipAt:      item.ExpectedShipAt,
+				Price:               item.Price,
+				Total:               item.Total,
 			}
 			if item.Product != nil {
 				productResponse := item.Product.ToResponse()
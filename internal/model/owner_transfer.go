@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+)
+
+type OwnerTransfer struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	FromUserID  uint       `json:"from_user_id" gorm:"not null;index"`
+	ToUserID    uint       `json:"to_user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:pending;index"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	FromUser *User `json:"from_user,omitempty" gorm:"foreignKey:FromUserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ToUser   *User `json:"to_user,omitempty" gorm:"foreignKey:ToUserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model OwnerTransfer
+func (OwnerTransfer) TableName() string {
+	return "owner_transfers"
+}
+
+type OwnerTransferInput struct {
+	ToUserID uint   `json:"to_user_id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ConfirmPasswordInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ImpersonateInput struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type OwnerTransferResponse struct {
+	ID          uint          `json:"id"`
+	FromUserID  uint          `json:"from_user_id"`
+	FromUser    *UserResponse `json:"from_user,omitempty"`
+	ToUserID    uint          `json:"to_user_id"`
+	ToUser      *UserResponse `json:"to_user,omitempty"`
+	Status      string        `json:"status"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	RespondedAt *time.Time    `json:"responded_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ToResponse chuyển OwnerTransfer thành OwnerTransferResponse
+func (t *OwnerTransfer) ToResponse() OwnerTransferResponse {
+	response := OwnerTransferResponse{
+		ID:          t.ID,
+		FromUserID:  t.FromUserID,
+		ToUserID:    t.ToUserID,
+		Status:      t.Status,
+		ExpiresAt:   t.ExpiresAt,
+		RespondedAt: t.RespondedAt,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+
+	if t.FromUser != nil {
+		fromUser := t.FromUser.ToResponse()
+		response.FromUser = &fromUser
+	}
+	if t.ToUser != nil {
+		toUser := t.ToUser.ToResponse()
+		response.ToUser = &toUser
+	}
+
+	return response
+}
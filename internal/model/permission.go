@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+)
+
+type UserPermission struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_resource"`
+	Resource   string    `json:"resource" gorm:"not null;size:50;uniqueIndex:idx_user_resource"`
+	Permission string    `json:"permission" gorm:"not null;size:20"`
+	GrantedBy  uint      `json:"granted_by" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model UserPermission
+func (UserPermission) TableName() string {
+	return "user_permissions"
+}
+
+type UserPermissionResponse struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Resource   string    `json:"resource"`
+	Permission string    `json:"permission"`
+	GrantedBy  uint      `json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EffectivePermissionResponse mô tả quyền thực tế của người dùng trên một tài nguyên
+type EffectivePermissionResponse struct {
+	Resource   string `json:"resource"`
+	Permission string `json:"permission"`
+	IsDefault  bool   `json:"is_default"` // true nếu quyền lấy từ vai trò, không phải phân quyền riêng
+}
+
+// ToResponse chuyển UserPermission thành UserPermissionResponse
+func (p *UserPermission) ToResponse() UserPermissionResponse {
+	return UserPermissionResponse{
+		ID:         p.ID,
+		UserID:     p.UserID,
+		Resource:   p.Resource,
+		Permission: p.Permission,
+		GrantedBy:  p.GrantedBy,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
@@ -1,36 +1,45 @@
 package model
 
 import (
+	"backend/internal/consts"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name        string         `json:"name" gorm:"not null;size:200;index"`
-	Description string         `json:"description" gorm:"type:text"`
-	Price       float64        `json:"price" gorm:"not null;type:decimal(10,2)"`
-	SKU         string         `json:"sku" gorm:"unique;not null;size:50;index"`
-	Stock       int            `json:"stock" gorm:"not null;default:0"`
-	CategoryID  *uint          `json:"category_id" gorm:"index"`
-	BrandID     *uint          `json:"brand_id" gorm:"index"`
-	Material    string         `json:"material" gorm:"size:100"`
-	Color       string         `json:"color" gorm:"size:50"`
-	Size        string         `json:"size" gorm:"size:50"`
-	Weight      float64        `json:"weight" gorm:"type:decimal(8,2)"`
-	Dimensions  string         `json:"dimensions" gorm:"size:100"`
-	IsActive    bool           `json:"is_active" gorm:"default:true;index"`
-	IsFeatured  bool           `json:"is_featured" gorm:"default:false;index"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name              string         `json:"name" gorm:"not null;size:200;index"`
+	Description       string         `json:"description" gorm:"type:text"`
+	Price             float64        `json:"price" gorm:"not null;type:decimal(10,2);index"`
+	SKU               string         `json:"sku" gorm:"unique;not null;size:50;index"`
+	Stock             int            `json:"stock" gorm:"not null;default:0"`
+	LowStockThreshold int            `json:"low_stock_threshold" gorm:"not null;default:0"`     // Điểm đặt hàng lại: cảnh báo khi Stock <= ngưỡng, 0 là không cảnh báo
+	LowStockAlertedAt *time.Time     `json:"-" gorm:"index"`                                    // Lần cảnh báo gần nhất, xóa khi tồn kho vượt lại ngưỡng
+	AverageCost       float64        `json:"-" gorm:"not null;type:decimal(12,2);default:0"`    // Giá vốn bình quân gia quyền, cập nhật khi nhận hàng
+	StockPolicy       string         `json:"stock_policy" gorm:"not null;size:20;default:deny"` // deny, backorder hoặc preorder
+	MaxBackorder      int            `json:"max_backorder" gorm:"not null;default:0"`           // Số lượng tối đa được bán vượt tồn kho (tồn kho âm tối đa), 0 là không cho bán vượt
+	PreorderShipDate  *time.Time     `json:"preorder_ship_date"`                                // Ngày dự kiến giao hàng đặt trước
+	CategoryID        *uint          `json:"category_id" gorm:"index"`
+	BrandID           *uint          `json:"brand_id" gorm:"index"`
+	Material          string         `json:"material" gorm:"size:100;index"`
+	Color             string         `json:"color" gorm:"size:50;index"`
+	Size              string         `json:"size" gorm:"size:50;index"`
+	Weight            float64        `json:"weight" gorm:"type:decimal(8,2)"`
+	Dimensions        string         `json:"dimensions" gorm:"size:100"`
+	IsActive          bool           `json:"is_active" gorm:"default:true;index"`
+	IsFeatured        bool           `json:"is_featured" gorm:"default:false;index"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Quan hệ
-	Category      *Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	Brand         *Brand          `json:"brand,omitempty" gorm:"foreignKey:BrandID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	ProductImages []ProductImage  `json:"product_images,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Reviews       []Review        `json:"reviews,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Category      *Category        `json:"category,omitempty" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Brand         *Brand           `json:"brand,omitempty" gorm:"foreignKey:BrandID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	ProductImages []ProductImage   `json:"product_images,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Options       []ProductOption  `json:"options,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variants      []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Reviews       []Review         `json:"reviews,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 // TableName chỉ định tên bảng cho model Product
@@ -39,66 +48,106 @@ func (Product) TableName() string {
 }
 
 type ProductInput struct {
-	Name        string  `json:"name" binding:"required,min=1,max=200"`
-	Description string  `json:"description" binding:"max=1000"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	SKU         string  `json:"sku" binding:"required,min=1,max=50"`
-	Stock       int     `json:"stock" binding:"gte=0"`
-	CategoryID  *uint   `json:"category_id"`
-	BrandID     *uint   `json:"brand_id"`
-	Material    string  `json:"material" binding:"max=100"`
-	Color       string  `json:"color" binding:"max=50"`
-	Size        string  `json:"size" binding:"max=50"`
-	Weight      float64 `json:"weight" binding:"gte=0"`
-	Dimensions  string  `json:"dimensions" binding:"max=100"`
-	IsFeatured  bool    `json:"is_featured"`
+	Name              string     `json:"name" binding:"required,min=1,max=200"`
+	Description       string     `json:"description" binding:"max=1000"`
+	Price             float64    `json:"price" binding:"required,gt=0"`
+	SKU               string     `json:"sku" binding:"required,min=1,max=50"`
+	Stock             int        `json:"stock"` // Không âm, trừ khi giữ nguyên tồn kho âm hiện tại của sản phẩm đang bán vượt
+	LowStockThreshold int        `json:"low_stock_threshold" binding:"gte=0"`
+	StockPolicy       string     `json:"stock_policy" binding:"omitempty,oneof=deny backorder preorder"` // Mặc định deny
+	MaxBackorder      int        `json:"max_backorder" binding:"gte=0"`                                  // Bắt buộc > 0 với backorder/preorder
+	PreorderShipDate  *time.Time `json:"preorder_ship_date"`                                             // Bắt buộc với preorder
+	CategoryID        *uint      `json:"category_id"`
+	BrandID           *uint      `json:"brand_id"`
+	Material          string     `json:"material" binding:"max=100"`
+	Color             string     `json:"color" binding:"max=50"`
+	Size              string     `json:"size" binding:"max=50"`
+	Weight            float64    `json:"weight" binding:"gte=0"`
+	Dimensions        string     `json:"dimensions" binding:"max=100"`
+	IsFeatured        bool       `json:"is_featured"`
 }
 
 type ProductResponse struct {
-	ID            uint                   `json:"id"`
-	Name          string                 `json:"name"`
-	Description   string                 `json:"description"`
-	Price         float64                `json:"price"`
-	SKU           string                 `json:"sku"`
-	Stock         int                    `json:"stock"`
-	CategoryID    *uint                  `json:"category_id"`
-	Category      *CategoryResponse      `json:"category,omitempty"`
-	BrandID       *uint                  `json:"brand_id"`
-	Brand         *BrandResponse         `json:"brand,omitempty"`
-	Material      string                 `json:"material"`
-	Color         string                 `json:"color"`
-	Size          string                 `json:"size"`
-	Weight        float64                `json:"weight"`
-	Dimensions    string                 `json:"dimensions"`
-	IsActive      bool                   `json:"is_active"`
-	IsFeatured    bool                   `json:"is_featured"`
-	ProductImages []ProductImageResponse `json:"product_images,omitempty"`
-	AverageRating float64                `json:"average_rating"`
-	ReviewCount   int                    `json:"review_count"`
-	CreatedAt     time.Time              `json:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at"`
+	ID                uint                     `json:"id"`
+	Name              string                   `json:"name"`
+	Description       string                   `json:"description"`
+	Price             float64                  `json:"price"`
+	SKU               string                   `json:"sku"`
+	Stock             int                      `json:"stock"`
+	LowStockThreshold int                      `json:"low_stock_threshold"`
+	StockPolicy       string                   `json:"stock_policy"`
+	MaxBackorder      int                      `json:"max_backorder"`
+	PreorderShipDate  *time.Time               `json:"preorder_ship_date"`
+	Availability      string                   `json:"availability"` // in_stock, out_of_stock, backorder hoặc preorder
+	CategoryID        *uint                    `json:"category_id"`
+	Category          *CategoryResponse        `json:"category,omitempty"`
+	BrandID           *uint                    `json:"brand_id"`
+	Brand             *BrandResponse           `json:"brand,omitempty"`
+	Material          string                   `json:"material"`
+	Color             string                   `json:"color"`
+	Size              string                   `json:"size"`
+	Weight            float64                  `json:"weight"`
+	Dimensions        string                   `json:"dimensions"`
+	IsActive          bool                     `json:"is_active"`
+	IsFeatured        bool                     `json:"is_featured"`
+	ProductImages     []ProductImageResponse   `json:"product_images,omitempty"`
+	HasVariants       bool                     `json:"has_variants"`
+	Options           []ProductOptionResponse  `json:"options,omitempty"`
+	Variants          []ProductVariantResponse `json:"variants,omitempty"`
+	AverageRating     float64                  `json:"average_rating"`
+	ReviewCount       int                      `json:"review_count"`
+	CreatedAt         time.Time                `json:"created_at"`
+	UpdatedAt         time.Time                `json:"updated_at"`
+}
+
+// FacetCount là số sản phẩm ứng với một giá trị lọc (thương hiệu, màu sắc, chất liệu, kích thước)
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeFacet là số sản phẩm trong khoảng giá [Min, Max); Max = nil là không giới hạn trên
+type PriceRangeFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+// ProductFacets tổng hợp số lượng sản phẩm theo từng nhóm lọc
+type ProductFacets struct {
+	Brands      []FacetCount      `json:"brands"`
+	Colors      []FacetCount      `json:"colors"`
+	Materials   []FacetCount      `json:"materials"`
+	Sizes       []FacetCount      `json:"sizes"`
+	PriceRanges []PriceRangeFacet `json:"price_ranges"`
 }
 
 // ToResponse chuyển Product thành ProductResponse
 func (p *Product) ToResponse() ProductResponse {
 	response := ProductResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		SKU:         p.SKU,
-		Stock:       p.Stock,
-		CategoryID:  p.CategoryID,
-		BrandID:     p.BrandID,
-		Material:    p.Material,
-		Color:       p.Color,
-		Size:        p.Size,
-		Weight:      p.Weight,
-		Dimensions:  p.Dimensions,
-		IsActive:    p.IsActive,
-		IsFeatured:  p.IsFeatured,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:                p.ID,
+		Name:              p.Name,
+		Description:       p.Description,
+		Price:             p.Price,
+		SKU:               p.SKU,
+		Stock:             p.Stock,
+		LowStockThreshold: p.LowStockThreshold,
+		StockPolicy:       p.stockPolicy(),
+		MaxBackorder:      p.MaxBackorder,
+		PreorderShipDate:  p.PreorderShipDate,
+		Availability:      p.Availability(p.Stock),
+		CategoryID:        p.CategoryID,
+		BrandID:           p.BrandID,
+		Material:          p.Material,
+		Color:             p.Color,
+		Size:              p.Size,
+		Weight:            p.Weight,
+		Dimensions:        p.Dimensions,
+		IsActive:          p.IsActive,
+		IsFeatured:        p.IsFeatured,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
 	}
 
 	// Bao gồm thông tin danh mục nếu đã được nạp
@@ -120,6 +169,20 @@ func (p *Product) ToResponse() ProductResponse {
 		}
 	}
 
+	// Bao gồm ma trận tùy chọn và biến thể nếu đã được nạp
+	if len(p.Variants) > 0 {
+		response.HasVariants = true
+		response.Options = buildOptionMatrix(p.Options, p.Variants)
+		for _, variant := range p.Variants {
+			variantResponse := variant.toResponse(p.Price)
+			variantResponse.Availability = consts.AvailabilityOutOfStock
+			if variant.IsActive {
+				variantResponse.Availability = p.Availability(variant.Stock)
+			}
+			response.Variants = append(response.Variants, variantResponse)
+		}
+	}
+
 	// Tính điểm đánh giá trung bình và số lượng đánh giá
 	if len(p.Reviews) > 0 {
 		totalRating := 0.0
@@ -138,3 +201,52 @@ func (p *Product) ToResponse() ProductResponse {
 
 	return response
 }
+
+// stockPolicy trả về chính sách bán khi hết hàng, mặc định deny
+func (p *Product) stockPolicy() string {
+	if p.StockPolicy == "" {
+		return consts.StockPolicyDeny
+	}
+	return p.StockPolicy
+}
+
+// Availability trả về tình trạng hàng với tồn kho stock (của sản phẩm hoặc một biến thể) theo chính sách
+// bán khi hết hàng của sản phẩm
+func (p *Product) Availability(stock int) string {
+	if stock > 0 {
+		return consts.AvailabilityInStock
+	}
+	if _, ok := p.Oversell(stock, 1); ok {
+		switch p.stockPolicy() {
+		case consts.StockPolicyBackorder:
+			return consts.AvailabilityBackorder
+		case consts.StockPolicyPreorder:
+			return consts.AvailabilityPreorder
+		}
+	}
+	return consts.AvailabilityOutOfStock
+}
+
+// Oversell kiểm tra có thể đặt quantity khi tồn kho là stock: trả về số lượng phải chờ hàng về (phần vượt tồn kho
+// hiện có) và false khi chính sách của sản phẩm không cho bán vượt hoặc vượt giới hạn MaxBackorder
+func (p *Product) Oversell(stock, quantity int) (int, bool) {
+	available := stock
+	if available < 0 {
+		available = 0
+	}
+	if quantity <= available {
+		return 0, true
+	}
+	if stock-quantity < p.OversellFloor() {
+		return 0, false
+	}
+	return quantity - available, true
+}
+
+// OversellFloor trả về tồn kho thấp nhất được phép khi bán: -MaxBackorder với chính sách backorder/preorder, 0 với deny
+func (p *Product) OversellFloor() int {
+	if p.stockPolicy() == consts.StockPolicyDeny {
+		return 0
+	}
+	return -p.MaxBackorder
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"backend/internal/consts"
+	"testing"
+)
+
+func TestProductOversell(t *testing.T) {
+	deny := Product{}
+	backorder := Product{StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 5}
+	noLimit := Product{StockPolicy: consts.StockPolicyBackorder}
+
+	tests := []struct {
+		name            string
+		product         Product
+		stock, quantity int
+		wantBackordered int
+		wantOK          bool
+	}{
+		{"in stock", deny, 5, 5, 0, true},
+		{"deny beyond stock", deny, 5, 6, 0, false},
+		{"deny with oversold stock", deny, -1, 1, 0, false},
+		{"backorder the shortfall", backorder, 2, 6, 4, true},
+		{"backorder up to the limit", backorder, 2, 7, 5, true},
+		{"backorder beyond the limit", backorder, 2, 8, 0, false},
+		{"already oversold counts toward the limit", backorder, -3, 2, 2, true},
+		{"already oversold beyond the limit", backorder, -3, 3, 0, false},
+		{"zero max backorder allows no overselling", noLimit, 0, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backordered, ok := tt.product.Oversell(tt.stock, tt.quantity)
+			if backordered != tt.wantBackordered || ok != tt.wantOK {
+				t.Errorf("Oversell(%d, %d) = %d, %v; want %d, %v", tt.stock, tt.quantity, backordered, ok, tt.wantBackordered, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProductOversellFloor(t *testing.T) {
+	tests := []struct {
+		product Product
+		want    int
+	}{
+		{Product{MaxBackorder: 10}, 0},
+		{Product{StockPolicy: consts.StockPolicyDeny, MaxBackorder: 10}, 0},
+		{Product{StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 10}, -10},
+		{Product{StockPolicy: consts.StockPolicyPreorder, MaxBackorder: 3}, -3},
+	}
+	for _, tt := range tests {
+		if got := tt.product.OversellFloor(); got != tt.want {
+			t.Errorf("OversellFloor() of %q/%d = %d, want %d", tt.product.StockPolicy, tt.product.MaxBackorder, got, tt.want)
+		}
+	}
+}
+
+func TestProductAvailability(t *testing.T) {
+	tests := []struct {
+		name    string
+		product Product
+		stock   int
+		want    string
+	}{
+		{"in stock", Product{StockPolicy: consts.StockPolicyPreorder, MaxBackorder: 5}, 1, consts.AvailabilityInStock},
+		{"deny", Product{}, 0, consts.AvailabilityOutOfStock},
+		{"backorder", Product{StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 5}, 0, consts.AvailabilityBackorder},
+		{"preorder", Product{StockPolicy: consts.StockPolicyPreorder, MaxBackorder: 5}, -4, consts.AvailabilityPreorder},
+		{"backorder limit reached", Product{StockPolicy: consts.StockPolicyBackorder, MaxBackorder: 5}, -5, consts.AvailabilityOutOfStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.Availability(tt.stock); got != tt.want {
+				t.Errorf("Availability(%d) = %q, want %q", tt.stock, got, tt.want)
+			}
+		})
+	}
+}
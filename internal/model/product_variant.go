@@ -0,0 +1,266 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductOption là một trục tùy chọn của sản phẩm (vd: color, size) cùng các giá trị cho phép
+type ProductOption struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"not null;size:50"`
+	Values    string    `json:"-" gorm:"type:text"` // JSON mảng giá trị theo thứ tự hiển thị
+	Position  int       `json:"position" gorm:"default:0"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ProductVariant là một biến thể bán được của sản phẩm với SKU, giá và tồn kho riêng
+type ProductVariant struct {
+	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID uint           `json:"product_id" gorm:"not null;index"`
+	SKU       string         `json:"sku" gorm:"unique;not null;size:50;index"`
+	Price     *float64       `json:"price" gorm:"type:decimal(10,2)"` // nil thì dùng giá sản phẩm
+	Stock     int            `json:"stock" gorm:"not null;default:0"`
+	Options   string         `json:"-" gorm:"type:text"`      // JSON map tên tùy chọn -> giá trị
+	OptionKey string         `json:"-" gorm:"size:255;index"` // Tổ hợp tùy chọn đã chuẩn hóa để kiểm tra trùng
+	IsActive  bool           `json:"is_active" gorm:"default:true;index"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Quan hệ
+	Product *Product       `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Images  []ProductImage `json:"images,omitempty" gorm:"foreignKey:VariantID"`
+}
+
+// ProductVariantValue là một giá trị tùy chọn của biến thể, tách khỏi JSON Options (tên tùy chọn viết thường)
+// để lọc và đếm sản phẩm theo màu sắc, chất liệu, kích thước bằng SQL
+type ProductVariantValue struct {
+	ID        uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	VariantID uint   `json:"variant_id" gorm:"not null;index"`
+	ProductID uint   `json:"product_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null;size:50;index:idx_product_variant_values_name_value,priority:1"`
+	Value     string `json:"value" gorm:"not null;size:50;index:idx_product_variant_values_name_value,priority:2"`
+
+	// Quan hệ
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model ProductOption
+func (ProductOption) TableName() string {
+	return "product_options"
+}
+
+// TableName chỉ định tên bảng cho model ProductVariant
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// TableName chỉ định tên bảng cho model ProductVariantValue
+func (ProductVariantValue) TableName() string {
+	return "product_variant_values"
+}
+
+type ProductOptionInput struct {
+	Name   string   `json:"name" binding:"required,min=1,max=50"`
+	Values []string `json:"values" binding:"required,min=1,max=50,dive,min=1,max=50"`
+}
+
+// ProductOptionsInput thay toàn bộ trục tùy chọn của sản phẩm
+type ProductOptionsInput struct {
+	Options []ProductOptionInput `json:"options" binding:"max=3,dive"`
+}
+
+type ProductVariantInput struct {
+	SKU      string            `json:"sku" binding:"required,min=1,max=50"`
+	Price    *float64          `json:"price" binding:"omitempty,gt=0"`
+	Stock    int               `json:"stock"` // Không âm, trừ khi giữ nguyên tồn kho âm hiện tại của biến thể đang bán vượt
+	Options  map[string]string `json:"options" binding:"required"`
+	IsActive *bool             `json:"is_active"`
+}
+
+type ProductOptionValueResponse struct {
+	Value     string `json:"value"`
+	Available bool   `json:"available"`
+}
+
+type ProductOptionResponse struct {
+	Name   string                       `json:"name"`
+	Values []ProductOptionValueResponse `json:"values"`
+}
+
+type ProductVariantResponse struct {
+	ID            uint                   `json:"id"`
+	ProductID     uint                   `json:"product_id"`
+	SKU           string                 `json:"sku"`
+	Price         float64                `json:"price"`
+	PriceOverride *float64               `json:"price_override"`
+	Stock         int                    `json:"stock"`
+	Options       map[string]string      `json:"options"`
+	IsActive      bool                   `json:"is_active"`
+	Available     bool                   `json:"available"`
+	Availability  string                 `json:"availability,omitempty"` // Theo chính sách bán khi hết hàng của sản phẩm
+	Images        []ProductImageResponse `json:"images,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// ValueList trả về danh sách giá trị của trục tùy chọn
+func (o *ProductOption) ValueList() []string {
+	var values []string
+	if o.Values == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(o.Values), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// OptionMap trả về tổ hợp tùy chọn của biến thể
+func (v *ProductVariant) OptionMap() map[string]string {
+	options := make(map[string]string)
+	if v.Options == "" {
+		return options
+	}
+	if err := json.Unmarshal([]byte(v.Options), &options); err != nil {
+		return make(map[string]string)
+	}
+	return options
+}
+
+// ValueRows trả về các giá trị tùy chọn của biến thể để lưu vào bảng product_variant_values, sắp theo tên
+func (v *ProductVariant) ValueRows() []ProductVariantValue {
+	options := v.OptionMap()
+	rows := make([]ProductVariantValue, 0, len(options))
+	for name, value := range options {
+		rows = append(rows, ProductVariantValue{
+			VariantID: v.ID,
+			ProductID: v.ProductID,
+			Name:      strings.ToLower(strings.TrimSpace(name)),
+			Value:     strings.TrimSpace(value),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows
+}
+
+// EffectivePrice trả về giá bán của biến thể, dùng giá sản phẩm nếu không ghi đè
+func (v *ProductVariant) EffectivePrice(basePrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return basePrice
+}
+
+// IsAvailable cho biết biến thể còn bán được hay không
+func (v *ProductVariant) IsAvailable() bool {
+	return v.IsActive && v.Stock > 0
+}
+
+// DisplayName trả về tổ hợp tùy chọn dạng "color: black, size: M" theo thứ tự tên tùy chọn
+func (v *ProductVariant) DisplayName() string {
+	options := v.OptionMap()
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+options[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// VariantOptionKey chuẩn hóa tổ hợp tùy chọn (không phân biệt hoa thường, sắp theo tên) để so sánh
+func VariantOptionKey(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, strings.ToLower(strings.TrimSpace(name)))
+	}
+	sort.Strings(names)
+
+	normalized := make(map[string]string, len(options))
+	for name, value := range options {
+		normalized[strings.ToLower(strings.TrimSpace(name))] = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+normalized[name])
+	}
+	return strings.Join(parts, ";")
+}
+
+// ToResponse chuyển ProductVariant thành ProductVariantResponse, giá tính theo sản phẩm nếu đã được nạp
+func (v *ProductVariant) ToResponse() ProductVariantResponse {
+	basePrice := 0.0
+	if v.Product != nil {
+		basePrice = v.Product.Price
+	}
+	return v.toResponse(basePrice)
+}
+
+func (v *ProductVariant) toResponse(basePrice float64) ProductVariantResponse {
+	response := ProductVariantResponse{
+		ID:            v.ID,
+		ProductID:     v.ProductID,
+		SKU:           v.SKU,
+		Price:         v.EffectivePrice(basePrice),
+		PriceOverride: v.Price,
+		Stock:         v.Stock,
+		Options:       v.OptionMap(),
+		IsActive:      v.IsActive,
+		Available:     v.IsAvailable(),
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+	}
+
+	for _, img := range v.Images {
+		response.Images = append(response.Images, img.ToResponse())
+	}
+
+	return response
+}
+
+// buildOptionMatrix dựng danh sách trục tùy chọn kèm trạng thái còn hàng của từng giá trị
+func buildOptionMatrix(options []ProductOption, variants []ProductVariant) []ProductOptionResponse {
+	available := make(map[string]map[string]bool)
+	for _, variant := range variants {
+		if !variant.IsAvailable() {
+			continue
+		}
+		for name, value := range variant.OptionMap() {
+			if available[name] == nil {
+				available[name] = make(map[string]bool)
+			}
+			available[name][value] = true
+		}
+	}
+
+	sorted := append([]ProductOption(nil), options...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	responses := make([]ProductOptionResponse, 0, len(sorted))
+	for _, option := range sorted {
+		response := ProductOptionResponse{Name: option.Name}
+		for _, value := range option.ValueList() {
+			response.Values = append(response.Values, ProductOptionValueResponse{
+				Value:     value,
+				Available: available[option.Name][value],
+			})
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
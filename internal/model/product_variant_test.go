@@ -0,0 +1,86 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariantOptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{"empty", map[string]string{}, ""},
+		{"sorted by name", map[string]string{"size": "M", "color": "Black"}, "color=black;size=m"},
+		{"case and spaces ignored", map[string]string{" Color ": " BLACK ", "Size": "m"}, "color=black;size=m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VariantOptionKey(tt.options); got != tt.want {
+				t.Errorf("VariantOptionKey(%v) = %q, want %q", tt.options, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductVariantOptions(t *testing.T) {
+	display := ProductVariant{Options: `{"size":"M","color":"black"}`}
+	if got, want := display.DisplayName(), "color: black, size: M"; got != want {
+		t.Errorf("DisplayName() = %q, want %q", got, want)
+	}
+
+	variant := ProductVariant{ID: 7, ProductID: 3, Options: `{"Size":" M ","color":"black"}`}
+	wantRows := []ProductVariantValue{
+		{VariantID: 7, ProductID: 3, Name: "color", Value: "black"},
+		{VariantID: 7, ProductID: 3, Name: "size", Value: "M"},
+	}
+	if got := variant.ValueRows(); !reflect.DeepEqual(got, wantRows) {
+		t.Errorf("ValueRows() = %+v, want %+v", got, wantRows)
+	}
+
+	invalid := ProductVariant{Options: "not json"}
+	if got := invalid.OptionMap(); len(got) != 0 {
+		t.Errorf("OptionMap() on invalid JSON = %v, want empty", got)
+	}
+}
+
+func TestProductVariantEffectivePrice(t *testing.T) {
+	override := 120.0
+	tests := []struct {
+		name  string
+		price *float64
+		want  float64
+	}{
+		{"inherits product price", nil, 100},
+		{"uses override", &override, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant := ProductVariant{Price: tt.price}
+			if got := variant.EffectivePrice(100); got != tt.want {
+				t.Errorf("EffectivePrice(100) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildOptionMatrix(t *testing.T) {
+	options := []ProductOption{
+		{Name: "size", Values: `["S","M","L"]`, Position: 1},
+		{Name: "color", Values: `["black","white"]`, Position: 0},
+	}
+	variants := []ProductVariant{
+		{Options: `{"color":"black","size":"S"}`, Stock: 2, IsActive: true},
+		{Options: `{"color":"white","size":"M"}`, Stock: 0, IsActive: true},
+		{Options: `{"color":"white","size":"L"}`, Stock: 5, IsActive: false},
+	}
+
+	want := []ProductOptionResponse{
+		{Name: "color", Values: []ProductOptionValueResponse{{"black", true}, {"white", false}}},
+		{Name: "size", Values: []ProductOptionValueResponse{{"S", true}, {"M", false}, {"L", false}}},
+	}
+	if got := buildOptionMatrix(options, variants); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildOptionMatrix() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+package model
+
+import "time"
+
+// Supplier là nhà cung cấp hàng nhập kho
+type Supplier struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"not null;size:200;index"`
+	ContactName string    `json:"contact_name" gorm:"size:100"`
+	Email       string    `json:"email" gorm:"size:100"`
+	Phone       string    `json:"phone" gorm:"size:20"`
+	Address     string    `json:"address" gorm:"type:text"`
+	TaxCode     string    `json:"tax_code" gorm:"size:20"`
+	Notes       string    `json:"notes" gorm:"type:text"`
+	IsActive    bool      `json:"is_active" gorm:"default:true;index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName chỉ định tên bảng cho model Supplier
+func (Supplier) TableName() string {
+	return "suppliers"
+}
+
+type SupplierInput struct {
+	Name        string `json:"name" binding:"required,min=1,max=200"`
+	ContactName string `json:"contact_name" binding:"max=100"`
+	Email       string `json:"email" binding:"omitempty,email,max=100"`
+	Phone       string `json:"phone" binding:"max=20"`
+	Address     string `json:"address" binding:"max=500"`
+	TaxCode     string `json:"tax_code" binding:"max=20"`
+	Notes       string `json:"notes" binding:"max=1000"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// PurchaseOrder là đơn đặt hàng nhà cung cấp, nhận hàng vào kho WarehouseID
+type PurchaseOrder struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code        string     `json:"code" gorm:"unique;not null;size:50"`
+	SupplierID  uint       `json:"supplier_id" gorm:"not null;index"`
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:draft;index"`
+	Notes       string     `json:"notes" gorm:"type:text"`
+	UserID      *uint      `json:"user_id" gorm:"index"` // Người tạo
+	ExpectedAt  *time.Time `json:"expected_at"`          // Ngày dự kiến nhận hàng
+	OrderedAt   *time.Time `json:"ordered_at"`
+	ReceivedAt  *time.Time `json:"received_at"` // Thời điểm nhận đủ hàng
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	Supplier  *Supplier           `json:"-" gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Warehouse *Warehouse          `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	User      *User               `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Lines     []PurchaseOrderLine `json:"-" gorm:"foreignKey:PurchaseOrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model PurchaseOrder
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// PurchaseOrderLine là một dòng hàng của đơn đặt hàng; ReceivedQuantity tăng dần qua các lần nhận hàng
+type PurchaseOrderLine struct {
+	ID               uint    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PurchaseOrderID  uint    `json:"purchase_order_id" gorm:"not null;index"`
+	ProductID        uint    `json:"product_id" gorm:"not null;index"`
+	VariantID        *uint   `json:"variant_id" gorm:"index"`
+	Quantity         int     `json:"quantity" gorm:"not null"`
+	ReceivedQuantity int     `json:"received_quantity" gorm:"not null;default:0"`
+	UnitCost         float64 `json:"unit_cost" gorm:"not null;type:decimal(12,2)"`
+
+	// Quan hệ
+	Product *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model PurchaseOrderLine
+func (PurchaseOrderLine) TableName() string {
+	return "purchase_order_lines"
+}
+
+// Remaining trả về số lượng còn chờ nhận
+func (l *PurchaseOrderLine) Remaining() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+type PurchaseOrderInput struct {
+	SupplierID  uint                     `json:"supplier_id" binding:"required"`
+	WarehouseID uint                     `json:"warehouse_id" binding:"required"`
+	Notes       string                   `json:"notes" binding:"max=1000"`
+	ExpectedAt  *time.Time               `json:"expected_at"`
+	Lines       []PurchaseOrderLineInput `json:"lines" binding:"required,min=1,dive"`
+}
+
+type PurchaseOrderLineInput struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	VariantID *uint   `json:"variant_id"` // Bắt buộc với sản phẩm có biến thể
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	UnitCost  float64 `json:"unit_cost" binding:"gte=0"`
+}
+
+// PurchaseReceiptInput là một lần nhận hàng: số lượng nhận theo từng dòng của đơn đặt hàng
+type PurchaseReceiptInput struct {
+	Lines []PurchaseReceiptLineInput `json:"lines" binding:"required,min=1,dive"`
+	Note  string                     `json:"note" binding:"max=255"`
+}
+
+type PurchaseReceiptLineInput struct {
+	LineID   uint `json:"line_id" binding:"required"`
+	Quantity int  `json:"quantity" binding:"required,min=1"`
+}
+
+type SupplierResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	ContactName string    `json:"contact_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Address     string    `json:"address"`
+	TaxCode     string    `json:"tax_code"`
+	Notes       string    `json:"notes"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse chuyển Supplier thành SupplierResponse
+func (s *Supplier) ToResponse() SupplierResponse {
+	return SupplierResponse{
+		ID:          s.ID,
+		Name:        s.Name,
+		ContactName: s.ContactName,
+		Email:       s.Email,
+		Phone:       s.Phone,
+		Address:     s.Address,
+		TaxCode:     s.TaxCode,
+		Notes:       s.Notes,
+		IsActive:    s.IsActive,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+type PurchaseOrderResponse struct {
+	ID            uint                        `json:"id"`
+	Code          string                      `json:"code"`
+	SupplierID    uint                        `json:"supplier_id"`
+	SupplierName  string                      `json:"supplier_name,omitempty"`
+	WarehouseID   uint                        `json:"warehouse_id"`
+	WarehouseCode string                      `json:"warehouse_code,omitempty"`
+	Status        string                      `json:"status"`
+	Notes         string                      `json:"notes"`
+	UserID        *uint                       `json:"user_id"`
+	TotalQuantity int                         `json:"total_quantity"`
+	TotalReceived int                         `json:"total_received"`
+	TotalCost     float64                     `json:"total_cost"`
+	ExpectedAt    *time.Time                  `json:"expected_at"`
+	OrderedAt     *time.Time                  `json:"ordered_at"`
+	ReceivedAt    *time.Time                  `json:"received_at"`
+	Lines         []PurchaseOrderLineResponse `json:"lines,omitempty"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	UpdatedAt     time.Time                   `json:"updated_at"`
+}
+
+type PurchaseOrderLineResponse struct {
+	ID               uint    `json:"id"`
+	ProductID        uint    `json:"product_id"`
+	ProductSKU       string  `json:"product_sku,omitempty"`
+	ProductName      string  `json:"product_name,omitempty"`
+	VariantID        *uint   `json:"variant_id"`
+	VariantSKU       string  `json:"variant_sku,omitempty"`
+	Quantity         int     `json:"quantity"`
+	ReceivedQuantity int     `json:"received_quantity"`
+	UnitCost         float64 `json:"unit_cost"`
+	Total            float64 `json:"total"`
+}
+
+// ToResponse chuyển PurchaseOrder thành PurchaseOrderResponse (kèm dòng hàng, nhà cung cấp và kho nếu đã nạp)
+func (p *PurchaseOrder) ToResponse() PurchaseOrderResponse {
+	response := PurchaseOrderResponse{
+		ID:          p.ID,
+		Code:        p.Code,
+		SupplierID:  p.SupplierID,
+		WarehouseID: p.WarehouseID,
+		Status:      p.Status,
+		Notes:       p.Notes,
+		UserID:      p.UserID,
+		ExpectedAt:  p.ExpectedAt,
+		OrderedAt:   p.OrderedAt,
+		ReceivedAt:  p.ReceivedAt,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if p.Supplier != nil {
+		response.SupplierName = p.Supplier.Name
+	}
+	if p.Warehouse != nil {
+		response.WarehouseCode = p.Warehouse.Code
+	}
+
+	for _, line := range p.Lines {
+		lineResponse := PurchaseOrderLineResponse{
+			ID:               line.ID,
+			ProductID:        line.ProductID,
+			VariantID:        line.VariantID,
+			Quantity:         line.Quantity,
+			ReceivedQuantity: line.ReceivedQuantity,
+			UnitCost:         line.UnitCost,
+			Total:            float64(line.Quantity) * line.UnitCost,
+		}
+		if line.Product != nil {
+			lineResponse.ProductSKU = line.Product.SKU
+			lineResponse.ProductName = line.Product.Name
+		}
+		if line.Variant != nil {
+			lineResponse.VariantSKU = line.Variant.SKU
+		}
+		response.TotalQuantity += line.Quantity
+		response.TotalReceived += line.ReceivedQuantity
+		response.TotalCost += lineResponse.Total
+		response.Lines = append(response.Lines, lineResponse)
+	}
+	return response
+}
@@ -0,0 +1,33 @@
+package model
+
+import "testing"
+
+func TestPurchaseOrderToResponseTotals(t *testing.T) {
+	order := PurchaseOrder{
+		Supplier:  &Supplier{Name: "Xưởng da"},
+		Warehouse: &Warehouse{Code: "HN"},
+		Lines: []PurchaseOrderLine{
+			{ID: 1, Quantity: 10, ReceivedQuantity: 4, UnitCost: 120000, Product: &Product{SKU: "VI-01"}},
+			{ID: 2, Quantity: 5, ReceivedQuantity: 5, UnitCost: 80000.5},
+		},
+	}
+
+	response := order.ToResponse()
+	if response.SupplierName != "Xưởng da" || response.WarehouseCode != "HN" {
+		t.Errorf("supplier, warehouse = %q, %q", response.SupplierName, response.WarehouseCode)
+	}
+	if response.TotalQuantity != 15 || response.TotalReceived != 9 {
+		t.Errorf("quantities = %d ordered, %d received; want 15, 9", response.TotalQuantity, response.TotalReceived)
+	}
+	if response.TotalCost != 1600002.5 {
+		t.Errorf("TotalCost = %v, want 1600002.5", response.TotalCost)
+	}
+	if got := response.Lines[0]; got.Total != 1200000 || got.ProductSKU != "VI-01" {
+		t.Errorf("first line = %+v", got)
+	}
+
+	remaining := []int{order.Lines[0].Remaining(), order.Lines[1].Remaining()}
+	if remaining[0] != 6 || remaining[1] != 0 {
+		t.Errorf("Remaining = %v, want [6 0]", remaining)
+	}
+}
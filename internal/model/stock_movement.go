@@ -0,0 +1,96 @@
+package model
+
+import "time"
+
+// StockMovement là một dòng sổ kho: mọi thay đổi tồn kho của sản phẩm/biến thể đều được ghi lại kèm lý do,
+// Product.Stock (và ProductVariant.Stock) là số dư sau biến động cuối cùng
+type StockMovement struct {
+	ID               uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index:idx_stock_movements_product"`
+	VariantID        *uint     `json:"variant_id" gorm:"index"`
+	Delta            int       `json:"delta" gorm:"not null"`
+	Balance          int       `json:"balance" gorm:"not null"` // Tồn kho sản phẩm sau biến động
+	VariantBalance   *int      `json:"variant_balance"`         // Tồn kho biến thể sau biến động
+	WarehouseID      *uint     `json:"warehouse_id" gorm:"index"`
+	WarehouseBalance *int      `json:"warehouse_balance"` // Tồn kho của sản phẩm/biến thể tại kho sau biến động
+	Reason           string    `json:"reason" gorm:"not null;size:20;index"`
+	OrderID          *uint     `json:"order_id" gorm:"index"`
+	PurchaseOrderID  *uint     `json:"purchase_order_id" gorm:"index"`
+	StocktakeID      *uint     `json:"stocktake_id" gorm:"index"`
+	UserID           *uint     `json:"user_id" gorm:"index"` // Người thực hiện (nil với đơn của khách hoặc tác vụ hệ thống)
+	Note             string    `json:"note" gorm:"size:255"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_stock_movements_product"`
+
+	// AllowBackorder cho phép tồn kho xuống dưới 0 theo chính sách bán khi hết hàng của sản phẩm (không lưu)
+	AllowBackorder bool `json:"-" gorm:"-"`
+
+	// Quan hệ
+	Product       *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant       *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Order         *Order          `json:"-" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	PurchaseOrder *PurchaseOrder  `json:"-" gorm:"foreignKey:PurchaseOrderID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Stocktake     *Stocktake      `json:"-" gorm:"foreignKey:StocktakeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	User          *User           `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Warehouse     *Warehouse      `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model StockMovement
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+type StockMovementResponse struct {
+	ID               uint      `json:"id"`
+	ProductID        uint      `json:"product_id"`
+	VariantID        *uint     `json:"variant_id"`
+	VariantSKU       string    `json:"variant_sku,omitempty"`
+	Delta            int       `json:"delta"`
+	Balance          int       `json:"balance"`
+	VariantBalance   *int      `json:"variant_balance,omitempty"`
+	WarehouseID      *uint     `json:"warehouse_id"`
+	WarehouseCode    string    `json:"warehouse_code,omitempty"`
+	WarehouseBalance *int      `json:"warehouse_balance,omitempty"`
+	Reason           string    `json:"reason"`
+	OrderID          *uint     `json:"order_id"`
+	OrderNumber      string    `json:"order_number,omitempty"`
+	PurchaseOrderID  *uint     `json:"purchase_order_id,omitempty"`
+	StocktakeID      *uint     `json:"stocktake_id,omitempty"`
+	UserID           *uint     `json:"user_id"`
+	Username         string    `json:"username,omitempty"`
+	Note             string    `json:"note,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ToResponse chuyển StockMovement thành StockMovementResponse (kèm SKU biến thể, mã đơn và người thực hiện nếu đã nạp)
+func (m *StockMovement) ToResponse() StockMovementResponse {
+	response := StockMovementResponse{
+		ID:               m.ID,
+		ProductID:        m.ProductID,
+		VariantID:        m.VariantID,
+		Delta:            m.Delta,
+		Balance:          m.Balance,
+		VariantBalance:   m.VariantBalance,
+		WarehouseID:      m.WarehouseID,
+		WarehouseBalance: m.WarehouseBalance,
+		Reason:           m.Reason,
+		OrderID:          m.OrderID,
+		PurchaseOrderID:  m.PurchaseOrderID,
+		StocktakeID:      m.StocktakeID,
+		UserID:           m.UserID,
+		Note:             m.Note,
+		CreatedAt:        m.CreatedAt,
+	}
+	if m.Variant != nil {
+		response.VariantSKU = m.Variant.SKU
+	}
+	if m.Order != nil {
+		response.OrderNumber = m.Order.OrderNumber
+	}
+	if m.User != nil {
+		response.Username = m.User.Username
+	}
+	if m.Warehouse != nil {
+		response.WarehouseCode = m.Warehouse.Code
+	}
+	return response
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// StockReservation giữ hàng cho một dòng của đơn chờ thanh toán online. Số lượng giữ đã được trừ khỏi tồn kho
+// (dòng sổ kho reservation) nên không thể bán cho đơn khác; khi thanh toán xong thì chuyển thành bán (converted),
+// khi hết hạn hoặc đơn bị hủy thì trả lại kho (released)
+type StockReservation struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID    uint       `json:"order_id" gorm:"not null;index"`
+	ProductID  uint       `json:"product_id" gorm:"not null;index"`
+	VariantID  *uint      `json:"variant_id" gorm:"index"`
+	Quantity   int        `json:"quantity" gorm:"not null"`
+	Status     string     `json:"status" gorm:"not null;size:20;default:held;index:idx_stock_reservations_status_expiry"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index:idx_stock_reservations_status_expiry"`
+	ResolvedAt *time.Time `json:"resolved_at"` // Thời điểm chuyển thành bán hoặc trả lại kho
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	Order   *Order          `json:"-" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model StockReservation
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
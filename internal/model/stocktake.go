@@ -0,0 +1,187 @@
+package model
+
+import "time"
+
+// Stocktake là một phiên kiểm kê tại kho WarehouseID, giới hạn trong danh mục CategoryID (kể cả danh mục con)
+// nếu có. Danh sách dòng kiểm kê được chốt khi mở phiên, số đếm được nhập dần; khi duyệt, chênh lệch giữa số đếm
+// và tồn kho hệ thống được ghi sổ kho với lý do stocktake. Phiên đã duyệt hoặc hủy được giữ lại làm hồ sơ kiểm kê.
+type Stocktake struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code        string     `json:"code" gorm:"unique;not null;size:50"`
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;index"`
+	CategoryID  *uint      `json:"category_id" gorm:"index"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:open;index"`
+	Notes       string     `json:"notes" gorm:"type:text"`
+	UserID      *uint      `json:"user_id" gorm:"index"`     // Người mở phiên
+	ApprovedBy  *uint      `json:"approved_by" gorm:"index"` // Người duyệt hoặc hủy phiên
+	ClosedAt    *time.Time `json:"closed_at"`                // Thời điểm duyệt hoặc hủy
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	Warehouse *Warehouse      `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Category  *Category       `json:"-" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	User      *User           `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Approver  *User           `json:"-" gorm:"foreignKey:ApprovedBy;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Lines     []StocktakeLine `json:"-" gorm:"foreignKey:StocktakeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model Stocktake
+func (Stocktake) TableName() string {
+	return "stocktakes"
+}
+
+// StocktakeLine là một sản phẩm/biến thể cần đếm trong phiên kiểm kê. SystemStock là tồn kho tại kho khi mở phiên,
+// được chốt lại lúc duyệt cùng với Adjustment là số lượng đã điều chỉnh.
+type StocktakeLine struct {
+	ID              uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StocktakeID     uint       `json:"stocktake_id" gorm:"not null;index"`
+	ProductID       uint       `json:"product_id" gorm:"not null;index"`
+	VariantID       *uint      `json:"variant_id" gorm:"index"`
+	SKU             string     `json:"sku" gorm:"not null;size:50;index"` // SKU biến thể hoặc sản phẩm, dùng để quét mã vạch
+	Name            string     `json:"name" gorm:"size:255"`
+	SystemStock     int        `json:"system_stock" gorm:"not null;default:0"`
+	CountedQuantity *int       `json:"counted_quantity"` // nil là chưa đếm
+	CountedBy       *uint      `json:"counted_by"`
+	CountedAt       *time.Time `json:"counted_at"`
+	Adjustment      *int       `json:"adjustment"`                  // Chênh lệch đã ghi sổ kho khi duyệt
+	SkipReason      string     `json:"skip_reason" gorm:"size:255"` // Lý do không điều chỉnh được khi duyệt (vd: sản phẩm đã bị xóa)
+
+	// Quan hệ
+	Product *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model StocktakeLine
+func (StocktakeLine) TableName() string {
+	return "stocktake_lines"
+}
+
+// Variance trả về chênh lệch số đếm so với tồn kho hệ thống, nil khi chưa đếm
+func (l *StocktakeLine) Variance() *int {
+	if l.CountedQuantity == nil {
+		return nil
+	}
+	variance := *l.CountedQuantity - l.SystemStock
+	return &variance
+}
+
+type StocktakeInput struct {
+	WarehouseID *uint  `json:"warehouse_id"` // Mặc định là kho mặc định
+	CategoryID  *uint  `json:"category_id"`  // Bỏ trống để kiểm kê toàn bộ kho
+	Notes       string `json:"notes" binding:"max=1000"`
+}
+
+// StocktakeCountInput là số đếm của các dòng kiểm kê theo line_id hoặc sku; mode=set (mặc định) ghi đè số đếm,
+// mode=add cộng thêm (đếm nhiều lượt)
+type StocktakeCountInput struct {
+	Mode   string                    `json:"mode" binding:"omitempty,oneof=set add"`
+	Counts []StocktakeCountLineInput `json:"counts" binding:"required,min=1,dive"`
+}
+
+type StocktakeCountLineInput struct {
+	LineID   uint   `json:"line_id"`
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity" binding:"gte=0"`
+}
+
+type StocktakeResponse struct {
+	ID            uint       `json:"id"`
+	Code          string     `json:"code"`
+	WarehouseID   uint       `json:"warehouse_id"`
+	WarehouseCode string     `json:"warehouse_code,omitempty"`
+	CategoryID    *uint      `json:"category_id"`
+	CategoryName  string     `json:"category_name,omitempty"`
+	Status        string     `json:"status"`
+	Notes         string     `json:"notes"`
+	UserID        *uint      `json:"user_id"`
+	ApprovedBy    *uint      `json:"approved_by"`
+	TotalLines    int        `json:"total_lines"`
+	CountedLines  int        `json:"counted_lines"`
+	VarianceLines int        `json:"variance_lines"` // Số dòng đã đếm có chênh lệch
+	VarianceUnits int        `json:"variance_units"` // Tổng chênh lệch (thừa trừ thiếu)
+	VarianceValue float64    `json:"variance_value"` // Tổng chênh lệch theo giá vốn bình quân
+	ClosedAt      *time.Time `json:"closed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type StocktakeLineResponse struct {
+	ID              uint       `json:"id"`
+	ProductID       uint       `json:"product_id"`
+	VariantID       *uint      `json:"variant_id"`
+	SKU             string     `json:"sku"`
+	Name            string     `json:"name"`
+	SystemStock     int        `json:"system_stock"`
+	CountedQuantity *int       `json:"counted_quantity"`
+	Variance        *int       `json:"variance"`
+	VarianceValue   *float64   `json:"variance_value,omitempty"`
+	Adjustment      *int       `json:"adjustment,omitempty"`
+	SkipReason      string     `json:"skip_reason,omitempty"`
+	CountedBy       *uint      `json:"counted_by"`
+	CountedAt       *time.Time `json:"counted_at"`
+}
+
+// ToResponse chuyển Stocktake thành StocktakeResponse, tổng hợp chênh lệch từ các dòng đã nạp
+// (giá trị chênh lệch cần nạp kèm sản phẩm)
+func (s *Stocktake) ToResponse() StocktakeResponse {
+	response := StocktakeResponse{
+		ID:          s.ID,
+		Code:        s.Code,
+		WarehouseID: s.WarehouseID,
+		CategoryID:  s.CategoryID,
+		Status:      s.Status,
+		Notes:       s.Notes,
+		UserID:      s.UserID,
+		ApprovedBy:  s.ApprovedBy,
+		TotalLines:  len(s.Lines),
+		ClosedAt:    s.ClosedAt,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.Warehouse != nil {
+		response.WarehouseCode = s.Warehouse.Code
+	}
+	if s.Category != nil {
+		response.CategoryName = s.Category.Name
+	}
+
+	for i := range s.Lines {
+		line := s.Lines[i].ToResponse()
+		if line.Variance == nil {
+			continue
+		}
+		response.CountedLines++
+		if *line.Variance != 0 {
+			response.VarianceLines++
+			response.VarianceUnits += *line.Variance
+		}
+		if line.VarianceValue != nil {
+			response.VarianceValue += *line.VarianceValue
+		}
+	}
+	return response
+}
+
+// ToResponse chuyển StocktakeLine thành StocktakeLineResponse
+func (l *StocktakeLine) ToResponse() StocktakeLineResponse {
+	response := StocktakeLineResponse{
+		ID:              l.ID,
+		ProductID:       l.ProductID,
+		VariantID:       l.VariantID,
+		SKU:             l.SKU,
+		Name:            l.Name,
+		SystemStock:     l.SystemStock,
+		CountedQuantity: l.CountedQuantity,
+		Variance:        l.Variance(),
+		Adjustment:      l.Adjustment,
+		SkipReason:      l.SkipReason,
+		CountedBy:       l.CountedBy,
+		CountedAt:       l.CountedAt,
+	}
+	if response.Variance != nil && l.Product != nil {
+		value := float64(*response.Variance) * l.Product.AverageCost
+		response.VarianceValue = &value
+	}
+	return response
+}
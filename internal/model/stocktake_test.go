@@ -0,0 +1,55 @@
+package model
+
+import "testing"
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestStocktakeLineVariance(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    StocktakeLine
+		want    *int
+		wantVal *float64
+	}{
+		{"not counted", StocktakeLine{SystemStock: 5}, nil, nil},
+		{"shortage", StocktakeLine{SystemStock: 5, CountedQuantity: intPtr(3)}, intPtr(-2), nil},
+		{"surplus valued at average cost", StocktakeLine{SystemStock: 5, CountedQuantity: intPtr(6), Product: &Product{AverageCost: 1500}}, intPtr(1), floatPtr(1500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := tt.line.ToResponse()
+			if (response.Variance == nil) != (tt.want == nil) || (tt.want != nil && *response.Variance != *tt.want) {
+				t.Errorf("Variance = %v, want %v", response.Variance, tt.want)
+			}
+			if (response.VarianceValue == nil) != (tt.wantVal == nil) || (tt.wantVal != nil && *response.VarianceValue != *tt.wantVal) {
+				t.Errorf("VarianceValue = %v, want %v", response.VarianceValue, tt.wantVal)
+			}
+		})
+	}
+}
+
+func TestStocktakeToResponseSummary(t *testing.T) {
+	product := &Product{AverageCost: 1000}
+	stocktake := Stocktake{Lines: []StocktakeLine{
+		{SystemStock: 10, CountedQuantity: intPtr(8), Product: product},
+		{SystemStock: 4, CountedQuantity: intPtr(5), Product: product},
+		{SystemStock: 3, CountedQuantity: intPtr(3), Product: product},
+		{SystemStock: 7},
+	}}
+
+	response := stocktake.ToResponse()
+	if response.TotalLines != 4 || response.CountedLines != 3 || response.VarianceLines != 2 {
+		t.Errorf("lines = %d total, %d counted, %d with variance; want 4, 3, 2",
+			response.TotalLines, response.CountedLines, response.VarianceLines)
+	}
+	if response.VarianceUnits != -1 || response.VarianceValue != -1000 {
+		t.Errorf("variance = %d units, %v value; want -1, -1000", response.VarianceUnits, response.VarianceValue)
+	}
+}
@@ -0,0 +1,224 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Warehouse là một kho hàng. Provinces là danh sách tỉnh/thành (phân tách bằng dấu phẩy) mà kho ưu tiên phục vụ
+// khi phân bổ đơn hàng; Priority nhỏ hơn được ưu tiên hơn, kho đang hoạt động có Priority nhỏ nhất là kho mặc định
+type Warehouse struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code      string    `json:"code" gorm:"unique;not null;size:20"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Province  string    `json:"province" gorm:"not null;size:100"`
+	Address   string    `json:"address" gorm:"type:text"`
+	Provinces string    `json:"provinces" gorm:"type:text"`
+	Priority  int       `json:"priority" gorm:"not null;default:0;index"`
+	IsActive  bool      `json:"is_active" gorm:"default:true;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName chỉ định tên bảng cho model Warehouse
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// ServedProvinces tách danh sách tỉnh/thành kho phục vụ
+func (w *Warehouse) ServedProvinces() []string {
+	provinces := []string{}
+	for _, province := range strings.Split(w.Provinces, ",") {
+		if province = strings.TrimSpace(province); province != "" {
+			provinces = append(provinces, province)
+		}
+	}
+	return provinces
+}
+
+type WarehouseInput struct {
+	Code      string   `json:"code" binding:"required,min=1,max=20"`
+	Name      string   `json:"name" binding:"required,min=1,max=100"`
+	Province  string   `json:"province" binding:"required,min=1,max=100"`
+	Address   string   `json:"address" binding:"max=500"`
+	Provinces []string `json:"provinces" binding:"dive,min=1,max=100"`
+	Priority  int      `json:"priority"`
+	IsActive  *bool    `json:"is_active"`
+}
+
+type WarehouseResponse struct {
+	ID         uint      `json:"id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Province   string    `json:"province"`
+	Address    string    `json:"address"`
+	Provinces  []string  `json:"provinces"`
+	Priority   int       `json:"priority"`
+	IsActive   bool      `json:"is_active"`
+	TotalStock int64     `json:"total_stock"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ToResponse chuyển Warehouse thành WarehouseResponse
+func (w *Warehouse) ToResponse() WarehouseResponse {
+	return WarehouseResponse{
+		ID:        w.ID,
+		Code:      w.Code,
+		Name:      w.Name,
+		Province:  w.Province,
+		Address:   w.Address,
+		Provinces: w.ServedProvinces(),
+		Priority:  w.Priority,
+		IsActive:  w.IsActive,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// WarehouseStock là tồn kho của một sản phẩm (hoặc biến thể) tại một kho. Product.Stock/ProductVariant.Stock
+// là tổng tồn kho các kho và được cập nhật cùng lúc qua sổ kho. VariantKey = ID biến thể, 0 với sản phẩm
+// không có biến thể, để khóa duy nhất hoạt động (MySQL cho phép nhiều NULL trong chỉ mục unique).
+type WarehouseStock struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_warehouse_stock,priority:1"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_warehouse_stock,priority:2;index"`
+	VariantKey  uint      `json:"-" gorm:"not null;default:0;uniqueIndex:idx_warehouse_stock,priority:3"`
+	VariantID   *uint     `json:"variant_id" gorm:"index"`
+	Stock       int       `json:"stock" gorm:"not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Quan hệ
+	Warehouse *Warehouse      `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product   *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant   *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// TableName chỉ định tên bảng cho model WarehouseStock
+func (WarehouseStock) TableName() string {
+	return "warehouse_stocks"
+}
+
+// VariantKeyOf trả về giá trị cột variant_key ứng với biến thể (0 khi không có biến thể)
+func VariantKeyOf(variantID *uint) uint {
+	if variantID == nil {
+		return 0
+	}
+	return *variantID
+}
+
+type WarehouseStockResponse struct {
+	WarehouseID   uint      `json:"warehouse_id"`
+	WarehouseCode string    `json:"warehouse_code,omitempty"`
+	ProductID     uint      `json:"product_id"`
+	ProductSKU    string    `json:"product_sku,omitempty"`
+	ProductName   string    `json:"product_name,omitempty"`
+	VariantID     *uint     `json:"variant_id"`
+	VariantSKU    string    `json:"variant_sku,omitempty"`
+	Stock         int       `json:"stock"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ToResponse chuyển WarehouseStock thành WarehouseStockResponse (kèm mã kho, SKU nếu đã nạp)
+func (s *WarehouseStock) ToResponse() WarehouseStockResponse {
+	response := WarehouseStockResponse{
+		WarehouseID: s.WarehouseID,
+		ProductID:   s.ProductID,
+		VariantID:   s.VariantID,
+		Stock:       s.Stock,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.Warehouse != nil {
+		response.WarehouseCode = s.Warehouse.Code
+	}
+	if s.Product != nil {
+		response.ProductSKU = s.Product.SKU
+		response.ProductName = s.Product.Name
+	}
+	if s.Variant != nil {
+		response.VariantSKU = s.Variant.SKU
+	}
+	return response
+}
+
+// StockTransfer là một lần chuyển hàng giữa hai kho, được ghi vào sổ kho thành hai dòng transfer (xuất và nhập)
+type StockTransfer struct {
+	ID              uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	FromWarehouseID uint      `json:"from_warehouse_id" gorm:"not null;index"`
+	ToWarehouseID   uint      `json:"to_warehouse_id" gorm:"not null;index"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	VariantID       *uint     `json:"variant_id" gorm:"index"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	UserID          *uint     `json:"user_id" gorm:"index"`
+	Note            string    `json:"note" gorm:"size:255"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+
+	// Quan hệ
+	FromWarehouse *Warehouse      `json:"-" gorm:"foreignKey:FromWarehouseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ToWarehouse   *Warehouse      `json:"-" gorm:"foreignKey:ToWarehouseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product       *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant       *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	User          *User           `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// TableName chỉ định tên bảng cho model StockTransfer
+func (StockTransfer) TableName() string {
+	return "stock_transfers"
+}
+
+type StockTransferInput struct {
+	FromWarehouseID uint   `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	ProductID       uint   `json:"product_id" binding:"required"`
+	VariantID       *uint  `json:"variant_id"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	Note            string `json:"note" binding:"max=255"`
+}
+
+type StockTransferResponse struct {
+	ID                uint      `json:"id"`
+	FromWarehouseID   uint      `json:"from_warehouse_id"`
+	FromWarehouseCode string    `json:"from_warehouse_code,omitempty"`
+	ToWarehouseID     uint      `json:"to_warehouse_id"`
+	ToWarehouseCode   string    `json:"to_warehouse_code,omitempty"`
+	ProductID         uint      `json:"product_id"`
+	ProductSKU        string    `json:"product_sku,omitempty"`
+	VariantID         *uint     `json:"variant_id"`
+	VariantSKU        string    `json:"variant_sku,omitempty"`
+	Quantity          int       `json:"quantity"`
+	UserID            *uint     `json:"user_id"`
+	Username          string    `json:"username,omitempty"`
+	Note              string    `json:"note,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ToResponse chuyển StockTransfer thành StockTransferResponse (kèm mã kho, SKU và người thực hiện nếu đã nạp)
+func (t *StockTransfer) ToResponse() StockTransferResponse {
+	response := StockTransferResponse{
+		ID:              t.ID,
+		FromWarehouseID: t.FromWarehouseID,
+		ToWarehouseID:   t.ToWarehouseID,
+		ProductID:       t.ProductID,
+		VariantID:       t.VariantID,
+		Quantity:        t.Quantity,
+		UserID:          t.UserID,
+		Note:            t.Note,
+		CreatedAt:       t.CreatedAt,
+	}
+	if t.FromWarehouse != nil {
+		response.FromWarehouseCode = t.FromWarehouse.Code
+	}
+	if t.ToWarehouse != nil {
+		response.ToWarehouseCode = t.ToWarehouse.Code
+	}
+	if t.Product != nil {
+		response.ProductSKU = t.Product.SKU
+	}
+	if t.Variant != nil {
+		response.VariantSKU = t.Variant.SKU
+	}
+	if t.User != nil {
+		response.Username = t.User.Username
+	}
+	return response
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mailer gửi thông báo qua SMTP (STARTTLS nếu máy chủ hỗ trợ)
+type Mailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewMailer tạo kênh email gửi từ from tới danh sách to qua máy chủ host:port
+func NewMailer(host, port, username, password, from string, to []string) *Mailer {
+	return &Mailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+	}
+}
+
+func newMailerFromEnv() *Mailer {
+	host := strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	var to []string
+	for _, address := range strings.Split(os.Getenv("ALERT_EMAIL_TO"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+	if host == "" || len(to) == 0 {
+		return nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+	return NewMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from, to)
+}
+
+// Notify gửi thông báo thành email văn bản thuần
+func (m *Mailer) Notify(ctx context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + strings.Join(m.to, ", "),
+		"Subject: " + alert.Subject,
+		"Date: " + alert.SentAt.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		alert.Message,
+	}, "\r\n")
+
+	// smtp.SendMail không nhận context nên chạy riêng để tôn trọng thời hạn của người gọi
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, auth, m.from, m.to, []byte(message))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send alert email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("send alert email: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantNil  bool
+		wantAddr string
+		wantFrom string
+		wantTo   []string
+	}{
+		{
+			name:    "no SMTP host",
+			env:     map[string]string{"ALERT_EMAIL_TO": "ops@example.com"},
+			wantNil: true,
+		},
+		{
+			name:    "no recipients",
+			env:     map[string]string{"SMTP_HOST": "smtp.example.com", "ALERT_EMAIL_TO": " , "},
+			wantNil: true,
+		},
+		{
+			name:     "defaults to port 587 and the username as sender",
+			env:      map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_USERNAME": "bot@example.com", "ALERT_EMAIL_TO": "a@example.com, b@example.com"},
+			wantAddr: "smtp.example.com:587",
+			wantFrom: "bot@example.com",
+			wantTo:   []string{"a@example.com", "b@example.com"},
+		},
+		{
+			name:     "explicit port and sender",
+			env:      map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_PORT": "2525", "SMTP_FROM": "alerts@example.com", "ALERT_EMAIL_TO": "a@example.com"},
+			wantAddr: "smtp.example.com:2525",
+			wantFrom: "alerts@example.com",
+			wantTo:   []string{"a@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_FROM", "ALERT_EMAIL_TO"} {
+				t.Setenv(key, tt.env[key])
+			}
+			mailer := newMailerFromEnv()
+			if tt.wantNil {
+				if mailer != nil {
+					t.Errorf("newMailerFromEnv() = %+v, want nil", mailer)
+				}
+				return
+			}
+			if mailer == nil {
+				t.Fatal("newMailerFromEnv() = nil")
+			}
+			if mailer.addr != tt.wantAddr || mailer.from != tt.wantFrom {
+				t.Errorf("addr, from = %q, %q; want %q, %q", mailer.addr, mailer.from, tt.wantAddr, tt.wantFrom)
+			}
+			if !reflect.DeepEqual(mailer.to, tt.wantTo) {
+				t.Errorf("to = %v, want %v", mailer.to, tt.wantTo)
+			}
+		})
+	}
+}
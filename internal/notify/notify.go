@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert là một thông báo gửi tới quản trị viên qua các kênh đã cấu hình (email, webhook)
+type Alert struct {
+	Event   string      `json:"event"`   // Mã sự kiện, ví dụ "stock.low"
+	Subject string      `json:"subject"` // Tiêu đề email
+	Message string      `json:"message"` // Nội dung dạng văn bản
+	Data    interface{} `json:"data"`    // Dữ liệu kèm theo cho webhook
+	SentAt  time.Time   `json:"sent_at"`
+}
+
+// Notifier là một kênh gửi thông báo
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+var (
+	defaultNotifiers     []Notifier
+	defaultNotifiersOnce sync.Once
+)
+
+// Default trả về các kênh được cấu hình qua biến môi trường: email khi có SMTP_HOST và ALERT_EMAIL_TO,
+// webhook khi có ALERT_WEBHOOK_URL. Không có kênh nào thì thông báo bị bỏ qua.
+func Default() []Notifier {
+	defaultNotifiersOnce.Do(func() {
+		if mailer := newMailerFromEnv(); mailer != nil {
+			defaultNotifiers = append(defaultNotifiers, mailer)
+		}
+		if url := strings.TrimSpace(os.Getenv("ALERT_WEBHOOK_URL")); url != "" {
+			defaultNotifiers = append(defaultNotifiers, NewWebhook(url, os.Getenv("ALERT_WEBHOOK_SECRET")))
+		}
+	})
+	return defaultNotifiers
+}
+
+// Enabled cho biết có ít nhất một kênh thông báo được cấu hình
+func Enabled() bool {
+	return len(Default()) > 0
+}
+
+// Send gửi thông báo tới mọi kênh mặc định; lỗi của từng kênh được gộp lại, kênh lỗi không chặn kênh khác
+func Send(ctx context.Context, alert Alert) error {
+	if alert.SentAt.IsZero() {
+		alert.SentAt = time.Now()
+	}
+
+	var errs []error
+	for _, notifier := range Default() {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		status        int
+		wantErr       bool
+		wantSignature bool
+	}{
+		{"unsigned", "", http.StatusOK, false, false},
+		{"signed", "s3cret", http.StatusAccepted, false, true},
+		{"receiver error", "", http.StatusInternalServerError, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			alert := Alert{Event: "stock.low", Subject: "Tồn kho thấp", Data: map[string]int{"products": 2}}
+			err := NewWebhook(server.URL, tt.secret).Notify(context.Background(), alert)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var received Alert
+			if err := json.Unmarshal(body, &received); err != nil || received.Event != alert.Event || received.Subject != alert.Subject {
+				t.Errorf("body = %s, want the alert as JSON", body)
+			}
+			if got := header.Get("X-Event"); got != alert.Event {
+				t.Errorf("X-Event = %q, want %q", got, alert.Event)
+			}
+
+			signature := header.Get("X-Signature")
+			if !tt.wantSignature {
+				if signature != "" {
+					t.Errorf("X-Signature = %q, want none", signature)
+				}
+				return
+			}
+			mac := hmac.New(sha256.New, []byte(tt.secret))
+			mac.Write(body)
+			if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+				t.Errorf("X-Signature = %q, want %q", signature, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo() *APIKeyRepo {
+	return &APIKeyRepo{
+		db: app.GetDB(),
+	}
+}
+
+// Create tạo mới một API key
+func (r *APIKeyRepo) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetByUserID lấy tất cả API key của người dùng, mới nhất trước
+func (r *APIKeyRepo) GetByUserID(userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// GetByIDForUser lấy API key theo ID, chỉ khi thuộc về người dùng
+func (r *APIKeyRepo) GetByIDForUser(id, userID uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("api key not found")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash tìm API key theo giá trị băm của khóa
+func (r *APIKeyRepo) GetByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("api key not found")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CountActiveByUserID đếm số API key còn hiệu lực của người dùng
+func (r *APIKeyRepo) CountActiveByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke thu hồi API key
+func (r *APIKeyRepo) Revoke(id uint) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed cập nhật thời điểm sử dụng gần nhất, bỏ qua nếu vừa cập nhật trong khoảng interval
+func (r *APIKeyRepo) TouchLastUsed(id uint, ip string, interval time.Duration) error {
+	now := time.Now()
+	return r.db.Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-interval)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo() *AuditRepo {
+	return &AuditRepo{
+		db: app.GetDB(),
+	}
+}
+
+// AuditLogFilter chứa các điều kiện tìm kiếm nhật ký thao tác
+type AuditLogFilter struct {
+	ActorID    *uint
+	Action     string
+	EntityType string
+	EntityID   *uint
+	RequestID  string
+	Keyword    string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Create ghi một bản ghi nhật ký thao tác
+func (r *AuditRepo) Create(log *model.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+// GetByID lấy bản ghi nhật ký theo ID
+func (r *AuditRepo) GetByID(id uint) (*model.AuditLog, error) {
+	var log model.AuditLog
+	err := r.db.Preload("Actor").First(&log, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("audit log not found")
+		}
+		return nil, err
+	}
+	return &log, nil
+}
+
+// Search tìm kiếm nhật ký thao tác theo bộ lọc, có phân trang
+func (r *AuditRepo) Search(filter AuditLogFilter, page, limit int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := r.db.Model(&model.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Keyword != "" {
+		keyword := "%" + filter.Keyword + "%"
+		query = query.Where("(`before` LIKE ? OR `after` LIKE ?)", keyword, keyword)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	// Đếm tổng số bản ghi
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Tính offset
+	offset := (page - 1) * limit
+
+	// Lấy nhật ký kèm thông tin người thực hiện
+	err := query.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&logs).Error
+
+	return logs, total, err
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"backend/internal/consts"
+	"backend/internal/model"
+	"sync"
+	"time"
+)
+
+// Người dùng và quyền riêng được đọc trên mọi request đã xác thực nên được giữ trong bộ nhớ đệm ngắn hạn của tiến trình.
+// Các thao tác ghi qua repo xóa mục tương ứng ngay; thay đổi từ tiến trình khác có hiệu lực sau tối đa AUTH_CACHE_SECONDS.
+var (
+	authUsers       = newTTLCache[uint, model.User](time.Duration(consts.AUTH_CACHE_SECONDS) * time.Second)
+	userPermissions = newTTLCache[uint, map[string]string](time.Duration(consts.AUTH_CACHE_SECONDS) * time.Second)
+)
+
+// ttlCache là bộ nhớ đệm khóa-giá trị có thời hạn, an toàn khi dùng đồng thời
+type ttlCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[K]ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration) *ttlCache[K, V] {
+	return &ttlCache[K, V]{ttl: ttl, entries: make(map[K]ttlEntry[V])}
+}
+
+// get trả về giá trị còn hạn của key
+func (c *ttlCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// set lưu value cho key đến hết thời hạn; ttl <= 0 là tắt bộ nhớ đệm
+func (c *ttlCache[K, V]) set(key K, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// delete xóa các key khỏi bộ nhớ đệm
+func (c *ttlCache[K, V]) delete(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
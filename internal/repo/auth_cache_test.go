@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCache(t *testing.T) {
+	cache := newTTLCache[uint, string](time.Minute)
+
+	if _, ok := cache.get(1); ok {
+		t.Fatal("get on empty cache returned a value")
+	}
+
+	cache.set(1, "alice")
+	cache.set(2, "bob")
+	if got, ok := cache.get(1); !ok || got != "alice" {
+		t.Fatalf("get(1) = %q, %v, want alice, true", got, ok)
+	}
+
+	cache.delete(1, 3)
+	if _, ok := cache.get(1); ok {
+		t.Error("get(1) after delete returned a value")
+	}
+	if got, ok := cache.get(2); !ok || got != "bob" {
+		t.Errorf("get(2) = %q, %v, want bob, true", got, ok)
+	}
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	cache := newTTLCache[uint, int](time.Minute)
+	cache.set(1, 10)
+	cache.entries[1] = ttlEntry[int]{value: 10, expiresAt: time.Now().Add(-time.Second)}
+
+	if _, ok := cache.get(1); ok {
+		t.Error("get returned an expired value")
+	}
+	if _, exists := cache.entries[1]; exists {
+		t.Error("expired entry was not evicted")
+	}
+}
+
+func TestTTLCacheDisabled(t *testing.T) {
+	cache := newTTLCache[uint, int](0)
+	cache.set(1, 10)
+	if _, ok := cache.get(1); ok {
+		t.Error("cache with ttl 0 stored a value")
+	}
+}
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type BrandRepo struct {
+	db *gorm.DB
+}
+
+func NewBrandRepo() *BrandRepo {
+	return &BrandRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *BrandRepo) WithTx(tx *gorm.DB) *BrandRepo {
+	return &BrandRepo{db: tx}
+}
+
+// Create tạo mới một thương hiệu
+func (r *BrandRepo) Create(brand *model.Brand) error {
+	return r.db.Create(brand).Error
+}
+
+// GetByID lấy thương hiệu theo ID
+func (r *BrandRepo) GetByID(id uint) (*model.Brand, error) {
+	var brand model.Brand
+	err := r.db.First(&brand, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("brand not found")
+		}
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// GetActiveByID lấy thương hiệu đang hoạt động theo ID
+func (r *BrandRepo) GetActiveByID(id uint) (*model.Brand, error) {
+	var brand model.Brand
+	err := r.db.Where("is_active = ?", true).First(&brand, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("brand not found")
+		}
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// GetBySlug lấy thương hiệu đang hoạt động theo slug
+func (r *BrandRepo) GetBySlug(slug string) (*model.Brand, error) {
+	var brand model.Brand
+	err := r.db.Where("slug = ? AND is_active = ?", slug, true).First(&brand).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("brand not found")
+		}
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// GetAll lấy danh sách thương hiệu theo tên; activeOnly = false để lấy cả thương hiệu đã ẩn (trang quản trị)
+func (r *BrandRepo) GetAll(activeOnly bool) ([]model.Brand, error) {
+	var brands []model.Brand
+	query := r.db.Order("name ASC")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&brands).Error
+	return brands, err
+}
+
+// CountProducts đếm số sản phẩm đang bán theo từng thương hiệu
+func (r *BrandRepo) CountProducts(brandIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64)
+	if len(brandIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BrandID uint
+		Total   int64
+	}
+	err := r.db.Model(&model.Product{}).
+		Select("brand_id, COUNT(*) AS total").
+		Where("brand_id IN ? AND is_active = ?", brandIDs, true).
+		Group("brand_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.BrandID] = row.Total
+	}
+	return counts, nil
+}
+
+// Update cập nhật thương hiệu
+func (r *BrandRepo) Update(brand *model.Brand) error {
+	return r.db.Save(brand).Error
+}
+
+// Delete xóa mềm thương hiệu và gỡ thương hiệu khỏi các sản phẩm liên quan (kể cả sản phẩm trong thùng rác);
+// trả về ID các sản phẩm đã gỡ để đồng bộ chỉ mục tìm kiếm
+func (r *BrandRepo) Delete(id uint) ([]uint, error) {
+	var productIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.Product{}).Where("brand_id = ?", id).Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+		if len(productIDs) > 0 {
+			if err := tx.Unscoped().Model(&model.Product{}).Where("id IN ?", productIDs).Update("brand_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&model.Brand{}, id).Error
+	})
+	return productIDs, err
+}
+
+// CheckSlugExists kiểm tra slug đã tồn tại hay chưa (loại trừ thương hiệu có ID = excludeID).
+// Tính cả thương hiệu đã xóa mềm vì cột slug có ràng buộc unique.
+func (r *BrandRepo) CheckSlugExists(slug string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Unscoped().Model(&model.Brand{}).Where("slug = ?", slug)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
@@ -22,9 +22,9 @@ func NewCartRepo() *CartRepo {
 func (r *CartRepo) GetOrCreateCart(userID uint) (*model.Cart, error) {
 	var cart model.Cart
 	err := r.db.Where("user_id = ?", userID).First(&cart).Error
-	
+
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		 // Tạo giỏ hàng mới nếu chưa tồn tại
+		// Tạo giỏ hàng mới nếu chưa tồn tại
 		cart = model.Cart{UserID: userID}
 		if err := r.db.Create(&cart).Error; err != nil {
 			return nil, err
@@ -34,15 +34,15 @@ func (r *CartRepo) GetOrCreateCart(userID uint) (*model.Cart, error) {
 	}
 
 	// Nạp các mục giỏ hàng kèm thông tin sản phẩm
-	if err := r.db.Preload("CartItems").Preload("CartItems.Product").Where("id = ?", cart.ID).First(&cart).Error; err != nil {
+	if err := r.db.Preload("CartItems").Preload("CartItems.Product").Preload("CartItems.Variant").Where("id = ?", cart.ID).First(&cart).Error; err != nil {
 		return nil, err
 	}
 
 	return &cart, nil
 }
 
-// AddItem thêm mới hoặc cập nhật một mục trong giỏ hàng
-func (r *CartRepo) AddItem(userID, productID uint, quantity int) error {
+// AddItem thêm mới hoặc cập nhật một mục trong giỏ hàng (mỗi biến thể là một mục riêng)
+func (r *CartRepo) AddItem(userID, productID uint, variantID *uint, quantity int) error {
 	cart, err := r.GetOrCreateCart(userID)
 	if err != nil {
 		return err
@@ -50,13 +50,14 @@ func (r *CartRepo) AddItem(userID, productID uint, quantity int) error {
 
 	// Kiểm tra xem mục đã tồn tại chưa
 	var cartItem model.CartItem
-	err = r.db.Where("cart_id = ? AND product_id = ?", cart.ID, productID).First(&cartItem).Error
-	
+	err = r.db.Scopes(matchVariant(variantID)).Where("cart_id = ? AND product_id = ?", cart.ID, productID).First(&cartItem).Error
+
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// Tạo mục mới
 		cartItem = model.CartItem{
 			CartID:    cart.ID,
 			ProductID: productID,
+			VariantID: variantID,
 			Quantity:  quantity,
 		}
 		return r.db.Create(&cartItem).Error
@@ -70,29 +71,40 @@ func (r *CartRepo) AddItem(userID, productID uint, quantity int) error {
 }
 
 // UpdateItemQuantity cập nhật số lượng của mục trong giỏ
-func (r *CartRepo) UpdateItemQuantity(userID, productID uint, quantity int) error {
+func (r *CartRepo) UpdateItemQuantity(userID, productID uint, variantID *uint, quantity int) error {
 	cart, err := r.GetOrCreateCart(userID)
 	if err != nil {
 		return err
 	}
 
 	if quantity <= 0 {
-		return r.RemoveItem(userID, productID)
+		return r.RemoveItem(userID, productID, variantID)
 	}
 
 	return r.db.Model(&model.CartItem{}).
+		Scopes(matchVariant(variantID)).
 		Where("cart_id = ? AND product_id = ?", cart.ID, productID).
 		Update("quantity", quantity).Error
 }
 
 // RemoveItem xóa một mục khỏi giỏ hàng
-func (r *CartRepo) RemoveItem(userID, productID uint) error {
+func (r *CartRepo) RemoveItem(userID, productID uint, variantID *uint) error {
 	cart, err := r.GetOrCreateCart(userID)
 	if err != nil {
 		return err
 	}
 
-	return r.db.Where("cart_id = ? AND product_id = ?", cart.ID, productID).Delete(&model.CartItem{}).Error
+	return r.db.Scopes(matchVariant(variantID)).Where("cart_id = ? AND product_id = ?", cart.ID, productID).Delete(&model.CartItem{}).Error
+}
+
+// matchVariant lọc mục giỏ hàng theo biến thể (variant_id NULL với sản phẩm không có biến thể)
+func matchVariant(variantID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
 }
 
 // ClearCart xóa toàn bộ mục trong giỏ hàng
@@ -174,9 +186,29 @@ func (r *NewsRepo) GetAll(page, limit int, publishedOnly bool) ([]model.News, in
 	return news, total, err
 }
 
+// GetPublishedByIDs lấy các bài viết đã xuất bản theo danh sách ID (không giữ thứ tự của ids)
+func (r *NewsRepo) GetPublishedByIDs(ids []uint) ([]model.News, error) {
+	var news []model.News
+	if len(ids) == 0 {
+		return news, nil
+	}
+	err := r.db.Preload("Author").Where("id IN ? AND is_published = ?", ids, true).Find(&news).Error
+	return news, err
+}
+
+// EachPublished duyệt tất cả bài viết đã xuất bản theo lô, dùng khi dựng lại chỉ mục tìm kiếm
+func (r *NewsRepo) EachPublished(batchSize int, fn func([]model.News) error) error {
+	var news []model.News
+	return r.db.Where("is_published = ?", true).
+		FindInBatches(&news, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(news)
+		}).Error
+}
+
 // Update cập nhật một bài viết tin tức
 func (r *NewsRepo) Update(news *model.News) error {
-	return r.db.Save(news).Error
+	// Ảnh dẫn xuất do worker ghi riêng, không ghi đè bằng dữ liệu cũ đã đọc trước đó
+	return r.db.Omit("Variants", "VariantStatus").Save(news).Error
 }
 
 // Delete xóa mềm một bài viết tin tức
@@ -189,6 +221,38 @@ func (r *NewsRepo) IncrementViewCount(id uint) error {
 	return r.db.Model(&model.News{}).Where("id = ?", id).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error
 }
 
+// UpdateImage gắn ảnh đại diện đã tải lên cho bài viết, ảnh dẫn xuất sẽ được tạo lại
+func (r *NewsRepo) UpdateImage(id uint, imageURL, imageKey string) error {
+	return r.db.Model(&model.News{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image_url":      imageURL,
+		"image_key":      imageKey,
+		"variants":       "",
+		"variant_status": model.ImageVariantPending,
+	}).Error
+}
+
+// ClearImageVariants bỏ liên kết ảnh đã tải lên khi bài viết chuyển sang dùng ảnh bên ngoài
+func (r *NewsRepo) ClearImageVariants(id uint) error {
+	return r.db.Model(&model.News{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image_key":      "",
+		"variants":       "",
+		"variant_status": "",
+	}).Error
+}
+
+// GetPendingImageIDs lấy ID các bài viết có ảnh đang chờ tạo ảnh dẫn xuất
+func (r *NewsRepo) GetPendingImageIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.News{}).Where("variant_status = ?", model.ImageVariantPending).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateImageVariants lưu ảnh dẫn xuất, chỉ khi ảnh đại diện chưa bị thay bằng ảnh khác trong lúc xử lý
+func (r *NewsRepo) UpdateImageVariants(id uint, imageKey, variants, status string) error {
+	return r.db.Model(&model.News{}).Where("id = ? AND image_key = ?", id, imageKey).
+		Updates(map[string]interface{}{"variants": variants, "variant_status": status}).Error
+}
+
 // CheckSlugExists kiểm tra slug đã tồn tại hay chưa
 func (r *NewsRepo) CheckSlugExists(slug string, excludeID uint) (bool, error) {
 	var count int64
@@ -12,12 +12,37 @@ type CategoryRepo struct {
 	db *gorm.DB
 }
 
+// Cách xử lý sản phẩm khi xóa danh mục
+const (
+	CategoryDeleteRestrict = "restrict" // Từ chối xóa nếu danh mục còn sản phẩm
+	CategoryDeleteReassign = "reassign" // Chuyển sản phẩm sang danh mục khác
+	CategoryDeleteDetach   = "detach"   // Bỏ danh mục khỏi sản phẩm (category_id = NULL)
+)
+
+// CategoryDeleteModes là danh sách cách xóa danh mục hợp lệ
+var CategoryDeleteModes = []string{CategoryDeleteRestrict, CategoryDeleteReassign, CategoryDeleteDetach}
+
+// CategoryDeleteResult cho biết số sản phẩm và danh mục con bị ảnh hưởng khi xóa danh mục
+type CategoryDeleteResult struct {
+	Mode             string `json:"mode"`
+	TargetCategoryID *uint  `json:"target_category_id"`
+	ProductCount     int64  `json:"product_count"` // Số sản phẩm còn trong danh mục khi xóa bị từ chối (restrict)
+	ProductsMoved    int    `json:"products_moved"`
+	ChildrenMoved    int64  `json:"children_moved"`
+	ProductIDs       []uint `json:"-"`
+}
+
 func NewCategoryRepo() *CategoryRepo {
 	return &CategoryRepo{
 		db: app.GetDB(),
 	}
 }
 
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *CategoryRepo) WithTx(tx *gorm.DB) *CategoryRepo {
+	return &CategoryRepo{db: tx}
+}
+
 // Create tạo mới một danh mục
 func (r *CategoryRepo) Create(category *model.Category) error {
 	return r.db.Create(category).Error
@@ -33,6 +58,9 @@ func (r *CategoryRepo) GetByID(id uint) (*model.Category, error) {
 		}
 		return nil, err
 	}
+	if err := loadCategoryPaths(r.db, &category); err != nil {
+		return nil, err
+	}
 	return &category, nil
 }
 
@@ -46,21 +74,115 @@ func (r *CategoryRepo) GetBySlug(slug string) (*model.Category, error) {
 		}
 		return nil, err
 	}
+	if err := loadCategoryPaths(r.db, &category); err != nil {
+		return nil, err
+	}
 	return &category, nil
 }
 
-// GetAll lấy tất cả danh mục đang hoạt động (is_active = true)
+// GetAll lấy tất cả danh mục đang hoạt động (is_active = true) kèm breadcrumb
 func (r *CategoryRepo) GetAll() ([]model.Category, error) {
 	var categories []model.Category
-	err := r.db.Where("is_active = ?", true).Find(&categories).Error
-	return categories, err
+	if err := r.db.Where("is_active = ?", true).Scopes(orderCategories).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, loadCategoryPaths(r.db, categoryPointers(categories)...)
 }
 
 // GetAllWithProducts lấy tất cả danh mục kèm theo danh sách sản phẩm
 func (r *CategoryRepo) GetAllWithProducts() ([]model.Category, error) {
 	var categories []model.Category
-	err := r.db.Preload("Products").Where("is_active = ?", true).Find(&categories).Error
-	return categories, err
+	if err := r.db.Preload("Products").Where("is_active = ?", true).Scopes(orderCategories).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, loadCategoryPaths(r.db, categoryPointers(categories)...)
+}
+
+// GetTree lấy danh mục để dựng cây; activeOnly = false để lấy cả danh mục đã ẩn (trang quản trị)
+func (r *CategoryRepo) GetTree(activeOnly bool) ([]model.CategoryResponse, error) {
+	var categories []model.Category
+	query := r.db.Model(&model.Category{})
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return model.BuildCategoryTree(categories), nil
+}
+
+// IsDescendant kiểm tra categoryID có nằm trong nhánh con của ancestorID hay không (kể cả chính nó)
+func (r *CategoryRepo) IsDescendant(ancestorID, categoryID uint) (bool, error) {
+	parents, err := r.parentMap()
+	if err != nil {
+		return false, err
+	}
+
+	visited := make(map[uint]bool)
+	for current := categoryID; current != 0 && !visited[current]; current = parents[current] {
+		if current == ancestorID {
+			return true, nil
+		}
+		visited[current] = true
+	}
+	return false, nil
+}
+
+// GetDescendantIDs lấy ID danh mục và toàn bộ danh mục con cháu đang hoạt động (nhánh của danh mục đã ẩn bị bỏ qua)
+func (r *CategoryRepo) GetDescendantIDs(id uint) ([]uint, error) {
+	return r.descendantIDs(id, true)
+}
+
+// GetSubtreeIDs lấy ID danh mục và toàn bộ danh mục con cháu, kể cả danh mục đã ẩn
+func (r *CategoryRepo) GetSubtreeIDs(id uint) ([]uint, error) {
+	return r.descendantIDs(id, false)
+}
+
+func (r *CategoryRepo) descendantIDs(id uint, activeOnly bool) ([]uint, error) {
+	var categories []model.Category
+	query := r.db.Select("id", "parent_id")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]uint)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	ids := []uint{id}
+	visited := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// parentMap trả về map ID danh mục -> ID danh mục cha (0 nếu là gốc)
+func (r *CategoryRepo) parentMap() (map[uint]uint, error) {
+	var categories []model.Category
+	if err := r.db.Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[uint]uint, len(categories))
+	for _, category := range categories {
+		if category.ParentID != nil {
+			parents[category.ID] = *category.ParentID
+		} else {
+			parents[category.ID] = 0
+		}
+	}
+	return parents, nil
 }
 
 // Update cập nhật danh mục
@@ -68,9 +190,81 @@ func (r *CategoryRepo) Update(category *model.Category) error {
 	return r.db.Save(category).Error
 }
 
-// Delete xóa mềm một danh mục
-func (r *CategoryRepo) Delete(id uint) error {
-	return r.db.Delete(&model.Category{}, id).Error
+// Delete xóa mềm một danh mục theo cách xử lý sản phẩm đã chọn (targetID chỉ dùng với reassign);
+// các danh mục con được chuyển lên danh mục cha của nó.
+// Xóa mềm không kích hoạt ràng buộc SET NULL nên sản phẩm phải được chuyển hoặc gỡ danh mục tại đây.
+func (r *CategoryRepo) Delete(id uint, mode string, targetID *uint) (*CategoryDeleteResult, error) {
+	result := &CategoryDeleteResult{Mode: mode, TargetCategoryID: targetID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var category model.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return err
+		}
+
+		switch mode {
+		case CategoryDeleteRestrict:
+			var count int64
+			if err := tx.Model(&model.Product{}).Where("category_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				result.ProductCount = count
+				return errors.New("category has products")
+			}
+		case CategoryDeleteReassign, CategoryDeleteDetach:
+			if mode == CategoryDeleteDetach {
+				targetID = nil
+			}
+			productIDs, err := moveProducts(tx, id, targetID, nil)
+			if err != nil {
+				return err
+			}
+			result.ProductIDs = productIDs
+			result.ProductsMoved = len(productIDs)
+		default:
+			return errors.New("invalid delete mode")
+		}
+
+		children := tx.Model(&model.Category{}).Where("parent_id = ?", id).Update("parent_id", category.ParentID)
+		if children.Error != nil {
+			return children.Error
+		}
+		result.ChildrenMoved = children.RowsAffected
+
+		return tx.Delete(&model.Category{}, id).Error
+	})
+	return result, err
+}
+
+// MoveProducts chuyển sản phẩm từ danh mục fromID sang toID (nil để bỏ danh mục);
+// productIDs rỗng nghĩa là chuyển tất cả sản phẩm của danh mục. Trả về ID các sản phẩm đã chuyển.
+func (r *CategoryRepo) MoveProducts(fromID uint, toID *uint, productIDs []uint) ([]uint, error) {
+	var moved []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		moved, err = moveProducts(tx, fromID, toID, productIDs)
+		return err
+	})
+	return moved, err
+}
+
+// moveProducts chuyển cả sản phẩm đã xóa mềm để khi khôi phục chúng không trỏ tới danh mục đã xóa
+func moveProducts(tx *gorm.DB, fromID uint, toID *uint, productIDs []uint) ([]uint, error) {
+	query := tx.Unscoped().Model(&model.Product{}).Where("category_id = ?", fromID)
+	if len(productIDs) > 0 {
+		query = query.Where("id IN ?", productIDs)
+	}
+
+	var ids []uint
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	err := tx.Unscoped().Model(&model.Product{}).Where("id IN ?", ids).Update("category_id", toID).Error
+	return ids, err
 }
 
 // CheckSlugExists kiểm tra slug đã tồn tại hay chưa (loại trừ danh mục có ID = excludeID)
@@ -83,3 +277,43 @@ func (r *CategoryRepo) CheckSlugExists(slug string, excludeID uint) (bool, error
 	err := query.Count(&count).Error
 	return count > 0, err
 }
+
+// loadCategoryPaths gán breadcrumb cho các danh mục (bỏ qua nil) bằng một truy vấn duy nhất trên bảng danh mục
+func loadCategoryPaths(db *gorm.DB, categories ...*model.Category) error {
+	needed := false
+	for _, category := range categories {
+		if category != nil {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil
+	}
+
+	var all []model.Category
+	if err := db.Select("id", "parent_id", "name", "slug").Find(&all).Error; err != nil {
+		return err
+	}
+
+	paths := model.CategoryPaths(all)
+	for _, category := range categories {
+		if category != nil {
+			category.Path = paths[category.ID]
+		}
+	}
+	return nil
+}
+
+func categoryPointers(categories []model.Category) []*model.Category {
+	pointers := make([]*model.Category, len(categories))
+	for i := range categories {
+		pointers[i] = &categories[i]
+	}
+	return pointers
+}
+
+// orderCategories sắp xếp danh mục theo thứ tự hiển thị
+func orderCategories(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, name ASC")
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveProductsSelectsSoftDeletedProducts(t *testing.T) {
+	tests := []struct {
+		name       string
+		productIDs []uint
+		wantSQL    string
+		wantVars   []interface{}
+	}{
+		{
+			name:     "every product of the category",
+			wantSQL:  "SELECT `id` FROM `products` WHERE category_id = ?",
+			wantVars: []interface{}{uint(4)},
+		},
+		{
+			name:       "selected products only",
+			productIDs: []uint{7, 8},
+			wantSQL:    "SELECT `id` FROM `products` WHERE category_id = ? AND id IN (?,?)",
+			wantVars:   []interface{}{uint(4), uint(7), uint(8)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dryRunDB(t)
+			statements := recordStatements(t, db)
+			// Truy vấn DryRun không trả về dòng nào nên chỉ có câu lệnh chọn sản phẩm được dựng
+			if _, err := moveProducts(db, 4, nil, tt.productIDs); err != nil {
+				t.Fatal(err)
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("statements = %v, want 1", *statements)
+			}
+			got := (*statements)[0]
+			if got.SQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", got.SQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(got.Vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", got.Vars, tt.wantVars)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobRepo struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepo() *ImportJobRepo {
+	return &ImportJobRepo{
+		db: app.GetDB(),
+	}
+}
+
+// Create tạo mới một job nhập sản phẩm
+func (r *ImportJobRepo) Create(job *model.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// GetByID lấy job nhập sản phẩm theo ID
+func (r *ImportJobRepo) GetByID(id uint) (*model.ImportJob, error) {
+	var job model.ImportJob
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("import job not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAll lấy danh sách job nhập có phân trang, mới nhất trước (không tải báo cáo lỗi)
+func (r *ImportJobRepo) GetAll(page, limit int) ([]model.ImportJob, int64, error) {
+	var jobs []model.ImportJob
+	var total int64
+
+	if err := r.db.Model(&model.ImportJob{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := r.db.Omit("Errors", "Columns").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+// MarkRunning chuyển job sang trạng thái đang xử lý
+func (r *ImportJobRepo) MarkRunning(id uint) error {
+	now := time.Now()
+	return r.db.Model(&model.ImportJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     consts.ImportJobRunning,
+		"started_at": &now,
+	}).Error
+}
+
+// UpdateProgress lưu tiến độ xử lý của job
+func (r *ImportJobRepo) UpdateProgress(job *model.ImportJob) error {
+	return r.db.Model(&model.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"processed_rows":  job.ProcessedRows,
+		"created_count":   job.CreatedCount,
+		"updated_count":   job.UpdatedCount,
+		"unchanged_count": job.UnchangedCount,
+		"failed_count":    job.FailedCount,
+	}).Error
+}
+
+// Finish lưu kết quả cuối cùng của job (trạng thái, số lượng, báo cáo lỗi)
+func (r *ImportJobRepo) Finish(job *model.ImportJob) error {
+	now := time.Now()
+	job.FinishedAt = &now
+	return r.db.Model(&model.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":          job.Status,
+		"processed_rows":  job.ProcessedRows,
+		"created_count":   job.CreatedCount,
+		"updated_count":   job.UpdatedCount,
+		"unchanged_count": job.UnchangedCount,
+		"failed_count":    job.FailedCount,
+		"errors":          job.Errors,
+		"message":         job.Message,
+		"finished_at":     job.FinishedAt,
+	}).Error
+}
+
+// FailInterrupted đánh dấu thất bại các job chưa xong khi máy chủ dừng (dữ liệu tệp chỉ nằm trong bộ nhớ nên không thể chạy tiếp)
+func (r *ImportJobRepo) FailInterrupted() (int64, error) {
+	now := time.Now()
+	result := r.db.Model(&model.ImportJob{}).
+		Where("status IN ?", []string{consts.ImportJobPending, consts.ImportJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      consts.ImportJobFailed,
+			"message":     "interrupted by server restart",
+			"finished_at": &now,
+		})
+	return result.RowsAffected, result.Error
+}
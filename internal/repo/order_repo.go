@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepo struct {
@@ -20,6 +21,11 @@ func NewOrderRepo() *OrderRepo {
 	}
 }
 
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *OrderRepo) WithTx(tx *gorm.DB) *OrderRepo {
+	return &OrderRepo{db: tx}
+}
+
 // Create tạo mới một đơn hàng
 func (r *OrderRepo) Create(order *model.Order) error {
 	return r.db.Create(order).Error
@@ -29,6 +35,7 @@ func (r *OrderRepo) Create(order *model.Order) error {
 func (r *OrderRepo) GetByID(id uint) (*model.Order, error) {
 	var order model.Order
 	err := r.db.Preload("User").
+		Preload("Warehouse").
 		Preload("OrderItems").
 		Preload("OrderItems.Product").
 		First(&order, id).Error
@@ -41,6 +48,22 @@ func (r *OrderRepo) GetByID(id uint) (*model.Order, error) {
 	return &order, nil
 }
 
+// LockByID lấy đơn hàng kèm các dòng đơn và khóa dòng đơn hàng (SELECT ... FOR UPDATE) để đổi trạng thái
+// trong giao dịch
+func (r *OrderRepo) LockByID(id uint) (*model.Order, error) {
+	var order model.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("OrderItems").
+		First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
 // GetByOrderNumber lấy đơn hàng theo mã đơn
 func (r *OrderRepo) GetByOrderNumber(orderNumber string) (*model.Order, error) {
 	var order model.Order
@@ -243,4 +266,32 @@ func (r *OrderRepo) GetGuestOrderStats() (map[string]interface{}, error) {
 	stats["guest_revenue"] = guestRevenue
 
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// LockOutstandingBackorders khóa và trả về các dòng đơn còn chờ hàng về của đơn chưa hủy, đơn cũ trước
+func (r *OrderRepo) LockOutstandingBackorders() ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.backordered_quantity > 0 AND orders.status <> ?", "cancelled").
+		Order("orders.created_at ASC, orders.id ASC, order_items.id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// SetBackorderedQuantity cập nhật số lượng còn chờ hàng về của một dòng đơn
+func (r *OrderRepo) SetBackorderedQuantity(itemID uint, quantity int) error {
+	return r.db.Model(&model.OrderItem{}).Where("id = ?", itemID).UpdateColumn("backordered_quantity", quantity).Error
+}
+
+// ClearAwaitingStock bỏ đánh dấu chờ hàng của các đơn không còn dòng nào chờ hàng về; trả về số đơn đã cập nhật
+func (r *OrderRepo) ClearAwaitingStock(orderIDs []uint) (int64, error) {
+	if len(orderIDs) == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&model.Order{}).
+		Where("id IN ? AND awaiting_stock = ?", orderIDs, true).
+		Where("NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.backordered_quantity > 0)").
+		Update("awaiting_stock", false)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OwnerTransferRepo struct {
+	db *gorm.DB
+}
+
+func NewOwnerTransferRepo() *OwnerTransferRepo {
+	return &OwnerTransferRepo{
+		db: app.GetDB(),
+	}
+}
+
+// Create tạo mới một yêu cầu chuyển quyền owner
+func (r *OwnerTransferRepo) Create(transfer *model.OwnerTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+// GetByID lấy yêu cầu chuyển quyền theo ID kèm thông tin hai bên
+func (r *OwnerTransferRepo) GetByID(id uint) (*model.OwnerTransfer, error) {
+	var transfer model.OwnerTransfer
+	err := r.db.Preload("FromUser").Preload("ToUser").First(&transfer, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("owner transfer not found")
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetPending lấy yêu cầu đang chờ xác nhận theo người gửi hoặc người nhận
+func (r *OwnerTransferRepo) GetPending(fromUserID, toUserID uint) (*model.OwnerTransfer, error) {
+	if err := r.ExpireStale(); err != nil {
+		return nil, err
+	}
+
+	query := r.db.Preload("FromUser").Preload("ToUser").Where("status = ?", consts.OwnerTransferPending)
+	if fromUserID > 0 {
+		query = query.Where("from_user_id = ?", fromUserID)
+	}
+	if toUserID > 0 {
+		query = query.Where("to_user_id = ?", toUserID)
+	}
+
+	var transfer model.OwnerTransfer
+	err := query.Order("created_at DESC").First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("owner transfer not found")
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// ExpireStale đánh dấu hết hạn các yêu cầu quá thời gian chờ
+func (r *OwnerTransferRepo) ExpireStale() error {
+	return r.db.Model(&model.OwnerTransfer{}).
+		Where("status = ? AND expires_at < ?", consts.OwnerTransferPending, time.Now()).
+		Update("status", consts.OwnerTransferExpired).Error
+}
+
+// CancelPendingFrom hủy tất cả yêu cầu đang chờ của người gửi
+func (r *OwnerTransferRepo) CancelPendingFrom(fromUserID uint) error {
+	now := time.Now()
+	return r.db.Model(&model.OwnerTransfer{}).
+		Where("from_user_id = ? AND status = ?", fromUserID, consts.OwnerTransferPending).
+		Updates(map[string]interface{}{"status": consts.OwnerTransferCancelled, "responded_at": &now}).Error
+}
+
+// UpdateStatus cập nhật trạng thái của yêu cầu đang chờ
+func (r *OwnerTransferRepo) UpdateStatus(id uint, status string) error {
+	now := time.Now()
+	return r.db.Model(&model.OwnerTransfer{}).
+		Where("id = ? AND status = ?", id, consts.OwnerTransferPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": &now}).Error
+}
+
+// Complete hoàn tất chuyển quyền: người nhận thành owner, owner cũ trở thành admin (trong một transaction)
+func (r *OwnerTransferRepo) Complete(transfer *model.OwnerTransfer) error {
+	// Vai trò và quyền riêng của cả hai người thay đổi: bỏ bộ nhớ đệm sau khi giao dịch kết thúc
+	defer func() {
+		authUsers.delete(transfer.FromUserID, transfer.ToUserID)
+		userPermissions.delete(transfer.ToUserID)
+	}()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Khóa và kiểm tra lại trạng thái để tránh xác nhận trùng
+		var current model.OwnerTransfer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, transfer.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != consts.OwnerTransferPending || current.ExpiresAt.Before(time.Now()) {
+			return errors.New("owner transfer is no longer pending")
+		}
+
+		// Người gửi phải vẫn đang là owner
+		var fromUser model.User
+		if err := tx.First(&fromUser, current.FromUserID).Error; err != nil {
+			return err
+		}
+		if fromUser.Role != consts.RoleOwner {
+			return errors.New("sender is no longer the owner")
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", current.FromUserID).Update("role", consts.RoleAdmin).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", current.ToUserID).Update("role", consts.RoleOwner).Error; err != nil {
+			return err
+		}
+
+		// Owner mới luôn có toàn quyền nên xóa các phân quyền riêng cũ
+		if err := tx.Where("user_id = ?", current.ToUserID).Delete(&model.UserPermission{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&model.OwnerTransfer{}).Where("id = ?", current.ID).
+			Updates(map[string]interface{}{"status": consts.OwnerTransferCompleted, "responded_at": &now}).Error
+	})
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PermissionRepo struct {
+	db *gorm.DB
+}
+
+func NewPermissionRepo() *PermissionRepo {
+	return &PermissionRepo{
+		db: app.GetDB(),
+	}
+}
+
+// Upsert tạo mới hoặc cập nhật quyền của người dùng trên một tài nguyên
+func (r *PermissionRepo) Upsert(permission *model.UserPermission) error {
+	defer userPermissions.delete(permission.UserID)
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "resource"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_by", "updated_at"}),
+	}).Create(permission).Error
+}
+
+// Get lấy quyền riêng của người dùng trên một tài nguyên
+func (r *PermissionRepo) Get(userID uint, resource string) (*model.UserPermission, error) {
+	var permission model.UserPermission
+	err := r.db.Where("user_id = ? AND resource = ?", userID, resource).First(&permission).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("permission not found")
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// GetByUserID lấy tất cả quyền riêng của người dùng
+func (r *PermissionRepo) GetByUserID(userID uint) ([]model.UserPermission, error) {
+	var permissions []model.UserPermission
+	err := r.db.Where("user_id = ?", userID).Order("resource ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// Delete thu hồi quyền riêng của người dùng trên một tài nguyên
+func (r *PermissionRepo) Delete(userID uint, resource string) (bool, error) {
+	defer userPermissions.delete(userID)
+	result := r.db.Where("user_id = ? AND resource = ?", userID, resource).Delete(&model.UserPermission{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetEffectiveLevel trả về cấp độ quyền thực tế của người dùng trên tài nguyên.
+// Owner luôn có toàn quyền; các vai trò khác dùng quyền riêng nếu có, ngược lại dùng mặc định theo vai trò.
+func (r *PermissionRepo) GetEffectiveLevel(userID uint, role, resource string) (string, bool, error) {
+	if role == consts.RoleOwner {
+		return consts.PermissionFull, true, nil
+	}
+
+	levels, err := r.levels(userID)
+	if err != nil {
+		return consts.PermissionNone, false, err
+	}
+	if level, exists := levels[resource]; exists {
+		return level, false, nil
+	}
+
+	level, exists := consts.DefaultRolePermissions[role]
+	if !exists {
+		level = consts.PermissionNone
+	}
+	return level, true, nil
+}
+
+// levels trả về quyền riêng của người dùng theo tài nguyên; các dòng quyền được đọc một lần rồi giữ trong bộ nhớ
+// đệm ngắn hạn để kiểm tra quyền trên mỗi request không phải truy vấn lại
+func (r *PermissionRepo) levels(userID uint) (map[string]string, error) {
+	if levels, ok := userPermissions.get(userID); ok {
+		return levels, nil
+	}
+	permissions, err := r.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[string]string, len(permissions))
+	for _, permission := range permissions {
+		levels[permission.Resource] = permission.Permission
+	}
+	userPermissions.set(userID, levels)
+	return levels, nil
+}
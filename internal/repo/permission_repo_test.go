@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"backend/internal/consts"
+	"testing"
+)
+
+// Các test dùng quyền riêng đã có trong bộ nhớ đệm nên không cần cơ sở dữ liệu
+func TestGetEffectiveLevel(t *testing.T) {
+	const memberID, adminID, unknownID uint = 9001, 9002, 9003
+	userPermissions.set(memberID, map[string]string{consts.ResourceProducts: consts.PermissionWrite})
+	userPermissions.set(adminID, map[string]string{consts.ResourceUsers: consts.PermissionRead})
+	userPermissions.set(unknownID, map[string]string{})
+	defer userPermissions.delete(memberID, adminID, unknownID)
+
+	tests := []struct {
+		name        string
+		userID      uint
+		role        string
+		resource    string
+		wantLevel   string
+		wantDefault bool
+	}{
+		{"owner always has full access", 0, consts.RoleOwner, consts.ResourceOrders, consts.PermissionFull, true},
+		{"member override", memberID, consts.RoleMember, consts.ResourceProducts, consts.PermissionWrite, false},
+		{"member falls back to role default", memberID, consts.RoleMember, consts.ResourceOrders, consts.PermissionNone, true},
+		{"admin override lowers access", adminID, consts.RoleAdmin, consts.ResourceUsers, consts.PermissionRead, false},
+		{"admin role default", adminID, consts.RoleAdmin, consts.ResourceProducts, consts.PermissionFull, true},
+		{"unknown role has no access", unknownID, "guest", consts.ResourceProducts, consts.PermissionNone, true},
+	}
+	repo := &PermissionRepo{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, isDefault, err := repo.GetEffectiveLevel(tt.userID, tt.role, tt.resource)
+			if err != nil {
+				t.Fatalf("GetEffectiveLevel returned error: %v", err)
+			}
+			if level != tt.wantLevel || isDefault != tt.wantDefault {
+				t.Errorf("GetEffectiveLevel = %q, %v, want %q, %v", level, isDefault, tt.wantLevel, tt.wantDefault)
+			}
+		})
+	}
+}
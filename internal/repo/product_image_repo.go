@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductImageRepo struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepo() *ProductImageRepo {
+	return &ProductImageRepo{
+		db: app.GetDB(),
+	}
+}
+
+// Create thêm ảnh vào cuối thư viện ảnh của sản phẩm; ảnh đầu tiên tự động là ảnh chính
+func (r *ProductImageRepo) Create(image *model.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Khóa sản phẩm để các lượt tải lên đồng thời không cùng trở thành ảnh chính
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.Product{}, image.ProductID).Error; err != nil {
+			return err
+		}
+
+		var stats struct {
+			Total    int64
+			MaxOrder int
+			Primary  int64
+		}
+		err := tx.Model(&model.ProductImage{}).
+			Select("COUNT(*) AS total, COALESCE(MAX(sort_order), -1) AS max_order, COALESCE(SUM(CASE WHEN is_primary THEN 1 ELSE 0 END), 0) AS `primary`").
+			Where("product_id = ?", image.ProductID).
+			Scan(&stats).Error
+		if err != nil {
+			return err
+		}
+
+		image.SortOrder = stats.MaxOrder + 1
+		image.IsPrimary = stats.Primary == 0
+		return tx.Create(image).Error
+	})
+}
+
+// GetByID lấy ảnh theo ID và ID sản phẩm
+func (r *ProductImageRepo) GetByID(productID, imageID uint) (*model.ProductImage, error) {
+	var image model.ProductImage
+	err := r.db.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product image not found")
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// FindByID lấy ảnh theo ID (không cần biết sản phẩm, dùng cho worker xử lý nền)
+func (r *ProductImageRepo) FindByID(id uint) (*model.ProductImage, error) {
+	var image model.ProductImage
+	err := r.db.First(&image, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product image not found")
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// GetPendingVariantIDs lấy ID các ảnh đang chờ tạo ảnh dẫn xuất
+func (r *ProductImageRepo) GetPendingVariantIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.ProductImage{}).Where("variant_status = ?", model.ImageVariantPending).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateVariants lưu danh sách ảnh dẫn xuất và trạng thái xử lý
+func (r *ProductImageRepo) UpdateVariants(id uint, variants, status string) error {
+	return r.db.Model(&model.ProductImage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"variants": variants, "variant_status": status}).Error
+}
+
+// GetByProductID lấy tất cả ảnh của sản phẩm theo thứ tự hiển thị
+func (r *ProductImageRepo) GetByProductID(productID uint) ([]model.ProductImage, error) {
+	var images []model.ProductImage
+	err := r.db.Where("product_id = ?", productID).Order("sort_order ASC, id ASC").Find(&images).Error
+	return images, err
+}
+
+// CountByProductID đếm số ảnh của sản phẩm
+func (r *ProductImageRepo) CountByProductID(productID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ProductImage{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// Update cập nhật ảnh
+func (r *ProductImageRepo) Update(image *model.ProductImage) error {
+	// Ảnh dẫn xuất do worker ghi riêng, không ghi đè bằng dữ liệu cũ đã đọc trước đó
+	return r.db.Omit("Variants", "VariantStatus").Save(image).Error
+}
+
+// SetPrimary đặt ảnh chính, đảm bảo mỗi sản phẩm chỉ có một ảnh chính
+func (r *ProductImageRepo) SetPrimary(productID, imageID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ProductImage{}).Where("product_id = ? AND id != ?", productID, imageID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ProductImage{}).Where("product_id = ? AND id = ?", productID, imageID).Update("is_primary", true).Error
+	})
+}
+
+// Reorder cập nhật sort_order theo thứ tự danh sách ID
+func (r *ProductImageRepo) Reorder(productID uint, imageIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for index, imageID := range imageIDs {
+			if err := tx.Model(&model.ProductImage{}).Where("product_id = ? AND id = ?", productID, imageID).Update("sort_order", index).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete xóa hẳn ảnh (tệp cũng bị xóa khỏi kho lưu trữ); nếu là ảnh chính thì ảnh kế tiếp trở thành ảnh chính
+func (r *ProductImageRepo) Delete(image *model.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&model.ProductImage{}, image.ID).Error; err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+
+		var next model.ProductImage
+		err := tx.Where("product_id = ?", image.ProductID).Order("sort_order ASC, id ASC").First(&next).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+}
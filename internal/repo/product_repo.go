@@ -4,20 +4,72 @@ import (
 	"backend/app"
 	"backend/internal/model"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepo struct {
 	db *gorm.DB
 }
 
+// Kiểu sắp xếp danh sách sản phẩm
+const (
+	ProductSortNewest      = "newest"
+	ProductSortPriceAsc    = "price_asc"
+	ProductSortPriceDesc   = "price_desc"
+	ProductSortNameAsc     = "name_asc"
+	ProductSortNameDesc    = "name_desc"
+	ProductSortRating      = "rating"
+	ProductSortBestSelling = "best_selling"
+)
+
+// ProductSorts là danh sách kiểu sắp xếp hợp lệ
+var ProductSorts = []string{
+	ProductSortNewest,
+	ProductSortPriceAsc,
+	ProductSortPriceDesc,
+	ProductSortNameAsc,
+	ProductSortNameDesc,
+	ProductSortRating,
+	ProductSortBestSelling,
+}
+
+// Mốc giá (VND) chia khoảng giá cho facet: <200k, 200k-500k, 500k-1tr, 1tr-2tr, >=2tr
+var priceFacetBounds = []float64{200000, 500000, 1000000, 2000000}
+
+// ProductFilter chứa các điều kiện lọc danh sách sản phẩm đang hoạt động.
+// Màu sắc, chất liệu và kích thước lọc theo thuộc tính của sản phẩm (không theo tùy chọn biến thể).
+type ProductFilter struct {
+	Search      string
+	CategoryIDs []uint
+	BrandIDs    []uint
+	MinPrice    *float64
+	MaxPrice    *float64
+	Colors      []string
+	Materials   []string
+	Sizes       []string
+	Featured    *bool
+	InStock     bool
+	Active      *bool // nil: chỉ sản phẩm đang hoạt động (mặc định của danh sách công khai)
+	Sort        string
+}
+
 func NewProductRepo() *ProductRepo {
 	return &ProductRepo{
 		db: app.GetDB(),
 	}
 }
 
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *ProductRepo) WithTx(tx *gorm.DB) *ProductRepo {
+	return &ProductRepo{db: tx}
+}
+
 // Create tạo mới một sản phẩm
 func (r *ProductRepo) Create(product *model.Product) error {
 	return r.db.Create(product).Error
@@ -26,79 +78,355 @@ func (r *ProductRepo) Create(product *model.Product) error {
 // GetByID lấy sản phẩm theo ID kèm danh mục
 func (r *ProductRepo) GetByID(id uint) (*model.Product, error) {
 	var product model.Product
-	err := r.db.Preload("Category").First(&product, id).Error
+	err := r.db.Preload("Category").Preload("Brand").Scopes(preloadProductDetails).First(&product, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("product not found")
 		}
 		return nil, err
 	}
+	if err := loadCategoryPaths(r.db, product.Category); err != nil {
+		return nil, err
+	}
 	return &product, nil
 }
 
 // GetBySKU lấy sản phẩm theo SKU
 func (r *ProductRepo) GetBySKU(sku string) (*model.Product, error) {
 	var product model.Product
-	err := r.db.Preload("Category").Where("sku = ?", sku).First(&product).Error
+	err := r.db.Preload("Category").Preload("Brand").Scopes(preloadProductDetails).Where("sku = ?", sku).First(&product).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("product not found")
 		}
 		return nil, err
 	}
+	if err := loadCategoryPaths(r.db, product.Category); err != nil {
+		return nil, err
+	}
 	return &product, nil
 }
 
-// GetAll lấy tất cả sản phẩm đang hoạt động (có phân trang)
-func (r *ProductRepo) GetAll(page, limit int) ([]model.Product, int64, error) {
+// GetActiveByIDs lấy các sản phẩm đang hoạt động theo danh sách ID (không giữ thứ tự của ids)
+func (r *ProductRepo) GetActiveByIDs(ids []uint) ([]model.Product, error) {
 	var products []model.Product
-	var total int64
-
-	// Đếm tổng số bản ghi
-	if err := r.db.Model(&model.Product{}).Where("is_active = ?", true).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if len(ids) == 0 {
+		return products, nil
+	}
+	err := r.db.Preload("Category").Preload("Brand").Scopes(preloadProductDetails).
+		Where("id IN ? AND is_active = ?", ids, true).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
 	}
+	return products, loadCategoryPaths(r.db, productCategories(products)...)
+}
 
-	// Tính offset
-	offset := (page - 1) * limit
+// GetActiveNames lấy ID, tên và cờ nổi bật của các sản phẩm đang hoạt động, dùng để dựng gợi ý tìm kiếm
+func (r *ProductRepo) GetActiveNames() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Select("id", "name", "is_featured").Where("is_active = ?", true).Find(&products).Error
+	return products, err
+}
 
-	// Lấy sản phẩm kèm danh mục
-	err := r.db.Preload("Category").
-		Where("is_active = ?", true).
-		Offset(offset).
+// EachActive duyệt tất cả sản phẩm đang hoạt động theo lô (kèm thương hiệu, danh mục, biến thể), dùng khi dựng lại chỉ mục tìm kiếm
+func (r *ProductRepo) EachActive(batchSize int, fn func([]model.Product) error) error {
+	return r.EachActiveMatching(ProductFilter{}, batchSize, fn)
+}
+
+// EachActiveMatching như EachActive nhưng chỉ với sản phẩm đang hoạt động khớp bộ lọc (bỏ qua filter.Active)
+func (r *ProductRepo) EachActiveMatching(filter ProductFilter, batchSize int, fn func([]model.Product) error) error {
+	var products []model.Product
+	filter.Active = nil
+	return r.db.Preload("Category").Preload("Brand").Preload("Variants").
+		Scopes(filter.scope).
+		FindInBatches(&products, batchSize, func(tx *gorm.DB, batch int) error {
+			if err := loadCategoryPaths(r.db, productCategories(products)...); err != nil {
+				return err
+			}
+			return fn(products)
+		}).Error
+}
+
+// EachFiltered duyệt theo lô (thứ tự ID) các sản phẩm khớp bộ lọc kèm danh mục và thương hiệu, dùng khi xuất bảng tính
+func (r *ProductRepo) EachFiltered(filter ProductFilter, batchSize int, fn func([]model.Product) error) error {
+	var products []model.Product
+	return r.db.Preload("Category").Preload("Brand").
+		Scopes(filter.scope).
+		FindInBatches(&products, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(products)
+		}).Error
+}
+
+// FilteredIDs lấy ID các sản phẩm khớp bộ lọc theo thứ tự ID, tối đa limit sản phẩm
+func (r *ProductRepo) FilteredIDs(filter ProductFilter, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.Product{}).
+		Scopes(filter.scope).
+		Order("products.id").
 		Limit(limit).
-		Find(&products).Error
+		Pluck("products.id", &ids).Error
+	return ids, err
+}
 
-	return products, total, err
+// LockByIDs lấy và khóa (SELECT ... FOR UPDATE) các sản phẩm theo ID kèm danh mục, thương hiệu và biến thể; dùng trong giao dịch
+func (r *ProductRepo) LockByIDs(ids []uint) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Variants").
+		Where("id IN ?", ids).
+		Find(&products).Error
+	return products, err
 }
 
-// GetByCategoryID lấy sản phẩm theo ID danh mục
-func (r *ProductRepo) GetByCategoryID(categoryID uint, page, limit int) ([]model.Product, int64, error) {
+// Search lấy sản phẩm đang hoạt động theo bộ lọc và kiểu sắp xếp, có phân trang
+func (r *ProductRepo) Search(filter ProductFilter, page, limit int) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
 	// Đếm tổng số bản ghi
-	query := r.db.Model(&model.Product{}).Where("category_id = ? AND is_active = ?", categoryID, true)
-	if err := query.Count(&total).Error; err != nil {
+	if err := r.db.Model(&model.Product{}).Scopes(filter.scope).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Tính offset
 	offset := (page - 1) * limit
 
-	// Lấy sản phẩm kèm danh mục
-	err := r.db.Preload("Category").
-		Where("category_id = ? AND is_active = ?", categoryID, true).
+	// Lấy sản phẩm kèm danh mục, thương hiệu, ảnh và biến thể
+	err := r.db.Preload("Category").Preload("Brand").Scopes(preloadProductDetails).
+		Select("products.*").
+		Scopes(filter.scope, filter.sortScope).
 		Offset(offset).
 		Limit(limit).
 		Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, loadCategoryPaths(r.db, productCategories(products)...)
+}
+
+// Facets đếm sản phẩm theo thương hiệu, màu sắc, chất liệu, kích thước và khoảng giá.
+// Mỗi nhóm bỏ qua điều kiện lọc của chính nhóm đó để client có thể chọn thêm giá trị khác trong nhóm.
+func (r *ProductRepo) Facets(filter ProductFilter) (*model.ProductFacets, error) {
+	facets := &model.ProductFacets{}
+
+	brandFilter := filter
+	brandFilter.BrandIDs = nil
+	if err := r.db.Model(&model.Product{}).
+		Scopes(brandFilter.scope).
+		Select("brands.slug AS value, brands.name AS label, COUNT(*) AS count").
+		Joins("JOIN brands ON brands.id = products.brand_id AND brands.deleted_at IS NULL AND brands.is_active = ?", true).
+		Group("brands.id, brands.slug, brands.name").
+		Order("count DESC, label ASC").
+		Scan(&facets.Brands).Error; err != nil {
+		return nil, err
+	}
+
+	colorFilter := filter
+	colorFilter.Colors = nil
+	if err := r.countByColumn(colorFilter, "color", &facets.Colors); err != nil {
+		return nil, err
+	}
+
+	materialFilter := filter
+	materialFilter.Materials = nil
+	if err := r.countByColumn(materialFilter, "material", &facets.Materials); err != nil {
+		return nil, err
+	}
 
-	return products, total, err
+	sizeFilter := filter
+	sizeFilter.Sizes = nil
+	if err := r.countByColumn(sizeFilter, "size", &facets.Sizes); err != nil {
+		return nil, err
+	}
+
+	priceFilter := filter
+	priceFilter.MinPrice, priceFilter.MaxPrice = nil, nil
+	prices, err := r.countByPriceRange(priceFilter)
+	if err != nil {
+		return nil, err
+	}
+	facets.PriceRanges = prices
+
+	return facets, nil
+}
+
+// countByColumn đếm sản phẩm theo giá trị của một cột thuộc tính (bỏ qua giá trị rỗng) cùng giá trị tùy chọn
+// cùng tên của các biến thể đang bán; mỗi sản phẩm chỉ được đếm một lần cho mỗi giá trị
+func (r *ProductRepo) countByColumn(filter ProductFilter, column string, dest *[]model.FacetCount) error {
+	productValues := r.db.Model(&model.Product{}).
+		Scopes(filter.scope).
+		Select("products.id AS product_id, products." + column + " AS value").
+		Where("products." + column + " <> ''")
+	variantValues := r.db.Model(&model.Product{}).
+		Scopes(filter.scope).
+		Select("products.id AS product_id, product_variant_values.value AS value").
+		Joins("JOIN product_variant_values ON product_variant_values.product_id = products.id AND product_variant_values.name = ?", column).
+		Joins("JOIN product_variants ON product_variants.id = product_variant_values.variant_id AND product_variants.deleted_at IS NULL AND product_variants.is_active = ?", true)
+
+	return r.db.Table("(? UNION ALL ?) AS attribute_values", productValues, variantValues).
+		Select("value, COUNT(DISTINCT product_id) AS count").
+		Group("value").
+		Order("count DESC, value ASC").
+		Scan(dest).Error
+}
+
+// countByPriceRange đếm sản phẩm trong từng khoảng giá theo priceFacetBounds
+func (r *ProductRepo) countByPriceRange(filter ProductFilter) ([]model.PriceRangeFacet, error) {
+	var bucket strings.Builder
+	bucket.WriteString("CASE")
+	for i, bound := range priceFacetBounds {
+		fmt.Fprintf(&bucket, " WHEN products.price < %.0f THEN %d", bound, i)
+	}
+	fmt.Fprintf(&bucket, " ELSE %d END", len(priceFacetBounds))
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := r.db.Model(&model.Product{}).
+		Scopes(filter.scope).
+		Select(bucket.String() + " AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+
+	ranges := make([]model.PriceRangeFacet, 0, len(priceFacetBounds)+1)
+	min := 0.0
+	for i := 0; i <= len(priceFacetBounds); i++ {
+		facet := model.PriceRangeFacet{Min: min, Count: counts[i]}
+		if i < len(priceFacetBounds) {
+			max := priceFacetBounds[i]
+			facet.Max = &max
+			min = max
+		}
+		ranges = append(ranges, facet)
+	}
+	return ranges, nil
+}
+
+// attributeIn lọc sản phẩm có cột thuộc tính (color, material, size) thuộc values, hoặc có biến thể đang bán
+// mang giá trị tùy chọn cùng tên thuộc values
+func attributeIn(db *gorm.DB, column string, values []string) *gorm.DB {
+	return db.Where("(products."+column+" IN ? OR EXISTS (SELECT 1 FROM product_variant_values"+
+		" JOIN product_variants ON product_variants.id = product_variant_values.variant_id"+
+		" AND product_variants.deleted_at IS NULL AND product_variants.is_active = ?"+
+		" WHERE product_variant_values.product_id = products.id AND product_variant_values.name = ?"+
+		" AND product_variant_values.value IN ?))", values, true, column, values)
+}
+
+// scope áp dụng các điều kiện lọc; tên cột có tiền tố bảng vì truy vấn có thể JOIN bảng khác
+func (f ProductFilter) scope(db *gorm.DB) *gorm.DB {
+	active := true
+	if f.Active != nil {
+		active = *f.Active
+	}
+	db = db.Where("products.is_active = ?", active)
+
+	if f.Search != "" {
+		keyword := "%" + escapeLike(f.Search) + "%"
+		db = db.Where("(products.name LIKE ? OR products.description LIKE ? OR products.sku LIKE ?)", keyword, keyword, keyword)
+	}
+	if len(f.CategoryIDs) > 0 {
+		db = db.Where("products.category_id IN ?", f.CategoryIDs)
+	}
+	if len(f.BrandIDs) > 0 {
+		db = db.Where("products.brand_id IN ?", f.BrandIDs)
+	}
+	if f.MinPrice != nil {
+		db = db.Where("products.price >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("products.price <= ?", *f.MaxPrice)
+	}
+	if len(f.Colors) > 0 {
+		db = attributeIn(db, "color", f.Colors)
+	}
+	if len(f.Materials) > 0 {
+		db = attributeIn(db, "material", f.Materials)
+	}
+	if len(f.Sizes) > 0 {
+		db = attributeIn(db, "size", f.Sizes)
+	}
+	if f.Featured != nil {
+		db = db.Where("products.is_featured = ?", *f.Featured)
+	}
+	if f.InStock {
+		db = db.Where("products.stock > 0")
+	}
+
+	return db
+}
+
+// sortScope sắp xếp theo kiểu đã chọn; điểm đánh giá và số lượng bán được tính bằng bảng con khi cần
+func (f ProductFilter) sortScope(db *gorm.DB) *gorm.DB {
+	switch f.Sort {
+	case ProductSortPriceAsc:
+		return db.Order("products.price ASC, products.id DESC")
+	case ProductSortPriceDesc:
+		return db.Order("products.price DESC, products.id DESC")
+	case ProductSortNameAsc:
+		return db.Order("products.name ASC, products.id DESC")
+	case ProductSortNameDesc:
+		return db.Order("products.name DESC, products.id DESC")
+	case ProductSortRating:
+		return db.Joins("LEFT JOIN (SELECT product_id, AVG(rating) AS avg_rating, COUNT(*) AS review_count FROM reviews WHERE is_active = ? AND deleted_at IS NULL GROUP BY product_id) product_ratings ON product_ratings.product_id = products.id", true).
+			Order("COALESCE(product_ratings.avg_rating, 0) DESC, COALESCE(product_ratings.review_count, 0) DESC, products.id DESC")
+	case ProductSortBestSelling:
+		return db.Joins("LEFT JOIN (SELECT order_items.product_id, SUM(order_items.quantity) AS sold FROM order_items JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL WHERE orders.status <> ? AND order_items.deleted_at IS NULL GROUP BY order_items.product_id) product_sales ON product_sales.product_id = products.id", "cancelled").
+			Order("COALESCE(product_sales.sold, 0) DESC, products.id DESC")
+	default:
+		return db.Order("products.created_at DESC, products.id DESC")
+	}
+}
+
+// escapeLike thoát các ký tự đại diện của LIKE trong từ khóa người dùng nhập
+func escapeLike(keyword string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(keyword)
+}
+
+// productCategories lấy danh mục đã nạp của các sản phẩm để gán breadcrumb
+func productCategories(products []model.Product) []*model.Category {
+	categories := make([]*model.Category, 0, len(products))
+	for i := range products {
+		categories = append(categories, products[i].Category)
+	}
+	return categories
+}
+
+// preloadProductDetails nạp ảnh, trục tùy chọn và biến thể của sản phẩm
+func preloadProductDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("ProductImages", orderImages).
+		Preload("Options", orderOptions).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Variants.Images", orderImages)
+}
+
+// orderImages sắp xếp ảnh sản phẩm theo thứ tự hiển thị khi preload
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, id ASC")
 }
 
 // Update cập nhật sản phẩm
 func (r *ProductRepo) Update(product *model.Product) error {
-	return r.db.Save(product).Error
+	// Không lưu quan hệ đã nạp (ảnh, biến thể...) để tránh ghi đè dữ liệu được cập nhật riêng;
+	// tồn kho chỉ thay đổi qua sổ kho (StockRepo.Move), trạng thái cảnh báo tồn kho thấp do tác vụ nền quản lý,
+	// giá vốn bình quân chỉ thay đổi khi nhận hàng
+	return r.db.Omit(clause.Associations, "stock", "low_stock_alerted_at", "average_cost").Save(product).Error
+}
+
+// UpdateFields cập nhật các cột của sản phẩm (tên cột -> giá trị)
+func (r *ProductRepo) UpdateFields(id uint, fields map[string]interface{}) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).Updates(fields).Error
 }
 
 // Delete xóa mềm sản phẩm
@@ -117,7 +445,118 @@ func (r *ProductRepo) CheckSKUExists(sku string, excludeID uint) (bool, error) {
 	return count > 0, err
 }
 
-// UpdateStock cập nhật tồn kho sản phẩm
-func (r *ProductRepo) UpdateStock(id uint, stock int) error {
-	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("stock", stock).Error
+// SetActive bật/tắt trạng thái kinh doanh của sản phẩm
+func (r *ProductRepo) SetActive(id uint, active bool) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("is_active", active).Error
+}
+
+// GetLowStock lấy các sản phẩm đang bán có tồn kho bằng hoặc dưới ngưỡng cảnh báo, tồn kho thấp nhất trước
+func (r *ProductRepo) GetLowStock() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Preload("Category").Preload("Brand").
+		Where("is_active = ? AND low_stock_threshold > 0 AND stock <= low_stock_threshold", true).
+		Order("stock ASC, id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// PendingLowStockAlerts lấy tối đa limit sản phẩm đã xuống bằng hoặc dưới ngưỡng mà chưa được cảnh báo
+func (r *ProductRepo) PendingLowStockAlerts(limit int) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.
+		Where("is_active = ? AND low_stock_threshold > 0 AND stock <= low_stock_threshold AND low_stock_alerted_at IS NULL", true).
+		Order("id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// MarkLowStockAlerted ghi nhận đã cảnh báo tồn kho thấp cho các sản phẩm
+func (r *ProductRepo) MarkLowStockAlerted(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.Product{}).Where("id IN ?", ids).UpdateColumn("low_stock_alerted_at", at).Error
+}
+
+// ResetRecoveredLowStock xóa trạng thái đã cảnh báo của các sản phẩm có tồn kho vượt lại ngưỡng (hoặc bỏ ngưỡng)
+// để lần xuống dưới ngưỡng tiếp theo được cảnh báo lại; trả về số sản phẩm đã đặt lại
+func (r *ProductRepo) ResetRecoveredLowStock() (int64, error) {
+	result := r.db.Model(&model.Product{}).
+		Where("low_stock_alerted_at IS NOT NULL AND (low_stock_threshold = 0 OR stock > low_stock_threshold)").
+		UpdateColumn("low_stock_alerted_at", nil)
+	return result.RowsAffected, result.Error
+}
+
+// ApplyPurchaseCost cập nhật giá vốn bình quân gia quyền khi nhận quantity sản phẩm với đơn giá unitCost,
+// tính trên tồn kho hiện tại (trước khi nhập); gọi trước khi ghi sổ kho nhận hàng trong cùng giao dịch.
+// Trả về giá vốn mới.
+func (r *ProductRepo) ApplyPurchaseCost(id uint, quantity int, unitCost float64) (float64, error) {
+	var product model.Product
+	if err := r.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "stock", "average_cost").First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("product not found")
+		}
+		return 0, err
+	}
+
+	cost := weightedAverageCost(product.Stock, product.AverageCost, quantity, unitCost)
+	err := r.db.Unscoped().Model(&model.Product{}).Where("id = ?", id).UpdateColumn("average_cost", cost).Error
+	return cost, err
+}
+
+// weightedAverageCost tính giá vốn bình quân sau khi nhận quantity với đơn giá unitCost, làm tròn 2 chữ số thập phân.
+// Tồn kho âm hoặc bằng 0 không còn giá trị vốn, giá nhập mới trở thành giá vốn.
+func weightedAverageCost(stock int, averageCost float64, quantity int, unitCost float64) float64 {
+	if stock < 0 {
+		stock = 0
+	}
+	cost := (float64(stock)*averageCost + float64(quantity)*unitCost) / float64(stock+quantity)
+	return math.Round(cost*100) / 100
+}
+
+// ProductMargin là doanh thu và giá vốn hàng bán của một sản phẩm trong kỳ báo cáo
+type ProductMargin struct {
+	ProductID   uint    `json:"product_id"`
+	SKU         string  `json:"sku"`
+	Name        string  `json:"name"`
+	AverageCost float64 `json:"average_cost"` // Giá vốn bình quân hiện tại
+	Quantity    int     `json:"quantity"`
+	Revenue     float64 `json:"revenue"`
+	Cost        float64 `json:"cost"` // Theo giá vốn chụp lại trên từng dòng đơn hàng lúc bán
+}
+
+// GetMargins tính doanh thu và giá vốn theo sản phẩm của các đơn không bị hủy tạo từ since, lãi gộp cao nhất
+// trước; kèm tổng của toàn bộ sản phẩm (không phân trang)
+func (r *ProductRepo) GetMargins(since time.Time, page, limit int) ([]ProductMargin, int64, *ProductMargin, error) {
+	sales := r.db.Table("order_items").
+		Select("order_items.product_id, products.sku, products.name, products.average_cost, "+
+			"SUM(order_items.quantity) AS quantity, SUM(order_items.total) AS revenue, "+
+			"SUM(order_items.unit_cost * order_items.quantity) AS cost").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("orders.status <> ? AND orders.created_at >= ? AND order_items.deleted_at IS NULL", "cancelled", since).
+		Group("order_items.product_id, products.sku, products.name, products.average_cost")
+
+	var summary struct {
+		Products int64
+		ProductMargin
+	}
+	if err := r.db.Table("(?) AS margins", sales).
+		Select("COUNT(*) AS products, COALESCE(SUM(quantity), 0) AS quantity, " +
+			"COALESCE(SUM(revenue), 0) AS revenue, COALESCE(SUM(cost), 0) AS cost").
+		Scan(&summary).Error; err != nil {
+		return nil, 0, nil, err
+	}
+
+	var margins []ProductMargin
+	offset := (page - 1) * limit
+	err := r.db.Table("(?) AS margins", sales).
+		Order("revenue - cost DESC, product_id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&margins).Error
+
+	return margins, summary.Products, &summary.ProductMargin, err
 }
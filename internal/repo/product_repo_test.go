@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"backend/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunDB trả về kết nối MySQL chỉ dựng câu lệnh (DryRun), không cần máy chủ cơ sở dữ liệu
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// productQuery dựng câu lệnh lấy sản phẩm với các scope và trả về SQL kèm tham số
+func productQuery(t *testing.T, scopes ...func(*gorm.DB) *gorm.DB) (string, []interface{}) {
+	t.Helper()
+	var products []model.Product
+	stmt := dryRunDB(t).Model(&model.Product{}).Scopes(scopes...).Find(&products).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestProductFilterScope(t *testing.T) {
+	inactive := false
+	minPrice, maxPrice := 100000.0, 500000.0
+
+	tests := []struct {
+		name     string
+		filter   ProductFilter
+		wantSQL  []string
+		wantVars []interface{}
+	}{
+		{
+			name:     "active products by default",
+			filter:   ProductFilter{},
+			wantSQL:  []string{"products.is_active = ?"},
+			wantVars: []interface{}{true},
+		},
+		{
+			name:     "inactive products",
+			filter:   ProductFilter{Active: &inactive},
+			wantSQL:  []string{"products.is_active = ?"},
+			wantVars: []interface{}{false},
+		},
+		{
+			name:     "brand filter",
+			filter:   ProductFilter{BrandIDs: []uint{3, 5}},
+			wantSQL:  []string{"products.brand_id IN (?,?)"},
+			wantVars: []interface{}{true, uint(3), uint(5)},
+		},
+		{
+			name:     "category and price range",
+			filter:   ProductFilter{CategoryIDs: []uint{7}, MinPrice: &minPrice, MaxPrice: &maxPrice},
+			wantSQL:  []string{"products.category_id IN (?)", "products.price >= ?", "products.price <= ?"},
+			wantVars: []interface{}{true, uint(7), minPrice, maxPrice},
+		},
+		{
+			name:     "search keyword escapes LIKE wildcards",
+			filter:   ProductFilter{Search: "50%_off"},
+			wantSQL:  []string{"(products.name LIKE ? OR products.description LIKE ? OR products.sku LIKE ?)"},
+			wantVars: []interface{}{true, `%50\%\_off%`, `%50\%\_off%`, `%50\%\_off%`},
+		},
+		{
+			name:   "colors match the product attribute or an active variant value",
+			filter: ProductFilter{Colors: []string{"black", "red"}},
+			wantSQL: []string{
+				"(products.color IN (?,?) OR EXISTS (SELECT 1 FROM product_variant_values",
+				"product_variants.is_active = ?",
+				"product_variant_values.name = ? AND product_variant_values.value IN (?,?))",
+			},
+			wantVars: []interface{}{true, "black", "red", true, "color", "black", "red"},
+		},
+		{
+			name:     "materials, sizes, featured and in stock",
+			filter:   ProductFilter{Materials: []string{"cotton"}, Sizes: []string{"M"}, Featured: &inactive, InStock: true},
+			wantSQL:  []string{"products.material IN (?)", "products.size IN (?)", "products.is_featured = ?", "products.stock > 0"},
+			wantVars: []interface{}{true, "cotton", true, "material", "cotton", "M", true, "size", "M", false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := productQuery(t, tt.filter.scope)
+			for _, fragment := range tt.wantSQL {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("SQL %q does not contain %q", sql, fragment)
+				}
+			}
+			if !reflect.DeepEqual(vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestProductSortScope(t *testing.T) {
+	tests := []struct {
+		sort    string
+		wantSQL []string
+	}{
+		{"", []string{"ORDER BY products.created_at DESC, products.id DESC"}},
+		{"unknown", []string{"ORDER BY products.created_at DESC, products.id DESC"}},
+		{ProductSortPriceAsc, []string{"ORDER BY products.price ASC, products.id DESC"}},
+		{ProductSortPriceDesc, []string{"ORDER BY products.price DESC, products.id DESC"}},
+		{ProductSortNameAsc, []string{"ORDER BY products.name ASC, products.id DESC"}},
+		{ProductSortNameDesc, []string{"ORDER BY products.name DESC, products.id DESC"}},
+		{ProductSortRating, []string{"LEFT JOIN (SELECT product_id, AVG(rating)", "ORDER BY COALESCE(product_ratings.avg_rating, 0) DESC"}},
+		{ProductSortBestSelling, []string{"LEFT JOIN (SELECT order_items.product_id", "ORDER BY COALESCE(product_sales.sold, 0) DESC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			sql, _ := productQuery(t, ProductFilter{Sort: tt.sort}.sortScope)
+			for _, fragment := range tt.wantSQL {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("SQL %q does not contain %q", sql, fragment)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedAverageCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		stock       int
+		averageCost float64
+		quantity    int
+		unitCost    float64
+		want        float64
+	}{
+		{"first receipt", 0, 0, 10, 120000, 120000},
+		{"weighted by quantity", 10, 100000, 30, 140000, 130000},
+		{"rounded to cents", 1, 10, 2, 10.01, 10.01},
+		{"repeating decimals", 2, 1, 1, 2, 1.33},
+		{"oversold stock carries no cost", -5, 90000, 10, 120000, 120000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weightedAverageCost(tt.stock, tt.averageCost, tt.quantity, tt.unitCost); got != tt.want {
+				t.Errorf("weightedAverageCost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
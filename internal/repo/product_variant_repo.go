@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductVariantRepo struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepo() *ProductVariantRepo {
+	return &ProductVariantRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *ProductVariantRepo) WithTx(tx *gorm.DB) *ProductVariantRepo {
+	return &ProductVariantRepo{db: tx}
+}
+
+// GetOptions lấy các trục tùy chọn của sản phẩm theo thứ tự hiển thị
+func (r *ProductVariantRepo) GetOptions(productID uint) ([]model.ProductOption, error) {
+	var options []model.ProductOption
+	err := r.db.Scopes(orderOptions).Where("product_id = ?", productID).Find(&options).Error
+	return options, err
+}
+
+// ReplaceOptions thay toàn bộ trục tùy chọn của sản phẩm và cập nhật tổ hợp tùy chọn đã chuẩn hóa của các biến thể
+func (r *ProductVariantRepo) ReplaceOptions(productID uint, options []model.ProductOption, variants []model.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if len(options) > 0 {
+			if err := tx.Create(&options).Error; err != nil {
+				return err
+			}
+		}
+		for i := range variants {
+			if err := tx.Model(&model.ProductVariant{}).Where("id = ?", variants[i].ID).
+				Updates(map[string]interface{}{"options": variants[i].Options, "option_key": variants[i].OptionKey}).Error; err != nil {
+				return err
+			}
+			if err := replaceVariantValues(tx, &variants[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByProductID lấy tất cả biến thể của sản phẩm kèm ảnh riêng
+func (r *ProductVariantRepo) GetByProductID(productID uint) ([]model.ProductVariant, error) {
+	var variants []model.ProductVariant
+	err := r.db.Preload("Images", orderImages).
+		Where("product_id = ?", productID).
+		Order("id ASC").
+		Find(&variants).Error
+	return variants, err
+}
+
+// GetByID lấy biến thể thuộc sản phẩm
+func (r *ProductVariantRepo) GetByID(productID, variantID uint) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	err := r.db.Preload("Images", orderImages).
+		Where("product_id = ?", productID).
+		First(&variant, variantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product variant not found")
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// CountByProductID đếm số biến thể của sản phẩm
+func (r *ProductVariantRepo) CountByProductID(productID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// CheckSKUExists kiểm tra SKU biến thể đã được dùng (kể cả biến thể đã xóa mềm vì cột SKU là unique)
+func (r *ProductVariantRepo) CheckSKUExists(sku string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Unscoped().Model(&model.ProductVariant{}).Where("sku = ?", sku)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// CheckOptionKeyExists kiểm tra tổ hợp tùy chọn đã có biến thể khác của cùng sản phẩm
+func (r *ProductVariantRepo) CheckOptionKeyExists(productID uint, optionKey string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&model.ProductVariant{}).Where("product_id = ? AND option_key = ?", productID, optionKey)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// Create tạo biến thể và cập nhật lại tồn kho tổng của sản phẩm
+func (r *ProductVariantRepo) Create(variant *model.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(variant).Error; err != nil {
+			return err
+		}
+		if err := replaceVariantValues(tx, variant); err != nil {
+			return err
+		}
+		return syncProductStock(tx, variant.ProductID)
+	})
+}
+
+// Update cập nhật biến thể; tồn kho chỉ thay đổi qua sổ kho (StockRepo.Move)
+func (r *ProductVariantRepo) Update(variant *model.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations, "stock").Save(variant).Error; err != nil {
+			return err
+		}
+		return replaceVariantValues(tx, variant)
+	})
+}
+
+// UpdatePrice đặt giá ghi đè của biến thể
+func (r *ProductVariantRepo) UpdatePrice(variantID uint, price float64) error {
+	return r.db.Model(&model.ProductVariant{}).Where("id = ?", variantID).Update("price", price).Error
+}
+
+// Delete xóa mềm biến thể: gỡ ảnh riêng khỏi biến thể và bỏ biến thể khỏi các giỏ hàng
+func (r *ProductVariantRepo) Delete(variant *model.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ProductImage{}).Where("variant_id = ?", variant.ID).Update("variant_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.ProductVariant{}, variant.ID).Error; err != nil {
+			return err
+		}
+		return syncProductStock(tx, variant.ProductID)
+	})
+}
+
+// BackfillValues tạo giá trị tùy chọn (product_variant_values) cho các biến thể chưa có (dữ liệu trước khi lọc
+// theo biến thể); trả về số biến thể đã xử lý
+func (r *ProductVariantRepo) BackfillValues() (int, error) {
+	var variants []model.ProductVariant
+	count := 0
+	err := r.db.Where("options <> '' AND NOT EXISTS (SELECT 1 FROM product_variant_values WHERE product_variant_values.variant_id = product_variants.id)").
+		FindInBatches(&variants, 500, func(tx *gorm.DB, batch int) error {
+			var rows []model.ProductVariantValue
+			for i := range variants {
+				rows = append(rows, variants[i].ValueRows()...)
+			}
+			count += len(variants)
+			if len(rows) == 0 {
+				return nil
+			}
+			return r.db.Omit(clause.Associations).Create(&rows).Error
+		}).Error
+	return count, err
+}
+
+// replaceVariantValues ghi lại các giá trị tùy chọn của biến thể theo Options hiện tại
+func replaceVariantValues(tx *gorm.DB, variant *model.ProductVariant) error {
+	if err := tx.Where("variant_id = ?", variant.ID).Delete(&model.ProductVariantValue{}).Error; err != nil {
+		return err
+	}
+	rows := variant.ValueRows()
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).Create(&rows).Error
+}
+
+// syncProductStock đặt tồn kho sản phẩm bằng tổng tồn kho các biến thể để danh sách/tìm kiếm sản phẩm vẫn đúng
+func syncProductStock(tx *gorm.DB, productID uint) error {
+	var total int64
+	if err := tx.Model(&model.ProductVariant{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(stock), 0)").
+		Scan(&total).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Product{}).Where("id = ?", productID).Update("stock", total).Error
+}
+
+// orderOptions sắp xếp trục tùy chọn theo thứ tự hiển thị khi preload
+func orderOptions(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
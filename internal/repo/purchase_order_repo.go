@@ -0,0 +1,142 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderRepo struct {
+	db *gorm.DB
+}
+
+// PurchaseOrderFilter lọc danh sách đơn đặt hàng nhà cung cấp
+type PurchaseOrderFilter struct {
+	Status      string
+	SupplierID  *uint
+	WarehouseID *uint
+}
+
+func NewPurchaseOrderRepo() *PurchaseOrderRepo {
+	return &PurchaseOrderRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *PurchaseOrderRepo) WithTx(tx *gorm.DB) *PurchaseOrderRepo {
+	return &PurchaseOrderRepo{db: tx}
+}
+
+// GenerateCode tạo mã đơn đặt hàng chưa tồn tại, dạng PO-20251019-0042
+func (r *PurchaseOrderRepo) GenerateCode() (string, error) {
+	for {
+		code := fmt.Sprintf("PO-%s-%04d", time.Now().Format("20060102"), rand.Intn(10000))
+		var count int64
+		if err := r.db.Model(&model.PurchaseOrder{}).Where("code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+}
+
+// Create tạo đơn đặt hàng kèm dòng hàng
+func (r *PurchaseOrderRepo) Create(order *model.PurchaseOrder) error {
+	return r.db.Omit("Supplier", "Warehouse", "User", "Lines.Product", "Lines.Variant").Create(order).Error
+}
+
+// GetByID lấy đơn đặt hàng kèm nhà cung cấp, kho và dòng hàng
+func (r *PurchaseOrderRepo) GetByID(id uint) (*model.PurchaseOrder, error) {
+	return r.get(r.db, id)
+}
+
+// LockByID lấy đơn đặt hàng và khóa dòng (SELECT ... FOR UPDATE) để nhận hàng trong giao dịch
+func (r *PurchaseOrderRepo) LockByID(id uint) (*model.PurchaseOrder, error) {
+	return r.get(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *PurchaseOrderRepo) get(db *gorm.DB, id uint) (*model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	err := db.Preload("Supplier").
+		Preload("Warehouse").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Product", unscopedPreload).
+		Preload("Lines.Variant", unscopedPreload).
+		First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("purchase order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetAll lấy danh sách đơn đặt hàng có phân trang, mới nhất trước
+func (r *PurchaseOrderRepo) GetAll(filter PurchaseOrderFilter, page, limit int) ([]model.PurchaseOrder, int64, error) {
+	var orders []model.PurchaseOrder
+	var total int64
+
+	query := r.db.Model(&model.PurchaseOrder{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SupplierID != nil {
+		query = query.Where("supplier_id = ?", *filter.SupplierID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Supplier").
+		Preload("Warehouse").
+		Preload("Lines").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+// ReplaceDraft cập nhật thông tin và thay toàn bộ dòng hàng của đơn nháp
+func (r *PurchaseOrderRepo) ReplaceDraft(order *model.PurchaseOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&model.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		for i := range order.Lines {
+			order.Lines[i].ID = 0
+			order.Lines[i].PurchaseOrderID = order.ID
+		}
+		if err := tx.Omit("Product", "Variant").Create(&order.Lines).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(order).Error
+	})
+}
+
+// UpdateStatus cập nhật trạng thái đơn đặt hàng và các cột thời gian kèm theo
+func (r *PurchaseOrderRepo) UpdateStatus(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&model.PurchaseOrder{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// AddReceived cộng số lượng đã nhận của một dòng hàng
+func (r *PurchaseOrderRepo) AddReceived(lineID uint, quantity int) error {
+	return r.db.Model(&model.PurchaseOrderLine{}).Where("id = ?", lineID).
+		UpdateColumn("received_quantity", gorm.Expr("received_quantity + ?", quantity)).Error
+}
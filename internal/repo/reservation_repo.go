@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReservationRepo struct {
+	db *gorm.DB
+}
+
+func NewReservationRepo() *ReservationRepo {
+	return &ReservationRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *ReservationRepo) WithTx(tx *gorm.DB) *ReservationRepo {
+	return &ReservationRepo{db: tx}
+}
+
+// Hold tạo bản ghi giữ hàng cho mọi dòng của đơn hàng, hết hạn lúc expiresAt.
+// Tồn kho được trừ riêng bằng StockRepo.MoveOrder với lý do reservation trong cùng giao dịch.
+func (r *ReservationRepo) Hold(order *model.Order, expiresAt time.Time) error {
+	if len(order.OrderItems) == 0 {
+		return nil
+	}
+
+	reservations := make([]model.StockReservation, 0, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		reservations = append(reservations, model.StockReservation{
+			OrderID:   order.ID,
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Status:    consts.ReservationHeld,
+			ExpiresAt: expiresAt,
+		})
+	}
+	return r.db.Omit("Order", "Product", "Variant").Create(&reservations).Error
+}
+
+// Convert chuyển hàng đang giữ của đơn thành bán; trả về số bản ghi đã chuyển
+func (r *ReservationRepo) Convert(orderID uint) (int64, error) {
+	return r.resolve(orderID, consts.ReservationConverted)
+}
+
+// Release đánh dấu hàng đang giữ của đơn đã được trả lại kho; trả về số bản ghi đã trả.
+// Số 0 nghĩa là đơn không còn giữ hàng (đã thanh toán, đã trả hoặc không cần giữ).
+func (r *ReservationRepo) Release(orderID uint) (int64, error) {
+	return r.resolve(orderID, consts.ReservationReleased)
+}
+
+func (r *ReservationRepo) resolve(orderID uint, status string) (int64, error) {
+	now := time.Now()
+	result := r.db.Model(&model.StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, consts.ReservationHeld).
+		Updates(map[string]interface{}{"status": status, "resolved_at": &now})
+	return result.RowsAffected, result.Error
+}
+
+// ExpiredOrderIDs trả về ID các đơn còn giữ hàng đã hết hạn trước now, tối đa limit đơn
+func (r *ReservationRepo) ExpiredOrderIDs(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.StockReservation{}).
+		Where("status = ? AND expires_at < ?", consts.ReservationHeld, now).
+		Distinct().
+		Order("order_id").
+		Limit(limit).
+		Pluck("order_id", &ids).Error
+	return ids, err
+}
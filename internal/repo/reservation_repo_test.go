@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"backend/internal/consts"
+	"backend/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordedStatement là một câu lệnh đã dựng (không chạy) trên kết nối DryRun
+type recordedStatement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// recordStatements ghi lại mọi câu lệnh repo dựng trên db để kiểm tra mà không cần máy chủ cơ sở dữ liệu
+func recordStatements(t *testing.T, db *gorm.DB) *[]recordedStatement {
+	t.Helper()
+	var statements []recordedStatement
+	record := func(db *gorm.DB) {
+		statements = append(statements, recordedStatement{SQL: db.Statement.SQL.String(), Vars: db.Statement.Vars})
+	}
+	callbacks := db.Callback()
+	for name, err := range map[string]error{
+		"create": callbacks.Create().After("gorm:create").Register("test:record", record),
+		"query":  callbacks.Query().After("gorm:query").Register("test:record", record),
+		"update": callbacks.Update().After("gorm:update").Register("test:record", record),
+		"delete": callbacks.Delete().After("gorm:delete").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatalf("register %s callback: %v", name, err)
+		}
+	}
+	return &statements
+}
+
+func TestReservationRepoHold(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	variantID := uint(9)
+
+	tests := []struct {
+		name     string
+		items    []model.OrderItem
+		wantRows int
+	}{
+		{"order without items", nil, 0},
+		{"one row per item", []model.OrderItem{{ProductID: 2, Quantity: 3}, {ProductID: 4, VariantID: &variantID, Quantity: 1}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dryRunDB(t)
+			statements := recordStatements(t, db)
+			order := &model.Order{ID: 5, OrderItems: tt.items}
+			if err := (&ReservationRepo{db: db}).Hold(order, expiresAt); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantRows == 0 {
+				if len(*statements) != 0 {
+					t.Errorf("statements = %v, want none", *statements)
+				}
+				return
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("statements = %v, want one insert", *statements)
+			}
+			insert := (*statements)[0]
+			if !strings.HasPrefix(insert.SQL, "INSERT INTO `stock_reservations`") {
+				t.Errorf("SQL = %q, want insert into stock_reservations", insert.SQL)
+			}
+			if got := strings.Count(insert.SQL, "),("); got != tt.wantRows-1 {
+				t.Errorf("SQL = %q, want %d rows", insert.SQL, tt.wantRows)
+			}
+			if held := countVar(insert.Vars, consts.ReservationHeld); held != tt.wantRows {
+				t.Errorf("vars = %#v, want %d held rows", insert.Vars, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestReservationRepoQueries(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := dryRunDB(t)
+	statements := recordStatements(t, db)
+	r := &ReservationRepo{db: db}
+
+	if _, err := r.ExpiredOrderIDs(now, 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Convert(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*statements) != 2 {
+		t.Fatalf("statements = %v, want 2", *statements)
+	}
+	expired, convert := (*statements)[0], (*statements)[1]
+
+	wantExpired := "SELECT DISTINCT `order_id` FROM `stock_reservations` WHERE status = ? AND expires_at < ? ORDER BY order_id LIMIT ?"
+	if expired.SQL != wantExpired {
+		t.Errorf("SQL = %q, want %q", expired.SQL, wantExpired)
+	}
+	if want := []interface{}{consts.ReservationHeld, now, 100}; !reflect.DeepEqual(expired.Vars, want) {
+		t.Errorf("vars = %#v, want %#v", expired.Vars, want)
+	}
+
+	// Chỉ các dòng còn giữ hàng của đơn được chuyển trạng thái
+	if !strings.Contains(convert.SQL, "WHERE order_id = ? AND status = ?") {
+		t.Errorf("SQL = %q, want held rows of the order only", convert.SQL)
+	}
+	if countVar(convert.Vars, consts.ReservationConverted) != 1 {
+		t.Errorf("vars = %#v, want status %s", convert.Vars, consts.ReservationConverted)
+	}
+}
+
+// countVar đếm số tham số bằng want
+func countVar(vars []interface{}, want interface{}) int {
+	count := 0
+	for _, v := range vars {
+		if v == want {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,334 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockRepo struct {
+	db *gorm.DB
+}
+
+// StockMovementFilter lọc lịch sử biến động tồn kho của một sản phẩm
+type StockMovementFilter struct {
+	VariantID *uint
+	Reason    string
+}
+
+func NewStockRepo() *StockRepo {
+	return &StockRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *StockRepo) WithTx(tx *gorm.DB) *StockRepo {
+	return &StockRepo{db: tx}
+}
+
+// Move cộng movement.Delta vào tồn kho của sản phẩm (hoặc biến thể, rồi đồng bộ tồn kho tổng của sản phẩm)
+// tại kho movement.WarehouseID (nil là kho mặc định) và ghi dòng sổ kho với số dư sau biến động, trong cùng
+// một giao dịch. Tồn kho không được âm ở cả tổng lẫn từng kho (trừ khi movement.AllowBackorder, khi đó tồn kho
+// được âm đến giới hạn MaxBackorder của sản phẩm): trả về lỗi "insufficient stock" nếu không đủ hàng,
+// "product not found"/"product variant not found"/"warehouse not found" nếu không tồn tại.
+// Sản phẩm/biến thể đã xóa mềm vẫn được cập nhật để hủy đơn cũ hoàn kho đúng.
+func (r *StockRepo) Move(movement *model.StockMovement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if movement.WarehouseID == nil {
+			warehouse, err := NewWarehouseRepo().WithTx(tx).Default()
+			if err != nil {
+				return err
+			}
+			movement.WarehouseID = &warehouse.ID
+		}
+
+		floor, err := stockFloor(tx, movement)
+		if err != nil {
+			return err
+		}
+
+		if movement.VariantID != nil {
+			result := tx.Unscoped().Model(&model.ProductVariant{}).
+				Where("id = ? AND product_id = ?", *movement.VariantID, movement.ProductID).
+				Scopes(aboveFloor(movement.Delta, floor)).
+				UpdateColumn("stock", gorm.Expr("stock + ?", movement.Delta))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return missingOrInsufficient(tx, &model.ProductVariant{}, *movement.VariantID, "product variant not found")
+			}
+			if err := syncProductStock(tx, movement.ProductID); err != nil {
+				return err
+			}
+
+			var variantBalance int
+			if err := tx.Unscoped().Model(&model.ProductVariant{}).Where("id = ?", *movement.VariantID).
+				Select("stock").Scan(&variantBalance).Error; err != nil {
+				return err
+			}
+			movement.VariantBalance = &variantBalance
+		} else {
+			result := tx.Unscoped().Model(&model.Product{}).
+				Where("id = ?", movement.ProductID).
+				Scopes(aboveFloor(movement.Delta, floor)).
+				UpdateColumn("stock", gorm.Expr("stock + ?", movement.Delta))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return missingOrInsufficient(tx, &model.Product{}, movement.ProductID, "product not found")
+			}
+		}
+
+		// Bán vượt tồn kho được ghi âm tại kho xuất hàng; giới hạn đã được kiểm tra trên tổng tồn kho
+		if movement.AllowBackorder {
+			err = addWarehouseStock(tx, *movement.WarehouseID, movement.ProductID, movement.VariantID, movement.Delta)
+		} else {
+			err = moveWarehouseStock(tx, *movement.WarehouseID, movement.ProductID, movement.VariantID, movement.Delta)
+		}
+		if err != nil {
+			return err
+		}
+		warehouseBalance, err := NewWarehouseRepo().WithTx(tx).Level(*movement.WarehouseID, movement.ProductID, movement.VariantID)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseBalance = &warehouseBalance
+
+		if err := tx.Unscoped().Model(&model.Product{}).Where("id = ?", movement.ProductID).
+			Select("stock").Scan(&movement.Balance).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Product", "Variant", "Order", "PurchaseOrder", "Stocktake", "User", "Warehouse").Create(movement).Error
+	})
+}
+
+// stockFloor trả về tồn kho thấp nhất được phép sau biến động: 0, hoặc -MaxBackorder khi movement cho phép bán
+// vượt tồn kho và sản phẩm có chính sách backorder/preorder; nil là không giới hạn (chỉ với nhập hàng, kể cả khi
+// tồn kho đang âm)
+func stockFloor(tx *gorm.DB, movement *model.StockMovement) (*int, error) {
+	if movement.Delta >= 0 {
+		return nil, nil
+	}
+	floor := 0
+	if !movement.AllowBackorder {
+		return &floor, nil
+	}
+
+	var product model.Product
+	if err := tx.Unscoped().Select("id", "stock_policy", "max_backorder").First(&product, movement.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	floor = product.OversellFloor()
+	return &floor, nil
+}
+
+// aboveFloor giới hạn câu lệnh cập nhật tồn kho ở các dòng còn đủ hàng: stock + delta >= floor (nil là không giới hạn)
+func aboveFloor(delta int, floor *int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if floor == nil {
+			return db
+		}
+		return db.Where("stock + ? >= ?", delta, *floor)
+	}
+}
+
+// missingOrInsufficient phân biệt bản ghi không tồn tại với không đủ tồn kho khi câu lệnh cập nhật không khớp dòng nào
+func missingOrInsufficient(tx *gorm.DB, value interface{}, id uint, notFound string) error {
+	var count int64
+	if err := tx.Unscoped().Model(value).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New(notFound)
+	}
+	return errors.New("insufficient stock")
+}
+
+// MoveToBalance đưa tồn kho của sản phẩm/biến thể về đúng số dư balance: khóa tồn kho hiện tại, tính Delta từ
+// số dư đã khóa rồi ghi sổ kho như Move, nên đơn hàng hay điều chỉnh vừa diễn ra không bị ghi đè bởi một số dư
+// cũ. Không ghi gì khi tồn kho đã bằng balance.
+func (r *StockRepo) MoveToBalance(movement *model.StockMovement, balance int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stock := r.WithTx(tx)
+		current, err := stock.LockStock(NewStockKey(movement.ProductID, movement.VariantID))
+		if err != nil {
+			return err
+		}
+		movement.Delta = balance - current
+		if movement.Delta == 0 {
+			return nil
+		}
+		return stock.Move(movement)
+	})
+}
+
+// MoveOrder ghi biến động tồn kho cho mọi dòng của đơn hàng: sign = -1 khi trừ kho (bán), +1 khi hoàn kho (hủy, trả hàng).
+// Dòng còn chờ hàng về (BackorderedQuantity > 0) được phép trừ vượt tồn kho theo chính sách của sản phẩm.
+func (r *StockRepo) MoveOrder(order *model.Order, reason string, sign int, userID *uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stock := r.WithTx(tx)
+		for _, item := range order.OrderItems {
+			orderID := order.ID
+			movement := model.StockMovement{
+				ProductID:      item.ProductID,
+				VariantID:      item.VariantID,
+				Delta:          sign * item.Quantity,
+				Reason:         reason,
+				OrderID:        &orderID,
+				UserID:         userID,
+				WarehouseID:    item.WarehouseID,
+				AllowBackorder: item.BackorderedQuantity > 0,
+			}
+			if err := stock.Move(&movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Clear ghi xuất toàn bộ tồn kho còn lại của sản phẩm/biến thể tại mọi kho (khi xóa biến thể hoặc chuyển
+// sản phẩm sang quản lý tồn kho theo biến thể); template cung cấp lý do, ghi chú và người thực hiện
+func (r *StockRepo) Clear(productID uint, variantID *uint, template model.StockMovement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		levels, err := NewWarehouseRepo().WithTx(tx).Levels(productID, variantID)
+		if err != nil {
+			return err
+		}
+		for _, level := range levels {
+			if level.Stock <= 0 {
+				continue
+			}
+			warehouseID := level.WarehouseID
+			movement := template
+			movement.ProductID = productID
+			movement.VariantID = variantID
+			movement.WarehouseID = &warehouseID
+			movement.Delta = -level.Stock
+			if err := r.WithTx(tx).Move(&movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Transfer chuyển hàng giữa hai kho: ghi hai dòng sổ kho transfer (xuất khỏi kho nguồn, nhập vào kho đích)
+// và lưu phiếu chuyển kho; tổng tồn kho không đổi. Trả về "insufficient stock" nếu kho nguồn không đủ hàng.
+func (r *StockRepo) Transfer(transfer *model.StockTransfer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("FromWarehouse", "ToWarehouse", "Product", "Variant", "User").Create(transfer).Error; err != nil {
+			return err
+		}
+
+		stock := r.WithTx(tx)
+		legs := []struct {
+			warehouseID uint
+			sign        int
+		}{{transfer.FromWarehouseID, -1}, {transfer.ToWarehouseID, 1}}
+		for _, leg := range legs {
+			warehouseID := leg.warehouseID
+			if err := stock.Move(&model.StockMovement{
+				ProductID:   transfer.ProductID,
+				VariantID:   transfer.VariantID,
+				WarehouseID: &warehouseID,
+				Delta:       leg.sign * transfer.Quantity,
+				Reason:      consts.StockReasonTransfer,
+				UserID:      transfer.UserID,
+				Note:        fmt.Sprintf("phiếu chuyển kho #%d", transfer.ID),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByProductID lấy lịch sử biến động tồn kho của sản phẩm có phân trang, mới nhất trước
+func (r *StockRepo) GetByProductID(productID uint, filter StockMovementFilter, page, limit int) ([]model.StockMovement, int64, error) {
+	var movements []model.StockMovement
+	var total int64
+
+	query := r.db.Model(&model.StockMovement{}).Where("product_id = ?", productID)
+	if filter.VariantID != nil {
+		query = query.Where("variant_id = ?", *filter.VariantID)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Variant", unscopedPreload).
+		Preload("Order", unscopedPreload).
+		Preload("User", unscopedPreload).
+		Preload("Warehouse").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&movements).Error
+
+	return movements, total, err
+}
+
+// unscopedPreload nạp cả bản ghi đã xóa mềm để lịch sử vẫn hiển thị SKU, mã đơn và người thực hiện
+func unscopedPreload(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// SalesByProduct trả về số lượng đã bán của từng sản phẩm kể từ since (bán và giữ hàng trừ đi số hoàn do hủy đơn)
+func (r *StockRepo) SalesByProduct(productIDs []uint, since time.Time) (map[uint]int, error) {
+	sales := make(map[uint]int, len(productIDs))
+	if len(productIDs) == 0 {
+		return sales, nil
+	}
+
+	var rows []struct {
+		ProductID uint
+		Sold      int
+	}
+	err := r.db.Model(&model.StockMovement{}).
+		Select("product_id, SUM(-delta) AS sold").
+		Where("product_id IN ? AND created_at >= ?", productIDs, since).
+		Where("reason IN ?", []string{consts.StockReasonSale, consts.StockReasonReservation, consts.StockReasonCancellation}).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Sold > 0 {
+			sales[row.ProductID] = row.Sold
+		}
+	}
+	return sales, nil
+}
+
+// LockStock đọc và khóa tồn kho hiện tại của sản phẩm/biến thể (cả bản ghi đã xóa mềm) đến hết giao dịch
+func (r *StockRepo) LockStock(key StockKey) (int, error) {
+	var stock int
+	query := r.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("stock")
+	if key.VariantKey != 0 {
+		query = query.Model(&model.ProductVariant{}).Where("id = ? AND product_id = ?", key.VariantKey, key.ProductID)
+	} else {
+		query = query.Model(&model.Product{}).Where("id = ?", key.ProductID)
+	}
+	err := query.Scan(&stock).Error
+	return stock, err
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"backend/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestStockFloorWithoutBackorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		movement model.StockMovement
+		want     *int
+	}{
+		{"inbound movement is never limited", model.StockMovement{Delta: 5}, nil},
+		{"inbound movement of a backorder line", model.StockMovement{Delta: 5, AllowBackorder: true}, nil},
+		{"outbound movement stops at zero", model.StockMovement{Delta: -5}, new(int)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Các trường hợp này không cần đọc chính sách của sản phẩm nên không chạm tới tx
+			got, err := stockFloor(nil, &tt.movement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stockFloor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAboveFloor(t *testing.T) {
+	floor := -3
+	tests := []struct {
+		name     string
+		floor    *int
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{"unlimited", nil, "UPDATE `products` SET `stock`=stock + ? WHERE id = ?", []interface{}{-2, 1}},
+		{"limited", &floor, "UPDATE `products` SET `stock`=stock + ? WHERE id = ? AND stock + ? >= ?", []interface{}{-2, 1, -2, -3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := dryRunDB(t).Model(&model.Product{}).Where("id = ?", 1).
+				Scopes(aboveFloor(-2, tt.floor)).
+				UpdateColumn("stock", gorm.Expr("stock + ?", -2)).Statement
+			if sql := stmt.SQL.String(); !strings.HasPrefix(sql, tt.wantSQL) {
+				t.Errorf("SQL = %q, want prefix %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(stmt.Vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Số dòng kiểm kê ghi mỗi lô khi mở phiên
+const stocktakeLineBatchSize = 500
+
+type StocktakeRepo struct {
+	db *gorm.DB
+}
+
+// StocktakeFilter lọc danh sách phiên kiểm kê
+type StocktakeFilter struct {
+	Status      string
+	WarehouseID *uint
+}
+
+func NewStocktakeRepo() *StocktakeRepo {
+	return &StocktakeRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *StocktakeRepo) WithTx(tx *gorm.DB) *StocktakeRepo {
+	return &StocktakeRepo{db: tx}
+}
+
+// GenerateCode tạo mã phiên kiểm kê chưa tồn tại, dạng ST-20251019-0042
+func (r *StocktakeRepo) GenerateCode() (string, error) {
+	for {
+		code := fmt.Sprintf("ST-%s-%04d", time.Now().Format("20060102"), rand.Intn(10000))
+		var count int64
+		if err := r.db.Model(&model.Stocktake{}).Where("code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+}
+
+// HasOpen kiểm tra kho đang có phiên kiểm kê chưa đóng
+func (r *StocktakeRepo) HasOpen(warehouseID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Stocktake{}).
+		Where("warehouse_id = ? AND status = ?", warehouseID, consts.StocktakeOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ScopeLines tạo dòng kiểm kê (chưa có tồn kho hệ thống) cho mọi sản phẩm chưa xóa thuộc categoryIDs
+// (nil là toàn bộ sản phẩm); sản phẩm có biến thể được đếm theo từng biến thể
+func (r *StocktakeRepo) ScopeLines(categoryIDs []uint) ([]model.StocktakeLine, error) {
+	var products []model.Product
+	query := r.db.Select("id", "sku", "name").
+		Preload("Variants", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "product_id", "sku").Order("id ASC")
+		})
+	if categoryIDs != nil {
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+	if err := query.Order("sku ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	lines := make([]model.StocktakeLine, 0, len(products))
+	for _, product := range products {
+		if len(product.Variants) == 0 {
+			lines = append(lines, model.StocktakeLine{ProductID: product.ID, SKU: product.SKU, Name: product.Name})
+			continue
+		}
+		for _, variant := range product.Variants {
+			variantID := variant.ID
+			lines = append(lines, model.StocktakeLine{ProductID: product.ID, VariantID: &variantID, SKU: variant.SKU, Name: product.Name})
+		}
+	}
+	return lines, nil
+}
+
+// Create tạo phiên kiểm kê kèm dòng kiểm kê
+func (r *StocktakeRepo) Create(stocktake *model.Stocktake) error {
+	lines := stocktake.Lines
+	stocktake.Lines = nil
+	defer func() { stocktake.Lines = lines }()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(stocktake).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		for i := range lines {
+			lines[i].StocktakeID = stocktake.ID
+		}
+		return tx.Omit(clause.Associations).CreateInBatches(&lines, stocktakeLineBatchSize).Error
+	})
+}
+
+// GetByID lấy phiên kiểm kê kèm kho, danh mục và dòng kiểm kê (cùng sản phẩm để tính giá trị chênh lệch)
+func (r *StocktakeRepo) GetByID(id uint) (*model.Stocktake, error) {
+	return r.get(r.db, id)
+}
+
+// LockByID lấy phiên kiểm kê và khóa dòng (SELECT ... FOR UPDATE) để cập nhật trong giao dịch
+func (r *StocktakeRepo) LockByID(id uint) (*model.Stocktake, error) {
+	return r.get(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *StocktakeRepo) get(db *gorm.DB, id uint) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := db.Preload("Warehouse").
+		Preload("Category").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("sku ASC, id ASC") }).
+		Preload("Lines.Product", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "average_cost")
+		}).
+		First(&stocktake, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stocktake not found")
+		}
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+// GetAll lấy danh sách phiên kiểm kê có phân trang, mới nhất trước
+func (r *StocktakeRepo) GetAll(filter StocktakeFilter, page, limit int) ([]model.Stocktake, int64, error) {
+	var stocktakes []model.Stocktake
+	var total int64
+
+	query := r.db.Model(&model.Stocktake{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Warehouse").
+		Preload("Category").
+		Preload("Lines").
+		Preload("Lines.Product", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "average_cost")
+		}).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&stocktakes).Error
+
+	return stocktakes, total, err
+}
+
+// UpdateLine cập nhật số đếm hoặc kết quả điều chỉnh của một dòng kiểm kê
+func (r *StocktakeRepo) UpdateLine(lineID uint, updates map[string]interface{}) error {
+	return r.db.Model(&model.StocktakeLine{}).Where("id = ?", lineID).Updates(updates).Error
+}
+
+// UpdateStatus cập nhật trạng thái phiên kiểm kê và các cột kèm theo
+func (r *StocktakeRepo) UpdateStatus(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&model.Stocktake{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type SupplierRepo struct {
+	db *gorm.DB
+}
+
+func NewSupplierRepo() *SupplierRepo {
+	return &SupplierRepo{
+		db: app.GetDB(),
+	}
+}
+
+// GetAll lấy nhà cung cấp theo tên (tìm gần đúng), activeOnly để chỉ lấy nhà cung cấp đang hợp tác
+func (r *SupplierRepo) GetAll(search string, activeOnly bool) ([]model.Supplier, error) {
+	var suppliers []model.Supplier
+	query := r.db.Model(&model.Supplier{})
+	if search != "" {
+		query = query.Where("name LIKE ?", "%"+search+"%")
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("name ASC").Find(&suppliers).Error
+	return suppliers, err
+}
+
+// GetByID lấy nhà cung cấp theo ID
+func (r *SupplierRepo) GetByID(id uint) (*model.Supplier, error) {
+	var supplier model.Supplier
+	err := r.db.First(&supplier, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("supplier not found")
+		}
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// Create tạo nhà cung cấp mới
+func (r *SupplierRepo) Create(supplier *model.Supplier) error {
+	return r.db.Create(supplier).Error
+}
+
+// Update cập nhật nhà cung cấp
+func (r *SupplierRepo) Update(supplier *model.Supplier) error {
+	return r.db.Save(supplier).Error
+}
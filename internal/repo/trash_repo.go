@@ -0,0 +1,349 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Loại bản ghi có thùng rác (khớp với đường dẫn /api/admin/trash/:type)
+const (
+	TrashProducts   = "products"
+	TrashCategories = "categories"
+	TrashNews       = "news"
+	TrashUsers      = "users"
+	TrashOrders     = "orders"
+)
+
+var TrashTypes = []string{TrashProducts, TrashCategories, TrashNews, TrashUsers, TrashOrders}
+
+// TrashPurgeResult là số bản ghi đã xóa vĩnh viễn theo từng loại.
+// Skipped là số bản ghi quá hạn nhưng vẫn được giữ lại vì còn bị tham chiếu (sản phẩm có trong đơn hàng, người dùng là tác giả tin tức).
+type TrashPurgeResult struct {
+	Before     time.Time `json:"before"`
+	Products   int64     `json:"products"`
+	Categories int64     `json:"categories"`
+	News       int64     `json:"news"`
+	Users      int64     `json:"users"`
+	Orders     int64     `json:"orders"`
+	Skipped    int64     `json:"skipped"`
+
+	// Ảnh đã tải lên của các bản ghi bị xóa, caller dọn tệp trong kho lưu trữ sau khi giao dịch thành công
+	ProductImages []model.ProductImage `json:"-"`
+	NewsImages    []model.News         `json:"-"`
+}
+
+type TrashRepo struct {
+	db *gorm.DB
+}
+
+func NewTrashRepo() *TrashRepo {
+	return &TrashRepo{
+		db: app.GetDB(),
+	}
+}
+
+// deleted giới hạn truy vấn vào các bản ghi đã xóa mềm
+func deleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// listDeleted lấy bản ghi đã xóa mềm có phân trang (kèm các quan hệ preloads), mới xóa nhất trước
+func listDeleted[T any](db *gorm.DB, page, limit int, preloads ...string) ([]T, int64, error) {
+	var items []T
+	var total int64
+
+	if err := deleted(db.Model(new(T))).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := deleted(db)
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&items).Error
+	return items, total, err
+}
+
+// firstDeleted lấy một bản ghi đã xóa mềm theo ID, trả về notFound nếu không có trong thùng rác
+func firstDeleted[T any](db *gorm.DB, id uint, notFound string) (*T, error) {
+	var item T
+	if err := deleted(db).First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(notFound)
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// existsLive kiểm tra bảng có bản ghi chưa xóa nào khớp điều kiện
+func existsLive(tx *gorm.DB, value interface{}, query string, args ...interface{}) (bool, error) {
+	var count int64
+	err := tx.Model(value).Where(query, args...).Count(&count).Error
+	return count > 0, err
+}
+
+// restore bỏ đánh dấu xóa mềm của bản ghi
+func restore(tx *gorm.DB, value interface{}, id uint) error {
+	return tx.Unscoped().Model(value).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// ListProducts lấy sản phẩm trong thùng rác
+func (r *TrashRepo) ListProducts(page, limit int) ([]model.Product, int64, error) {
+	return listDeleted[model.Product](r.db, page, limit, "Category", "Brand")
+}
+
+// ListCategories lấy danh mục trong thùng rác
+func (r *TrashRepo) ListCategories(page, limit int) ([]model.Category, int64, error) {
+	return listDeleted[model.Category](r.db, page, limit)
+}
+
+// ListNews lấy bài viết trong thùng rác
+func (r *TrashRepo) ListNews(page, limit int) ([]model.News, int64, error) {
+	return listDeleted[model.News](r.db, page, limit, "Author")
+}
+
+// ListUsers lấy người dùng trong thùng rác
+func (r *TrashRepo) ListUsers(page, limit int) ([]model.User, int64, error) {
+	return listDeleted[model.User](r.db, page, limit)
+}
+
+// ListOrders lấy đơn hàng trong thùng rác
+func (r *TrashRepo) ListOrders(page, limit int) ([]model.Order, int64, error) {
+	return listDeleted[model.Order](r.db, page, limit, "User", "OrderItems")
+}
+
+// RestoreProduct khôi phục sản phẩm; SKU không được trùng với sản phẩm hoặc biến thể đang tồn tại.
+// Danh mục hoặc thương hiệu đã bị xóa trong lúc sản phẩm nằm trong thùng rác sẽ được gỡ khỏi sản phẩm.
+func (r *TrashRepo) RestoreProduct(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := firstDeleted[model.Product](tx, id, "product not found")
+		if err != nil {
+			return err
+		}
+
+		exists, err := existsLive(tx, &model.Product{}, "sku = ?", product.SKU)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			exists, err = existsLive(tx, &model.ProductVariant{}, "sku = ? AND product_id != ?", product.SKU, id)
+			if err != nil {
+				return err
+			}
+		}
+		if exists {
+			return errors.New("sku already exists")
+		}
+
+		updates := map[string]interface{}{}
+		if product.CategoryID != nil {
+			if exists, err := existsLive(tx, &model.Category{}, "id = ?", *product.CategoryID); err != nil {
+				return err
+			} else if !exists {
+				updates["category_id"] = nil
+			}
+		}
+		if product.BrandID != nil {
+			if exists, err := existsLive(tx, &model.Brand{}, "id = ?", *product.BrandID); err != nil {
+				return err
+			} else if !exists {
+				updates["brand_id"] = nil
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Unscoped().Model(&model.Product{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		return restore(tx, &model.Product{}, id)
+	})
+}
+
+// RestoreCategory khôi phục danh mục; slug không được trùng. Danh mục cha đã bị xóa thì danh mục trở thành gốc.
+// Sản phẩm và danh mục con đã được chuyển đi khi xóa không tự động quay về.
+func (r *TrashRepo) RestoreCategory(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		category, err := firstDeleted[model.Category](tx, id, "category not found")
+		if err != nil {
+			return err
+		}
+
+		exists, err := existsLive(tx, &model.Category{}, "slug = ?", category.Slug)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("slug already exists")
+		}
+
+		if category.ParentID != nil {
+			exists, err := existsLive(tx, &model.Category{}, "id = ?", *category.ParentID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				if err := tx.Unscoped().Model(&model.Category{}).Where("id = ?", id).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return restore(tx, &model.Category{}, id)
+	})
+}
+
+// RestoreNews khôi phục bài viết; slug không được trùng
+func (r *TrashRepo) RestoreNews(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		news, err := firstDeleted[model.News](tx, id, "news not found")
+		if err != nil {
+			return err
+		}
+
+		exists, err := existsLive(tx, &model.News{}, "slug = ?", news.Slug)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("slug already exists")
+		}
+
+		return restore(tx, &model.News{}, id)
+	})
+}
+
+// RestoreUser khôi phục người dùng; username và email không được trùng, và chỉ có một owner
+func (r *TrashRepo) RestoreUser(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		user, err := firstDeleted[model.User](tx, id, "user not found")
+		if err != nil {
+			return err
+		}
+
+		if exists, err := existsLive(tx, &model.User{}, "username = ?", user.Username); err != nil {
+			return err
+		} else if exists {
+			return errors.New("username already exists")
+		}
+		if exists, err := existsLive(tx, &model.User{}, "email = ?", user.Email); err != nil {
+			return err
+		} else if exists {
+			return errors.New("email already exists")
+		}
+		if user.Role == consts.RoleOwner {
+			if exists, err := existsLive(tx, &model.User{}, "role = ?", consts.RoleOwner); err != nil {
+				return err
+			} else if exists {
+				return errors.New("owner already exists")
+			}
+		}
+
+		return restore(tx, &model.User{}, id)
+	})
+}
+
+// RestoreOrder khôi phục đơn hàng; mã đơn không được trùng
+func (r *TrashRepo) RestoreOrder(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := firstDeleted[model.Order](tx, id, "order not found")
+		if err != nil {
+			return err
+		}
+
+		exists, err := existsLive(tx, &model.Order{}, "order_number = ?", order.OrderNumber)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("order number already exists")
+		}
+
+		return restore(tx, &model.Order{}, id)
+	})
+}
+
+// Purge xóa vĩnh viễn các bản ghi đã nằm trong thùng rác từ trước thời điểm before.
+// Bảng con (ảnh, biến thể, mục đơn hàng, giỏ hàng...) được dọn theo ràng buộc khóa ngoại của model.
+func (r *TrashRepo) Purge(before time.Time) (*TrashPurgeResult, error) {
+	result := &TrashPurgeResult{Before: before}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := func(value interface{}) *gorm.DB {
+			return deleted(tx.Model(value)).Where("deleted_at < ?", before)
+		}
+
+		// Sản phẩm còn nằm trong đơn hàng được giữ lại để không mất lịch sử bán hàng
+		var productIDs []uint
+		var total int64
+		if err := expired(&model.Product{}).Count(&total).Error; err != nil {
+			return err
+		}
+		if err := expired(&model.Product{}).
+			Where("NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.product_id = products.id)").
+			Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+		result.Skipped += total - int64(len(productIDs))
+		if len(productIDs) > 0 {
+			if err := tx.Unscoped().Where("product_id IN ?", productIDs).Find(&result.ProductImages).Error; err != nil {
+				return err
+			}
+			res := tx.Unscoped().Where("id IN ?", productIDs).Delete(&model.Product{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Products = res.RowsAffected
+		}
+
+		res := expired(&model.Category{}).Delete(&model.Category{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Categories = res.RowsAffected
+
+		if err := expired(&model.News{}).Where("image_key != ''").Find(&result.NewsImages).Error; err != nil {
+			return err
+		}
+		res = expired(&model.News{}).Delete(&model.News{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.News = res.RowsAffected
+
+		// Người dùng là tác giả tin tức (kể cả tin đã xóa mềm) được giữ lại
+		if err := expired(&model.User{}).
+			Where("EXISTS (SELECT 1 FROM news WHERE news.author_id = users.id)").
+			Count(&total).Error; err != nil {
+			return err
+		}
+		result.Skipped += total
+		res = expired(&model.User{}).
+			Where("NOT EXISTS (SELECT 1 FROM news WHERE news.author_id = users.id)").
+			Delete(&model.User{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Users = res.RowsAffected
+
+		res = expired(&model.Order{}).Delete(&model.Order{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Orders = res.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"backend/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestTrashQueries(t *testing.T) {
+	db := dryRunDB(t)
+	statements := recordStatements(t, db)
+
+	if _, _, err := listDeleted[model.News](db, 3, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(db, &model.News{}, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []recordedStatement{
+		{SQL: "SELECT count(*) FROM `news` WHERE deleted_at IS NOT NULL"},
+		{SQL: "SELECT * FROM `news` WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT ? OFFSET ?", Vars: []interface{}{20, 40}},
+		{SQL: "UPDATE `news` SET `deleted_at`=?,`updated_at`=? WHERE id = ?"},
+	}
+	if len(*statements) != len(want) {
+		t.Fatalf("statements = %v, want %d", *statements, len(want))
+	}
+	for i, statement := range *statements {
+		if statement.SQL != want[i].SQL {
+			t.Errorf("statement %d SQL = %q, want %q", i, statement.SQL, want[i].SQL)
+		}
+		if want[i].Vars != nil && !reflect.DeepEqual(statement.Vars, want[i].Vars) {
+			t.Errorf("statement %d vars = %#v, want %#v", i, statement.Vars, want[i].Vars)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"backend/app"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// errDryRun buộc giao dịch chạy thử rollback sau khi fn hoàn tất
+var errDryRun = errors.New("dry run")
+
+// Transaction chạy fn trong một giao dịch; dùng WithTx của các repo để thao tác trong tx
+func Transaction(fn func(tx *gorm.DB) error) error {
+	return app.GetDB().Transaction(fn)
+}
+
+// DryRun chạy fn trong một giao dịch luôn được rollback: fn đọc và ghi như thật
+// nhưng không thay đổi nào được lưu lại. Lỗi do fn trả về được trả lại nguyên vẹn.
+func DryRun(fn func(tx *gorm.DB) error) error {
+	err := Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
@@ -27,6 +27,19 @@ func (r *UserRepository) GetUserByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
+// GetAuthUser lấy người dùng khi xác thực request, dùng bộ nhớ đệm ngắn hạn (xem AUTH_CACHE_SECONDS)
+func (r *UserRepository) GetAuthUser(id uint) (*model.User, error) {
+	if user, ok := authUsers.get(id); ok {
+		return &user, nil
+	}
+	user, err := r.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	authUsers.set(id, *user)
+	return user, nil
+}
+
 func (r *UserRepository) GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
 	err := r.db.Where("username = ?", username).First(&user).Error
@@ -46,10 +59,12 @@ func (r *UserRepository) GetUserByEmail(email string) (*model.User, error) {
 }
 
 func (r *UserRepository) UpdateUser(user *model.User) error {
+	defer authUsers.delete(user.ID)
 	return r.db.Save(user).Error
 }
 
 func (r *UserRepository) DeleteUser(id uint) error {
+	defer authUsers.delete(id)
 	return r.db.Delete(&model.User{}, id).Error
 }
 
@@ -103,6 +118,7 @@ func (r *UserRepository) GetUsersByRole(role string, page, limit int) ([]model.U
 
 // UpdateUserRole cập nhật vai trò người dùng (kèm kiểm tra quyền)
 func (r *UserRepository) UpdateUserRole(userID uint, newRole string) error {
+	defer authUsers.delete(userID)
 	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("role", newRole).Error
 }
 
@@ -0,0 +1,360 @@
+package repo
+
+import (
+	"backend/app"
+	"backend/internal/model"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WarehouseRepo struct {
+	db *gorm.DB
+}
+
+// StockTransferFilter lọc lịch sử chuyển kho
+type StockTransferFilter struct {
+	WarehouseID *uint // Kho xuất hoặc kho nhận
+	ProductID   *uint
+}
+
+// StockKey xác định một dòng tồn kho, dùng làm khóa map: sản phẩm và biến thể (0 với sản phẩm không có biến thể)
+type StockKey struct {
+	ProductID  uint
+	VariantKey uint
+}
+
+func NewWarehouseRepo() *WarehouseRepo {
+	return &WarehouseRepo{
+		db: app.GetDB(),
+	}
+}
+
+// WithTx trả về bản sao repo chạy trong giao dịch tx
+func (r *WarehouseRepo) WithTx(tx *gorm.DB) *WarehouseRepo {
+	return &WarehouseRepo{db: tx}
+}
+
+// GetAll lấy tất cả kho theo thứ tự ưu tiên
+func (r *WarehouseRepo) GetAll() ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	err := r.db.Order("priority ASC, id ASC").Find(&warehouses).Error
+	return warehouses, err
+}
+
+// GetActive lấy các kho đang hoạt động theo thứ tự ưu tiên
+func (r *WarehouseRepo) GetActive() ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	err := r.db.Where("is_active = ?", true).Order("priority ASC, id ASC").Find(&warehouses).Error
+	return warehouses, err
+}
+
+// GetByID lấy kho theo ID
+func (r *WarehouseRepo) GetByID(id uint) (*model.Warehouse, error) {
+	var warehouse model.Warehouse
+	err := r.db.First(&warehouse, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("warehouse not found")
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// Default lấy kho mặc định: kho đang hoạt động có độ ưu tiên cao nhất
+func (r *WarehouseRepo) Default() (*model.Warehouse, error) {
+	var warehouse model.Warehouse
+	err := r.db.Where("is_active = ?", true).Order("priority ASC, id ASC").First(&warehouse).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("warehouse not found")
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// Create tạo kho mới
+func (r *WarehouseRepo) Create(warehouse *model.Warehouse) error {
+	return r.db.Create(warehouse).Error
+}
+
+// Update cập nhật kho
+func (r *WarehouseRepo) Update(warehouse *model.Warehouse) error {
+	return r.db.Save(warehouse).Error
+}
+
+// CheckCodeExists kiểm tra mã kho đã tồn tại (loại trừ kho khác khi truyền excludeID)
+func (r *WarehouseRepo) CheckCodeExists(code string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&model.Warehouse{}).Where("code = ?", code)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// TotalStock trả về tổng tồn kho theo từng kho
+func (r *WarehouseRepo) TotalStock() (map[uint]int64, error) {
+	var rows []struct {
+		WarehouseID uint
+		Total       int64
+	}
+	if err := r.db.Model(&model.WarehouseStock{}).
+		Select("warehouse_id, COALESCE(SUM(stock), 0) AS total").
+		Group("warehouse_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		totals[row.WarehouseID] = row.Total
+	}
+	return totals, nil
+}
+
+// Level trả về tồn kho của sản phẩm/biến thể tại một kho (0 khi chưa có dòng tồn kho)
+func (r *WarehouseRepo) Level(warehouseID, productID uint, variantID *uint) (int, error) {
+	return r.level(r.db, warehouseID, productID, variantID)
+}
+
+// LockLevel như Level nhưng khóa dòng tồn kho (SELECT ... FOR UPDATE) đến hết giao dịch
+func (r *WarehouseRepo) LockLevel(warehouseID, productID uint, variantID *uint) (int, error) {
+	return r.level(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), warehouseID, productID, variantID)
+}
+
+func (r *WarehouseRepo) level(db *gorm.DB, warehouseID, productID uint, variantID *uint) (int, error) {
+	var stock int
+	err := db.Model(&model.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND variant_key = ?", warehouseID, productID, model.VariantKeyOf(variantID)).
+		Select("COALESCE(SUM(stock), 0)").
+		Scan(&stock).Error
+	return stock, err
+}
+
+// Levels lấy tồn kho tại từng kho của đúng sản phẩm/biến thể (variantID = nil là tồn kho riêng của sản phẩm)
+func (r *WarehouseRepo) Levels(productID uint, variantID *uint) ([]model.WarehouseStock, error) {
+	var levels []model.WarehouseStock
+	err := r.db.Where("product_id = ? AND variant_key = ?", productID, model.VariantKeyOf(variantID)).
+		Order("warehouse_id ASC").
+		Find(&levels).Error
+	return levels, err
+}
+
+// GetByProductID lấy tồn kho tại từng kho của sản phẩm và mọi biến thể
+func (r *WarehouseRepo) GetByProductID(productID uint) ([]model.WarehouseStock, error) {
+	var levels []model.WarehouseStock
+	err := r.db.Preload("Warehouse").Preload("Variant", unscopedPreload).
+		Where("product_id = ?", productID).
+		Order("warehouse_id ASC, variant_key ASC").
+		Find(&levels).Error
+	return levels, err
+}
+
+// LevelsFor lấy tồn kho tại mọi kho của các dòng tồn kho keys, theo kho rồi theo khóa
+func (r *WarehouseRepo) LevelsFor(keys []StockKey) (map[uint]map[StockKey]int, error) {
+	levels := make(map[uint]map[StockKey]int)
+	if len(keys) == 0 {
+		return levels, nil
+	}
+
+	pairs := make([][]interface{}, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, []interface{}{key.ProductID, key.VariantKey})
+	}
+
+	var rows []model.WarehouseStock
+	if err := r.db.Where("(product_id, variant_key) IN ?", pairs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if levels[row.WarehouseID] == nil {
+			levels[row.WarehouseID] = make(map[StockKey]int)
+		}
+		levels[row.WarehouseID][StockKey{ProductID: row.ProductID, VariantKey: row.VariantKey}] = row.Stock
+	}
+	return levels, nil
+}
+
+// NewStockKey tạo khóa tồn kho của sản phẩm/biến thể
+func NewStockKey(productID uint, variantID *uint) StockKey {
+	return StockKey{ProductID: productID, VariantKey: model.VariantKeyOf(variantID)}
+}
+
+// GetStock lấy tồn kho của một kho có phân trang (bỏ dòng bằng 0), sản phẩm mới cập nhật trước
+func (r *WarehouseRepo) GetStock(warehouseID uint, page, limit int) ([]model.WarehouseStock, int64, error) {
+	var levels []model.WarehouseStock
+	var total int64
+
+	query := r.db.Model(&model.WarehouseStock{}).Where("warehouse_id = ? AND stock <> 0", warehouseID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Product", unscopedPreload).
+		Preload("Variant", unscopedPreload).
+		Order("updated_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&levels).Error
+
+	return levels, total, err
+}
+
+// GetTransfers lấy lịch sử chuyển kho có phân trang, mới nhất trước
+func (r *WarehouseRepo) GetTransfers(filter StockTransferFilter, page, limit int) ([]model.StockTransfer, int64, error) {
+	var transfers []model.StockTransfer
+	var total int64
+
+	query := r.db.Model(&model.StockTransfer{})
+	if filter.WarehouseID != nil {
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", *filter.WarehouseID, *filter.WarehouseID)
+	}
+	if filter.ProductID != nil {
+		query = query.Where("product_id = ?", *filter.ProductID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("FromWarehouse").
+		Preload("ToWarehouse").
+		Preload("Product", unscopedPreload).
+		Preload("Variant", unscopedPreload).
+		Preload("User", unscopedPreload).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&transfers).Error
+
+	return transfers, total, err
+}
+
+// defaultWarehouses là hai kho ban đầu; tỉnh/thành theo đơn vị hành chính cấp tỉnh từ 07/2025
+var defaultWarehouses = []model.Warehouse{
+	{
+		Code:     "HN",
+		Name:     "Kho Hà Nội",
+		Province: "Hà Nội",
+		Provinces: strings.Join([]string{
+			"Hà Nội", "Hải Phòng", "Quảng Ninh", "Cao Bằng", "Lạng Sơn", "Tuyên Quang", "Lào Cai",
+			"Thái Nguyên", "Phú Thọ", "Bắc Ninh", "Hưng Yên", "Ninh Bình", "Điện Biên", "Lai Châu",
+			"Sơn La", "Thanh Hóa", "Nghệ An", "Hà Tĩnh", "Quảng Trị", "Huế",
+		}, ", "),
+		Priority: 1,
+		IsActive: true,
+	},
+	{
+		Code:     "HCM",
+		Name:     "Kho TP. Hồ Chí Minh",
+		Province: "Hồ Chí Minh",
+		Provinces: strings.Join([]string{
+			"Hồ Chí Minh", "Đà Nẵng", "Quảng Ngãi", "Gia Lai", "Khánh Hòa", "Đắk Lắk", "Lâm Đồng",
+			"Đồng Nai", "Tây Ninh", "Cần Thơ", "Vĩnh Long", "Đồng Tháp", "Cà Mau", "An Giang",
+		}, ", "),
+		Priority: 2,
+		IsActive: true,
+	},
+}
+
+// SeedDefaults tạo kho Hà Nội và TP. Hồ Chí Minh khi chưa có kho nào; trả về true nếu đã tạo
+func (r *WarehouseRepo) SeedDefaults() (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.Warehouse{}).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	warehouses := make([]model.Warehouse, len(defaultWarehouses))
+	copy(warehouses, defaultWarehouses)
+	return true, r.db.Create(&warehouses).Error
+}
+
+// BackfillStock đưa phần tồn kho chưa được phân vào kho nào (dữ liệu trước khi có nhiều kho) vào kho mặc định;
+// tổng tồn kho không đổi nên không ghi sổ kho. Trả về số dòng tồn kho đã bổ sung.
+func (r *WarehouseRepo) BackfillStock() (int, error) {
+	warehouse, err := r.Default()
+	if err != nil {
+		return 0, err
+	}
+
+	var missing []struct {
+		ProductID uint
+		VariantID *uint
+		Missing   int
+	}
+	// Sản phẩm không có biến thể: tồn kho riêng của sản phẩm
+	if err := r.db.Raw(`SELECT p.id AS product_id, NULL AS variant_id, p.stock - COALESCE(SUM(ws.stock), 0) AS missing
+		FROM products p
+		LEFT JOIN warehouse_stocks ws ON ws.product_id = p.id AND ws.variant_key = 0
+		WHERE NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+		GROUP BY p.id, p.stock
+		HAVING missing > 0`).Scan(&missing).Error; err != nil {
+		return 0, err
+	}
+	var variants []struct {
+		ProductID uint
+		VariantID *uint
+		Missing   int
+	}
+	if err := r.db.Raw(`SELECT v.product_id, v.id AS variant_id, v.stock - COALESCE(SUM(ws.stock), 0) AS missing
+		FROM product_variants v
+		LEFT JOIN warehouse_stocks ws ON ws.variant_key = v.id
+		GROUP BY v.id, v.product_id, v.stock
+		HAVING missing > 0`).Scan(&variants).Error; err != nil {
+		return 0, err
+	}
+	missing = append(missing, variants...)
+
+	for _, row := range missing {
+		if err := addWarehouseStock(r.db, warehouse.ID, row.ProductID, row.VariantID, row.Missing); err != nil {
+			return 0, err
+		}
+	}
+	return len(missing), nil
+}
+
+// moveWarehouseStock cộng delta vào tồn kho tại kho; trả về "insufficient stock" nếu kho không đủ hàng để trừ
+func moveWarehouseStock(tx *gorm.DB, warehouseID, productID uint, variantID *uint, delta int) error {
+	if delta >= 0 {
+		return addWarehouseStock(tx, warehouseID, productID, variantID, delta)
+	}
+
+	result := tx.Model(&model.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND variant_key = ? AND stock + ? >= 0", warehouseID, productID, model.VariantKeyOf(variantID), delta).
+		UpdateColumns(map[string]interface{}{"stock": gorm.Expr("stock + ?", delta), "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("insufficient stock")
+	}
+	return nil
+}
+
+// addWarehouseStock cộng delta vào tồn kho tại kho không kiểm tra số dư (delta âm chỉ dùng khi bán vượt tồn kho),
+// tạo dòng tồn kho nếu chưa có
+func addWarehouseStock(tx *gorm.DB, warehouseID, productID uint, variantID *uint, delta int) error {
+	return tx.Omit("Warehouse", "Product", "Variant").Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"stock": gorm.Expr("stock + ?", delta), "updated_at": time.Now()}),
+	}).Create(&model.WarehouseStock{
+		WarehouseID: warehouseID,
+		ProductID:   productID,
+		VariantKey:  model.VariantKeyOf(variantID),
+		VariantID:   variantID,
+		Stock:       delta,
+	}).Error
+}
@@ -0,0 +1,282 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/char/html"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	htmlHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+const (
+	vietnameseAnalyzer = "vietnamese"
+	skuAnalyzer        = "sku"
+)
+
+// Trọng số theo trường khi xếp hạng kết quả
+const (
+	boostTitle  = 3.0
+	boostSKU    = 5.0
+	boostTags   = 1.5
+	boostBody   = 1.0
+	boostPhrase = 4.0
+	boostFuzzy  = 0.3
+)
+
+// BleveIndex là chỉ mục ngược nhúng trong tiến trình, dùng bleve
+type BleveIndex struct {
+	index bleve.Index
+}
+
+// OpenBleve mở chỉ mục tại thư mục dir, tạo mới nếu chưa có
+func OpenBleve(dir string) (*BleveIndex, error) {
+	index, err := bleve.Open(dir)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return nil, err
+		}
+		index, err = bleve.New(dir, newIndexMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open search index %s: %w", dir, err)
+	}
+	return &BleveIndex{index: index}, nil
+}
+
+// NewMemoryBleve tạo chỉ mục chỉ nằm trong bộ nhớ, được dựng lại mỗi lần khởi động
+func NewMemoryBleve() (*BleveIndex, error) {
+	index, err := bleve.NewMemOnly(newIndexMapping())
+	if err != nil {
+		return nil, err
+	}
+	return &BleveIndex{index: index}, nil
+}
+
+// newIndexMapping khai báo bộ phân tích tiếng Việt (bỏ thẻ HTML, tách từ, chữ thường, bỏ dấu) và các trường
+func newIndexMapping() mapping.IndexMapping {
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.AddCustomAnalyzer(vietnameseAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{html.Name},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, FoldFilterName},
+	})
+	indexMapping.AddCustomAnalyzer(skuAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name, FoldFilterName},
+	})
+
+	textField := func(store bool) *mapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = vietnameseAnalyzer
+		field.Store = store
+		field.IncludeTermVectors = store
+		field.IncludeInAll = false
+		return field
+	}
+
+	typeField := bleve.NewKeywordFieldMapping()
+	typeField.Analyzer = keyword.Name
+	typeField.IncludeInAll = false
+
+	skuField := bleve.NewTextFieldMapping()
+	skuField.Analyzer = skuAnalyzer
+	skuField.Store = false
+	skuField.IncludeInAll = false
+
+	document := bleve.NewDocumentMapping()
+	document.AddFieldMappingsAt("type", typeField)
+	document.AddFieldMappingsAt("title", textField(true))
+	document.AddFieldMappingsAt("body", textField(true))
+	document.AddFieldMappingsAt("tags", textField(false))
+	document.AddFieldMappingsAt("sku", skuField)
+
+	indexMapping.DefaultMapping = document
+	indexMapping.DefaultAnalyzer = vietnameseAnalyzer
+	return indexMapping
+}
+
+func docID(docType string, id uint) string {
+	return docType + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+func parseDocID(value string) (string, uint, bool) {
+	docType, idStr, found := strings.Cut(value, ":")
+	if !found {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return docType, uint(id), true
+}
+
+// Index thêm hoặc thay thế tài liệu theo lô
+func (b *BleveIndex) Index(docs ...Document) error {
+	batch := b.index.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(docID(doc.Type, doc.ID), map[string]interface{}{
+			"type":  doc.Type,
+			"title": doc.Title,
+			"body":  doc.Body,
+			"sku":   doc.SKUs,
+			"tags":  doc.Tags,
+		}); err != nil {
+			return err
+		}
+	}
+	return b.index.Batch(batch)
+}
+
+// Delete xóa tài liệu khỏi chỉ mục
+func (b *BleveIndex) Delete(docType string, id uint) error {
+	return b.index.Delete(docID(docType, id))
+}
+
+// Search tìm tài liệu chứa mọi từ trong truy vấn (mỗi từ khớp chính xác hoặc gần đúng ở một trường bất kỳ),
+// cộng điểm khi cả cụm từ xuất hiện liền nhau trong tiêu đề; khớp SKU chính xác luôn được trả về
+func (b *BleveIndex) Search(q Query) (*Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return &Result{}, nil
+	}
+
+	// Mỗi từ phải khớp ít nhất một trường
+	textQuery := bleve.NewBooleanQuery()
+	for _, term := range terms {
+		textQuery.AddMust(termQuery(term))
+	}
+	if len(terms) > 1 {
+		phrase := bleve.NewMatchPhraseQuery(q.Text)
+		phrase.SetField("title")
+		phrase.SetBoost(boostPhrase)
+		textQuery.AddShould(phrase)
+	}
+
+	sku := bleve.NewTermQuery(Fold(strings.TrimSpace(q.Text)))
+	sku.SetField("sku")
+	sku.SetBoost(boostSKU)
+
+	var root query.Query = bleve.NewDisjunctionQuery(textQuery, sku)
+	if len(q.Types) > 0 {
+		types := make([]query.Query, 0, len(q.Types))
+		for _, docType := range q.Types {
+			typeQuery := bleve.NewTermQuery(docType)
+			typeQuery.SetField("type")
+			types = append(types, typeQuery)
+		}
+		root = bleve.NewConjunctionQuery(root, bleve.NewDisjunctionQuery(types...))
+	}
+
+	request := bleve.NewSearchRequestOptions(root, q.Size, q.From, false)
+	request.Highlight = bleve.NewHighlightWithStyle(htmlHighlighter.Name)
+	request.Highlight.AddField("title")
+	request.Highlight.AddField("body")
+
+	searchResult, err := b.index.Search(request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Total: searchResult.Total}
+	for _, match := range searchResult.Hits {
+		docType, id, ok := parseDocID(match.ID)
+		if !ok {
+			continue
+		}
+		result.Hits = append(result.Hits, Hit{
+			Type:       docType,
+			ID:         id,
+			Score:      match.Score,
+			Highlights: match.Fragments,
+		})
+	}
+	return result, nil
+}
+
+// termQuery khớp một từ (đã bỏ dấu) ở các trường văn bản theo trọng số; từ từ 5 ký tự cho phép sai 1-2 ký tự
+// (âm tiết tiếng Việt ngắn nên khớp gần đúng với từ ngắn gây nhiễu)
+func termQuery(term string) query.Query {
+	fields := []struct {
+		name  string
+		boost float64
+	}{
+		{"title", boostTitle},
+		{"tags", boostTags},
+		{"body", boostBody},
+	}
+
+	fuzziness := 0
+	switch length := utf8.RuneCountInString(term); {
+	case length >= 8:
+		fuzziness = 2
+	case length >= 5:
+		fuzziness = 1
+	}
+
+	var disjuncts []query.Query
+	for _, field := range fields {
+		exact := bleve.NewTermQuery(term)
+		exact.SetField(field.name)
+		exact.SetBoost(field.boost)
+		disjuncts = append(disjuncts, exact)
+
+		if fuzziness > 0 {
+			fuzzy := bleve.NewFuzzyQuery(term)
+			fuzzy.SetField(field.name)
+			fuzzy.SetFuzziness(fuzziness)
+			fuzzy.SetPrefix(1)
+			fuzzy.SetBoost(field.boost * boostFuzzy)
+			disjuncts = append(disjuncts, fuzzy)
+		}
+	}
+	return bleve.NewDisjunctionQuery(disjuncts...)
+}
+
+// IDs trả về ID của mọi tài liệu thuộc loại docType
+func (b *BleveIndex) IDs(docType string) ([]uint, error) {
+	total, err := b.index.DocCount()
+	if err != nil {
+		return nil, err
+	}
+
+	typeQuery := bleve.NewTermQuery(docType)
+	typeQuery.SetField("type")
+	searchResult, err := b.index.Search(bleve.NewSearchRequestOptions(typeQuery, int(total), 0, false))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(searchResult.Hits))
+	for _, match := range searchResult.Hits {
+		if _, id, ok := parseDocID(match.ID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Count trả về tổng số tài liệu đã lập chỉ mục
+func (b *BleveIndex) Count() (uint64, error) {
+	return b.index.DocCount()
+}
+
+// Close đóng chỉ mục
+func (b *BleveIndex) Close() error {
+	return b.index.Close()
+}
@@ -0,0 +1,63 @@
+package search
+
+import "testing"
+
+func TestBleveSearch(t *testing.T) {
+	index, err := NewMemoryBleve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	if err := index.Index(
+		Document{Type: TypeProduct, ID: 1, Title: "Ví da nam Đà Nẵng", SKUs: []string{"VD-001"}, Tags: []string{"Ví nam"}},
+		Document{Type: TypeProduct, ID: 2, Title: "Túi xách nữ", Body: "Chất liệu da bò", SKUs: []string{"TX-002"}},
+		Document{Type: TypeNews, ID: 1, Title: "Xu hướng túi xách", Body: "Bài viết về túi"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"without diacritics", Query{Text: "vi da da nang", Size: 10}, []string{"product:1"}},
+		{"every term must match", Query{Text: "túi xách", Size: 10}, []string{"product:2", "news:1"}},
+		{"filtered by type", Query{Text: "túi xách", Types: []string{TypeNews}, Size: 10}, []string{"news:1"}},
+		{"exact SKU", Query{Text: "TX-002", Size: 10}, []string{"product:2"}},
+		{"typo in a long word", Query{Text: "xachh", Size: 10}, []string{"product:2", "news:1"}},
+		{"no terms", Query{Text: "  !! ", Size: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := index.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]bool)
+			for _, hit := range result.Hits {
+				got[docID(hit.Type, hit.ID)] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("hits = %v, missing %s", got, id)
+				}
+			}
+		})
+	}
+
+	if err := index.Delete(TypeProduct, 2); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := index.IDs(TypeProduct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("IDs(product) after delete = %v, want [1]", ids)
+	}
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"backend/internal/model"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ProductDocument dựng tài liệu chỉ mục từ sản phẩm (kèm thương hiệu, danh mục và SKU biến thể nếu đã nạp)
+func ProductDocument(product *model.Product) Document {
+	doc := Document{
+		Type:  TypeProduct,
+		ID:    product.ID,
+		Title: product.Name,
+		Body:  product.Description,
+		SKUs:  []string{product.SKU},
+	}
+	for _, variant := range product.Variants {
+		doc.SKUs = append(doc.SKUs, variant.SKU)
+	}
+	if product.Brand != nil {
+		doc.Tags = append(doc.Tags, product.Brand.Name)
+	}
+	// Tên các danh mục cha cũng được lập chỉ mục để "ví nam" tìm được sản phẩm trong "Ví nam > Ví da"
+	if product.Category != nil {
+		if len(product.Category.Path) > 0 {
+			for _, item := range product.Category.Path {
+				doc.Tags = append(doc.Tags, item.Name)
+			}
+		} else {
+			doc.Tags = append(doc.Tags, product.Category.Name)
+		}
+	}
+	for _, value := range []string{product.Material, product.Color} {
+		if value != "" {
+			doc.Tags = append(doc.Tags, value)
+		}
+	}
+	return doc
+}
+
+// NewsDocument dựng tài liệu chỉ mục từ bài viết; nội dung HTML được chuyển thành văn bản thuần
+func NewsDocument(news *model.News) Document {
+	body := stripHTML(news.Content)
+	if news.Summary != "" {
+		body = news.Summary + "\n" + body
+	}
+	return Document{
+		Type:  TypeNews,
+		ID:    news.ID,
+		Title: news.Title,
+		Body:  body,
+		Tags:  strings.Split(news.Tags, ","),
+	}
+}
+
+// SyncProduct cập nhật sản phẩm trong chỉ mục: sản phẩm ngừng bán bị gỡ khỏi kết quả tìm kiếm.
+// Lỗi chỉ được ghi log để không làm hỏng thao tác chính; chỉ mục có thể dựng lại bằng reindex.
+func SyncProduct(product *model.Product) {
+	if !product.IsActive {
+		RemoveProduct(product.ID)
+		return
+	}
+	if err := MustDefault().Index(ProductDocument(product)); err != nil {
+		log.Printf("⚠️  Failed to index product #%d: %v", product.ID, err)
+	}
+}
+
+// RemoveProduct gỡ sản phẩm khỏi chỉ mục
+func RemoveProduct(id uint) {
+	if err := MustDefault().Delete(TypeProduct, id); err != nil {
+		log.Printf("⚠️  Failed to remove product #%d from search index: %v", id, err)
+	}
+}
+
+// SyncNews cập nhật bài viết trong chỉ mục: chỉ bài viết đã xuất bản mới tìm kiếm được
+func SyncNews(news *model.News) {
+	if !news.IsPublished {
+		RemoveNews(news.ID)
+		return
+	}
+	if err := MustDefault().Index(NewsDocument(news)); err != nil {
+		log.Printf("⚠️  Failed to index news #%d: %v", news.ID, err)
+	}
+}
+
+// RemoveNews gỡ bài viết khỏi chỉ mục
+func RemoveNews(id uint) {
+	if err := MustDefault().Delete(TypeNews, id); err != nil {
+		log.Printf("⚠️  Failed to remove news #%d from search index: %v", id, err)
+	}
+}
+
+// stripHTML bỏ thẻ HTML và giải mã thực thể để đoạn trích highlight là văn bản thuần
+func stripHTML(content string) string {
+	return html.UnescapeString(htmlTagPattern.ReplaceAllString(content, " "))
+}
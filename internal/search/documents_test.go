@@ -0,0 +1,75 @@
+package search
+
+import (
+	"backend/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestProductDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  model.Product
+		wantSKUs []string
+		wantTags []string
+	}{
+		{
+			name:     "bare product",
+			product:  model.Product{SKU: "P1"},
+			wantSKUs: []string{"P1"},
+		},
+		{
+			name: "brand, category path, attributes and variant SKUs",
+			product: model.Product{
+				SKU:      "P2",
+				Material: "Da bò",
+				Color:    "Đen",
+				Brand:    &model.Brand{Name: "Gucci"},
+				Category: &model.Category{
+					Name: "Ví da",
+					Path: []model.CategoryPathItem{{Name: "Ví nam"}, {Name: "Ví da"}},
+				},
+				Variants: []model.ProductVariant{{SKU: "P2-S"}, {SKU: "P2-M"}},
+			},
+			wantSKUs: []string{"P2", "P2-S", "P2-M"},
+			wantTags: []string{"Gucci", "Ví nam", "Ví da", "Da bò", "Đen"},
+		},
+		{
+			name:     "category without loaded path",
+			product:  model.Product{SKU: "P3", Category: &model.Category{Name: "Túi xách"}},
+			wantSKUs: []string{"P3"},
+			wantTags: []string{"Túi xách"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ProductDocument(&tt.product)
+			if doc.Type != TypeProduct {
+				t.Errorf("Type = %q, want %q", doc.Type, TypeProduct)
+			}
+			if !reflect.DeepEqual(doc.SKUs, tt.wantSKUs) {
+				t.Errorf("SKUs = %v, want %v", doc.SKUs, tt.wantSKUs)
+			}
+			if !reflect.DeepEqual(doc.Tags, tt.wantTags) {
+				t.Errorf("Tags = %v, want %v", doc.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestNewsDocument(t *testing.T) {
+	news := model.News{
+		ID:      4,
+		Title:   "Khai trương",
+		Summary: "Tóm tắt",
+		Content: "<p>Giảm giá&nbsp;<b>50%</b></p>",
+		Tags:    "sale,khai truong",
+	}
+	doc := NewsDocument(&news)
+	if want := "Tóm tắt\n Giảm giá  50%  "; doc.Body != want {
+		t.Errorf("Body = %q, want %q", doc.Body, want)
+	}
+	if want := []string{"sale", "khai truong"}; !reflect.DeepEqual(doc.Tags, want) {
+		t.Errorf("Tags = %v, want %v", doc.Tags, want)
+	}
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldFilterName là tên token filter bỏ dấu tiếng Việt đăng ký với bleve
+const FoldFilterName = "vietnamese_fold"
+
+func init() {
+	registry.RegisterTokenFilter(FoldFilterName, func(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+		return foldFilter{}, nil
+	})
+}
+
+// Fold chuyển chuỗi về chữ thường không dấu: "Ví Da Đà Nẵng" -> "vi da da nang"
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Bỏ dấu thanh và dấu mũ đã được tách ra bởi NFD
+		case r == 'đ' || r == 'Đ':
+			b.WriteRune('d')
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// Terms tách truy vấn thành các từ đã bỏ dấu theo cùng quy tắc với bộ phân tích của chỉ mục
+func Terms(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// foldFilter bỏ dấu trên từng token sau khi tách từ để vị trí đánh dấu (highlight) vẫn khớp văn bản gốc
+type foldFilter struct{}
+
+func (foldFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		token.Term = []byte(Fold(string(token.Term)))
+	}
+	return input
+}
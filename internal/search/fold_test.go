@@ -0,0 +1,40 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Ví Da Đà Nẵng", "vi da da nang"},
+		{"Áo sơ mi TRẮNG", "ao so mi trang"},
+		{"Nguyễn Thị Hương", "nguyen thi huong"},
+		{"SKU-123", "sku-123"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.input); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Ví  da, bò!", []string{"vi", "da", "bo"}},
+		{"áo-thun 2024", []string{"ao", "thun", "2024"}},
+		{" ... ", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Terms(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
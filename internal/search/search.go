@@ -0,0 +1,112 @@
+package search
+
+import (
+	"backend/internal/helpers"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Loại tài liệu trong chỉ mục tìm kiếm
+const (
+	TypeProduct = "product"
+	TypeNews    = "news"
+)
+
+// Types là danh sách loại tài liệu hợp lệ
+var Types = []string{TypeProduct, TypeNews}
+
+// Document là một bản ghi đã được chuẩn bị để đưa vào chỉ mục.
+// Title có trọng số cao nhất, sau đó là SKU (khớp chính xác), Tags (thương hiệu, danh mục, thẻ) và Body.
+type Document struct {
+	Type  string
+	ID    uint
+	Title string
+	Body  string
+	SKUs  []string
+	Tags  []string
+}
+
+// Query là yêu cầu tìm kiếm; Types rỗng nghĩa là tìm mọi loại tài liệu
+type Query struct {
+	Text  string
+	Types []string
+	From  int
+	Size  int
+}
+
+// Hit là một kết quả tìm kiếm, Highlights chứa đoạn trích có đánh dấu <mark> theo từng trường (title, body)
+type Hit struct {
+	Type       string
+	ID         uint
+	Score      float64
+	Highlights map[string][]string
+}
+
+// Result là trang kết quả tìm kiếm đã xếp theo độ liên quan
+type Result struct {
+	Total uint64
+	Hits  []Hit
+}
+
+// Index là giao diện chung cho các bộ máy tìm kiếm toàn văn
+type Index interface {
+	// Index thêm mới hoặc thay thế tài liệu (theo Type + ID)
+	Index(docs ...Document) error
+	// Delete xóa tài liệu khỏi chỉ mục; không lỗi nếu tài liệu không tồn tại
+	Delete(docType string, id uint) error
+	// Search tìm tài liệu khớp truy vấn, không phân biệt dấu tiếng Việt và chấp nhận lỗi gõ nhẹ
+	Search(query Query) (*Result, error)
+	// IDs trả về ID của mọi tài liệu thuộc một loại, dùng để gỡ tài liệu cũ khi dựng lại chỉ mục
+	IDs(docType string) ([]uint, error)
+	// Count trả về tổng số tài liệu trong chỉ mục
+	Count() (uint64, error)
+	// Close đóng chỉ mục
+	Close() error
+}
+
+var (
+	defaultIndex     Index
+	defaultIndexErr  error
+	defaultIndexOnce sync.Once
+)
+
+// Default trả về chỉ mục được cấu hình qua SEARCH_DRIVER (mặc định: bleve lưu trên đĩa)
+func Default() (Index, error) {
+	defaultIndexOnce.Do(func() {
+		defaultIndex, defaultIndexErr = newFromEnv()
+	})
+	return defaultIndex, defaultIndexErr
+}
+
+// MustDefault trả về chỉ mục mặc định, panic nếu cấu hình lỗi (main đã kiểm tra khi khởi động)
+func MustDefault() Index {
+	index, err := Default()
+	if err != nil {
+		panic(err)
+	}
+	return index
+}
+
+func newFromEnv() (Index, error) {
+	driver := strings.ToLower(os.Getenv("SEARCH_DRIVER"))
+	switch driver {
+	case "", "bleve":
+		return OpenBleve(helpers.EnvOrDefault("SEARCH_INDEX_DIR", "data/search"))
+	case "memory":
+		return NewMemoryBleve()
+	default:
+		return nil, fmt.Errorf("unsupported SEARCH_DRIVER %q", driver)
+	}
+}
+
+// ValidType kiểm tra loại tài liệu hợp lệ
+func ValidType(docType string) bool {
+	for _, t := range Types {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// Loại gợi ý tự hoàn thành
+const (
+	SuggestionProduct  = "product"
+	SuggestionBrand    = "brand"
+	SuggestionCategory = "category"
+)
+
+const (
+	// Số gợi ý tốt nhất giữ sẵn tại mỗi nút của trie
+	suggestTopK = 10
+	// Chỉ lập chỉ mục tối đa chừng này ký tự tính từ đầu tên / đầu từ giữa tên để giới hạn bộ nhớ
+	suggestMaxKeyLength     = 40
+	suggestMaxWordKeyLength = 20
+	// Điểm cộng khi khớp từ đầu tên, lớn hơn mọi chênh lệch trọng số để luôn xếp trước khớp giữa tên
+	suggestNameStartBonus = 1000
+	// Truy vấn phổ biến được tính trong 24 giờ gần nhất, theo từng giờ
+	popularWindowHours = 24
+	popularMaxQueries  = 5000
+)
+
+// Suggestion là một gợi ý hoàn thành (tên sản phẩm, thương hiệu hoặc danh mục)
+type Suggestion struct {
+	Type   string `json:"type"`
+	ID     uint   `json:"id"`
+	Text   string `json:"text"`
+	Slug   string `json:"slug,omitempty"`
+	Weight int    `json:"-"`
+}
+
+// SuggestResult là kết quả gợi ý; Partial = true khi hết thời gian xử lý cho phép trước khi tra cứu xong
+type SuggestResult struct {
+	Completions    []Suggestion `json:"completions"`
+	PopularQueries []string     `json:"popular_queries"`
+	Partial        bool         `json:"partial"`
+}
+
+type trieCandidate struct {
+	entry int
+	score int
+}
+
+// trieNode lưu con dạng slice thay vì map: số nhánh mỗi nút nhỏ và trie có hàng triệu nút
+type trieNode struct {
+	keys     []rune
+	children []*trieNode
+	top      []trieCandidate
+}
+
+func (n *trieNode) child(r rune) *trieNode {
+	for i, key := range n.keys {
+		if key == r {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
+// suggestTrie là trie bất biến trên chuỗi đã bỏ dấu; mỗi nút giữ sẵn các gợi ý điểm cao nhất của nhánh con
+type suggestTrie struct {
+	root    *trieNode
+	entries []Suggestion
+}
+
+var currentTrie atomic.Pointer[suggestTrie]
+
+// ReplaceSuggestions dựng trie mới từ danh sách gợi ý và thay thế trie đang dùng.
+// Mỗi gợi ý được tra được từ đầu tên hoặc từ đầu bất kỳ từ nào trong tên ("da" khớp "Ví da bò");
+// khớp từ đầu tên được ưu tiên hơn.
+func ReplaceSuggestions(entries []Suggestion) {
+	trie := &suggestTrie{root: &trieNode{}, entries: entries}
+	for i, entry := range entries {
+		runes := []rune(normalizeQuery(Fold(entry.Text)))
+		for start := 0; start < len(runes); start++ {
+			if !isWordStart(runes, start) {
+				continue
+			}
+			key, score := runes[start:], entry.Weight
+			if start == 0 {
+				score += suggestNameStartBonus
+				key = truncateRunes(key, suggestMaxKeyLength)
+			} else {
+				key = truncateRunes(key, suggestMaxWordKeyLength)
+			}
+			trie.insert(key, trieCandidate{entry: i, score: score})
+		}
+	}
+	currentTrie.Store(trie)
+}
+
+func isWordStart(runes []rune, i int) bool {
+	if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+		return false
+	}
+	return i == 0 || !(unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+}
+
+func truncateRunes(runes []rune, max int) []rune {
+	if len(runes) > max {
+		return runes[:max]
+	}
+	return runes
+}
+
+func (t *suggestTrie) insert(key []rune, candidate trieCandidate) {
+	node := t.root
+	for _, r := range key {
+		child := node.child(r)
+		if child == nil {
+			child = &trieNode{}
+			node.keys = append(node.keys, r)
+			node.children = append(node.children, child)
+		}
+		child.offer(candidate)
+		node = child
+	}
+}
+
+// offer thêm ứng viên vào danh sách top-K (giảm dần theo điểm) của nút; mỗi gợi ý chỉ giữ điểm cao nhất
+func (n *trieNode) offer(candidate trieCandidate) {
+	for i, existing := range n.top {
+		if existing.entry != candidate.entry {
+			continue
+		}
+		if candidate.score <= existing.score {
+			return
+		}
+		n.top = append(n.top[:i], n.top[i+1:]...)
+		break
+	}
+	if len(n.top) >= suggestTopK && candidate.score <= n.top[len(n.top)-1].score {
+		return
+	}
+
+	// Chèn vào sau các ứng viên cùng điểm để giữ thứ tự ổn định
+	pos := len(n.top)
+	for pos > 0 && n.top[pos-1].score < candidate.score {
+		pos--
+	}
+	if len(n.top) < suggestTopK {
+		n.top = append(n.top, trieCandidate{})
+	}
+	copy(n.top[pos+1:], n.top[pos:])
+	n.top[pos] = candidate
+}
+
+func (t *suggestTrie) lookup(ctx context.Context, prefix string, limit int) ([]Suggestion, bool) {
+	node := t.root
+	for _, r := range prefix {
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		node = node.child(r)
+		if node == nil {
+			return nil, true
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, limit)
+	for _, candidate := range node.top {
+		if len(suggestions) >= limit {
+			break
+		}
+		suggestions = append(suggestions, t.entries[candidate.entry])
+	}
+	return suggestions, true
+}
+
+// Suggest trả về gợi ý hoàn thành và truy vấn phổ biến gần đây cho tiền tố (không phân biệt dấu).
+// Khi ctx hết hạn, trả về phần kết quả đã có với Partial = true.
+func Suggest(ctx context.Context, prefix string, limit int) SuggestResult {
+	result := SuggestResult{Completions: []Suggestion{}, PopularQueries: []string{}}
+	key := normalizeQuery(Fold(prefix))
+	if key == "" {
+		return result
+	}
+
+	if trie := currentTrie.Load(); trie != nil {
+		completions, ok := trie.lookup(ctx, key, limit)
+		if !ok {
+			result.Partial = true
+			return result
+		}
+		result.Completions = completions
+	}
+
+	if ctx.Err() != nil {
+		result.Partial = true
+		return result
+	}
+	result.PopularQueries = popular.top(ctx, key, limit)
+	result.Partial = ctx.Err() != nil
+	return result
+}
+
+// popularQueries đếm số lần tìm kiếm theo từng giờ trong cửa sổ popularWindowHours
+type popularQueries struct {
+	mu      sync.Mutex
+	queries map[string]*queryStat
+}
+
+type queryStat struct {
+	text    string
+	buckets [popularWindowHours]int
+	hours   [popularWindowHours]int64
+}
+
+var popular = &popularQueries{queries: make(map[string]*queryStat)}
+
+// RecordQuery ghi nhận một truy vấn tìm kiếm có kết quả để gợi ý truy vấn phổ biến
+func RecordQuery(text string) {
+	popular.record(text, time.Now())
+}
+
+func (p *popularQueries) record(text string, now time.Time) {
+	text = normalizeQuery(strings.ToLower(text))
+	key := Fold(text)
+	if key == "" {
+		return
+	}
+	hour := now.Unix() / 3600
+	slot := int(hour % popularWindowHours)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat := p.queries[key]
+	if stat == nil {
+		if len(p.queries) >= popularMaxQueries {
+			p.evict(hour)
+		}
+		stat = &queryStat{text: text}
+		p.queries[key] = stat
+	}
+	if stat.hours[slot] != hour {
+		stat.hours[slot] = hour
+		stat.buckets[slot] = 0
+	}
+	stat.buckets[slot]++
+}
+
+// evict bỏ các truy vấn không còn lượt tìm nào trong cửa sổ; nếu vẫn đầy thì bỏ truy vấn ít lượt nhất
+func (p *popularQueries) evict(hour int64) {
+	var weakestKey string
+	weakest := -1
+	for key, stat := range p.queries {
+		count := stat.count(hour)
+		if count == 0 {
+			delete(p.queries, key)
+			continue
+		}
+		if weakest < 0 || count < weakest {
+			weakestKey, weakest = key, count
+		}
+	}
+	if len(p.queries) >= popularMaxQueries && weakestKey != "" {
+		delete(p.queries, weakestKey)
+	}
+}
+
+func (s *queryStat) count(hour int64) int {
+	total := 0
+	for i, h := range s.hours {
+		if hour-h < popularWindowHours {
+			total += s.buckets[i]
+		}
+	}
+	return total
+}
+
+func (p *popularQueries) top(ctx context.Context, prefix string, limit int) []string {
+	hour := time.Now().Unix() / 3600
+
+	type scored struct {
+		text  string
+		count int
+	}
+	var matches []scored
+
+	p.mu.Lock()
+	for key, stat := range p.queries {
+		if ctx.Err() != nil {
+			break
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if count := stat.count(hour); count > 0 {
+			matches = append(matches, scored{text: stat.text, count: count})
+		}
+	}
+	p.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].count != matches[j].count {
+			return matches[i].count > matches[j].count
+		}
+		return matches[i].text < matches[j].text
+	})
+
+	queries := make([]string, 0, limit)
+	for _, match := range matches {
+		if len(queries) >= limit {
+			break
+		}
+		queries = append(queries, match.text)
+	}
+	return queries
+}
+
+// normalizeQuery gộp khoảng trắng liên tiếp để "ví  da" và "ví da" được tính là một truy vấn
+func normalizeQuery(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
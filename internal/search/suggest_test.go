@@ -0,0 +1,102 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func suggestionTexts(suggestions []Suggestion) []string {
+	texts := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		texts = append(texts, suggestion.Text)
+	}
+	return texts
+}
+
+func TestSuggestCompletions(t *testing.T) {
+	ReplaceSuggestions([]Suggestion{
+		{Type: SuggestionProduct, ID: 1, Text: "Ví da bò", Weight: 5},
+		{Type: SuggestionProduct, ID: 2, Text: "Ví  vải", Weight: 9},
+		{Type: SuggestionBrand, ID: 3, Text: "Dây da Đà Lạt", Weight: 50},
+		{Type: SuggestionCategory, ID: 4, Text: "Túi xách", Weight: 1},
+	})
+	t.Cleanup(func() { ReplaceSuggestions(nil) })
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"name start ranked by weight", "ví", 10, []string{"Ví  vải", "Ví da bò"}},
+		{"spaces normalized", "vi  v", 10, []string{"Ví  vải"}},
+		{"name start beats word start", "da", 10, []string{"Dây da Đà Lạt", "Ví da bò"}},
+		{"limit", "d", 1, []string{"Dây da Đà Lạt"}},
+		{"no match", "giày", 10, []string{}},
+		{"blank prefix", "  ", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Suggest(context.Background(), tt.prefix, tt.limit)
+			if result.Partial {
+				t.Error("Partial = true, want false")
+			}
+			if got := suggestionTexts(result.Completions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestCancelled(t *testing.T) {
+	ReplaceSuggestions([]Suggestion{{Type: SuggestionProduct, ID: 1, Text: "Ví da", Weight: 1}})
+	t.Cleanup(func() { ReplaceSuggestions(nil) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := Suggest(ctx, "vi", 10)
+	if !result.Partial || len(result.Completions) != 0 {
+		t.Errorf("Suggest with cancelled context = %+v, want empty partial result", result)
+	}
+}
+
+func TestTrieNodeOfferKeepsTopK(t *testing.T) {
+	node := &trieNode{}
+	for i := 0; i < suggestTopK+5; i++ {
+		node.offer(trieCandidate{entry: i, score: i})
+	}
+	// Cùng gợi ý với điểm cao hơn thay thế điểm cũ, điểm thấp hơn bị bỏ qua
+	node.offer(trieCandidate{entry: 5, score: 100})
+	node.offer(trieCandidate{entry: 14, score: 0})
+
+	if len(node.top) != suggestTopK {
+		t.Fatalf("len(top) = %d, want %d", len(node.top), suggestTopK)
+	}
+	want := []int{5, 14, 13, 12, 11, 10, 9, 8, 7, 6}
+	for i, candidate := range node.top {
+		if candidate.entry != want[i] {
+			t.Fatalf("top entries = %v, want %v", node.top, want)
+		}
+	}
+}
+
+func TestPopularQueries(t *testing.T) {
+	now := time.Now()
+	p := &popularQueries{queries: make(map[string]*queryStat)}
+	p.record("Ví  Da", now)
+	p.record("ví da", now)
+	p.record("vi dep", now)
+	p.record("túi", now)
+	p.record("ví cũ", now.Add(-(popularWindowHours+1)*time.Hour))
+	p.record("   ", now)
+
+	got := p.top(context.Background(), "vi", 10)
+	if want := []string{"ví da", "vi dep"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("top(vi) = %v, want %v", got, want)
+	}
+	if got := p.top(context.Background(), "vi", 1); !reflect.DeepEqual(got, []string{"ví da"}) {
+		t.Errorf("top(vi, 1) = %v, want [ví da]", got)
+	}
+}
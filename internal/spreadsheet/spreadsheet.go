@@ -0,0 +1,227 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Định dạng bảng tính được hỗ trợ khi nhập/xuất dữ liệu
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var Formats = []string{FormatCSV, FormatXLSX}
+
+// ErrTooManyRows được trả về khi tệp có nhiều dòng dữ liệu hơn giới hạn cho phép
+var ErrTooManyRows = errors.New("too many rows")
+
+// utf8BOM do Excel thêm vào đầu tệp CSV lưu dạng UTF-8
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FormatFromFilename xác định định dạng theo phần mở rộng của tên tệp
+func FormatFromFilename(filename string) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	if !ValidFormat(format) {
+		return "", fmt.Errorf("unsupported file format %q", format)
+	}
+	return format, nil
+}
+
+// ValidFormat kiểm tra định dạng có được hỗ trợ không
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType trả về MIME type dùng khi tải tệp xuống
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read đọc toàn bộ các dòng (kể cả dòng tiêu đề) của tệp CSV hoặc sheet đầu tiên của tệp XLSX.
+// Dòng trống ở cuối bị bỏ qua; trả về ErrTooManyRows nếu số dòng dữ liệu vượt quá maxRows.
+func Read(r io.Reader, format string, maxRows int) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r, maxRows)
+	case FormatXLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for len(rows) > 0 && IsBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows)-1 > maxRows {
+		return nil, ErrTooManyRows
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader, maxRows int) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+		// Dừng sớm với tệp quá lớn thay vì đọc hết vào bộ nhớ (dòng trống cuối tệp đã bị csv bỏ qua)
+		if len(rows)-1 > maxRows {
+			return nil, ErrTooManyRows
+		}
+	}
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no sheet")
+	}
+	// Lấy giá trị gốc của ô (không theo định dạng hiển thị) để số như 1250000 không thành "1,250,000"
+	return file.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+// IsBlank kiểm tra dòng không có ô nào chứa dữ liệu
+func IsBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Writer ghi lần lượt từng dòng ra tệp CSV hoặc XLSX; Close phải được gọi để hoàn tất tệp.
+// Giá trị số và bool được giữ kiểu khi xuất XLSX, chuỗi luôn là văn bản (không làm mất số 0 đầu của SKU).
+type Writer interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// NewWriter tạo Writer theo định dạng; sheet là tên sheet khi xuất XLSX
+func NewWriter(w io.Writer, format, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		// Thêm BOM để Excel nhận đúng tiếng Việt khi mở tệp CSV
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, fmt.Errorf("unsupported file format %q", format)
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		record[i] = CellString(value)
+	}
+	return w.writer.Write(record)
+}
+
+// CellString định dạng giá trị của một ô thành chuỗi như khi ghi ra CSV
+func CellString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (w *xlsxWriter) Write(row []interface{}) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, row)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(w.out)
+}
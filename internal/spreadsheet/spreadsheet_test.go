@@ -0,0 +1,136 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatFromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{"products.csv", FormatCSV, false},
+		{"Products.XLSX", FormatXLSX, false},
+		{"products.xls", "", true},
+		{"products", "", true},
+	}
+	for _, tt := range tests {
+		got, err := FormatFromFilename(tt.filename)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("FormatFromFilename(%q) = %q, %v; want %q, error %v", tt.filename, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		maxRows int
+		want    [][]string
+		wantErr error
+	}{
+		{
+			name:    "BOM, leading spaces and trailing blank rows",
+			data:    "\xEF\xBB\xBFsku, name\nA1, Ví da\n,\n",
+			maxRows: 10,
+			want:    [][]string{{"sku", "name"}, {"A1", "Ví da"}},
+		},
+		{
+			name:    "ragged rows",
+			data:    "sku,name,price\nA1\n",
+			maxRows: 10,
+			want:    [][]string{{"sku", "name", "price"}, {"A1"}},
+		},
+		{
+			name:    "header does not count toward the limit",
+			data:    "sku\nA1\nA2\n",
+			maxRows: 2,
+			want:    [][]string{{"sku"}, {"A1"}, {"A2"}},
+		},
+		{
+			name:    "too many rows",
+			data:    "sku\nA1\nA2\nA3\n",
+			maxRows: 2,
+			wantErr: ErrTooManyRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Read(strings.NewReader(tt.data), FormatCSV, tt.maxRows)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Read error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("Read = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	rows := [][]interface{}{
+		{"sku", "price", "stock", "is_active", "note"},
+		{"0012", 1250000.0, 5, true, nil},
+	}
+	// Giá trị bool được giữ kiểu trong XLSX nên đọc lại dạng giá trị gốc "1"
+	active := map[string]string{FormatCSV: "true", FormatXLSX: "1"}
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format, "Products")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := writer.Write(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Read(&buf, format, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// CSV giữ ô trống cuối dòng, XLSX thì không
+			if format == FormatCSV {
+				got[1] = got[1][:4]
+			}
+			want := [][]string{
+				{"sku", "price", "stock", "is_active", "note"},
+				{"0012", "1250000", "5", active[format]},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCellString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"SKU-1", "SKU-1"},
+		{1250000.0, "1250000"},
+		{0.5, "0.5"},
+		{false, "false"},
+		{42, "42"},
+	}
+	for _, tt := range tests {
+		if got := CellString(tt.value); got != tt.want {
+			t.Errorf("CellString(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
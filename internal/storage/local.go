@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local lưu tệp trên đĩa cục bộ, phục vụ qua route /uploads của chính ứng dụng
+type Local struct {
+	baseDir string
+	baseURL string
+}
+
+// NewLocal tạo kho lưu trữ trên đĩa tại baseDir, URL công khai bắt đầu bằng baseURL
+func NewLocal(baseDir, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+	return &Local{
+		baseDir: baseDir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.baseDir, filepath.FromSlash(key)), nil
+}
+
+// Put ghi tệp qua tệp tạm rồi đổi tên để tránh phục vụ tệp ghi dở
+func (s *Local) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get mở tệp để đọc
+func (s *Local) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ObjectInfo{}, ErrNotFound
+		}
+		return nil, ObjectInfo{}, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return file, ObjectInfo{Size: stat.Size(), ContentType: contentType, ModTime: stat.ModTime()}, nil
+}
+
+// Delete xóa tệp khỏi đĩa
+func (s *Local) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL trả về đường dẫn công khai của tệp
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
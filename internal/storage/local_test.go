@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocal(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "products/12/abc.png"
+	if err := store.Put(ctx, key, strings.NewReader("first"), "image/png"); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	if err := store.Put(ctx, key, strings.NewReader("second"), "image/png"); err != nil {
+		t.Fatalf("Put overwrite returned error: %v", err)
+	}
+
+	reader, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" || info.Size != int64(len("second")) || info.ContentType != "image/png" {
+		t.Errorf("Get = %q, %+v, want second with size 6 and image/png", data, info)
+	}
+
+	temps, _ := filepath.Glob(filepath.Join(dir, "products", "12", ".upload-*"))
+	if len(temps) != 0 {
+		t.Errorf("temporary upload files left behind: %v", temps)
+	}
+
+	if got := store.URL(key); got != "/uploads/products/12/abc.png" {
+		t.Errorf("URL = %q, want /uploads/products/12/abc.png", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "products", "12", "abc.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing object returned error: %v", err)
+	}
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../escape.txt", "/abs.txt", "a/../../b.txt"} {
+		t.Run(key, func(t *testing.T) {
+			if err := store.Put(ctx, key, strings.NewReader("x"), "text/plain"); err == nil {
+				t.Error("Put accepted an invalid key")
+			}
+			if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get error = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, key); err == nil {
+				t.Error("Delete accepted an invalid key")
+			}
+		})
+	}
+}
+
+func TestLocalGetDirectory(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "products/a.txt", strings.NewReader("x"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get(ctx, "products"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(directory) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"errors"
+)
+
+// S3Config là cấu hình cho kho lưu trữ tương thích S3 (AWS S3, MinIO, R2...)
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // CDN hoặc URL công khai của bucket, dùng để dựng URL ảnh
+}
+
+// NewS3 tạo kho lưu trữ S3-compatible.
+// Driver chưa được triển khai: cấu hình được giữ sẵn để bổ sung sau mà không đổi giao diện Storage.
+func NewS3(config S3Config) (Storage, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("S3_BUCKET is required for the s3 storage driver")
+	}
+	return nil, errors.New("s3 storage driver is not implemented yet, use STORAGE_DRIVER=local")
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"backend/internal/helpers"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound được trả về khi đối tượng không tồn tại trong kho lưu trữ
+var ErrNotFound = errors.New("storage object not found")
+
+// ObjectInfo mô tả siêu dữ liệu của một đối tượng đã lưu
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage là giao diện chung cho các kho lưu trữ tệp (đĩa cục bộ, S3-compatible...).
+// Key là đường dẫn tương đối dạng "products/12/abc.jpg", không bắt đầu bằng "/".
+type Storage interface {
+	// Put lưu nội dung vào key, ghi đè nếu đã tồn tại
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get mở đối tượng để đọc; người gọi phải đóng reader
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete xóa đối tượng; không lỗi nếu đối tượng không tồn tại
+	Delete(ctx context.Context, key string) error
+	// URL trả về đường dẫn công khai để client tải đối tượng
+	URL(key string) string
+}
+
+var (
+	defaultStorage     Storage
+	defaultStorageErr  error
+	defaultStorageOnce sync.Once
+)
+
+// Default trả về kho lưu trữ được cấu hình qua STORAGE_DRIVER (mặc định: local)
+func Default() (Storage, error) {
+	defaultStorageOnce.Do(func() {
+		defaultStorage, defaultStorageErr = newFromEnv()
+	})
+	return defaultStorage, defaultStorageErr
+}
+
+// MustDefault trả về kho lưu trữ mặc định, panic nếu cấu hình lỗi (main đã kiểm tra khi khởi động)
+func MustDefault() Storage {
+	store, err := Default()
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
+
+func newFromEnv() (Storage, error) {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	switch driver {
+	case "", "local":
+		return NewLocal(helpers.EnvOrDefault("STORAGE_LOCAL_DIR", "uploads"), helpers.EnvOrDefault("STORAGE_PUBLIC_URL", "/uploads"))
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported STORAGE_DRIVER %q", driver)
+	}
+}
+
+// ValidKey kiểm tra key an toàn (không chứa "..", không phải đường dẫn tuyệt đối)
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package storage
+
+import "testing"
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"products/12/abc.jpg", true},
+		{"logo.png", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"products/../secret", false},
+		{"products/./abc.jpg", false},
+		{"products//abc.jpg", false},
+		{"products/", false},
+		{`products\abc.jpg`, false},
+		{"..", false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
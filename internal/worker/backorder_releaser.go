@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dòng đơn chờ hàng về được đối chiếu với tồn kho mỗi phút
+const backorderReleaseInterval = time.Minute
+
+var backorderReleaseOnce sync.Once
+
+// StartBackorderReleaser chạy nền việc giải phóng các dòng đơn bán vượt tồn kho khi hàng đã về
+func StartBackorderReleaser() {
+	backorderReleaseOnce.Do(func() {
+		go func() {
+			for {
+				released, err := ReleaseBackorders()
+				if err != nil {
+					log.Printf("⚠️  Failed to release backordered order items: %v", err)
+				} else if released > 0 {
+					log.Printf("✅ Released %d order(s) awaiting stock", released)
+				}
+				time.Sleep(backorderReleaseInterval)
+			}
+		}()
+	})
+}
+
+// backorderSlot là tồn kho của một sản phẩm/biến thể tại một kho; dòng đơn chờ hàng về tại kho xuất của dòng
+type backorderSlot struct {
+	WarehouseID uint
+	Key         repo.StockKey
+}
+
+// slotOf trả về tồn kho mà dòng đơn chờ hàng về; dòng chưa gán kho (đơn trước khi có nhiều kho) xuất từ kho mặc định
+func slotOf(item *model.OrderItem, defaultWarehouseID uint) backorderSlot {
+	warehouseID := defaultWarehouseID
+	if item.WarehouseID != nil {
+		warehouseID = *item.WarehouseID
+	}
+	return backorderSlot{WarehouseID: warehouseID, Key: repo.NewStockKey(item.ProductID, item.VariantID)}
+}
+
+// ReleaseBackorders phân phần hàng đã về cho các dòng đơn còn chờ hàng theo thứ tự đặt (đơn cũ trước).
+// Hàng bán vượt đã được trừ khỏi tồn kho tại kho xuất khi tạo đơn nên tồn kho âm của kho đó chính là phần
+// còn thiếu: với mỗi sản phẩm/biến thể tại mỗi kho, tổng số lượng chờ hàng vượt quá phần thiếu đó đã có hàng.
+// Hàng về kho khác không giải phóng dòng đơn xuất từ kho này (cần chuyển kho trước). Đơn không còn dòng nào chờ
+// hàng được bỏ đánh dấu chờ hàng để tiếp tục xử lý; trả về số đơn đó.
+func ReleaseBackorders() (int64, error) {
+	var released int64
+	err := repo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := repo.NewOrderRepo().WithTx(tx)
+		warehouseRepo := repo.NewWarehouseRepo().WithTx(tx)
+
+		items, err := orderRepo.LockOutstandingBackorders()
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		var defaultWarehouseID uint
+		for _, item := range items {
+			if item.WarehouseID == nil {
+				warehouse, err := warehouseRepo.Default()
+				if err != nil {
+					return err
+				}
+				defaultWarehouseID = warehouse.ID
+				break
+			}
+		}
+
+		levels := make(map[backorderSlot]int)
+		for i := range items {
+			slot := slotOf(&items[i], defaultWarehouseID)
+			if _, ok := levels[slot]; ok {
+				continue
+			}
+			level, err := warehouseRepo.LockLevel(slot.WarehouseID, items[i].ProductID, items[i].VariantID)
+			if err != nil {
+				return err
+			}
+			levels[slot] = level
+		}
+
+		var orderIDs []uint
+		for i, quantity := range planBackorderRelease(items, defaultWarehouseID, levels) {
+			if quantity == 0 {
+				continue
+			}
+			if err := orderRepo.SetBackorderedQuantity(items[i].ID, items[i].BackorderedQuantity-quantity); err != nil {
+				return err
+			}
+			orderIDs = append(orderIDs, items[i].OrderID)
+		}
+
+		released, err = orderRepo.ClearAwaitingStock(orderIDs)
+		return err
+	})
+	return released, err
+}
+
+// planBackorderRelease tính số lượng được giải phóng của từng dòng chờ hàng (items theo thứ tự đặt) từ tồn kho
+// levels tại kho xuất của dòng: phần chờ hàng vượt quá phần thiếu (tồn kho âm) đã có hàng và được chia cho
+// dòng đặt trước
+func planBackorderRelease(items []model.OrderItem, defaultWarehouseID uint, levels map[backorderSlot]int) []int {
+	outstanding := make(map[backorderSlot]int)
+	for i := range items {
+		outstanding[slotOf(&items[i], defaultWarehouseID)] += items[i].BackorderedQuantity
+	}
+
+	arrived := make(map[backorderSlot]int, len(outstanding))
+	for slot, quantity := range outstanding {
+		shortfall := 0
+		if levels[slot] < 0 {
+			shortfall = -levels[slot]
+		}
+		if quantity > shortfall {
+			arrived[slot] = quantity - shortfall
+		}
+	}
+
+	release := make([]int, len(items))
+	for i := range items {
+		slot := slotOf(&items[i], defaultWarehouseID)
+		quantity := items[i].BackorderedQuantity
+		if arrived[slot] < quantity {
+			quantity = arrived[slot]
+		}
+		arrived[slot] -= quantity
+		release[i] = quantity
+	}
+	return release
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"reflect"
+	"testing"
+)
+
+func TestPlanBackorderRelease(t *testing.T) {
+	north, south := uint(1), uint(2)
+	variantID := uint(7)
+	shirt := repo.NewStockKey(10, nil)
+	shoe := repo.NewStockKey(20, &variantID)
+
+	item := func(warehouseID *uint, productID uint, variantID *uint, backordered int) model.OrderItem {
+		return model.OrderItem{WarehouseID: warehouseID, ProductID: productID, VariantID: variantID, BackorderedQuantity: backordered}
+	}
+
+	tests := []struct {
+		name   string
+		items  []model.OrderItem
+		levels map[backorderSlot]int
+		want   []int
+	}{
+		{
+			name:   "nothing arrived while stock is still short",
+			items:  []model.OrderItem{item(&north, 10, nil, 3)},
+			levels: map[backorderSlot]int{{north, shirt}: -3},
+			want:   []int{0},
+		},
+		{
+			name:   "arrivals go to the oldest lines first",
+			items:  []model.OrderItem{item(&north, 10, nil, 3), item(&north, 10, nil, 2)},
+			levels: map[backorderSlot]int{{north, shirt}: -1},
+			want:   []int{3, 1},
+		},
+		{
+			name:   "everything arrived",
+			items:  []model.OrderItem{item(&north, 10, nil, 3), item(&north, 10, nil, 2)},
+			levels: map[backorderSlot]int{{north, shirt}: 4},
+			want:   []int{3, 2},
+		},
+		{
+			name:  "stock arriving at another warehouse does not release",
+			items: []model.OrderItem{item(&north, 10, nil, 2), item(&south, 10, nil, 2)},
+			levels: map[backorderSlot]int{
+				{north, shirt}: -2,
+				{south, shirt}: 0,
+			},
+			want: []int{0, 2},
+		},
+		{
+			name:  "variants are tracked separately",
+			items: []model.OrderItem{item(&north, 20, &variantID, 2), item(&north, 10, nil, 2)},
+			levels: map[backorderSlot]int{
+				{north, shoe}:  0,
+				{north, shirt}: -2,
+			},
+			want: []int{2, 0},
+		},
+		{
+			name:   "lines without a warehouse ship from the default one",
+			items:  []model.OrderItem{item(nil, 10, nil, 2), item(&south, 10, nil, 1)},
+			levels: map[backorderSlot]int{{north, shirt}: -1, {south, shirt}: -1},
+			want:   []int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planBackorderRelease(tt.items, north, tt.levels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planBackorderRelease = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"backend/internal/imaging"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/storage"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Loại bản ghi có ảnh cần tạo ảnh dẫn xuất
+const (
+	ImageJobProductImage = "product_image"
+	ImageJobNews         = "news"
+)
+
+const imageQueueSize = 256
+
+// ImageJob là yêu cầu tạo ảnh dẫn xuất cho một bản ghi
+type ImageJob struct {
+	Kind string
+	ID   uint
+}
+
+var (
+	imageQueue     chan ImageJob
+	imageQueueOnce sync.Once
+)
+
+// StartImageWorkers khởi chạy các worker tạo ảnh dẫn xuất (số lượng theo IMAGE_WORKERS, mặc định 2)
+// và đưa lại vào hàng đợi các ảnh còn đang chờ xử lý từ lần chạy trước
+func StartImageWorkers() {
+	imageQueueOnce.Do(func() {
+		workers, err := strconv.Atoi(os.Getenv("IMAGE_WORKERS"))
+		if err != nil || workers <= 0 {
+			workers = 2
+		}
+
+		imageQueue = make(chan ImageJob, imageQueueSize)
+		for i := 0; i < workers; i++ {
+			go runImageWorker()
+		}
+		log.Printf("✅ Started %d image worker(s)", workers)
+
+		go requeuePendingImages()
+	})
+}
+
+// EnqueueImage đưa ảnh vào hàng đợi mà không chặn request; ảnh vẫn ở trạng thái pending
+// nếu hàng đợi đầy và sẽ được xử lý lại ở lần khởi động sau
+func EnqueueImage(job ImageJob) {
+	if imageQueue == nil {
+		log.Printf("⚠️  Image workers not started, %s #%d left pending", job.Kind, job.ID)
+		return
+	}
+
+	select {
+	case imageQueue <- job:
+	default:
+		log.Printf("⚠️  Image queue is full, %s #%d left pending", job.Kind, job.ID)
+	}
+}
+
+func requeuePendingImages() {
+	imageIDs, err := repo.NewProductImageRepo().GetPendingVariantIDs()
+	if err != nil {
+		log.Printf("⚠️  Failed to load pending product images: %v", err)
+	}
+	for _, id := range imageIDs {
+		imageQueue <- ImageJob{Kind: ImageJobProductImage, ID: id}
+	}
+
+	newsIDs, err := repo.NewNewsRepo().GetPendingImageIDs()
+	if err != nil {
+		log.Printf("⚠️  Failed to load pending news images: %v", err)
+	}
+	for _, id := range newsIDs {
+		imageQueue <- ImageJob{Kind: ImageJobNews, ID: id}
+	}
+}
+
+func runImageWorker() {
+	store := storage.MustDefault()
+	imageRepo := repo.NewProductImageRepo()
+	newsRepo := repo.NewNewsRepo()
+
+	for job := range imageQueue {
+		switch job.Kind {
+		case ImageJobProductImage:
+			processProductImage(store, imageRepo, job.ID)
+		case ImageJobNews:
+			processNewsImage(store, newsRepo, job.ID)
+		default:
+			log.Printf("⚠️  Unknown image job kind %q", job.Kind)
+		}
+	}
+}
+
+func processProductImage(store storage.Storage, imageRepo *repo.ProductImageRepo, id uint) {
+	image, err := imageRepo.FindByID(id)
+	if err != nil {
+		// Ảnh đã bị xóa trước khi đến lượt xử lý
+		if err.Error() != "product image not found" {
+			log.Printf("⚠️  Failed to load product image #%d: %v", id, err)
+		}
+		return
+	}
+	if image.StorageKey == "" {
+		return
+	}
+
+	variants, err := generateVariants(store, image.StorageKey)
+	status := model.ImageVariantReady
+	if err != nil {
+		log.Printf("⚠️  Failed to generate variants for product image #%d: %v", id, err)
+		status = model.ImageVariantFailed
+	}
+
+	if err := imageRepo.UpdateVariants(id, encodeVariants(variants), status); err != nil {
+		log.Printf("⚠️  Failed to save variants for product image #%d: %v", id, err)
+		deleteVariants(store, variants)
+		return
+	}
+
+	// Ảnh bị xóa trong lúc xử lý thì dọn các tệp vừa tạo
+	if _, err := imageRepo.FindByID(id); err != nil && err.Error() == "product image not found" {
+		deleteVariants(store, variants)
+	}
+}
+
+func processNewsImage(store storage.Storage, newsRepo *repo.NewsRepo, id uint) {
+	news, err := newsRepo.GetByID(id)
+	if err != nil {
+		if err.Error() != "news not found" {
+			log.Printf("⚠️  Failed to load news #%d: %v", id, err)
+		}
+		return
+	}
+	if news.ImageKey == "" {
+		return
+	}
+
+	variants, err := generateVariants(store, news.ImageKey)
+	status := model.ImageVariantReady
+	if err != nil {
+		log.Printf("⚠️  Failed to generate variants for news #%d: %v", id, err)
+		status = model.ImageVariantFailed
+	}
+
+	if err := newsRepo.UpdateImageVariants(id, news.ImageKey, encodeVariants(variants), status); err != nil {
+		log.Printf("⚠️  Failed to save variants for news #%d: %v", id, err)
+		deleteVariants(store, variants)
+		return
+	}
+
+	// Ảnh đại diện đã được thay trong lúc xử lý thì bỏ các tệp vừa tạo
+	if current, err := newsRepo.GetByID(id); err == nil && current.ImageKey != news.ImageKey {
+		deleteVariants(store, variants)
+	}
+}
+
+// generateVariants đọc ảnh gốc, tạo ảnh dẫn xuất và lưu cạnh ảnh gốc với tên <gốc>_<kích thước>.<định dạng>
+func generateVariants(store storage.Storage, key string) ([]model.ImageVariant, error) {
+	ctx := context.Background()
+
+	reader, _, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := imaging.GenerateVariants(data, imaging.ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(key, path.Ext(key))
+	variants := make([]model.ImageVariant, 0, len(generated))
+	for _, item := range generated {
+		variantKey := base + "_" + item.Size + item.Extension
+		if err := store.Put(ctx, variantKey, bytes.NewReader(item.Data), item.ContentType); err != nil {
+			deleteVariants(store, variants)
+			return nil, err
+		}
+		variants = append(variants, model.ImageVariant{
+			Size:   item.Size,
+			Format: item.Format,
+			Width:  item.Width,
+			Height: item.Height,
+			Key:    variantKey,
+			URL:    store.URL(variantKey),
+		})
+	}
+
+	return variants, nil
+}
+
+func encodeVariants(variants []model.ImageVariant) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(variants)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DeleteImageVariants xóa các tệp ảnh dẫn xuất đã lưu (dạng JSON trong bản ghi)
+func DeleteImageVariants(store storage.Storage, raw string) {
+	deleteVariants(store, model.ParseImageVariants(raw))
+}
+
+func deleteVariants(store storage.Storage, variants []model.ImageVariant) {
+	for _, variant := range variants {
+		if err := store.Delete(context.Background(), variant.Key); err != nil {
+			log.Printf("⚠️  Failed to delete image variant %s: %v", variant.Key, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"backend/internal/notify"
+	"backend/internal/repo"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tồn kho thấp được kiểm tra mỗi phút, tối đa lowStockAlertBatch sản phẩm trong một cảnh báo
+const (
+	lowStockCheckInterval = time.Minute
+	lowStockAlertBatch    = 100
+)
+
+var lowStockCheckOnce sync.Once
+
+// LowStockItem là một sản phẩm trong cảnh báo tồn kho thấp
+type LowStockItem struct {
+	ProductID uint   `json:"product_id"`
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+}
+
+// StartLowStockChecker chạy nền việc cảnh báo khi tồn kho sản phẩm xuống bằng hoặc dưới ngưỡng sau khi bán/điều chỉnh
+func StartLowStockChecker() {
+	lowStockCheckOnce.Do(func() {
+		go func() {
+			for {
+				if alerted, err := CheckLowStock(); err != nil {
+					log.Printf("⚠️  Failed to check low stock: %v", err)
+				} else if alerted > 0 {
+					log.Printf("✅ Sent low-stock alert for %d product(s)", alerted)
+				}
+				time.Sleep(lowStockCheckInterval)
+			}
+		}()
+	})
+}
+
+// CheckLowStock gửi một cảnh báo gộp cho các sản phẩm vừa xuống bằng hoặc dưới ngưỡng. Mỗi lần xuống ngưỡng chỉ
+// cảnh báo một lần; khi tồn kho vượt lại ngưỡng thì trạng thái được đặt lại. Trả về số sản phẩm đã cảnh báo.
+func CheckLowStock() (int, error) {
+	productRepo := repo.NewProductRepo()
+	if _, err := productRepo.ResetRecoveredLowStock(); err != nil {
+		return 0, err
+	}
+
+	products, err := productRepo.PendingLowStockAlerts(lowStockAlertBatch)
+	if err != nil || len(products) == 0 {
+		return 0, err
+	}
+
+	items := make([]LowStockItem, 0, len(products))
+	ids := make([]uint, 0, len(products))
+	lines := make([]string, 0, len(products))
+	for _, product := range products {
+		items = append(items, LowStockItem{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Stock:     product.Stock,
+			Threshold: product.LowStockThreshold,
+		})
+		ids = append(ids, product.ID)
+		lines = append(lines, fmt.Sprintf("- %s (%s): còn %d, ngưỡng %d", product.Name, product.SKU, product.Stock, product.LowStockThreshold))
+	}
+
+	alert := notify.Alert{
+		Event:   "stock.low",
+		Subject: fmt.Sprintf("Cảnh báo tồn kho thấp: %d sản phẩm", len(items)),
+		Message: "Các sản phẩm sau đã xuống bằng hoặc dưới ngưỡng đặt hàng lại:\n" + strings.Join(lines, "\n"),
+		Data:    map[string]interface{}{"products": items},
+	}
+	if !notify.Enabled() {
+		log.Printf("⚠️  No alert channel configured, low stock: %s", strings.Join(lines, "; "))
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		// Vẫn ghi nhận đã cảnh báo khi một kênh lỗi để kênh còn lại không nhận cảnh báo trùng mỗi phút
+		if err := notify.Send(ctx, alert); err != nil {
+			log.Printf("⚠️  Failed to deliver low-stock alert: %v", err)
+		}
+	}
+
+	if err := productRepo.MarkLowStockAlerted(ids, time.Now()); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"backend/internal/catalog"
+	"backend/internal/consts"
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"backend/internal/spreadsheet"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+)
+
+// Số dòng xử lý giữa hai lần lưu tiến độ và cập nhật chỉ mục tìm kiếm
+const productImportBatchSize = 50
+
+// ErrImportRunning được trả về khi đang có một job nhập sản phẩm khác
+var ErrImportRunning = errors.New("product import already running")
+
+var importMu sync.Mutex
+
+// StartProductImport lưu job và chạy nền việc nhập các dòng dữ liệu (không gồm dòng tiêu đề).
+// Mỗi lần chỉ chạy một job để hai tệp không ghi đè cùng một SKU đồng thời.
+func StartProductImport(job *model.ImportJob, importer *catalog.Importer, rows [][]string) error {
+	if !importMu.TryLock() {
+		return ErrImportRunning
+	}
+
+	jobRepo := repo.NewImportJobRepo()
+	job.Status = consts.ImportJobPending
+	job.TotalRows = len(rows)
+	if err := jobRepo.Create(job); err != nil {
+		importMu.Unlock()
+		return err
+	}
+
+	// Goroutine xử lý trên bản sao để caller đọc job (trạng thái pending) mà không tranh chấp dữ liệu
+	running := *job
+	go func() {
+		defer importMu.Unlock()
+		runProductImport(jobRepo, &running, importer, rows)
+	}()
+	return nil
+}
+
+func runProductImport(jobRepo *repo.ImportJobRepo, job *model.ImportJob, importer *catalog.Importer, rows [][]string) {
+	if err := jobRepo.MarkRunning(job.ID); err != nil {
+		log.Printf("⚠️  Failed to start import job #%d: %v", job.ID, err)
+	}
+
+	var rowErrors []model.ImportRowError
+	var changedIDs []uint
+	var failure error
+
+	for i, row := range rows {
+		// Dòng dữ liệu bắt đầu từ dòng 2 của tệp (sau dòng tiêu đề); dòng trống được bỏ qua
+		if spreadsheet.IsBlank(row) {
+			job.ProcessedRows++
+			continue
+		}
+		result, err := importer.Apply(i+2, row)
+		if err != nil {
+			failure = err
+			break
+		}
+
+		job.ProcessedRows++
+		switch result.Action {
+		case catalog.ActionCreated:
+			job.CreatedCount++
+			changedIDs = append(changedIDs, result.ProductID)
+		case catalog.ActionUpdated:
+			job.UpdatedCount++
+			changedIDs = append(changedIDs, result.ProductID)
+		case catalog.ActionUnchanged:
+			job.UnchangedCount++
+		default:
+			job.FailedCount++
+			rowErrors = append(rowErrors, model.ImportRowError{
+				Line:   result.Line,
+				SKU:    result.SKU,
+				Errors: result.Errors,
+				Values: row,
+			})
+		}
+
+		if job.ProcessedRows%productImportBatchSize == 0 {
+			SyncProducts(changedIDs)
+			changedIDs = changedIDs[:0]
+			if err := jobRepo.UpdateProgress(job); err != nil {
+				log.Printf("⚠️  Failed to save progress of import job #%d: %v", job.ID, err)
+			}
+		}
+	}
+	SyncProducts(changedIDs)
+	if job.CreatedCount+job.UpdatedCount > 0 {
+		RefreshSuggestions()
+	}
+
+	job.Status = consts.ImportJobCompleted
+	if failure != nil {
+		// Các dòng trước đó đã được ghi; dừng lại để không tạo hàng loạt lỗi khi cơ sở dữ liệu gặp sự cố
+		job.Status = consts.ImportJobFailed
+		job.Message = failure.Error()
+	}
+	if len(rowErrors) > 0 {
+		if data, err := json.Marshal(rowErrors); err == nil {
+			job.Errors = string(data)
+		}
+	}
+	if err := jobRepo.Finish(job); err != nil {
+		log.Printf("⚠️  Failed to save result of import job #%d: %v", job.ID, err)
+		return
+	}
+
+	if failure != nil {
+		log.Printf("⚠️  Import job #%d failed after %d row(s): %v", job.ID, job.ProcessedRows, failure)
+		return
+	}
+	log.Printf("✅ Import job #%d finished: %d created, %d updated, %d unchanged, %d rejected",
+		job.ID, job.CreatedCount, job.UpdatedCount, job.UnchangedCount, job.FailedCount)
+}
+
+// SyncProducts cập nhật chỉ mục tìm kiếm cho các sản phẩm vừa thay đổi hàng loạt; sản phẩm ngừng bán bị gỡ khỏi chỉ mục
+func SyncProducts(ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	products, err := repo.NewProductRepo().GetActiveByIDs(ids)
+	if err != nil {
+		log.Printf("⚠️  Failed to reload products for search index: %v", err)
+		return
+	}
+
+	active := make(map[uint]bool, len(products))
+	for i := range products {
+		active[products[i].ID] = true
+		search.SyncProduct(&products[i])
+	}
+	for _, id := range ids {
+		if !active[id] {
+			search.RemoveProduct(id)
+		}
+	}
+}
+
+// FailInterruptedImports đánh dấu thất bại các job nhập bị dừng giữa chừng do máy chủ khởi động lại
+func FailInterruptedImports() {
+	count, err := repo.NewImportJobRepo().FailInterrupted()
+	if err != nil {
+		log.Printf("⚠️  Failed to check interrupted import jobs: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("⚠️  Marked %d interrupted import job(s) as failed", count)
+	}
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"backend/internal/consts"
+	"backend/internal/repo"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Đơn giữ hàng quá hạn được quét mỗi phút, tối đa reservationSweepBatch đơn mỗi lượt (phần còn lại để lượt sau)
+const (
+	reservationSweepInterval = time.Minute
+	reservationSweepBatch    = 100
+)
+
+var reservationSweepOnce sync.Once
+
+// ReservationTimeout đọc số phút giữ hàng chờ thanh toán online từ RESERVATION_TIMEOUT_MINUTES (mặc định 30)
+func ReservationTimeout() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("RESERVATION_TIMEOUT_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// StartReservationSweeper chạy nền việc tự hủy các đơn chờ thanh toán online đã quá hạn giữ hàng và hoàn kho
+func StartReservationSweeper() {
+	reservationSweepOnce.Do(func() {
+		go func() {
+			for {
+				cancelled, err := SweepExpiredReservations(time.Now())
+				if err != nil {
+					log.Printf("⚠️  Failed to sweep expired stock reservations: %v", err)
+				} else if cancelled > 0 {
+					log.Printf("✅ Cancelled %d unpaid order(s) with expired stock reservations", cancelled)
+				}
+				time.Sleep(reservationSweepInterval)
+			}
+		}()
+	})
+}
+
+// SweepExpiredReservations xử lý các đơn còn giữ hàng đã hết hạn trước now: đơn vẫn chờ xử lý và chưa thanh toán
+// bị hủy và hoàn kho; đơn đã thanh toán hoặc đã được xác nhận thì giữ nguyên hàng (chuyển thành bán).
+// Trả về số đơn đã hủy.
+func SweepExpiredReservations(now time.Time) (int, error) {
+	reservationRepo := repo.NewReservationRepo()
+	orderRepo := repo.NewOrderRepo()
+	stockRepo := repo.NewStockRepo()
+
+	ids, err := reservationRepo.ExpiredOrderIDs(now, reservationSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, id := range ids {
+		order, err := orderRepo.GetByID(id)
+		if err != nil && err.Error() != "order not found" {
+			return cancelled, err
+		}
+		// Đơn đã xóa mềm, đã thanh toán hoặc đã được xác nhận thủ công: hàng đã cam kết cho đơn
+		if order == nil || order.Status != "pending" || order.PaymentStatus == "paid" {
+			if _, err := reservationRepo.Convert(id); err != nil {
+				return cancelled, err
+			}
+			continue
+		}
+
+		released := false
+		err = repo.Transaction(func(tx *gorm.DB) error {
+			// Khóa đơn trước (cùng thứ tự khóa với xử lý đổi trạng thái/thanh toán) và kiểm tra lại: đơn vừa được
+			// thanh toán, xác nhận hoặc hủy song song thì bỏ qua
+			locked, err := orderRepo.WithTx(tx).LockByID(id)
+			if err != nil {
+				if err.Error() == "order not found" {
+					return nil
+				}
+				return err
+			}
+			if locked.Status != "pending" || locked.PaymentStatus == "paid" {
+				return nil
+			}
+			// Release chỉ khớp khi hàng còn đang giữ nên hàng đã được chuyển/trả sẽ không bị hoàn lại lần nữa
+			count, err := reservationRepo.WithTx(tx).Release(id)
+			if err != nil || count == 0 {
+				return err
+			}
+			if err := stockRepo.WithTx(tx).MoveOrder(locked, consts.StockReasonCancellation, 1, nil); err != nil {
+				return err
+			}
+			released = true
+			return orderRepo.WithTx(tx).UpdateStatus(id, "cancelled")
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to cancel order %s with expired stock reservation: %v", order.OrderNumber, err)
+			continue
+		}
+		if released {
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
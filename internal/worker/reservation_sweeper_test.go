@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservationTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 30 * time.Minute},
+		{"15", 15 * time.Minute},
+		{"0", 30 * time.Minute},
+		{"-5", 30 * time.Minute},
+		{"abc", 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("RESERVATION_TIMEOUT_MINUTES", tt.value)
+		if got := ReservationTimeout(); got != tt.want {
+			t.Errorf("ReservationTimeout() with %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"backend/internal/model"
+	"backend/internal/repo"
+	"backend/internal/search"
+	"errors"
+	"log"
+	"sync"
+)
+
+const searchIndexBatchSize = 200
+
+// ErrReindexRunning được trả về khi đang có một lần dựng lại chỉ mục khác
+var ErrReindexRunning = errors.New("search reindex already running")
+
+var reindexMu sync.Mutex
+
+// ReindexStats là số tài liệu đã lập chỉ mục và đã gỡ sau khi dựng lại
+type ReindexStats struct {
+	Products int `json:"products"`
+	News     int `json:"news"`
+	Removed  int `json:"removed"`
+}
+
+// StartSearchIndexer dựng chỉ mục tìm kiếm ở nền nếu chỉ mục đang trống (lần chạy đầu hoặc SEARCH_DRIVER=memory)
+func StartSearchIndexer() {
+	count, err := search.MustDefault().Count()
+	if err != nil {
+		log.Printf("⚠️  Failed to read search index: %v", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	go func() {
+		stats, err := ReindexSearch()
+		if err != nil {
+			log.Printf("⚠️  Failed to build search index: %v", err)
+			return
+		}
+		log.Printf("✅ Search index built: %d product(s), %d news", stats.Products, stats.News)
+	}()
+}
+
+// SyncMatchingProducts lập chỉ mục lại theo lô các sản phẩm đang bán khớp bộ lọc, dùng khi thương hiệu hoặc danh mục
+// đổi tên/chuyển chỗ làm thay đổi thẻ (tên thương hiệu, đường dẫn danh mục) của các sản phẩm đó
+func SyncMatchingProducts(filter repo.ProductFilter) {
+	index := search.MustDefault()
+	synced := 0
+	err := repo.NewProductRepo().EachActiveMatching(filter, searchIndexBatchSize, func(products []model.Product) error {
+		docs := make([]search.Document, 0, len(products))
+		for i := range products {
+			docs = append(docs, search.ProductDocument(&products[i]))
+		}
+		synced += len(docs)
+		return index.Index(docs...)
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to sync products for search index after %d product(s): %v", synced, err)
+	}
+}
+
+// ReindexSearch lập chỉ mục lại toàn bộ sản phẩm đang bán và bài viết đã xuất bản,
+// sau đó gỡ các tài liệu không còn tương ứng với bản ghi nào
+func ReindexSearch() (*ReindexStats, error) {
+	if !reindexMu.TryLock() {
+		return nil, ErrReindexRunning
+	}
+	defer reindexMu.Unlock()
+
+	index := search.MustDefault()
+	stats := &ReindexStats{}
+
+	productIDs := make(map[uint]bool)
+	err := repo.NewProductRepo().EachActive(searchIndexBatchSize, func(products []model.Product) error {
+		docs := make([]search.Document, 0, len(products))
+		for i := range products {
+			docs = append(docs, search.ProductDocument(&products[i]))
+			productIDs[products[i].ID] = true
+		}
+		stats.Products += len(docs)
+		return index.Index(docs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newsIDs := make(map[uint]bool)
+	err = repo.NewNewsRepo().EachPublished(searchIndexBatchSize, func(news []model.News) error {
+		docs := make([]search.Document, 0, len(news))
+		for i := range news {
+			docs = append(docs, search.NewsDocument(&news[i]))
+			newsIDs[news[i].ID] = true
+		}
+		stats.News += len(docs)
+		return index.Index(docs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for docType, keep := range map[string]map[uint]bool{search.TypeProduct: productIDs, search.TypeNews: newsIDs} {
+		ids, err := index.IDs(docType)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if keep[id] {
+				continue
+			}
+			if err := index.Delete(docType, id); err != nil {
+				return nil, err
+			}
+			stats.Removed++
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"backend/internal/repo"
+	"backend/internal/search"
+	"log"
+	"sync"
+	"time"
+)
+
+// Gom các thay đổi liên tiếp (vd: nhập nhiều sản phẩm) thành một lần dựng lại
+const suggestionRebuildDelay = 2 * time.Second
+
+// Trọng số gợi ý: thương hiệu và danh mục được ưu tiên hơn tên sản phẩm
+const (
+	suggestionWeightBrand    = 3
+	suggestionWeightCategory = 2
+	suggestionWeightFeatured = 2
+	suggestionWeightProduct  = 1
+)
+
+var (
+	suggestionSignal chan struct{}
+	suggestionOnce   sync.Once
+)
+
+// StartSuggestionBuilder dựng trie gợi ý tìm kiếm lần đầu và chạy nền để dựng lại khi có thay đổi
+func StartSuggestionBuilder() {
+	suggestionOnce.Do(func() {
+		suggestionSignal = make(chan struct{}, 1)
+		if err := rebuildSuggestions(); err != nil {
+			log.Printf("⚠️  Failed to build search suggestions: %v", err)
+		}
+		go runSuggestionBuilder()
+	})
+}
+
+// RefreshSuggestions yêu cầu dựng lại trie gợi ý sau khi sản phẩm, thương hiệu hoặc danh mục thay đổi; không chặn request
+func RefreshSuggestions() {
+	if suggestionSignal == nil {
+		return
+	}
+	select {
+	case suggestionSignal <- struct{}{}:
+	default:
+		// Đã có yêu cầu đang chờ, lần dựng lại đó sẽ bao gồm thay đổi này
+	}
+}
+
+func runSuggestionBuilder() {
+	for range suggestionSignal {
+		time.Sleep(suggestionRebuildDelay)
+		// Bỏ các yêu cầu đến trong lúc chờ
+		select {
+		case <-suggestionSignal:
+		default:
+		}
+		if err := rebuildSuggestions(); err != nil {
+			log.Printf("⚠️  Failed to rebuild search suggestions: %v", err)
+		}
+	}
+}
+
+func rebuildSuggestions() error {
+	products, err := repo.NewProductRepo().GetActiveNames()
+	if err != nil {
+		return err
+	}
+	brands, err := repo.NewBrandRepo().GetAll(true)
+	if err != nil {
+		return err
+	}
+	categories, err := repo.NewCategoryRepo().GetAll()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]search.Suggestion, 0, len(products)+len(brands)+len(categories))
+	for _, brand := range brands {
+		entries = append(entries, search.Suggestion{
+			Type:   search.SuggestionBrand,
+			ID:     brand.ID,
+			Text:   brand.Name,
+			Slug:   brand.Slug,
+			Weight: suggestionWeightBrand,
+		})
+	}
+	for _, category := range categories {
+		entries = append(entries, search.Suggestion{
+			Type:   search.SuggestionCategory,
+			ID:     category.ID,
+			Text:   category.Name,
+			Slug:   category.Slug,
+			Weight: suggestionWeightCategory,
+		})
+	}
+	for _, product := range products {
+		weight := suggestionWeightProduct
+		if product.IsFeatured {
+			weight = suggestionWeightFeatured
+		}
+		entries = append(entries, search.Suggestion{
+			Type:   search.SuggestionProduct,
+			ID:     product.ID,
+			Text:   product.Name,
+			Weight: weight,
+		})
+	}
+
+	search.ReplaceSuggestions(entries)
+	return nil
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"backend/internal/repo"
+	"backend/internal/storage"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Thùng rác được dọn mỗi ngày một lần
+const trashPurgeInterval = 24 * time.Hour
+
+var trashPurgeOnce sync.Once
+
+// TrashRetention đọc số ngày giữ bản ghi trong thùng rác từ TRASH_RETENTION_DAYS (mặc định 30)
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// StartTrashPurger chạy nền việc xóa vĩnh viễn các bản ghi đã quá thời hạn lưu trong thùng rác
+func StartTrashPurger() {
+	trashPurgeOnce.Do(func() {
+		go func() {
+			for {
+				result, err := PurgeTrash(time.Now().Add(-TrashRetention()))
+				if err != nil {
+					log.Printf("⚠️  Failed to purge trash: %v", err)
+				} else if purged := result.Products + result.Categories + result.News + result.Users + result.Orders; purged > 0 {
+					log.Printf("✅ Purged %d record(s) from trash, %d kept because still referenced", purged, result.Skipped)
+				}
+				time.Sleep(trashPurgeInterval)
+			}
+		}()
+	})
+}
+
+// PurgeTrash xóa vĩnh viễn các bản ghi bị xóa mềm trước thời điểm before và dọn ảnh đã tải lên của chúng
+func PurgeTrash(before time.Time) (*repo.TrashPurgeResult, error) {
+	result, err := repo.NewTrashRepo().Purge(before)
+	if err != nil {
+		return nil, err
+	}
+
+	store := storage.MustDefault()
+	for _, image := range result.ProductImages {
+		deleteStoredFile(store, image.StorageKey, image.Variants)
+	}
+	for _, news := range result.NewsImages {
+		deleteStoredFile(store, news.ImageKey, news.Variants)
+	}
+	return result, nil
+}
+
+// deleteStoredFile xóa tệp gốc (nếu có) và các ảnh dẫn xuất; lỗi chỉ để lại tệp mồ côi
+func deleteStoredFile(store storage.Storage, key, variants string) {
+	if key != "" {
+		if err := store.Delete(context.Background(), key); err != nil {
+			log.Printf("⚠️  Failed to delete stored image %s: %v", key, err)
+		}
+	}
+	DeleteImageVariants(store, variants)
+}
@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"backend/internal/repo"
+	"log"
+)
+
+// InitVariantValues tạo giá trị tùy chọn dùng để lọc/đếm facet cho các biến thể tạo trước khi có bảng
+// product_variant_values
+func InitVariantValues() {
+	count, err := repo.NewProductVariantRepo().BackfillValues()
+	if err != nil {
+		log.Printf("⚠️  Failed to backfill variant option values: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("✅ Backfilled option values of %d product variant(s)", count)
+	}
+}
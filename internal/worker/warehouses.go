@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"backend/internal/repo"
+	"log"
+)
+
+// InitWarehouses tạo kho Hà Nội và TP. Hồ Chí Minh khi chưa có kho nào và đưa tồn kho chưa được phân
+// vào kho nào (dữ liệu trước khi có nhiều kho) vào kho mặc định
+func InitWarehouses() {
+	warehouseRepo := repo.NewWarehouseRepo()
+	created, err := warehouseRepo.SeedDefaults()
+	if err != nil {
+		log.Printf("⚠️  Failed to create default warehouses: %v", err)
+		return
+	}
+	if created {
+		log.Println("✅ Created default warehouses")
+	}
+
+	count, err := warehouseRepo.BackfillStock()
+	if err != nil {
+		log.Printf("⚠️  Failed to assign unallocated stock to the default warehouse: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("✅ Assigned unallocated stock of %d product(s)/variant(s) to the default warehouse", count)
+	}
+}
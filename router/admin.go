@@ -2,6 +2,7 @@ package router
 
 import (
 	"backend/app"
+	"backend/internal/consts"
 	"backend/internal/handle"
 	"backend/internal/repo"
 	"backend/utils"
@@ -12,33 +13,52 @@ import (
 func SetupAdminRoutes(router *gin.Engine) {
 	userRepo := repo.NewUserRepository(app.GetDB())
 	adminHandler := handle.NewAdminHandler(userRepo)
+	permissionHandler := handle.NewPermissionHandler(userRepo)
+	auditHandler := handle.NewAuditHandler()
+	ownerHandler := handle.NewOwnerHandler(userRepo)
 
 	// Các routes admin với các cấp độ quyền khác nhau
 	admin := router.Group("/api/admin")
 	admin.Use(utils.AuthMiddleware())
 	
-	// Routes chỉ dành cho Owner
+	// Routes chỉ dành cho Owner (không cho phép dùng API key)
 	ownerRoutes := admin.Group("/owner")
-	ownerRoutes.Use(utils.OwnerMiddleware())
+	ownerRoutes.Use(utils.OwnerMiddleware(), utils.DenyAPIKey())
 	{
 		// Chỉ owner mới có thể xem thống kê hệ thống
 		ownerRoutes.GET("/stats/system", adminHandler.GetUserStats)
+
+		// Phân quyền chi tiết theo tài nguyên
+		ownerRoutes.GET("/permissions/:user_id", permissionHandler.GetUserPermissions)
+		ownerRoutes.POST("/permissions", permissionHandler.AssignPermission)
+		ownerRoutes.DELETE("/permissions/:user_id/:resource", permissionHandler.RevokePermission)
+
+		// Nhật ký thao tác của quản trị viên
+		ownerRoutes.GET("/audit-logs", auditHandler.GetAuditLogs)
+		ownerRoutes.GET("/audit-logs/:id", auditHandler.GetAuditLogByID)
+
+		// Chuyển quyền owner (cần người nhận xác nhận)
+		ownerRoutes.POST("/transfer", utils.DenyImpersonation(), ownerHandler.InitiateOwnerTransfer)
+		ownerRoutes.GET("/transfer", ownerHandler.GetOutgoingOwnerTransfer)
+		ownerRoutes.DELETE("/transfer/:id", utils.DenyImpersonation(), ownerHandler.CancelOwnerTransfer)
+
+		// Giả danh người dùng để hỗ trợ khách hàng
+		ownerRoutes.POST("/impersonate/:user_id", utils.DenyImpersonation(), ownerHandler.ImpersonateUser)
 	}
 
-	// Routes dành cho Owner và Admin (cấp độ quản lý)
+	// Routes quản lý người dùng, kiểm tra quyền theo tài nguyên users
 	managerRoutes := admin.Group("/manage")
-	managerRoutes.Use(utils.OwnerOrAdminMiddleware())
 	{
 		// Quản lý người dùng
-		managerRoutes.POST("/users", adminHandler.CreateUser)
-		managerRoutes.GET("/users", adminHandler.GetAllUsers)
-		managerRoutes.GET("/users/:id", adminHandler.GetUserByID)
-		managerRoutes.GET("/users/role/:role", adminHandler.GetUsersByRole)
-		managerRoutes.PUT("/users/:id/role", adminHandler.AssignUserRole)
-		managerRoutes.PUT("/users/:id/status", adminHandler.ToggleUserStatus)
-		managerRoutes.DELETE("/users/:id", adminHandler.DeleteUser)
-		
-		 // Thống kê
-		managerRoutes.GET("/stats/users", adminHandler.GetUserStats)
+		managerRoutes.POST("/users", utils.RequirePermission(consts.ResourceUsers, consts.PermissionWrite), adminHandler.CreateUser)
+		managerRoutes.GET("/users", utils.RequirePermission(consts.ResourceUsers, consts.PermissionRead), adminHandler.GetAllUsers)
+		managerRoutes.GET("/users/:id", utils.RequirePermission(consts.ResourceUsers, consts.PermissionRead), adminHandler.GetUserByID)
+		managerRoutes.GET("/users/role/:role", utils.RequirePermission(consts.ResourceUsers, consts.PermissionRead), adminHandler.GetUsersByRole)
+		managerRoutes.PUT("/users/:id/role", utils.RequirePermission(consts.ResourceUsers, consts.PermissionWrite), adminHandler.AssignUserRole)
+		managerRoutes.PUT("/users/:id/status", utils.RequirePermission(consts.ResourceUsers, consts.PermissionWrite), adminHandler.ToggleUserStatus)
+		managerRoutes.DELETE("/users/:id", utils.RequirePermission(consts.ResourceUsers, consts.PermissionFull), adminHandler.DeleteUser)
+
+		// Thống kê
+		managerRoutes.GET("/stats/users", utils.RequirePermission(consts.ResourceUsers, consts.PermissionRead), adminHandler.GetUserStats)
 	}
 }
\ No newline at end of file
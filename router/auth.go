@@ -13,6 +13,11 @@ func SetupAuthRoutes(router *gin.Engine) {
 	// Khởi tạo repository và handler
 	userRepo := repo.NewUserRepository(app.GetDB())
 	authHandler := handle.NewAuthHandler(userRepo)
+	ownerHandler := handle.NewOwnerHandler(userRepo)
+	apiKeyHandler := handle.NewAPIKeyHandler()
+
+	// Khóa công khai để các dịch vụ khác xác minh token
+	router.GET("/.well-known/jwks.json", authHandler.GetJWKS)
 
 	// Routes công khai
 	auth := router.Group("/api/auth")
@@ -26,6 +31,16 @@ func SetupAuthRoutes(router *gin.Engine) {
 	protected.Use(utils.AuthMiddleware())
 	{
 		protected.GET("/profile", authHandler.GetProfile)
-		protected.PUT("/profile", authHandler.UpdateProfile)
+		protected.PUT("/profile", utils.DenyAPIKey(), authHandler.UpdateProfile)
+
+		// API key cho tích hợp hệ thống; chỉ quản lý được từ phiên đăng nhập
+		protected.GET("/api-keys", utils.DenyAPIKey(), apiKeyHandler.GetAPIKeys)
+		protected.POST("/api-keys", utils.DenyAPIKey(), utils.DenyImpersonation(), apiKeyHandler.CreateAPIKey)
+		protected.DELETE("/api-keys/:id", utils.DenyAPIKey(), utils.DenyImpersonation(), apiKeyHandler.RevokeAPIKey)
+
+		// Người nhận xác nhận hoặc từ chối chuyển quyền owner
+		protected.GET("/owner-transfer", ownerHandler.GetIncomingOwnerTransfer)
+		protected.POST("/owner-transfer/:id/accept", utils.DenyAPIKey(), utils.DenyImpersonation(), ownerHandler.AcceptOwnerTransfer)
+		protected.POST("/owner-transfer/:id/decline", utils.DenyAPIKey(), utils.DenyImpersonation(), ownerHandler.DeclineOwnerTransfer)
 	}
 }
\ No newline at end of file
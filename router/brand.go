@@ -0,0 +1,31 @@
+package router
+
+import (
+	"backend/internal/consts"
+	"backend/internal/handle"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupBrandRoutes(r *gin.Engine) {
+	brandHandler := handle.NewBrandHandler()
+
+	// Routes công khai
+	publicRoutes := r.Group("/api/brands")
+	{
+		publicRoutes.GET("/", brandHandler.GetBrands)
+		publicRoutes.GET("/slug/:slug", brandHandler.GetBrandBySlug)
+	}
+
+	// Routes được bảo vệ (admin và owner)
+	adminRoutes := r.Group("/api/admin/brands")
+	adminRoutes.Use(utils.AuthMiddleware())
+	{
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceBrands, consts.PermissionRead), brandHandler.GetAllBrands)
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceBrands, consts.PermissionRead), brandHandler.GetBrandByID)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceBrands, consts.PermissionWrite), brandHandler.CreateBrand)
+		adminRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceBrands, consts.PermissionWrite), brandHandler.UpdateBrand)
+		adminRoutes.DELETE("/:id", utils.RequirePermission(consts.ResourceBrands, consts.PermissionFull), brandHandler.DeleteBrand)
+	}
+}
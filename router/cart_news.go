@@ -1,6 +1,7 @@
 package router
 
 import (
+	"backend/internal/consts"
 	"backend/internal/handle"
 	"backend/utils"
 
@@ -36,12 +37,12 @@ func SetupNewsRoutes(r *gin.Engine) {
 	// Routes admin
 	adminRoutes := r.Group("/api/admin/news")
 	adminRoutes.Use(utils.AuthMiddleware())
-	adminRoutes.Use(utils.AdminMiddleware())
 	{
-		adminRoutes.GET("/", newsHandler.GetNews) // Tất cả tin tức bao gồm cả chưa xuất bản
-		adminRoutes.GET("/:id", newsHandler.GetNewsByID)
-		adminRoutes.POST("/", newsHandler.CreateNews)
-		adminRoutes.PUT("/:id", newsHandler.UpdateNews)
-		adminRoutes.DELETE("/:id", newsHandler.DeleteNews)
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceNews, consts.PermissionRead), newsHandler.GetNews) // Tất cả tin tức bao gồm cả chưa xuất bản
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceNews, consts.PermissionRead), newsHandler.GetNewsByID)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceNews, consts.PermissionWrite), newsHandler.CreateNews)
+		adminRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceNews, consts.PermissionWrite), newsHandler.UpdateNews)
+		adminRoutes.POST("/:id/image", utils.RequirePermission(consts.ResourceNews, consts.PermissionWrite), newsHandler.UploadNewsImage)
+		adminRoutes.DELETE("/:id", utils.RequirePermission(consts.ResourceNews, consts.PermissionFull), newsHandler.DeleteNews)
 	}
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"backend/internal/consts"
 	"backend/internal/handle"
 	"backend/utils"
 
@@ -14,6 +15,7 @@ func SetupCategoryRoutes(r *gin.Engine) {
 	publicRoutes := r.Group("/api/categories")
 	{
 		publicRoutes.GET("/", categoryHandler.GetCategories)
+		publicRoutes.GET("/tree", categoryHandler.GetCategoryTree)
 		publicRoutes.GET("/:id", categoryHandler.GetCategoryByID)
 		publicRoutes.GET("/slug/:slug", categoryHandler.GetCategoryBySlug)
 	}
@@ -21,12 +23,13 @@ func SetupCategoryRoutes(r *gin.Engine) {
 	// Routes được bảo vệ (admin và owner)
 	adminRoutes := r.Group("/api/admin/categories")
 	adminRoutes.Use(utils.AuthMiddleware())
-	adminRoutes.Use(utils.AdminMiddleware()) // Bây giờ cho phép cả admin và owner
 	{
-		adminRoutes.GET("/", categoryHandler.GetCategories) 
-		adminRoutes.GET("/:id", categoryHandler.GetCategoryByID)
-		adminRoutes.POST("/", categoryHandler.CreateCategory)
-		adminRoutes.PUT("/:id", categoryHandler.UpdateCategory)
-		adminRoutes.DELETE("/:id", categoryHandler.DeleteCategory)
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceCategories, consts.PermissionRead), categoryHandler.GetCategories)
+		adminRoutes.GET("/tree", utils.RequirePermission(consts.ResourceCategories, consts.PermissionRead), categoryHandler.GetAdminCategoryTree)
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceCategories, consts.PermissionRead), categoryHandler.GetCategoryByID)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceCategories, consts.PermissionWrite), categoryHandler.CreateCategory)
+		adminRoutes.POST("/move-products", utils.RequirePermission(consts.ResourceCategories, consts.PermissionWrite), categoryHandler.MoveCategoryProducts)
+		adminRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceCategories, consts.PermissionWrite), categoryHandler.UpdateCategory)
+		adminRoutes.DELETE("/:id", utils.RequirePermission(consts.ResourceCategories, consts.PermissionFull), categoryHandler.DeleteCategory)
 	}
 }
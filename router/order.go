@@ -1,6 +1,7 @@
 package router
 
 import (
+	"backend/internal/consts"
 	"backend/internal/handle"
 	"backend/utils"
 
@@ -44,13 +45,12 @@ func SetupOrderRoutes(r *gin.Engine) {
 	// Routes admin
 	adminRoutes := r.Group("/api/admin/orders")
 	adminRoutes.Use(utils.AuthMiddleware())
-	adminRoutes.Use(utils.AdminMiddleware())
 	{
-		adminRoutes.GET("/", orderHandler.GetOrders)
-		adminRoutes.GET("/stats", orderHandler.GetOrderStats)
-		adminRoutes.GET("/guest-stats", orderHandler.GetGuestOrderStats)
-		adminRoutes.GET("/:id", orderHandler.GetOrderByID)
-		adminRoutes.PUT("/:id/status", orderHandler.UpdateOrderStatus)
-		adminRoutes.PUT("/:id/payment", orderHandler.UpdatePaymentStatus)
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceOrders, consts.PermissionRead), orderHandler.GetOrders)
+		adminRoutes.GET("/stats", utils.RequirePermission(consts.ResourceOrders, consts.PermissionRead), orderHandler.GetOrderStats)
+		adminRoutes.GET("/guest-stats", utils.RequirePermission(consts.ResourceOrders, consts.PermissionRead), orderHandler.GetGuestOrderStats)
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceOrders, consts.PermissionRead), orderHandler.GetOrderByID)
+		adminRoutes.PUT("/:id/status", utils.RequirePermission(consts.ResourceOrders, consts.PermissionWrite), orderHandler.UpdateOrderStatus)
+		adminRoutes.PUT("/:id/payment", utils.RequirePermission(consts.ResourceOrders, consts.PermissionWrite), orderHandler.UpdatePaymentStatus)
 	}
 }
\ No newline at end of file
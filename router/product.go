@@ -1,6 +1,7 @@
 package router
 
 import (
+	"backend/internal/consts"
 	"backend/internal/handle"
 	"backend/utils"
 
@@ -9,6 +10,12 @@ import (
 
 func SetupProductRoutes(r *gin.Engine) {
 	productHandler := handle.NewProductHandler()
+	imageHandler := handle.NewProductImageHandler()
+	variantHandler := handle.NewProductVariantHandler()
+	uploadHandler := handle.NewUploadHandler()
+
+	// Phục vụ tệp đã tải lên (ảnh sản phẩm...)
+	r.GET("/uploads/*filepath", uploadHandler.ServeUpload)
 
 	// Routes công khai
 	publicRoutes := r.Group("/api/products")
@@ -16,18 +23,53 @@ func SetupProductRoutes(r *gin.Engine) {
 		publicRoutes.GET("/", productHandler.GetProducts)
 		publicRoutes.GET("/:id", productHandler.GetProductByID)
 		publicRoutes.GET("/sku/:sku", productHandler.GetProductBySKU)
+		publicRoutes.GET("/:id/images", imageHandler.GetProductImages)
+		publicRoutes.GET("/:id/variants", variantHandler.GetProductVariants)
 	}
 
 	// Routes được bảo vệ (admin và owner)
 	adminRoutes := r.Group("/api/admin/products")
 	adminRoutes.Use(utils.AuthMiddleware())
-	adminRoutes.Use(utils.AdminMiddleware()) // Bây giờ cho phép cả admin và owner
 	{
-		adminRoutes.GET("/", productHandler.GetProducts)
-		adminRoutes.GET("/:id", productHandler.GetProductByID)
-		adminRoutes.POST("/", productHandler.CreateProduct)
-		adminRoutes.PUT("/:id", productHandler.UpdateProduct)
-		adminRoutes.DELETE("/:id", productHandler.DeleteProduct)
-		adminRoutes.PATCH("/:id/stock", productHandler.UpdateProductStock)
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetProducts)
+
+		// Nhập/xuất sản phẩm bằng bảng tính (CSV, XLSX)
+		adminRoutes.GET("/export", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.ExportProducts)
+		adminRoutes.POST("/import", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), productHandler.ImportProducts)
+		adminRoutes.GET("/import", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetImportJobs)
+		adminRoutes.GET("/import/:job_id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetImportJob)
+		adminRoutes.GET("/import/:job_id/errors", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.DownloadImportErrors)
+
+		// Thao tác hàng loạt (theo danh sách ID hoặc bộ lọc), hỗ trợ dry_run
+		adminRoutes.POST("/bulk", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), productHandler.BulkUpdateProducts)
+		adminRoutes.POST("/bulk/delete", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), productHandler.BulkDeleteProducts)
+
+		// Báo cáo sản phẩm bằng hoặc dưới ngưỡng tồn kho, kèm tốc độ bán và số ngày còn đủ hàng
+		adminRoutes.GET("/low-stock", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetLowStockReport)
+		adminRoutes.GET("/margins", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetMarginReport)
+
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetProductByID)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), productHandler.CreateProduct)
+		adminRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), productHandler.UpdateProduct)
+		adminRoutes.DELETE("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), productHandler.DeleteProduct)
+		adminRoutes.PATCH("/:id/stock", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), productHandler.UpdateProductStock)
+		adminRoutes.GET("/:id/stock-movements", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetStockMovements)
+		adminRoutes.GET("/:id/warehouse-stock", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), productHandler.GetWarehouseStock)
+
+		// Thư viện ảnh sản phẩm
+		adminRoutes.GET("/:id/images", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), imageHandler.GetProductImages)
+		adminRoutes.POST("/:id/images", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), imageHandler.UploadProductImages)
+		adminRoutes.PUT("/:id/images/reorder", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), imageHandler.ReorderProductImages)
+		adminRoutes.PUT("/:id/images/:image_id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), imageHandler.UpdateProductImage)
+		adminRoutes.PATCH("/:id/images/:image_id/primary", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), imageHandler.SetPrimaryProductImage)
+		adminRoutes.DELETE("/:id/images/:image_id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), imageHandler.DeleteProductImage)
+
+		// Tùy chọn và biến thể sản phẩm
+		adminRoutes.GET("/:id/variants", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), variantHandler.GetProductVariants)
+		adminRoutes.PUT("/:id/options", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), variantHandler.UpdateProductOptions)
+		adminRoutes.POST("/:id/variants", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), variantHandler.CreateProductVariant)
+		adminRoutes.PUT("/:id/variants/:variant_id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), variantHandler.UpdateProductVariant)
+		adminRoutes.PATCH("/:id/variants/:variant_id/stock", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), variantHandler.UpdateProductVariantStock)
+		adminRoutes.DELETE("/:id/variants/:variant_id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), variantHandler.DeleteProductVariant)
 	}
 }
@@ -0,0 +1,35 @@
+package router
+
+import (
+	"backend/internal/consts"
+	"backend/internal/handle"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupPurchasingRoutes(r *gin.Engine) {
+	supplierHandler := handle.NewSupplierHandler()
+	purchaseOrderHandler := handle.NewPurchaseOrderHandler()
+
+	// Nhà cung cấp (quản lý cùng quyền sản phẩm)
+	supplierRoutes := r.Group("/api/admin/suppliers")
+	supplierRoutes.Use(utils.AuthMiddleware())
+	{
+		supplierRoutes.GET("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), supplierHandler.GetSuppliers)
+		supplierRoutes.POST("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), supplierHandler.CreateSupplier)
+		supplierRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), supplierHandler.UpdateSupplier)
+	}
+
+	// Đơn đặt hàng nhà cung cấp và nhận hàng vào kho
+	purchaseOrderRoutes := r.Group("/api/admin/purchase-orders")
+	purchaseOrderRoutes.Use(utils.AuthMiddleware())
+	{
+		purchaseOrderRoutes.GET("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), purchaseOrderHandler.GetPurchaseOrders)
+		purchaseOrderRoutes.POST("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), purchaseOrderHandler.CreatePurchaseOrder)
+		purchaseOrderRoutes.GET("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), purchaseOrderHandler.GetPurchaseOrder)
+		purchaseOrderRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), purchaseOrderHandler.UpdatePurchaseOrder)
+		purchaseOrderRoutes.POST("/:id/order", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), purchaseOrderHandler.PlacePurchaseOrder)
+		purchaseOrderRoutes.POST("/:id/receive", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), purchaseOrderHandler.ReceivePurchaseOrder)
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"backend/internal/handle"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSearchRoutes(r *gin.Engine) {
+	searchHandler := handle.NewSearchHandler()
+
+	// Routes công khai
+	r.GET("/api/search", searchHandler.Search)
+	r.GET("/api/search/suggest", searchHandler.Suggest)
+
+	// Dựng lại chỉ mục tìm kiếm (chỉ owner, không dùng API key)
+	adminRoutes := r.Group("/api/admin/search")
+	adminRoutes.Use(utils.AuthMiddleware(), utils.OwnerMiddleware(), utils.DenyAPIKey())
+	{
+		adminRoutes.POST("/reindex", searchHandler.Reindex)
+	}
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"backend/internal/consts"
+	"backend/internal/handle"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupStocktakeRoutes(r *gin.Engine) {
+	stocktakeHandler := handle.NewStocktakeHandler()
+
+	// Kiểm kê kho (quản lý cùng quyền sản phẩm, duyệt điều chỉnh tồn kho cần quyền đầy đủ)
+	adminRoutes := r.Group("/api/admin/stocktakes")
+	adminRoutes.Use(utils.AuthMiddleware())
+	{
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), stocktakeHandler.GetStocktakes)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), stocktakeHandler.OpenStocktake)
+		adminRoutes.GET("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), stocktakeHandler.GetStocktake)
+		adminRoutes.GET("/:id/lines", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), stocktakeHandler.GetStocktakeLines)
+		adminRoutes.POST("/:id/counts", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), stocktakeHandler.SubmitStocktakeCounts)
+		adminRoutes.POST("/:id/counts/upload", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), stocktakeHandler.UploadStocktakeCounts)
+		adminRoutes.POST("/:id/approve", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), stocktakeHandler.ApproveStocktake)
+		adminRoutes.POST("/:id/cancel", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), stocktakeHandler.CancelStocktake)
+	}
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"backend/app"
+	"backend/internal/consts"
+	"backend/internal/handle"
+	"backend/internal/repo"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTrashRoutes(r *gin.Engine) {
+	trashHandler := handle.NewTrashHandler(repo.NewUserRepository(app.GetDB()))
+
+	// Thùng rác: xem và khôi phục bản ghi đã xóa mềm, kiểm tra quyền theo tài nguyên tương ứng
+	trashRoutes := r.Group("/api/admin/trash")
+	trashRoutes.Use(utils.AuthMiddleware())
+	{
+		trashRoutes.GET("/products", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), trashHandler.List(repo.TrashProducts))
+		trashRoutes.POST("/products/:id/restore", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), trashHandler.Restore(repo.TrashProducts))
+		trashRoutes.GET("/categories", utils.RequirePermission(consts.ResourceCategories, consts.PermissionRead), trashHandler.List(repo.TrashCategories))
+		trashRoutes.POST("/categories/:id/restore", utils.RequirePermission(consts.ResourceCategories, consts.PermissionWrite), trashHandler.Restore(repo.TrashCategories))
+		trashRoutes.GET("/news", utils.RequirePermission(consts.ResourceNews, consts.PermissionRead), trashHandler.List(repo.TrashNews))
+		trashRoutes.POST("/news/:id/restore", utils.RequirePermission(consts.ResourceNews, consts.PermissionWrite), trashHandler.Restore(repo.TrashNews))
+		trashRoutes.GET("/users", utils.RequirePermission(consts.ResourceUsers, consts.PermissionRead), trashHandler.List(repo.TrashUsers))
+		trashRoutes.POST("/users/:id/restore", utils.RequirePermission(consts.ResourceUsers, consts.PermissionWrite), trashHandler.Restore(repo.TrashUsers))
+		trashRoutes.GET("/orders", utils.RequirePermission(consts.ResourceOrders, consts.PermissionRead), trashHandler.List(repo.TrashOrders))
+		trashRoutes.POST("/orders/:id/restore", utils.RequirePermission(consts.ResourceOrders, consts.PermissionWrite), trashHandler.Restore(repo.TrashOrders))
+
+		// Xóa vĩnh viễn bản ghi quá hạn lưu trữ (chỉ owner, không dùng API key)
+		trashRoutes.POST("/purge", utils.OwnerMiddleware(), utils.DenyAPIKey(), trashHandler.PurgeTrash)
+	}
+}
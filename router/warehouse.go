@@ -0,0 +1,25 @@
+package router
+
+import (
+	"backend/internal/consts"
+	"backend/internal/handle"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWarehouseRoutes(r *gin.Engine) {
+	warehouseHandler := handle.NewWarehouseHandler()
+
+	// Kho hàng và chuyển kho (quản lý cùng quyền sản phẩm)
+	adminRoutes := r.Group("/api/admin/warehouses")
+	adminRoutes.Use(utils.AuthMiddleware())
+	{
+		adminRoutes.GET("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), warehouseHandler.GetWarehouses)
+		adminRoutes.POST("/", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), warehouseHandler.CreateWarehouse)
+		adminRoutes.GET("/transfers", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), warehouseHandler.GetTransfers)
+		adminRoutes.POST("/transfers", utils.RequirePermission(consts.ResourceProducts, consts.PermissionWrite), warehouseHandler.CreateTransfer)
+		adminRoutes.PUT("/:id", utils.RequirePermission(consts.ResourceProducts, consts.PermissionFull), warehouseHandler.UpdateWarehouse)
+		adminRoutes.GET("/:id/stock", utils.RequirePermission(consts.ResourceProducts, consts.PermissionRead), warehouseHandler.GetWarehouseStock)
+	}
+}
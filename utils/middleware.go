@@ -1,10 +1,18 @@
 package utils
 
 import (
+	"backend/app"
+	"backend/internal/audit"
 	"backend/internal/consts"
 	"backend/internal/helpers"
+	"backend/internal/repo"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,6 +21,18 @@ import (
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+
+		// Tích hợp hệ thống (ERP, đồng bộ sàn) có thể xác thực bằng API key thay cho Bearer token
+		if authHeader == "" && c.GetHeader("X-API-Key") != "" {
+			if !authenticateAPIKey(c, c.GetHeader("X-API-Key")) {
+				helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		if (authHeader == "") {
 			helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
 			c.Abort()
@@ -42,15 +62,122 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		rawUserID, ok := claims["user_id"].(float64)
+		if !ok {
+			helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+			c.Abort()
+			return
+		}
+
+		// Nạp lại người dùng (qua bộ nhớ đệm ngắn hạn, xóa khi người dùng được cập nhật) để vai trò/trạng thái
+		// thay đổi (vd: chuyển quyền owner) có hiệu lực ngay
+		userRepo := repo.NewUserRepository(app.GetDB())
+		user, err := userRepo.GetAuthUser(uint(rawUserID))
+		if err != nil || !user.IsActive {
+			helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+			c.Abort()
+			return
+		}
+
 		 // Đặt thông tin người dùng vào context
-		c.Set("user_id", uint(claims["user_id"].(float64)))
-		c.Set("username", claims["username"].(string))
-		c.Set("user_role", claims["role"].(string)) // Changed from "role" to "user_role"
+		c.Set("user_id", user.ID)
+		c.Set("username", user.Username)
+		c.Set("user_role", user.Role) // Changed from "role" to "user_role"
+
+		// Token giả danh: chỉ hợp lệ khi người giả danh vẫn là owner đang hoạt động
+		if rawImpersonatorID, isImpersonation := claims["impersonator_id"].(float64); isImpersonation {
+			impersonator, err := userRepo.GetAuthUser(uint(rawImpersonatorID))
+			if err != nil || !impersonator.IsActive || impersonator.Role != consts.RoleOwner {
+				helpers.UnauthorizedResponse(c, consts.MSG_UNAUTHORIZED)
+				c.Abort()
+				return
+			}
+
+			c.Set("impersonator_id", impersonator.ID)
+			c.Writer.Header().Set("X-Impersonated-By", strconv.FormatUint(uint64(impersonator.ID), 10))
+
+			c.Next()
+
+			// Ghi nhật ký mọi thao tác thực hiện dưới danh nghĩa người dùng khác
+			audit.Record(c, "impersonation.request", audit.EntityUser, user.ID, nil, map[string]interface{}{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"query":  c.Request.URL.RawQuery,
+				"status": c.Writer.Status(),
+			})
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// authenticateAPIKey xác thực X-API-Key và đặt thông tin chủ sở hữu khóa vào context
+func authenticateAPIKey(c *gin.Context, rawKey string) bool {
+	if !strings.HasPrefix(rawKey, consts.API_KEY_PREFIX) {
+		return false
+	}
+
+	apiKeyRepo := repo.NewAPIKeyRepo()
+	key, err := apiKeyRepo.GetByHash(helpers.HashAPIKey(rawKey))
+	if err != nil || !key.IsUsable() {
+		return false
+	}
+
+	user, err := repo.NewUserRepository(app.GetDB()).GetAuthUser(key.UserID)
+	if err != nil || !user.IsActive {
+		return false
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("user_role", user.Role)
+	c.Set("api_key_id", key.ID)
+	if key.Scopes != "" {
+		c.Set("api_key_scopes", key.ScopeMap())
+	}
+
+	// Giới hạn tần suất ghi last_used_at để không phát sinh một lệnh UPDATE cho mỗi request
+	interval := time.Duration(consts.API_KEY_LAST_USED_UPDATE_SECONDS) * time.Second
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > interval {
+		if err := apiKeyRepo.TouchLastUsed(key.ID, c.ClientIP(), interval); err != nil {
+			log.Printf("⚠️  Failed to update last_used_at for API key #%d: %v", key.ID, err)
+		}
+	}
+
+	return true
+}
+
+// Middleware chặn các thao tác chỉ dành cho phiên đăng nhập (vd: tạo API key bằng chính một API key)
+func DenyAPIKey() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if _, usingAPIKey := c.Get("api_key_id"); usingAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "Không thể thực hiện thao tác này bằng API key",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
+// Middleware chặn các thao tác nhạy cảm khi đang dùng token giả danh
+func DenyImpersonation() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if _, impersonating := c.Get("impersonator_id"); impersonating {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "Không thể thực hiện thao tác này khi đang giả danh người dùng",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
@@ -67,7 +194,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -79,6 +206,23 @@ func CORSMiddleware() gin.HandlerFunc {
 	})
 }
 
+// Middleware gán mã định danh cho mỗi request (dùng lại X-Request-ID nếu client gửi lên)
+func RequestIDMiddleware() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 64 {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err == nil {
+				requestID = hex.EncodeToString(buf)
+			}
+		}
+
+		c.Set("request_id", requestID)
+		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Next()
+	})
+}
+
 // Middleware kiểm tra người dùng có vai trò owner
 func OwnerMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
@@ -126,3 +270,52 @@ func MemberOrAboveMiddleware() gin.HandlerFunc {
 		c.Next()
 	})
 }
+
+// Middleware kiểm tra người dùng có cấp độ quyền yêu cầu trên tài nguyên
+func RequirePermission(resource, level string) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		allowed, err := CheckPermission(c, resource, level)
+		if err != nil {
+			helpers.ErrorResponse(c, http.StatusInternalServerError, consts.MSG_INTERNAL_ERROR, err)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "Không đủ quyền truy cập tài nguyên " + resource,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
+// CheckPermission kiểm tra người dùng hiện tại có cấp độ quyền yêu cầu trên tài nguyên không
+func CheckPermission(c *gin.Context, resource, level string) (bool, error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return false, nil
+	}
+	userRole, exists := c.Get("user_role")
+	if !exists {
+		return false, nil
+	}
+
+	granted, _, err := repo.NewPermissionRepo().GetEffectiveLevel(userID.(uint), userRole.(string), resource)
+	if err != nil {
+		return false, err
+	}
+
+	// API key có scope chỉ được dùng tối đa cấp độ trong scope, tài nguyên ngoài scope bị từ chối
+	if scopes, limited := c.Get("api_key_scopes"); limited {
+		scopeLevel, exists := scopes.(map[string]string)[resource]
+		if !exists {
+			return false, nil
+		}
+		granted = consts.MinPermission(granted, scopeLevel)
+	}
+
+	return consts.PermissionSatisfies(granted, level), nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"backend/internal/consts"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckPermissionAPIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		scopes   map[string]string
+		resource string
+		level    string
+		want     bool
+	}{
+		{"session token has full owner access", nil, consts.ResourceProducts, consts.PermissionFull, true},
+		{"scope within grant", map[string]string{consts.ResourceProducts: consts.PermissionWrite}, consts.ResourceProducts, consts.PermissionWrite, true},
+		{"scope caps the grant", map[string]string{consts.ResourceProducts: consts.PermissionRead}, consts.ResourceProducts, consts.PermissionWrite, false},
+		{"resource outside scope", map[string]string{consts.ResourceProducts: consts.PermissionFull}, consts.ResourceOrders, consts.PermissionRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set("user_id", uint(1))
+			c.Set("user_role", consts.RoleOwner)
+			if tt.scopes != nil {
+				c.Set("api_key_scopes", tt.scopes)
+			}
+
+			allowed, err := CheckPermission(c, tt.resource, tt.level)
+			if err != nil {
+				t.Fatalf("CheckPermission returned error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("CheckPermission = %v, want %v", allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPermissionWithoutUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	allowed, err := CheckPermission(c, consts.ResourceProducts, consts.PermissionRead)
+	if err != nil || allowed {
+		t.Errorf("CheckPermission without user = %v, %v, want false, nil", allowed, err)
+	}
+}